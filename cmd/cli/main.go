@@ -0,0 +1,268 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	accountingRepository "Dedenruslan19/med-project/repository/accounting"
+	icd10Repository "Dedenruslan19/med-project/repository/icd10"
+	safetyRepository "Dedenruslan19/med-project/repository/safety"
+	accountingService "Dedenruslan19/med-project/service/accounting"
+	appointmentService "Dedenruslan19/med-project/service/appointments"
+	diagnoseService "Dedenruslan19/med-project/service/diagnoses"
+	icd10Service "Dedenruslan19/med-project/service/icd10"
+	insuranceService "Dedenruslan19/med-project/service/insurance"
+	messageService "Dedenruslan19/med-project/service/messages"
+	prescriptionService "Dedenruslan19/med-project/service/prescriptions"
+	safetyService "Dedenruslan19/med-project/service/safety"
+	"Dedenruslan19/med-project/util/database"
+	"Dedenruslan19/med-project/util/encryption"
+
+	cfg "github.com/pobyzaarif/go-config"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+type Config struct {
+	DBDriver string `env:"DB_DRIVER"`
+
+	DBMySQLHost     string `env:"DB_MYSQL_HOST"`
+	DBMySQLPort     string `env:"DB_MYSQL_PORT"`
+	DBMySQLUser     string `env:"DB_MYSQL_USER"`
+	DBMySQLPassword string `env:"DB_MYSQL_PASSWORD"`
+	DBMySQLName     string `env:"DB_MYSQL_NAME"`
+
+	DBSQLiteName string `env:"DB_SQLITE_NAME"`
+
+	DBPostgreSQLHost     string `env:"DB_POSTGRESQL_HOST"`
+	DBPostgreSQLPort     string `env:"DB_POSTGRESQL_PORT"`
+	DBPostgreSQLUser     string `env:"DB_POSTGRESQL_USER"`
+	DBPostgreSQLPassword string `env:"DB_POSTGRESQL_PASSWORD"`
+	DBPostgreSQLName     string `env:"DB_POSTGRESQL_NAME"`
+
+	EncryptionKeys        string `env:"ENCRYPTION_KEYS"`
+	EncryptionActiveKeyID string `env:"ENCRYPTION_ACTIVE_KEY_ID"`
+}
+
+// commands maps each subcommand to its handler, which receives the remaining
+// arguments.
+var commands = map[string]func(args []string) error{
+	"export-accounting":   exportAccounting,
+	"import-icd10":        importICD10,
+	"import-interactions": importInteractions,
+	"rotate-keys":         rotateKeys,
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cli <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  export-accounting    export invoices, payments, refunds and credit notes")
+	fmt.Fprintln(os.Stderr, "  import-icd10         load an ICD-10 catalogue CSV (code,title)")
+	fmt.Fprintln(os.Stderr, "  import-interactions  load drug interaction rules (drug_a,drug_b,severity,description)")
+	fmt.Fprintln(os.Stderr, "  rotate-keys          re-encrypt clinical notes and messages with the active encryption key")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := command(os.Args[2:]); err != nil {
+		log.Fatalf("%s: %v", os.Args[1], err)
+	}
+}
+
+func connect() *gorm.DB {
+	config := Config{}
+	if err := cfg.LoadConfig(&config); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	databaseConfig := database.Config{
+		DBDriver:             config.DBDriver,
+		DBMySQLHost:          config.DBMySQLHost,
+		DBMySQLPort:          config.DBMySQLPort,
+		DBMySQLUser:          config.DBMySQLUser,
+		DBMySQLPassword:      config.DBMySQLPassword,
+		DBMySQLName:          config.DBMySQLName,
+		DBSQLiteName:         config.DBSQLiteName,
+		DBPostgreSQLHost:     config.DBPostgreSQLHost,
+		DBPostgreSQLPort:     config.DBPostgreSQLPort,
+		DBPostgreSQLUser:     config.DBPostgreSQLUser,
+		DBPostgreSQLPassword: config.DBPostgreSQLPassword,
+		DBPostgreSQLName:     config.DBPostgreSQLName,
+	}
+
+	if config.EncryptionKeys != "" {
+		keyring, err := encryption.ParseKeyring(config.EncryptionKeys, config.EncryptionActiveKeyID)
+		if err != nil {
+			log.Fatalf("Failed to load encryption keys: %v", err)
+		}
+		encryption.Use(keyring)
+	}
+	return databaseConfig.GetDatabaseConnection()
+}
+
+// exportAccounting writes the accounting export of an inclusive date range as
+// CSV, either the document list or the journal.
+func exportAccounting(args []string) error {
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	flags := flag.NewFlagSet("export-accounting", flag.ContinueOnError)
+	fromFlag := flags.String("from", monthStart.Format(dateLayout), "first day of the period, YYYY-MM-DD")
+	toFlag := flags.String("to", monthStart.AddDate(0, 1, -1).Format(dateLayout), "last day of the period, YYYY-MM-DD")
+	format := flags.String("format", accountingService.FormatDocuments, "csv for the document list or journal for journal entries")
+	out := flags.String("out", "", "output file, defaults to stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *format != accountingService.FormatDocuments && *format != accountingService.FormatJournal {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	from, err := time.Parse(dateLayout, *fromFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	to, err := time.Parse(dateLayout, *toFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+
+	db := connect()
+	service := accountingService.NewService(logger, accountingRepository.NewAccountingRepo(db, logger))
+	export, err := service.Export(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return export.WriteCSV(w, *format)
+}
+
+// importICD10 loads a code,title catalogue CSV, or the bundled catalogue
+// when no file is given.
+func importICD10(args []string) error {
+	flags := flag.NewFlagSet("import-icd10", flag.ContinueOnError)
+	file := flags.String("file", "", "catalogue CSV with a code,title header, defaults to the bundled catalogue")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db := connect()
+	service := icd10Service.NewService(logger, icd10Repository.NewCodeRepo(db, logger))
+
+	if *file == "" {
+		imported, err := service.LoadBundled()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("imported %d codes\n", imported)
+		return nil
+	}
+
+	src, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	imported, err := service.Import(src)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d codes\n", imported)
+	return nil
+}
+
+// importInteractions loads the drug interaction rules checked when
+// prescribing.
+func importInteractions(args []string) error {
+	flags := flag.NewFlagSet("import-interactions", flag.ContinueOnError)
+	file := flags.String("file", "", "rule CSV with a drug_a,drug_b,severity,description header")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("-file is required")
+	}
+
+	src, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	db := connect()
+	service := safetyService.NewService(logger, safetyRepository.NewSafetyRepo(db, logger))
+	imported, err := service.ImportInteractions(src)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d rules\n", imported)
+	return nil
+}
+
+// rotateKeys re-encrypts every encrypted column with the active key, and
+// encrypts values stored before encryption was enabled. ENCRYPTION_KEYS must
+// still list the old keys; they can be removed once this has run.
+func rotateKeys(args []string) error {
+	flags := flag.NewFlagSet("rotate-keys", flag.ContinueOnError)
+	batch := flags.Int("batch", 500, "rows read per query")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config := Config{}
+	if err := cfg.LoadConfig(&config); err != nil {
+		return err
+	}
+	keyring, err := encryption.ParseKeyring(config.EncryptionKeys, config.EncryptionActiveKeyID)
+	if err != nil {
+		return err
+	}
+
+	db := connect()
+	rotations, err := encryption.Rotate(db, keyring, *batch,
+		&appointmentService.Appointment{},
+		&diagnoseService.Diagnose{},
+		&diagnoseService.DiagnoseVersion{},
+		&prescriptionService.Prescription{},
+		&safetyService.Override{},
+		&insuranceService.Claim{},
+		&messageService.Message{},
+	)
+	for _, rotation := range rotations {
+		fmt.Printf("%s.%s: %d rotated\n", rotation.Table, rotation.Column, rotation.Rotated)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("all values use key %s\n", keyring.ActiveKeyID())
+	return nil
+}
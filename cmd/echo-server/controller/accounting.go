@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/service/accounting"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AccountingController struct {
+	service accounting.Service
+	logger  *slog.Logger
+}
+
+func NewAccountingController(service accounting.Service, logger *slog.Logger) *AccountingController {
+	return &AccountingController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ExportAccounting exports the invoices, payments, refunds and credit notes of
+// a period, as a document list (format=csv), as a double-entry journal
+// (format=journal) or as JSON with both.
+func (ac *AccountingController) ExportAccounting(c echo.Context) error {
+	from, to, err := reportPeriod(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Dates must be formatted as YYYY-MM-DD",
+		})
+	}
+
+	export, err := ac.service.Export(from, to)
+	if errors.Is(err, accounting.ErrInvalidPeriod) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to build accounting export",
+		})
+	}
+
+	period := from.Format(reportDateLayout) + "_" + to.AddDate(0, 0, -1).Format(reportDateLayout)
+	switch format := c.QueryParam("format"); format {
+	case accounting.FormatDocuments, accounting.FormatJournal:
+		return writeCSV(c, "accounting-"+format+"-"+period+".csv", func(w *echo.Response) error {
+			return export.WriteCSV(w, format)
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Accounting export generated successfully",
+		"data":    export,
+	})
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	"Dedenruslan19/med-project/service/history"
+	"Dedenruslan19/med-project/service/safety"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type AllergyController struct {
+	service        safety.Service
+	historyService history.Service
+	validate       *validator.Validate
+	logger         *slog.Logger
+}
+
+func NewAllergyController(service safety.Service, historyService history.Service, logger *slog.Logger) *AllergyController {
+	return &AllergyController{
+		service:        service,
+		historyService: historyService,
+		validate:       validator.New(),
+		logger:         logger,
+	}
+}
+
+type AddAllergyRequest struct {
+	Substance string `json:"substance" validate:"required"`
+	Reaction  string `json:"reaction"`
+	Severity  string `json:"severity" validate:"required,oneof=mild moderate severe"`
+}
+
+func (ac *AllergyController) GetAllergies(c echo.Context) error {
+	patientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid patient ID",
+		})
+	}
+	if ok, err := patientAccess(c, ac.historyService, patientID); !ok {
+		return err
+	}
+
+	allergies, err := ac.service.GetAllergies(patientID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get allergies",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Allergies retrieved successfully",
+		"data":    allergies,
+	})
+}
+
+// AddAllergy records an allergy reported by the patient or noted by a doctor
+// treating them.
+func (ac *AllergyController) AddAllergy(c echo.Context) error {
+	patientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid patient ID",
+		})
+	}
+	if ok, err := patientAccess(c, ac.historyService, patientID); !ok {
+		return err
+	}
+
+	var req AddAllergyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if err := ac.validate.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	callerID, _ := middleware.GetUserID(c)
+	role, _ := middleware.GetRole(c)
+	allergy := &safety.Allergy{
+		UserID:       patientID,
+		Substance:    req.Substance,
+		Reaction:     req.Reaction,
+		Severity:     req.Severity,
+		RecordedBy:   role,
+		RecordedByID: callerID,
+	}
+
+	_, err = ac.service.AddAllergy(allergy)
+	if errors.Is(err, safety.ErrInvalidAllergy) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to add allergy",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Allergy added successfully",
+		"data":    allergy,
+	})
+}
+
+func (ac *AllergyController) RemoveAllergy(c echo.Context) error {
+	patientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid patient ID",
+		})
+	}
+	allergyID, err := strconv.ParseInt(c.Param("allergy_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid allergy ID",
+		})
+	}
+	if ok, err := patientAccess(c, ac.historyService, patientID); !ok {
+		return err
+	}
+
+	err = ac.service.RemoveAllergy(allergyID, patientID)
+	if errors.Is(err, safety.ErrAllergyNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Allergy not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to remove allergy",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Allergy removed successfully",
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"Dedenruslan19/med-project/cmd/echo-server/middleware"
 	"Dedenruslan19/med-project/service/appointments"
 	errs "Dedenruslan19/med-project/service/errors"
+	"Dedenruslan19/med-project/service/notifications"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -15,16 +17,18 @@ import (
 )
 
 type AppointmentController struct {
-	service  appointments.Service
-	validate *validator.Validate
-	logger   *slog.Logger
+	service             appointments.Service
+	notificationService notifications.Service
+	validate            *validator.Validate
+	logger              *slog.Logger
 }
 
-func NewAppointmentController(service appointments.Service, logger *slog.Logger) *AppointmentController {
+func NewAppointmentController(service appointments.Service, notificationService notifications.Service, logger *slog.Logger) *AppointmentController {
 	return &AppointmentController{
-		service:  service,
-		validate: validator.New(),
-		logger:   logger,
+		service:             service,
+		notificationService: notificationService,
+		validate:            validator.New(),
+		logger:              logger,
 	}
 }
 
@@ -32,6 +36,7 @@ type CreateAppointmentRequest struct {
 	DoctorID        int64  `json:"doctor_id" validate:"required"`
 	AppointmentDate string `json:"appointment_date" validate:"required"`
 	Notes           string `json:"notes"`
+	Modality        string `json:"modality" validate:"omitempty,oneof=in_person video"`
 }
 
 func (ac *AppointmentController) CreateAppointment(c echo.Context) error {
@@ -66,8 +71,9 @@ func (ac *AppointmentController) CreateAppointment(c echo.Context) error {
 		UserID:          userID,
 		DoctorID:        req.DoctorID,
 		AppointmentDate: appointmentDate,
-		Status:          "pending",
+		Status:          appointments.StatusPending,
 		Notes:           req.Notes,
+		Modality:        req.Modality,
 	}
 
 	id, err := ac.service.Create(appointment)
@@ -77,6 +83,11 @@ func (ac *AppointmentController) CreateAppointment(c echo.Context) error {
 				"error": "Doctor is not available at the requested time. Please choose another time or doctor.",
 			})
 		}
+		if errors.Is(err, appointments.ErrInvalidModality) || errors.Is(err, appointments.ErrVideoUnavailable) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
 
 		ac.logger.Error("Failed to create appointment",
 			slog.Any("error", err),
@@ -88,6 +99,17 @@ func (ac *AppointmentController) CreateAppointment(c echo.Context) error {
 	}
 
 	appointment.ID = id
+	middleware.SetAuditResource(c, id)
+
+	err = ac.notificationService.Notify(userID, notifications.CategoryAppointment,
+		"Appointment booked",
+		fmt.Sprintf("Your appointment #%d is booked for %s.", id, appointmentDate.Format("2006-01-02 15:04")))
+	if err != nil {
+		ac.logger.Warn("Failed to queue appointment notification",
+			slog.Any("error", err),
+			slog.Int64("appointment_id", id),
+		)
+	}
 
 	return c.JSON(http.StatusCreated, map[string]interface{}{
 		"message": "Appointment created successfully",
@@ -139,9 +161,125 @@ func (ac *AppointmentController) GetAppointmentByID(c echo.Context) error {
 			"error": "Appointment not found",
 		})
 	}
+	middleware.SetAuditPatient(c, appointment.UserID)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"message": "Appointment retrieved successfully",
 		"data":    appointment,
 	})
 }
+
+// ConfirmAppointment is used by the appointment's doctor to accept a pending
+// booking.
+func (ac *AppointmentController) ConfirmAppointment(c echo.Context) error {
+	return ac.changeStatus(c, "confirm", func(id, callerID int64, role string) (*appointments.Appointment, error) {
+		return ac.service.Confirm(id, callerID)
+	})
+}
+
+// CancelAppointment is used by the patient or the doctor before the
+// appointment is completed.
+func (ac *AppointmentController) CancelAppointment(c echo.Context) error {
+	return ac.changeStatus(c, "cancel", func(id, callerID int64, role string) (*appointments.Appointment, error) {
+		return ac.service.Cancel(id, role, callerID)
+	})
+}
+
+func (ac *AppointmentController) changeStatus(c echo.Context, action string,
+	change func(id, callerID int64, role string) (*appointments.Appointment, error)) error {
+	callerID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+	role, _ := middleware.GetRole(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid appointment ID",
+		})
+	}
+
+	appointment, err := change(id, callerID, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, appointments.ErrForbidden):
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": err.Error(),
+			})
+		case errors.Is(err, appointments.ErrInvalidTransition):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Appointment not found",
+		})
+	}
+	middleware.SetAuditPatient(c, appointment.UserID)
+
+	subject, body := "Appointment confirmed",
+		fmt.Sprintf("Your appointment #%d on %s is confirmed.", appointment.ID, appointment.AppointmentDate.Format("2006-01-02 15:04"))
+	if appointment.Status == appointments.StatusCancelled {
+		subject, body = "Appointment cancelled",
+			fmt.Sprintf("Your appointment #%d on %s is cancelled.", appointment.ID, appointment.AppointmentDate.Format("2006-01-02 15:04"))
+	}
+	if err := ac.notificationService.Notify(appointment.UserID, notifications.CategoryAppointment, subject, body); err != nil {
+		ac.logger.Warn("Failed to queue appointment notification",
+			slog.Any("error", err),
+			slog.Int64("appointment_id", appointment.ID),
+		)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Appointment " + appointment.Status + " successfully",
+		"data":    appointment,
+	})
+}
+
+// JoinVideo returns the caller's own join link to a video appointment. Links
+// are only handed out shortly before and during the slot.
+func (ac *AppointmentController) JoinVideo(c echo.Context) error {
+	callerID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+	role, _ := middleware.GetRole(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid appointment ID",
+		})
+	}
+
+	link, err := ac.service.JoinLink(id, role, callerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, appointments.ErrForbidden):
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": err.Error(),
+			})
+		case errors.Is(err, appointments.ErrNotVideo), errors.Is(err, appointments.ErrVideoUnavailable):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		case errors.Is(err, appointments.ErrJoinNotOpen), errors.Is(err, appointments.ErrJoinClosed):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Appointment not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Join link created successfully",
+		"data":    link,
+	})
+}
@@ -0,0 +1,248 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	"Dedenruslan19/med-project/service/attachments"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AttachmentController struct {
+	service attachments.Service
+	logger  *slog.Logger
+}
+
+func NewAttachmentController(service attachments.Service, logger *slog.Logger) *AttachmentController {
+	return &AttachmentController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func attachmentCaller(c echo.Context) (attachments.Caller, bool) {
+	id, ok := middleware.GetUserID(c)
+	if !ok {
+		return attachments.Caller{}, false
+	}
+	role, _ := middleware.GetRole(c)
+	return attachments.Caller{ID: id, Role: role}, true
+}
+
+// attachmentError answers a failed attachment request.
+func (ac *AttachmentController) attachmentError(c echo.Context, err error, action string) error {
+	switch {
+	case errors.Is(err, attachments.ErrOwnerNotFound), errors.Is(err, attachments.ErrAttachmentNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, attachments.ErrForbidden), errors.Is(err, attachments.ErrNotUploader):
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, attachments.ErrTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, attachments.ErrUnsupportedType):
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, attachments.ErrEmptyFile), errors.Is(err, attachments.ErrInvalidCategory):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	ac.logger.Error("Failed to "+action, slog.Any("error", err))
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Failed to " + action,
+	})
+}
+
+// upload stores the multipart "file" field on an appointment or diagnose;
+// the optional "category" field is lab_result, scan, referral or other.
+func (ac *AttachmentController) upload(c echo.Context, ownerType string) error {
+	caller, ok := attachmentCaller(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	ownerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid " + ownerType + " ID",
+		})
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "A file is required in the multipart field \"file\"",
+		})
+	}
+	file, err := header.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Failed to read uploaded file",
+		})
+	}
+	defer file.Close()
+
+	attachment, err := ac.service.Upload(caller, ownerType, ownerID, attachments.Upload{
+		FileName: header.Filename,
+		Category: c.FormValue("category"),
+		Content:  file,
+	})
+	if err != nil {
+		return ac.attachmentError(c, err, "upload attachment")
+	}
+	middleware.SetAuditPatient(c, attachment.UserID)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Attachment uploaded successfully",
+		"data":    attachment,
+	})
+}
+
+func (ac *AttachmentController) list(c echo.Context, ownerType string) error {
+	caller, ok := attachmentCaller(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	ownerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid " + ownerType + " ID",
+		})
+	}
+
+	list, err := ac.service.List(caller, ownerType, ownerID)
+	if err != nil {
+		return ac.attachmentError(c, err, "get attachments")
+	}
+	if len(list) > 0 {
+		middleware.SetAuditPatient(c, list[0].UserID)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Attachments retrieved successfully",
+		"data":    list,
+	})
+}
+
+func (ac *AttachmentController) UploadAppointmentAttachment(c echo.Context) error {
+	return ac.upload(c, attachments.OwnerAppointment)
+}
+
+func (ac *AttachmentController) GetAppointmentAttachments(c echo.Context) error {
+	return ac.list(c, attachments.OwnerAppointment)
+}
+
+func (ac *AttachmentController) UploadDiagnoseAttachment(c echo.Context) error {
+	return ac.upload(c, attachments.OwnerDiagnose)
+}
+
+func (ac *AttachmentController) GetDiagnoseAttachments(c echo.Context) error {
+	return ac.list(c, attachments.OwnerDiagnose)
+}
+
+// GetAttachment returns the attachment with a fresh signed download link.
+func (ac *AttachmentController) GetAttachment(c echo.Context) error {
+	caller, ok := attachmentCaller(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid attachment ID",
+		})
+	}
+
+	attachment, err := ac.service.Get(caller, id)
+	if err != nil {
+		return ac.attachmentError(c, err, "get attachment")
+	}
+	middleware.SetAuditPatient(c, attachment.UserID)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Attachment retrieved successfully",
+		"data":    attachment,
+	})
+}
+
+func (ac *AttachmentController) DeleteAttachment(c echo.Context) error {
+	caller, ok := attachmentCaller(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid attachment ID",
+		})
+	}
+
+	if err := ac.service.Delete(caller, id); err != nil {
+		return ac.attachmentError(c, err, "delete attachment")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Attachment deleted successfully",
+	})
+}
+
+// DownloadAttachment streams the file of a signed download link. The link
+// itself is the authorization, so the route needs no token.
+func (ac *AttachmentController) DownloadAttachment(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid attachment ID",
+		})
+	}
+	expires, err := strconv.ParseInt(c.QueryParam("expires"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": attachments.ErrInvalidSignature.Error(),
+		})
+	}
+
+	attachment, content, err := ac.service.Open(id, expires, c.QueryParam("signature"))
+	switch {
+	case errors.Is(err, attachments.ErrInvalidSignature), errors.Is(err, attachments.ErrLinkExpired):
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return ac.attachmentError(c, err, "download attachment")
+	}
+	defer content.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentDisposition, disposition)
+	header.Set(echo.HeaderContentLength, strconv.FormatInt(attachment.Size, 10))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Cache-Control", "private, no-store")
+	return c.Stream(http.StatusOK, attachment.ContentType, content)
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	"Dedenruslan19/med-project/service/audit"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AuditController struct {
+	service audit.Service
+	logger  *slog.Logger
+}
+
+func NewAuditController(service audit.Service, logger *slog.Logger) *AuditController {
+	return &AuditController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetMyAccessLog shows the authenticated patient who accessed their
+// records, newest first.
+func (ac *AuditController) GetMyAccessLog(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	page, ok := positiveQueryInt(c, "page")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid page",
+		})
+	}
+	perPage, ok := positiveQueryInt(c, "per_page")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid per_page",
+		})
+	}
+
+	result, err := ac.service.ForPatient(userID, page, perPage)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get access log",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Access log retrieved successfully",
+		"data":    result,
+	})
+}
+
+// SearchAccessLog lets admins query the access log by patient_id, by staff
+// member (principal_id and role), by resource and by from/to date.
+func (ac *AuditController) SearchAccessLog(c echo.Context) error {
+	var query audit.Query
+
+	patientID, ok := positiveQueryInt(c, "patient_id")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid patient_id",
+		})
+	}
+	principalID, ok := positiveQueryInt(c, "principal_id")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid principal_id",
+		})
+	}
+	if query.Page, ok = positiveQueryInt(c, "page"); !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid page",
+		})
+	}
+	if query.PerPage, ok = positiveQueryInt(c, "per_page"); !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid per_page",
+		})
+	}
+	query.PatientID = int64(patientID)
+	query.PrincipalID = int64(principalID)
+	query.Role = c.QueryParam("role")
+	query.Resource = c.QueryParam("resource")
+
+	if param := c.QueryParam("from"); param != "" {
+		from, err := time.Parse(reportDateLayout, param)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Dates must be formatted as YYYY-MM-DD",
+			})
+		}
+		query.From = from
+	}
+	if param := c.QueryParam("to"); param != "" {
+		to, err := time.Parse(reportDateLayout, param)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Dates must be formatted as YYYY-MM-DD",
+			})
+		}
+		query.To = to.AddDate(0, 0, 1)
+	}
+
+	result, err := ac.service.Search(query)
+	if errors.Is(err, audit.ErrInvalidRole) || errors.Is(err, audit.ErrInvalidPeriod) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to search access log",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Access log retrieved successfully",
+		"data":    result,
+	})
+}
@@ -5,6 +5,9 @@ import (
 	"Dedenruslan19/med-project/service/appointments"
 	"Dedenruslan19/med-project/service/billings"
 	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/notifications"
+	"Dedenruslan19/med-project/service/pricing"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -16,20 +19,43 @@ import (
 )
 
 type BillingController struct {
-	service            billings.Service
-	invoiceService     invoices.Service
-	appointmentService appointments.Service
-	validate           *validator.Validate
-	logger             *slog.Logger
+	service             billings.Service
+	invoiceService      invoices.Service
+	appointmentService  appointments.Service
+	notificationService notifications.Service
+	validate            *validator.Validate
+	logger              *slog.Logger
 }
 
-func NewBillingController(service billings.Service, invoiceService invoices.Service, appointmentService appointments.Service, logger *slog.Logger) *BillingController {
+func NewBillingController(service billings.Service, invoiceService invoices.Service, appointmentService appointments.Service, notificationService notifications.Service, logger *slog.Logger) *BillingController {
 	return &BillingController{
-		service:            service,
-		invoiceService:     invoiceService,
-		appointmentService: appointmentService,
-		validate:           validator.New(),
-		logger:             logger,
+		service:             service,
+		invoiceService:      invoiceService,
+		appointmentService:  appointmentService,
+		notificationService: notificationService,
+		validate:            validator.New(),
+		logger:              logger,
+	}
+}
+
+// notifyPatient queues a notification for the patient of the billing's
+// appointment. Failures are logged and never fail the request.
+func (bc *BillingController) notifyPatient(billing *billings.Billing, category, subject, body string) {
+	appointment, err := bc.appointmentService.GetByID(billing.AppointmentID)
+	if err != nil {
+		bc.logger.Warn("Failed to resolve patient for notification",
+			slog.Any("error", err),
+			slog.Int64("billing_id", billing.ID),
+		)
+		return
+	}
+
+	if err := bc.notificationService.Notify(appointment.UserID, category, subject, body); err != nil {
+		bc.logger.Warn("Failed to queue patient notification",
+			slog.Any("error", err),
+			slog.Int64("billing_id", billing.ID),
+			slog.String("category", category),
+		)
 	}
 }
 
@@ -168,17 +194,7 @@ func (bc *BillingController) CreateInvoice(c echo.Context) error {
 		})
 	}
 
-	bc.logger.Info("Invoice created",
-		slog.Int64("billing_id", billingID),
-		slog.String("payer_email", req.PayerEmail),
-		slog.Float64("amount", billing.TotalAmount))
-
-	// Create invoice record and return it so Postman shows the invoice details
-	consultationFee := billing.TotalAmount
-	medicationFee := 0.0
-	totalAmount := consultationFee + medicationFee
-
-	invoice, err := bc.invoiceService.CreateInvoice(billingID, consultationFee, medicationFee, totalAmount, req.PayerEmail)
+	invoice, err := bc.service.GenerateInvoice(billingID, req.PayerEmail)
 	if err != nil {
 		bc.logger.Error("Failed to create invoice record",
 			slog.Any("error", err),
@@ -189,6 +205,11 @@ func (bc *BillingController) CreateInvoice(c echo.Context) error {
 		})
 	}
 
+	bc.logger.Info("Invoice created",
+		slog.Int64("billing_id", billingID),
+		slog.String("payer_email", req.PayerEmail),
+		slog.Float64("amount", invoice.TotalAmount))
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"message": "Payment invoice created successfully",
 		"data":    invoice,
@@ -225,6 +246,11 @@ func (bc *BillingController) UpdatePaymentStatus(c echo.Context) error {
 
 	// Update payment status
 	err = bc.service.UpdatePaymentStatus(id, req.PaymentStatus)
+	if errors.Is(err, billings.ErrInvalidTransition) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		bc.logger.Error("Failed to update payment status",
 			slog.Any("error", err),
@@ -235,39 +261,24 @@ func (bc *BillingController) UpdatePaymentStatus(c echo.Context) error {
 		})
 	}
 
-	// Auto-create invoice when payment status is "paid"
-	if strings.ToLower(strings.TrimSpace(req.PaymentStatus)) == "paid" {
-		// Get billing details
-		_, err := bc.service.GetByID(id)
-		if err != nil {
-			bc.logger.Error("Failed to get billing for invoice creation",
-				slog.Any("error", err),
-				slog.Int64("billing_id", id),
-			)
-			// Don't fail the payment status update, just log the error
-		} else {
-			// In a real scenario, you would fetch the diagnosis to calculate medication fees
-			consultationFee := 200000.0
-			medicationFee := 0.0
-			totalAmount := consultationFee + medicationFee
-
-			// For now, use a default email. In production, fetch user email from appointment
-			email := "user@example.com"
-
-			invoice, err := bc.invoiceService.CreateInvoice(id, consultationFee, medicationFee, totalAmount, email)
-			if err != nil {
-				bc.logger.Error("Failed to auto-create invoice",
-					slog.Any("error", err),
-					slog.Int64("billing_id", id),
-				)
-			} else {
-				bc.logger.Info("Invoice auto-created successfully",
-					slog.Int64("billing_id", id),
-					slog.Int64("invoice_id", invoice.ID),
-					slog.String("invoice_number", invoice.InvoiceNumber),
-				)
-			}
+	billing, err := bc.service.GetByID(id)
+	if err != nil {
+		bc.logger.Error("Failed to get billing after payment status update",
+			slog.Any("error", err),
+			slog.Int64("billing_id", id),
+		)
+	} else if strings.ToLower(strings.TrimSpace(req.PaymentStatus)) == "paid" {
+		// the invoice was generated by the billing service together with the status
+		invoice, err := bc.invoiceService.GetByBillingID(id)
+		if err == nil {
+			bc.notifyPatient(billing, notifications.CategoryInvoice,
+				fmt.Sprintf("Invoice %s", invoice.InvoiceNumber),
+				fmt.Sprintf("Your invoice %s for %.2f is available.", invoice.InvoiceNumber, invoice.PatientAmount))
 		}
+	} else {
+		bc.notifyPatient(billing, notifications.CategoryBilling,
+			"Billing updated",
+			fmt.Sprintf("The payment status of billing #%d is now %s.", id, req.PaymentStatus))
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -280,3 +291,308 @@ func (bc *BillingController) UpdatePaymentStatus(c echo.Context) error {
 			}()),
 	})
 }
+
+// GetMyBillings lists the billings of the authenticated patient's appointments.
+func (bc *BillingController) GetMyBillings(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	list, err := bc.service.GetByUserID(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get billings",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Billings retrieved successfully",
+		"data":    list,
+	})
+}
+
+// PayMyBilling starts payment of one of the patient's own unpaid billings and
+// returns the invoice to pay.
+func (bc *BillingController) PayMyBilling(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid billing ID",
+		})
+	}
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	billing, err := bc.service.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Billing not found",
+		})
+	}
+
+	appointment, err := bc.appointmentService.GetByID(billing.AppointmentID)
+	if err != nil || appointment.UserID != userID {
+		// do not reveal billings of other patients
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Billing not found",
+		})
+	}
+
+	if billing.PaymentStatus != billings.StatusUnpaid && billing.PaymentStatus != billings.StatusFailed {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": fmt.Sprintf("Billing is %s and cannot be paid", billing.PaymentStatus),
+		})
+	}
+
+	invoice, err := bc.service.StartPayment(id)
+	if errors.Is(err, billings.ErrInvalidTransition) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		bc.logger.Error("Failed to start payment",
+			slog.Any("error", err),
+			slog.Int64("billing_id", id),
+			slog.Int64("user_id", userID),
+		)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to start payment",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Payment started, waiting for payment",
+		"data": map[string]interface{}{
+			"billing_id":     id,
+			"payment_status": billings.StatusWaitingPayment,
+			"invoice":        invoice,
+		},
+	})
+}
+
+type ApplyPricingRequest struct {
+	Discounts         []pricing.Discount `json:"discounts" validate:"dive"`
+	PromoCode         string             `json:"promo_code"`
+	InsuranceCoverage float64            `json:"insurance_coverage" validate:"gte=0,lte=1"`
+}
+
+type ApplyPromoCodeRequest struct {
+	PromoCode string `json:"promo_code" validate:"required"`
+}
+
+// repriceError answers a failed reprice of an unpaid billing.
+func (bc *BillingController) repriceError(c echo.Context, id int64, err error) error {
+	if errors.Is(err, billings.ErrBillingLocked) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	status := pricingErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		bc.logger.Error("Failed to reprice billing",
+			slog.Any("error", err),
+			slog.Int64("billing_id", id),
+		)
+		return c.JSON(status, map[string]string{
+			"error": "Failed to apply pricing",
+		})
+	}
+	return c.JSON(status, map[string]string{
+		"error": err.Error(),
+	})
+}
+
+// ApplyPricing sets the discounts, promo code and insurance coverage of an
+// unpaid billing. Only the appointment's doctor may change them.
+func (bc *BillingController) ApplyPricing(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid billing ID",
+		})
+	}
+
+	var req ApplyPricingRequest
+	if bindErr := c.Bind(&req); bindErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if validateErr := bc.validate.Struct(req); validateErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": validateErr.Error(),
+		})
+	}
+
+	doctorIDFromToken, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	billing, err := bc.service.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Billing not found",
+		})
+	}
+
+	appointment, err := bc.appointmentService.GetByID(billing.AppointmentID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to validate ownership"})
+	}
+	if appointment.DoctorID != doctorIDFromToken {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "You are not authorized to price this billing"})
+	}
+
+	billing, err = bc.service.Reprice(id, pricing.Adjustments{
+		Discounts:         req.Discounts,
+		PromoCode:         req.PromoCode,
+		InsuranceCoverage: req.InsuranceCoverage,
+	})
+	if err != nil {
+		return bc.repriceError(c, id, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Billing pricing updated successfully",
+		"data":    billing,
+	})
+}
+
+// ApplyMyPromoCode lets a patient redeem a promo code on their own unpaid billing.
+func (bc *BillingController) ApplyMyPromoCode(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid billing ID",
+		})
+	}
+
+	var req ApplyPromoCodeRequest
+	if bindErr := c.Bind(&req); bindErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if validateErr := bc.validate.Struct(req); validateErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": validateErr.Error(),
+		})
+	}
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	billing, err := bc.service.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Billing not found",
+		})
+	}
+
+	appointment, err := bc.appointmentService.GetByID(billing.AppointmentID)
+	if err != nil || appointment.UserID != userID {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Billing not found",
+		})
+	}
+
+	billing, err = bc.service.ApplyPromoCode(id, req.PromoCode)
+	if err != nil {
+		return bc.repriceError(c, id, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Promo code applied successfully",
+		"data":    billing,
+	})
+}
+
+type RefundRequest struct {
+	Amount float64 `json:"amount" validate:"gt=0"`
+	Reason string  `json:"reason" validate:"required"`
+}
+
+// RefundBilling returns money to the patient of a paid billing and issues a
+// credit note against its invoice.
+func (bc *BillingController) RefundBilling(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid billing ID",
+		})
+	}
+
+	var req RefundRequest
+	if bindErr := c.Bind(&req); bindErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if validateErr := bc.validate.Struct(req); validateErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": validateErr.Error(),
+		})
+	}
+
+	refund, note, err := bc.service.Refund(id, req.Amount, req.Reason)
+	switch {
+	case errors.Is(err, billings.ErrInvalidTransition):
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, billings.ErrInvalidRefund), errors.Is(err, invoices.ErrInvalidCreditAmount):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	case err != nil:
+		bc.logger.Error("Failed to refund billing",
+			slog.Any("error", err),
+			slog.Int64("billing_id", id),
+		)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to refund billing",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Billing refunded successfully",
+		"data": map[string]interface{}{
+			"refund":      refund,
+			"credit_note": note,
+		},
+	})
+}
+
+// GetPayments lists the payments and refunds recorded for a billing.
+func (bc *BillingController) GetPayments(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid billing ID",
+		})
+	}
+
+	payments, err := bc.service.GetPayments(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get payments",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Payments retrieved successfully",
+		"data":    payments,
+	})
+}
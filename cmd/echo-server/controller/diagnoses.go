@@ -5,6 +5,10 @@ import (
 	"Dedenruslan19/med-project/service/appointments"
 	"Dedenruslan19/med-project/service/billings"
 	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/icd10"
+	"Dedenruslan19/med-project/service/prescriptions"
+	"Dedenruslan19/med-project/service/safety"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -14,33 +18,54 @@ import (
 )
 
 type DiagnoseController struct {
-	service            diagnoses.Service
-	appointmentService appointments.Service
-	billingService     billings.Service
-	validate           *validator.Validate
-	logger             *slog.Logger
+	service             diagnoses.Service
+	appointmentService  appointments.Service
+	billingService      billings.Service
+	safetyService       safety.Service
+	prescriptionService prescriptions.Service
+	validate            *validator.Validate
+	logger              *slog.Logger
 }
 
-func NewDiagnoseController(service diagnoses.Service, appointmentService appointments.Service, billingService billings.Service, logger *slog.Logger) *DiagnoseController {
+func NewDiagnoseController(service diagnoses.Service, appointmentService appointments.Service, billingService billings.Service, safetyService safety.Service,
+	prescriptionService prescriptions.Service, logger *slog.Logger) *DiagnoseController {
 	return &DiagnoseController{
-		service:            service,
-		appointmentService: appointmentService,
-		billingService:     billingService,
-		validate:           validator.New(),
-		logger:             logger,
+		service:             service,
+		appointmentService:  appointmentService,
+		billingService:      billingService,
+		safetyService:       safetyService,
+		prescriptionService: prescriptionService,
+		validate:            validator.New(),
+		logger:              logger,
 	}
 }
 
+// CreateDiagnoseRequest has no doctor, the diagnosis is authored by the
+// doctor of the token; a doctor_id sent by older clients is ignored.
 type CreateDiagnoseRequest struct {
-	AppointmentID         int64  `json:"appointment_id" validate:"required"`
-	DoctorID              int64  `json:"doctor_id" validate:"required"`
-	Notes                 string `json:"notes" validate:"required"`
-	PrescribedMedications string `json:"prescribed_medications"`
+	AppointmentID         int64    `json:"appointment_id" validate:"required"`
+	Notes                 string   `json:"notes" validate:"required"`
+	PrescribedMedications string   `json:"prescribed_medications"`
+	PrimaryCode           string   `json:"primary_code" validate:"required"`
+	SecondaryCodes        []string `json:"secondary_codes"`
+	OverrideJustification string   `json:"override_justification"`
 }
 
+// UpdateDiagnoseRequest replaces the codes when primary_code is given.
 type UpdateDiagnoseRequest struct {
-	Notes                 string `json:"notes"`
-	PrescribedMedications string `json:"prescribed_medications"`
+	Notes                 string   `json:"notes"`
+	PrescribedMedications string   `json:"prescribed_medications"`
+	PrimaryCode           string   `json:"primary_code"`
+	SecondaryCodes        []string `json:"secondary_codes"`
+	Reason                string   `json:"reason"`
+	OverrideJustification string   `json:"override_justification"`
+}
+
+// isCodingError tells whether err rejects the ICD-10 codes of a request.
+func isCodingError(err error) bool {
+	return errors.Is(err, icd10.ErrUnknownCode) ||
+		errors.Is(err, diagnoses.ErrPrimaryCodeRequired) ||
+		errors.Is(err, diagnoses.ErrDuplicateCode)
 }
 
 func (dc *DiagnoseController) CreateDiagnose(c echo.Context) error {
@@ -76,6 +101,8 @@ func (dc *DiagnoseController) CreateDiagnose(c echo.Context) error {
 		})
 	}
 
+	middleware.SetAuditPatient(c, appointment.UserID)
+
 	if appointment.DoctorID != doctorIDFromToken {
 		dc.logger.Warn("Doctor attempting to create diagnose for another doctor's appointment",
 			slog.Int64("token_doctor_id", doctorIDFromToken),
@@ -87,14 +114,27 @@ func (dc *DiagnoseController) CreateDiagnose(c echo.Context) error {
 		})
 	}
 
+	assessment, err := dc.safetyService.Assess(appointment.UserID,
+		safety.SplitMedications(req.PrescribedMedications), req.OverrideJustification)
+	if err != nil {
+		return dc.prescriptionError(c, assessment, err)
+	}
+
 	diagnose := &diagnoses.Diagnose{
 		AppointmentID:         req.AppointmentID,
-		DoctorID:              req.DoctorID,
+		DoctorID:              doctorIDFromToken,
 		Notes:                 req.Notes,
 		PrescribedMedications: req.PrescribedMedications,
+		Codes:                 diagnoses.NewCodes(req.PrimaryCode, req.SecondaryCodes),
 	}
 
-	id, err := dc.service.Create(diagnose)
+	// the override justification is stored with the diagnosis or not at all
+	id, err := dc.service.Create(diagnose, dc.safetyService.OverrideRecords(doctorIDFromToken, assessment))
+	if isCodingError(err) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		dc.logger.Error("Failed to create diagnose",
 			slog.Any("error", err),
@@ -106,28 +146,120 @@ func (dc *DiagnoseController) CreateDiagnose(c echo.Context) error {
 	}
 
 	diagnose.ID = id
+	middleware.SetAuditResource(c, id)
 
-	totalAmount := dc.service.CalculateTotalAmount(diagnose)
-
-	billing := &billings.Billing{
-		AppointmentID: req.AppointmentID,
-		TotalAmount:   totalAmount,
-		PaymentStatus: "unpaid",
-	}
-
-	billingID, err := dc.billingService.Create(billing)
-	if err != nil {
+	if _, err := dc.billingService.CreateFromLines(req.AppointmentID, dc.service.PricingLines(diagnose)); err != nil {
 		dc.logger.Error("Failed to create billing after diagnose",
 			slog.Any("error", err),
 			slog.Int64("appointment_id", req.AppointmentID),
 		)
-	} else {
-		billing.ID = billingID
 	}
 
-	return c.JSON(http.StatusCreated, map[string]interface{}{
+	response := map[string]interface{}{
 		"message":  "Diagnose created successfully",
 		"diagnose": diagnose,
+	}
+	addPrescriptionAlerts(response, assessment)
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+// prescriptionError answers a failed prescription check, listing the alerts
+// when the prescription was blocked.
+func (dc *DiagnoseController) prescriptionError(c echo.Context, assessment *safety.Assessment, err error) error {
+	if errors.Is(err, safety.ErrPrescriptionBlocked) {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":  err.Error(),
+			"alerts": assessment.Alerts,
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Failed to check prescription safety",
+	})
+}
+
+// addPrescriptionAlerts adds the warnings and the overridden alerts of a
+// prescription check to a response.
+func addPrescriptionAlerts(response map[string]interface{}, assessment *safety.Assessment) {
+	if warnings := assessment.Warnings(); len(warnings) > 0 {
+		response["prescription_warnings"] = warnings
+	}
+	if assessment.Overridden() {
+		var overridden []safety.Alert
+		for _, alert := range assessment.Alerts {
+			if alert.Blocking {
+				overridden = append(overridden, alert)
+			}
+		}
+		response["prescription_overrides"] = overridden
+	}
+}
+
+const (
+	readerNone = iota
+	readerDoctor
+	readerPatient
+)
+
+// diagnoseReader tells how the caller may read diagnose: as its author or
+// the doctor treating its appointment, as the appointment's patient, or not
+// at all.
+func (dc *DiagnoseController) diagnoseReader(c echo.Context, diagnose *diagnoses.Diagnose) int {
+	callerID, ok := middleware.GetUserID(c)
+	if !ok {
+		return readerNone
+	}
+	role, _ := middleware.GetRole(c)
+
+	if role == "doctor" && diagnose.DoctorID == callerID {
+		return readerDoctor
+	}
+
+	appointment, err := dc.appointmentService.GetByID(diagnose.AppointmentID)
+	if err != nil {
+		dc.logger.Error("Failed to get appointment of diagnose",
+			slog.Any("error", err),
+			slog.Int64("diagnose_id", diagnose.ID),
+		)
+		return readerNone
+	}
+	middleware.SetAuditPatient(c, appointment.UserID)
+
+	switch {
+	case role == "doctor" && appointment.DoctorID == callerID:
+		return readerDoctor
+	case role == "user" && appointment.UserID == callerID:
+		return readerPatient
+	}
+	return readerNone
+}
+
+// respondDiagnose answers with the full diagnosis for treating doctors and
+// the patient view for the patient, who only sees signed diagnoses.
+func (dc *DiagnoseController) respondDiagnose(c echo.Context, diagnose *diagnoses.Diagnose) error {
+	switch dc.diagnoseReader(c, diagnose) {
+	case readerDoctor:
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"message": "diagnose retrieved successfully",
+			"data":    diagnose,
+		})
+	case readerPatient:
+		if !diagnose.Signed() {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "diagnose not found",
+			})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"message": "diagnose retrieved successfully",
+			"data":    diagnose.PatientView(),
+		})
+	}
+
+	dc.logger.Warn("Unauthorized attempt to read diagnose",
+		slog.Int64("diagnose_id", diagnose.ID),
+	)
+	return c.JSON(http.StatusForbidden, map[string]string{
+		"error": "You are not authorized to view this diagnose",
 	})
 }
 
@@ -151,10 +283,7 @@ func (dc *DiagnoseController) GetDiagnoseByID(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"message": "diagnose retrieved successfully",
-		"data":    diagnose,
-	})
+	return dc.respondDiagnose(c, diagnose)
 }
 
 func (dc *DiagnoseController) GetDiagnoseByAppointmentID(c echo.Context) error {
@@ -173,16 +302,45 @@ func (dc *DiagnoseController) GetDiagnoseByAppointmentID(c echo.Context) error {
 			slog.Int64("appointment_id", appointmentID),
 		)
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"message": "diagnose not found for this appointment",
+			"error": "diagnose not found for this appointment",
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"message": "diagnose retrieved successfully",
-		"data":    diagnose,
+	return dc.respondDiagnose(c, diagnose)
+}
+
+// diagnoseError answers a failed change of a diagnosis.
+func (dc *DiagnoseController) diagnoseError(c echo.Context, id int64, err error) error {
+	switch {
+	case errors.Is(err, diagnoses.ErrDiagnoseNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, diagnoses.ErrNotAuthor):
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, diagnoses.ErrAmendmentReasonRequired), errors.Is(err, diagnoses.ErrNoChanges), isCodingError(err):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, diagnoses.ErrAlreadySigned), errors.Is(err, diagnoses.ErrVersionConflict):
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	dc.logger.Error("Failed to change diagnose",
+		slog.Any("error", err),
+		slog.Int64("diagnose_id", id),
+	)
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Failed to update diagnose",
 	})
 }
 
+// UpdateDiagnose revises a draft diagnosis, or amends a signed one when a
+// reason is given. Only the authoring doctor may change it.
 func (dc *DiagnoseController) UpdateDiagnose(c echo.Context) error {
 	idParam := c.Param("id")
 	id, err := strconv.ParseInt(idParam, 10, 64)
@@ -192,14 +350,6 @@ func (dc *DiagnoseController) UpdateDiagnose(c echo.Context) error {
 		})
 	}
 
-	// Get existing diagnose
-	diagnose, err := dc.service.GetByID(id)
-	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "diagnose not found",
-		})
-	}
-
 	var req UpdateDiagnoseRequest
 	if bindErr := c.Bind(&req); bindErr != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
@@ -207,36 +357,219 @@ func (dc *DiagnoseController) UpdateDiagnose(c echo.Context) error {
 		})
 	}
 
-	if req.Notes != "" {
-		diagnose.Notes = req.Notes
+	doctorIDFromToken, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	amendment := diagnoses.Amendment{
+		Notes:                 req.Notes,
+		PrescribedMedications: req.PrescribedMedications,
+		Reason:                req.Reason,
+	}
+	if req.PrimaryCode != "" {
+		amendment.Codes = diagnoses.NewCodes(req.PrimaryCode, req.SecondaryCodes)
 	}
+
+	// new medications are checked against the patient before amending; an
+	// unknown diagnosis is left to Amend to report
+	var assessment *safety.Assessment
 	if req.PrescribedMedications != "" {
-		diagnose.PrescribedMedications = req.PrescribedMedications
+		if current, err := dc.service.GetByID(id); err == nil {
+			appointment, err := dc.appointmentService.GetByID(current.AppointmentID)
+			if err != nil {
+				return c.JSON(http.StatusNotFound, map[string]string{
+					"error": "Appointment not found",
+				})
+			}
+			assessment, err = dc.safetyService.Assess(appointment.UserID,
+				safety.SplitMedications(req.PrescribedMedications), req.OverrideJustification)
+			if err != nil {
+				return dc.prescriptionError(c, assessment, err)
+			}
+		}
 	}
 
-	err = dc.service.Update(diagnose)
+	// a signed diagnosis gets its amended prescription in the same change
+	diagnose, err := dc.service.Amend(id, doctorIDFromToken, amendment,
+		dc.safetyService.OverrideRecords(doctorIDFromToken, assessment), dc.prescriptionService.Records())
 	if err != nil {
-		dc.logger.Error("Failed to update diagnose",
+		return dc.diagnoseError(c, id, err)
+	}
+	auditAppointmentPatient(c, dc.appointmentService, diagnose.AppointmentID)
+
+	response := map[string]interface{}{
+		"message": "diagnose updated successfully",
+		"data":    diagnose,
+	}
+	if assessment != nil {
+		addPrescriptionAlerts(response, assessment)
+	}
+	if req.PrescribedMedications != "" {
+		billing, warning := dc.recalculateBilling(diagnose)
+		response["billing"] = billing
+		if warning != "" {
+			response["billing_warning"] = warning
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// recalculateBilling reprices the billing of the diagnosis for its current
+// medications. A billing that is already being paid keeps its amount and the
+// returned warning says so.
+func (dc *DiagnoseController) recalculateBilling(diagnose *diagnoses.Diagnose) (*billings.Billing, string) {
+	billing, err := dc.billingService.GetByAppointmentID(diagnose.AppointmentID)
+	if err != nil {
+		return nil, "no billing found for this appointment"
+	}
+
+	updated, err := dc.billingService.ReplaceLines(billing.ID, dc.service.PricingLines(diagnose))
+	if errors.Is(err, billings.ErrBillingLocked) {
+		dc.logger.Warn("Billing not recalculated after diagnose change",
+			slog.Int64("billing_id", billing.ID),
+			slog.String("payment_status", billing.PaymentStatus),
+		)
+		return billing, "billing is " + billing.PaymentStatus + " and was not recalculated"
+	}
+	if err != nil {
+		dc.logger.Error("Failed to recalculate billing after diagnose change",
 			slog.Any("error", err),
-			slog.Int64("diagnose_id", id),
+			slog.Int64("billing_id", billing.ID),
 		)
+		return billing, "billing could not be recalculated"
+	}
+	return updated, ""
+}
+
+// SignDiagnose finalises a diagnosis; later changes become amendments.
+func (dc *DiagnoseController) SignDiagnose(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid diagnose ID",
+		})
+	}
+
+	doctorIDFromToken, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	diagnose, err := dc.service.Sign(id, doctorIDFromToken, dc.prescriptionService.Records())
+	if err != nil {
+		return dc.diagnoseError(c, id, err)
+	}
+	auditAppointmentPatient(c, dc.appointmentService, diagnose.AppointmentID)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "diagnose signed successfully",
+		"data":    diagnose,
+	})
+}
+
+// readableDiagnose returns the diagnosis when the calling doctor wrote it or
+// treats its appointment, otherwise it answers the request and returns nil.
+func (dc *DiagnoseController) readableDiagnose(c echo.Context, id int64) (*diagnoses.Diagnose, error) {
+	diagnose, err := dc.service.GetByID(id)
+	if err != nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "diagnose not found",
+		})
+	}
+
+	if dc.diagnoseReader(c, diagnose) != readerDoctor {
+		return nil, c.JSON(http.StatusForbidden, map[string]string{
+			"error": "You are not authorized to view this diagnose",
+		})
+	}
+	return diagnose, nil
+}
+
+// GetDiagnoseVersions lists every version of a diagnosis, oldest first.
+func (dc *DiagnoseController) GetDiagnoseVersions(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid diagnose ID",
+		})
+	}
+
+	diagnose, err := dc.readableDiagnose(c, id)
+	if diagnose == nil {
+		return err
+	}
+
+	versions, err := dc.service.GetVersions(id)
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update diagnose",
+			"error": "Failed to get diagnose versions",
 		})
 	}
 
-	newTotalAmount := dc.service.CalculateTotalAmount(diagnose)
-	billing, err := dc.billingService.GetByAppointmentID(diagnose.AppointmentID)
-	if err == nil && billing != nil {
-		dc.logger.Info("Billing amount should be updated",
-			slog.Float64("new_amount", newTotalAmount),
-			slog.Int64("billing_id", billing.ID),
-		)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "diagnose versions retrieved successfully",
+		"data":    versions,
+	})
+}
+
+func (dc *DiagnoseController) GetDiagnoseVersion(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid diagnose ID",
+		})
+	}
+	versionNumber, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid version",
+		})
+	}
+
+	diagnose, err := dc.readableDiagnose(c, id)
+	if diagnose == nil {
+		return err
+	}
+
+	version, err := dc.service.GetVersion(id, versionNumber)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "diagnose version retrieved successfully",
+		"data":    version,
+	})
+}
+
+// GetPrescriptionOverrides lists the safety alerts overridden when prescribing
+// in a diagnosis, with the doctor's justification.
+func (dc *DiagnoseController) GetPrescriptionOverrides(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid diagnose ID",
+		})
+	}
+
+	diagnose, err := dc.readableDiagnose(c, id)
+	if diagnose == nil {
+		return err
+	}
+
+	overrides, err := dc.safetyService.GetOverrides(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to get prescription overrides",
+		})
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"message":      "diagnose updated successfully",
-		"data":         diagnose,
-		"total_amount": newTotalAmount,
+		"message": "prescription overrides retrieved successfully",
+		"data":    overrides,
 	})
 }
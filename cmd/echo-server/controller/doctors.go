@@ -29,6 +29,7 @@ type DoctorRegisterRequest struct {
 	Email          string `json:"email" validate:"required,email"`
 	Password       string `json:"password" validate:"required,min=6"`
 	Specialization string `json:"specialization" validate:"required"`
+	LicenseNumber  string `json:"license_number" validate:"required,max=50"`
 }
 
 func (dc *DoctorController) Register(c echo.Context) error {
@@ -44,7 +45,7 @@ func (dc *DoctorController) Register(c echo.Context) error {
 			"error": err.Error(),
 		})
 	}
-	doctor, err := dc.service.Register(req.FullName, req.Email, req.Password, req.Specialization)
+	doctor, err := dc.service.Register(req.FullName, req.Email, req.Password, req.Specialization, req.LicenseNumber)
 	if err != nil {
 		dc.logger.Error("Failed to register doctor",
 			slog.Any("error", err),
@@ -62,6 +63,7 @@ func (dc *DoctorController) Register(c echo.Context) error {
 			"full_name":      doctor.FullName,
 			"email":          doctor.Email,
 			"specialization": doctor.Specialization,
+			"license_number": doctor.LicenseNumber,
 		},
 	})
 }
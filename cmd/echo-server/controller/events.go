@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	"Dedenruslan19/med-project/repository/pubsub"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type EventController struct {
+	broker    pubsub.Broker
+	keepAlive time.Duration
+	logger    *slog.Logger
+}
+
+// NewEventController streams events, writing a comment every keepAlive so
+// proxies do not close idle connections.
+func NewEventController(broker pubsub.Broker, keepAlive time.Duration, logger *slog.Logger) *EventController {
+	return &EventController{
+		broker:    broker,
+		keepAlive: keepAlive,
+		logger:    logger,
+	}
+}
+
+// StreamEvents pushes the caller's events as Server-Sent Events until the
+// client disconnects. Each event carries its type and ID; the data is the
+// JSON encoded event.
+func (ec *EventController) StreamEvents(c echo.Context) error {
+	id, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+	role, _ := middleware.GetRole(c)
+
+	subscription, err := ec.broker.Subscribe(pubsub.Principal{Role: role, ID: id})
+	if err != nil {
+		ec.logger.Error("Failed to subscribe to events", slog.Any("error", err))
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to subscribe to events",
+		})
+	}
+	defer subscription.Close()
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	w.Flush()
+
+	ticker := time.NewTicker(ec.keepAlive)
+	defer ticker.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				ec.logger.Error("Failed to encode event",
+					slog.Any("error", err),
+					slog.String("event_type", event.Type),
+				)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return nil
+			}
+			w.Flush()
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/history"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type HistoryController struct {
+	service history.Service
+	logger  *slog.Logger
+}
+
+func NewHistoryController(service history.Service, logger *slog.Logger) *HistoryController {
+	return &HistoryController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// patientAccess lets the patient and the doctors who have or had an
+// appointment with them through. Anyone else gets an answer here and false.
+func patientAccess(c echo.Context, historyService history.Service, patientID int64) (bool, error) {
+	middleware.SetAuditPatient(c, patientID)
+
+	callerID, ok := middleware.GetUserID(c)
+	if !ok {
+		return false, c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+	role, _ := middleware.GetRole(c)
+
+	switch role {
+	case "user":
+		if patientID == callerID {
+			return true, nil
+		}
+	case "doctor":
+		err := historyService.Authorize(callerID, patientID)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, history.ErrNoRelationship) {
+			return false, c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to check access to patient",
+			})
+		}
+	}
+	return false, c.JSON(http.StatusForbidden, map[string]string{
+		"error": "You are not authorized to view this patient",
+	})
+}
+
+// auditAppointmentPatient names the patient of an appointment in the access
+// log when the handler has not loaded the appointment itself.
+func auditAppointmentPatient(c echo.Context, appointmentService appointments.Service, appointmentID int64) {
+	if appointment, err := appointmentService.GetByID(appointmentID); err == nil {
+		middleware.SetAuditPatient(c, appointment.UserID)
+	}
+}
+
+// positiveQueryInt reads an optional positive integer query parameter, zero
+// when it is absent.
+func positiveQueryInt(c echo.Context, name string) (int, bool) {
+	param := c.QueryParam(name)
+	if param == "" {
+		return 0, true
+	}
+	parsed, err := strconv.Atoi(param)
+	if err != nil || parsed <= 0 {
+		return 0, false
+	}
+	return parsed, true
+}
+
+// GetPatientHistory returns a page of the patient's timeline, newest first.
+// type filters the events, e.g. type=diagnosis,prescription.
+func (hc *HistoryController) GetPatientHistory(c echo.Context) error {
+	doctorID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	patientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid patient ID",
+		})
+	}
+
+	page, ok := positiveQueryInt(c, "page")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid page",
+		})
+	}
+	perPage, ok := positiveQueryInt(c, "per_page")
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid per_page",
+		})
+	}
+	types, err := history.ParseTypes(c.QueryParam("type"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	timeline, err := hc.service.Timeline(doctorID, patientID, history.Query{Types: types, Page: page, PerPage: perPage})
+	switch {
+	case errors.Is(err, history.ErrNoRelationship):
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, history.ErrInvalidType):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get patient history",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Patient history retrieved successfully",
+		"data":    timeline,
+	})
+}
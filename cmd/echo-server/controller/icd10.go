@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/service/icd10"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CodeController struct {
+	service icd10.Service
+	logger  *slog.Logger
+}
+
+func NewCodeController(service icd10.Service, logger *slog.Logger) *CodeController {
+	return &CodeController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// SearchCodes searches the ICD-10 catalogue by code prefix (q=J06) or by
+// keywords of the title (q=upper respiratory).
+func (cc *CodeController) SearchCodes(c echo.Context) error {
+	limit := 0
+	if param := c.QueryParam("limit"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid limit",
+			})
+		}
+		limit = parsed
+	}
+
+	codes, err := cc.service.Search(c.QueryParam("q"), limit)
+	if errors.Is(err, icd10.ErrEmptyQuery) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to search ICD-10 codes",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "ICD-10 codes retrieved successfully",
+		"data":    codes,
+	})
+}
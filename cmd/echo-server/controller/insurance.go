@@ -0,0 +1,384 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/insurance"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type InsuranceController struct {
+	service            insurance.Service
+	billingService     billings.Service
+	appointmentService appointments.Service
+	validate           *validator.Validate
+	logger             *slog.Logger
+}
+
+func NewInsuranceController(service insurance.Service, billingService billings.Service, appointmentService appointments.Service, logger *slog.Logger) *InsuranceController {
+	return &InsuranceController{
+		service:            service,
+		billingService:     billingService,
+		appointmentService: appointmentService,
+		validate:           validator.New(),
+		logger:             logger,
+	}
+}
+
+type CreateInsurerRequest struct {
+	Code         string `json:"code" validate:"required,max=50"`
+	Name         string `json:"name" validate:"required"`
+	ContactEmail string `json:"contact_email" validate:"omitempty,email"`
+}
+
+type CreatePolicyRequest struct {
+	InsurerID     int64      `json:"insurer_id" validate:"required"`
+	PolicyNumber  string     `json:"policy_number" validate:"required,max=100"`
+	CoverageRate  float64    `json:"coverage_rate" validate:"gt=0,lte=1"`
+	PerClaimLimit float64    `json:"per_claim_limit" validate:"gte=0"`
+	AnnualLimit   float64    `json:"annual_limit" validate:"gte=0"`
+	ValidFrom     *time.Time `json:"valid_from"`
+	ValidUntil    *time.Time `json:"valid_until"`
+}
+
+type CreateClaimRequest struct {
+	PolicyID int64 `json:"policy_id" validate:"required"`
+}
+
+type DecideClaimRequest struct {
+	Status         string  `json:"status" validate:"required,oneof=approved partially_approved rejected"`
+	ApprovedAmount float64 `json:"approved_amount" validate:"gte=0"`
+	Reason         string  `json:"reason"`
+}
+
+// claimErrorStatus maps the business rule errors of the claim workflow.
+func claimErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, insurance.ErrClaimExists),
+		errors.Is(err, insurance.ErrClaimDecided),
+		errors.Is(err, billings.ErrBillingLocked):
+		return http.StatusConflict
+	case errors.Is(err, insurance.ErrInsurerInactive),
+		errors.Is(err, insurance.ErrInvalidCoverageRate),
+		errors.Is(err, insurance.ErrPolicyNotOwned),
+		errors.Is(err, insurance.ErrPolicyNotInForce),
+		errors.Is(err, insurance.ErrDiagnosisRequired),
+		errors.Is(err, insurance.ErrLimitExhausted),
+		errors.Is(err, insurance.ErrInvalidDecision),
+		errors.Is(err, insurance.ErrInvalidApprovedAmount),
+		errors.Is(err, billings.ErrInvalidInsuranceAmount):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (ic *InsuranceController) GetInsurers(c echo.Context) error {
+	insurers, err := ic.service.ListInsurers()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get insurers",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Insurers retrieved successfully",
+		"data":    insurers,
+	})
+}
+
+func (ic *InsuranceController) CreateInsurer(c echo.Context) error {
+	var req CreateInsurerRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := ic.validate.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	insurer := &insurance.Insurer{
+		Code:         req.Code,
+		Name:         req.Name,
+		ContactEmail: req.ContactEmail,
+		Active:       true,
+	}
+
+	id, err := ic.service.CreateInsurer(insurer)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create insurer",
+		})
+	}
+	insurer.ID = id
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Insurer created successfully",
+		"data":    insurer,
+	})
+}
+
+// GetMyPolicies lists the insurance policies of the authenticated patient.
+func (ic *InsuranceController) GetMyPolicies(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	policies, err := ic.service.GetPolicies(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get policies",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Policies retrieved successfully",
+		"data":    policies,
+	})
+}
+
+// AddMyPolicy registers an insurance policy for the authenticated patient.
+func (ic *InsuranceController) AddMyPolicy(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	var req CreatePolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := ic.validate.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	policy := &insurance.Policy{
+		UserID:        userID,
+		InsurerID:     req.InsurerID,
+		PolicyNumber:  req.PolicyNumber,
+		CoverageRate:  req.CoverageRate,
+		PerClaimLimit: req.PerClaimLimit,
+		AnnualLimit:   req.AnnualLimit,
+		ValidFrom:     req.ValidFrom,
+		ValidUntil:    req.ValidUntil,
+	}
+
+	id, err := ic.service.AddPolicy(policy)
+	if err != nil {
+		status := claimErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			return c.JSON(status, map[string]string{
+				"error": "Failed to add policy",
+			})
+		}
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	policy.ID = id
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Policy added successfully",
+		"data":    policy,
+	})
+}
+
+// CreateClaim submits a claim for a billing of one of the doctor's appointments.
+func (ic *InsuranceController) CreateClaim(c echo.Context) error {
+	billingID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid billing ID",
+		})
+	}
+
+	var req CreateClaimRequest
+	if bindErr := c.Bind(&req); bindErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if validateErr := ic.validate.Struct(req); validateErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": validateErr.Error(),
+		})
+	}
+
+	doctorIDFromToken, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	billing, err := ic.billingService.GetByID(billingID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Billing not found",
+		})
+	}
+
+	appointment, err := ic.appointmentService.GetByID(billing.AppointmentID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to validate ownership"})
+	}
+	if appointment.DoctorID != doctorIDFromToken {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "You are not authorized to claim this billing"})
+	}
+
+	claim, err := ic.service.GenerateClaim(billingID, req.PolicyID)
+	if err != nil {
+		status := claimErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			ic.logger.Error("Failed to generate claim",
+				slog.Any("error", err),
+				slog.Int64("billing_id", billingID),
+			)
+			return c.JSON(status, map[string]string{
+				"error": "Failed to generate claim",
+			})
+		}
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Claim submitted successfully",
+		"data":    claim,
+	})
+}
+
+func (ic *InsuranceController) GetClaims(c echo.Context) error {
+	var filter insurance.ClaimFilter
+	filter.Status = c.QueryParam("status")
+	if insurerParam := c.QueryParam("insurer_id"); insurerParam != "" {
+		insurerID, err := strconv.ParseInt(insurerParam, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid insurer ID",
+			})
+		}
+		filter.InsurerID = insurerID
+	}
+
+	claims, err := ic.service.ListClaims(filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get claims",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Claims retrieved successfully",
+		"data":    claims,
+	})
+}
+
+func (ic *InsuranceController) GetClaimByID(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid claim ID",
+		})
+	}
+
+	claim, err := ic.service.GetClaimByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Claim not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Claim retrieved successfully",
+		"data":    claim,
+	})
+}
+
+// DecideClaim records the insurer's decision and reconciles the billing.
+func (ic *InsuranceController) DecideClaim(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid claim ID",
+		})
+	}
+
+	var req DecideClaimRequest
+	if bindErr := c.Bind(&req); bindErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if validateErr := ic.validate.Struct(req); validateErr != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": validateErr.Error(),
+		})
+	}
+
+	claim, err := ic.service.DecideClaim(id, req.Status, req.ApprovedAmount, req.Reason)
+	if err != nil {
+		status := claimErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			ic.logger.Error("Failed to decide claim",
+				slog.Any("error", err),
+				slog.Int64("claim_id", id),
+			)
+			return c.JSON(status, map[string]string{
+				"error": "Failed to decide claim",
+			})
+		}
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Claim decision recorded successfully",
+		"data":    claim,
+	})
+}
+
+// ExportClaims returns the claim batch of one insurer as JSON or, with
+// format=csv, as a CSV download.
+func (ic *InsuranceController) ExportClaims(c echo.Context) error {
+	insurerID, err := strconv.ParseInt(c.QueryParam("insurer_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "insurer_id is required",
+		})
+	}
+
+	batch, err := ic.service.ExportBatch(insurerID, c.QueryParam("status"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to export claims",
+		})
+	}
+
+	if c.QueryParam("format") != "csv" {
+		return c.JSON(http.StatusOK, batch)
+	}
+
+	return writeCSV(c, batch.BatchID+".csv", func(w *echo.Response) error {
+		return batch.WriteCSV(w)
+	})
+}
@@ -82,6 +82,8 @@ func (ic *InvoiceController) GetInvoiceByBillingID(c echo.Context) error {
 			"error": "Failed to get appointment details",
 		})
 	}
+	middleware.SetAuditPatient(c, appointment.UserID)
+	middleware.SetAuditResource(c, invoice.ID)
 
 	if appointment.DoctorID != doctorIDFromToken {
 		ic.logger.Warn("doctor attempting to access invoice for another doctor's appointment",
@@ -187,6 +189,7 @@ func (ic *InvoiceController) SendInvoice(c echo.Context) error {
 		)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get appointment"})
 	}
+	middleware.SetAuditPatient(c, appointment.UserID)
 
 	if appointment.DoctorID != doctorIDFromToken {
 		ic.logger.Warn("doctor attempting to send invoice for another doctor's appointment",
@@ -197,6 +200,16 @@ func (ic *InvoiceController) SendInvoice(c echo.Context) error {
 		return c.JSON(http.StatusForbidden, map[string]string{"error": "You are not authorized to send invoice for this billing"})
 	}
 
+	if _, err := ic.billingService.GenerateInvoice(req.BillingID, ""); err != nil {
+		ic.logger.Error("Failed to generate invoice before sending",
+			slog.Any("error", err),
+			slog.Int64("billing_id", req.BillingID),
+		)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to generate invoice",
+		})
+	}
+
 	invoice, err := ic.invoiceService.SendInvoice(req.BillingID, req.Email)
 	if err != nil {
 		ic.logger.Error("Failed to send invoice",
@@ -208,8 +221,58 @@ func (ic *InvoiceController) SendInvoice(c echo.Context) error {
 		})
 	}
 
+	middleware.SetAuditResource(c, invoice.ID)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Invoice queued for sending",
+		"data":    invoice,
+	})
+}
+
+// GetMyInvoices lists the invoices of the authenticated patient.
+func (ic *InvoiceController) GetMyInvoices(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	list, err := ic.invoiceService.GetByUserID(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get invoices",
+		})
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"message": "Invoice sent successfully",
+		"message": "Invoices retrieved successfully",
+		"data":    list,
+	})
+}
+
+// GetMyInvoiceByID returns one invoice of the authenticated patient. Invoices
+// of other patients are reported as not found.
+func (ic *InvoiceController) GetMyInvoiceByID(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid invoice ID",
+		})
+	}
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+	}
+
+	invoice, err := ic.invoiceService.GetByIDForUser(id, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Invoice not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Invoice retrieved successfully",
 		"data":    invoice,
 	})
 }
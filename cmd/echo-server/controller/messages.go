@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	"Dedenruslan19/med-project/service/messages"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MessageController struct {
+	service messages.Service
+	logger  *slog.Logger
+}
+
+func NewMessageController(service messages.Service, logger *slog.Logger) *MessageController {
+	return &MessageController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+type PostMessageInput struct {
+	Body          string  `json:"body"`
+	AttachmentIDs []int64 `json:"attachment_ids"`
+}
+
+func messageCaller(c echo.Context) (messages.Caller, bool) {
+	id, ok := middleware.GetUserID(c)
+	if !ok {
+		return messages.Caller{}, false
+	}
+	role, _ := middleware.GetRole(c)
+	return messages.Caller{ID: id, Role: role}, true
+}
+
+// messageError answers a failed messaging request.
+func (mc *MessageController) messageError(c echo.Context, err error, action string) error {
+	switch {
+	case errors.Is(err, messages.ErrAppointmentNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, messages.ErrForbidden):
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, messages.ErrThreadClosed):
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, messages.ErrEmptyMessage), errors.Is(err, messages.ErrMessageTooLong),
+		errors.Is(err, messages.ErrInvalidAttachment):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	mc.logger.Error("Failed to "+action, slog.Any("error", err))
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Failed to " + action,
+	})
+}
+
+// GetThreads lists the caller's message threads with their unread counts.
+func (mc *MessageController) GetThreads(c echo.Context) error {
+	caller, ok := messageCaller(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	threads, err := mc.service.Threads(caller)
+	if err != nil {
+		return mc.messageError(c, err, "get message threads")
+	}
+
+	var unread int64
+	for _, thread := range threads {
+		unread += thread.UnreadCount
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Message threads retrieved successfully",
+		"data": map[string]interface{}{
+			"threads":      threads,
+			"unread_count": unread,
+		},
+	})
+}
+
+// GetAppointmentMessages returns the appointment's thread and marks the
+// other party's messages as read.
+func (mc *MessageController) GetAppointmentMessages(c echo.Context) error {
+	caller, ok := messageCaller(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	appointmentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid appointment ID",
+		})
+	}
+
+	thread, err := mc.service.Thread(caller, appointmentID)
+	if err != nil {
+		return mc.messageError(c, err, "get messages")
+	}
+	middleware.SetAuditPatient(c, thread.UserID)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Messages retrieved successfully",
+		"data":    thread,
+	})
+}
+
+// PostAppointmentMessage posts to the appointment's thread. Attachments are
+// uploaded to the appointment first and referenced by ID.
+func (mc *MessageController) PostAppointmentMessage(c echo.Context) error {
+	caller, ok := messageCaller(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	appointmentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid appointment ID",
+		})
+	}
+
+	var input PostMessageInput
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	thread, message, err := mc.service.Post(caller, appointmentID, messages.Post{
+		Body:          input.Body,
+		AttachmentIDs: input.AttachmentIDs,
+	})
+	if err != nil {
+		return mc.messageError(c, err, "post message")
+	}
+	middleware.SetAuditPatient(c, thread.UserID)
+	middleware.SetAuditResource(c, message.ID)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Message posted successfully",
+		"data":    message,
+	})
+}
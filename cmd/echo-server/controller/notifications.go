@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	"Dedenruslan19/med-project/repository/notification"
+	"Dedenruslan19/med-project/service/notifications"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationController struct {
+	service  notifications.Service
+	validate *validator.Validate
+	logger   *slog.Logger
+}
+
+func NewNotificationController(service notifications.Service, logger *slog.Logger) *NotificationController {
+	return &NotificationController{
+		service:  service,
+		validate: validator.New(),
+		logger:   logger,
+	}
+}
+
+type NotificationChannelInput struct {
+	Channel     string `json:"channel" validate:"required"`
+	Destination string `json:"destination"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Category string                     `json:"category" validate:"required,oneof=appointment billing invoice message"`
+	Channels []NotificationChannelInput `json:"channels" validate:"dive"`
+}
+
+func (nc *NotificationController) GetPreferences(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrUnauthorized)
+	}
+
+	prefs, err := nc.service.GetPreferences(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrInternalServer)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":            "Notification preferences retrieved successfully",
+		"data":               prefs,
+		"available_channels": nc.service.Channels(),
+	})
+}
+
+func (nc *NotificationController) UpdatePreferences(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrUnauthorized)
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrInvalidRequestBody)
+	}
+
+	if err := nc.validate.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	prefs := make([]notifications.Preference, 0, len(req.Channels))
+	for _, ch := range req.Channels {
+		prefs = append(prefs, notifications.Preference{
+			Channel:     ch.Channel,
+			Destination: ch.Destination,
+		})
+	}
+
+	saved, err := nc.service.SetPreferences(userID, req.Category, prefs)
+	if err != nil {
+		if errors.Is(err, notifications.ErrUnsupportedChannel) ||
+			errors.Is(err, notifications.ErrUnsupportedCategory) ||
+			errors.Is(err, notifications.ErrDestinationRequired) ||
+			errors.Is(err, notification.ErrUnsafeWebhookURL) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		nc.logger.Error("Failed to update notification preferences",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+		)
+		return c.JSON(http.StatusInternalServerError, ErrInternalServer)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Notification preferences updated successfully",
+		"data":    saved,
+	})
+}
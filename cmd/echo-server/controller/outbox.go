@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/service/outbox"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OutboxController struct {
+	service outbox.Service
+	logger  *slog.Logger
+}
+
+func NewOutboxController(service outbox.Service, logger *slog.Logger) *OutboxController {
+	return &OutboxController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetMessages lists outbox messages by status, dead letters by default.
+func (oc *OutboxController) GetMessages(c echo.Context) error {
+	status := c.QueryParam("status")
+	if status == "" {
+		status = outbox.StatusDead
+	}
+	if status != outbox.StatusPending && status != outbox.StatusDelivered && status != outbox.StatusDead {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid status",
+		})
+	}
+
+	limit := 100
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid limit",
+			})
+		}
+		limit = parsed
+	}
+
+	messages, err := oc.service.ListByStatus(status, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get outbox messages",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Outbox messages retrieved successfully",
+		"data":    messages,
+	})
+}
+
+func (oc *OutboxController) GetMessageByID(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid message ID",
+		})
+	}
+
+	msg, err := oc.service.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Outbox message not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Outbox message retrieved successfully",
+		"data":    msg,
+	})
+}
+
+func (oc *OutboxController) ReplayMessage(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid message ID",
+		})
+	}
+
+	if err := oc.service.Replay(id); err != nil {
+		if errors.Is(err, outbox.ErrNotDeadLettered) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		oc.logger.Error("Failed to replay outbox message",
+			slog.Any("error", err),
+			slog.Int64("message_id", id),
+		)
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Outbox message not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Outbox message queued for replay",
+	})
+}
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/prescriptions"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type PrescriptionController struct {
+	service            prescriptions.Service
+	diagnoseService    diagnoses.Service
+	appointmentService appointments.Service
+	validate           *validator.Validate
+	logger             *slog.Logger
+}
+
+func NewPrescriptionController(service prescriptions.Service, diagnoseService diagnoses.Service, appointmentService appointments.Service, logger *slog.Logger) *PrescriptionController {
+	return &PrescriptionController{
+		service:            service,
+		diagnoseService:    diagnoseService,
+		appointmentService: appointmentService,
+		validate:           validator.New(),
+		logger:             logger,
+	}
+}
+
+type RegisterPharmacyRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// GetPrescription returns the prescription of a signed diagnosis as a PDF,
+// or as JSON with format=json, to its doctor and its patient.
+func (pc *PrescriptionController) GetPrescription(c echo.Context) error {
+	callerID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+	role, _ := middleware.GetRole(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid diagnose ID",
+		})
+	}
+
+	diagnose, err := pc.diagnoseService.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Diagnose not found",
+		})
+	}
+	appointment, err := pc.appointmentService.GetByID(diagnose.AppointmentID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Appointment not found",
+		})
+	}
+	middleware.SetAuditPatient(c, appointment.UserID)
+	isDoctor := role == "doctor" && (diagnose.DoctorID == callerID || appointment.DoctorID == callerID)
+	isPatient := role == "user" && appointment.UserID == callerID
+	if !isDoctor && !isPatient {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "You are not authorized to view this prescription",
+		})
+	}
+
+	prescription, err := pc.service.Issue(id)
+	switch {
+	case errors.Is(err, prescriptions.ErrNotSigned), errors.Is(err, prescriptions.ErrNoMedications),
+		errors.Is(err, prescriptions.ErrLicenseMissing):
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, prescriptions.ErrDiagnoseNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Diagnose not found",
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to issue prescription",
+		})
+	}
+
+	if c.QueryParam("format") == "json" {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"message": "Prescription retrieved successfully",
+			"data":    prescription,
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/pdf")
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`inline; filename="prescription-%s.pdf"`, prescription.DisplayCode()))
+	c.Response().WriteHeader(http.StatusOK)
+	return pc.service.WritePDF(c.Response(), prescription)
+}
+
+// VerifyPrescription lets a pharmacy check a printed verification code
+// without an account.
+func (pc *PrescriptionController) VerifyPrescription(c echo.Context) error {
+	verification, err := pc.service.Verify(c.Param("code"))
+	if errors.Is(err, prescriptions.ErrPrescriptionNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{
+			"error":   "No prescription matches this code",
+			"genuine": false,
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to verify prescription",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Prescription verified",
+		"data":    verification,
+	})
+}
+
+// DispensePrescription marks a verified prescription as dispensed by the
+// pharmacy of the API key, so the same paper cannot be used twice.
+func (pc *PrescriptionController) DispensePrescription(c echo.Context) error {
+	pharmacy, ok := middleware.GetPharmacy(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	verification, err := pc.service.Dispense(c.Param("code"), pharmacy)
+	switch {
+	case errors.Is(err, prescriptions.ErrPrescriptionNotFound):
+		return c.JSON(http.StatusNotFound, map[string]interface{}{
+			"error":   "No prescription matches this code",
+			"genuine": false,
+		})
+	case errors.Is(err, prescriptions.ErrAlreadyDispensed), errors.Is(err, prescriptions.ErrPrescriptionRevoked):
+		return c.JSON(http.StatusConflict, map[string]interface{}{
+			"error": err.Error(),
+			"data":  verification,
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to dispense prescription",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Prescription dispensed",
+		"data":    verification,
+	})
+}
+
+// RegisterPharmacy allows a pharmacy to dispense prescriptions and returns
+// its API key, which is not shown again.
+func (pc *PrescriptionController) RegisterPharmacy(c echo.Context) error {
+	var req RegisterPharmacyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if err := pc.validate.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	pharmacy, key, err := pc.service.RegisterPharmacy(req.Name)
+	if errors.Is(err, prescriptions.ErrInvalidPharmacy) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to register pharmacy",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Pharmacy registered, keep the key: it is not shown again",
+		"data":    pharmacy,
+		"key":     key,
+	})
+}
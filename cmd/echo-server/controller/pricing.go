@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/service/pricing"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type PricingController struct {
+	service  pricing.Service
+	validate *validator.Validate
+	logger   *slog.Logger
+}
+
+func NewPricingController(service pricing.Service, logger *slog.Logger) *PricingController {
+	return &PricingController{
+		service:  service,
+		validate: validator.New(),
+		logger:   logger,
+	}
+}
+
+type CreatePromoCodeRequest struct {
+	Code           string     `json:"code" validate:"required,max=50"`
+	DiscountType   string     `json:"discount_type" validate:"required,oneof=percentage fixed"`
+	Value          float64    `json:"value" validate:"gt=0"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	MaxRedemptions int        `json:"max_redemptions" validate:"gte=0"`
+}
+
+func (pc *PricingController) GetPromoCodes(c echo.Context) error {
+	promos, err := pc.service.ListPromoCodes()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get promo codes",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Promo codes retrieved successfully",
+		"data":    promos,
+	})
+}
+
+func (pc *PricingController) CreatePromoCode(c echo.Context) error {
+	var req CreatePromoCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := pc.validate.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	promo := &pricing.PromoCode{
+		Code:           req.Code,
+		DiscountType:   req.DiscountType,
+		Value:          req.Value,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		MaxRedemptions: req.MaxRedemptions,
+		Active:         true,
+	}
+
+	id, err := pc.service.CreatePromoCode(promo)
+	if errors.Is(err, pricing.ErrInvalidDiscount) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create promo code",
+		})
+	}
+	promo.ID = id
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Promo code created successfully",
+		"data":    promo,
+	})
+}
+
+// pricingErrorStatus maps validation errors of the pricing rules to 400 and
+// anything else to 500.
+func pricingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pricing.ErrInvalidDiscount),
+		errors.Is(err, pricing.ErrInvalidCoverage),
+		errors.Is(err, pricing.ErrPromoNotFound),
+		errors.Is(err, pricing.ErrPromoNotUsable),
+		errors.Is(err, pricing.ErrPromoExhausted):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
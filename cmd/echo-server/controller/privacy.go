@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	errs "Dedenruslan19/med-project/service/errors"
+	"Dedenruslan19/med-project/service/privacy"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PrivacyController struct {
+	service privacy.Service
+	logger  *slog.Logger
+}
+
+func NewPrivacyController(service privacy.Service, logger *slog.Logger) *PrivacyController {
+	return &PrivacyController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+type EraseAccountInput struct {
+	Password string `json:"password"`
+}
+
+// ExportMyData downloads the authenticated patient's data as a ZIP of JSON
+// files.
+func (pc *PrivacyController) ExportMyData(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	export, err := pc.service.Export(userID)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "User not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to export data",
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="export-%d-%s.zip"`, userID, export.GeneratedAt.Format("20060102")))
+	c.Response().WriteHeader(http.StatusOK)
+	return export.WriteZIP(c.Response())
+}
+
+// DeleteMyAccount anonymises the authenticated patient's account once they
+// confirmed their password. Financial records are retained.
+func (pc *PrivacyController) DeleteMyAccount(c echo.Context) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	var input EraseAccountInput
+	if err := c.Bind(&input); err != nil || input.Password == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Password is required",
+		})
+	}
+
+	if err := pc.service.Erase(userID, input.Password); err != nil {
+		switch {
+		case errors.Is(err, errs.ErrUserNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "User not found",
+			})
+		case errors.Is(err, errs.ErrInvalidPass):
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Password doesn't match",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to delete account",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Account deleted successfully",
+		"data":    map[string]interface{}{"id": userID},
+	})
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/reconciliation"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// maxStatementSize bounds an uploaded bank statement.
+const maxStatementSize = 5 << 20
+
+type ReconciliationController struct {
+	service  reconciliation.Service
+	validate *validator.Validate
+	logger   *slog.Logger
+}
+
+func NewReconciliationController(service reconciliation.Service, logger *slog.Logger) *ReconciliationController {
+	return &ReconciliationController{
+		service:  service,
+		validate: validator.New(),
+		logger:   logger,
+	}
+}
+
+type ConfirmMatchesRequest struct {
+	Matches []reconciliation.Confirmation `json:"matches" validate:"required,min=1,dive"`
+}
+
+// UploadStatement matches a bank statement CSV against the billings waiting
+// for payment. The statement is sent as the "statement" file of a multipart
+// form, or as a text/csv body.
+func (rc *ReconciliationController) UploadStatement(c echo.Context) error {
+	var statement io.Reader
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), "text/csv") {
+		statement = c.Request().Body
+	} else {
+		file, err := c.FormFile("statement")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Upload the bank statement as the statement file or as a text/csv body",
+			})
+		}
+		src, err := file.Open()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Failed to read bank statement",
+			})
+		}
+		defer src.Close()
+		statement = src
+	}
+
+	result, err := rc.service.Reconcile(io.LimitReader(statement, maxStatementSize))
+	if errors.Is(err, reconciliation.ErrInvalidStatement) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to reconcile bank statement",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Bank statement reconciled, confirm the matches to record the payments",
+		"data":    result,
+	})
+}
+
+// ConfirmMatches records the payments of confirmed matches. Each match is
+// confirmed on its own, so one failing match does not block the others.
+func (rc *ReconciliationController) ConfirmMatches(c echo.Context) error {
+	var req ConfirmMatchesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := rc.validate.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	type outcome struct {
+		BillingID int64             `json:"billing_id"`
+		Payment   *billings.Payment `json:"payment,omitempty"`
+		Error     string            `json:"error,omitempty"`
+	}
+	outcomes := make([]outcome, 0, len(req.Matches))
+	confirmed := 0
+	for _, match := range req.Matches {
+		payment, err := rc.service.Confirm(match)
+		if err != nil {
+			rc.logger.Warn("Failed to confirm reconciliation match",
+				slog.Any("error", err),
+				slog.Int64("billing_id", match.BillingID),
+			)
+			message := err.Error()
+			if !errors.Is(err, reconciliation.ErrNotAwaitingPayment) && !errors.Is(err, reconciliation.ErrAmountMismatch) &&
+				!errors.Is(err, billings.ErrInvalidTransition) {
+				message = "Failed to record payment"
+			}
+			outcomes = append(outcomes, outcome{BillingID: match.BillingID, Error: message})
+			continue
+		}
+		confirmed++
+		outcomes = append(outcomes, outcome{BillingID: match.BillingID, Payment: payment})
+	}
+
+	status := http.StatusOK
+	if confirmed == 0 {
+		status = http.StatusConflict
+	}
+	return c.JSON(status, map[string]interface{}{
+		"message":   "Reconciliation matches processed",
+		"confirmed": confirmed,
+		"data":      outcomes,
+	})
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/service/reports"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const reportDateLayout = "2006-01-02"
+
+type ReportController struct {
+	service reports.Service
+	logger  *slog.Logger
+}
+
+func NewReportController(service reports.Service, logger *slog.Logger) *ReportController {
+	return &ReportController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// reportPeriod reads the inclusive from/to dates of a report, defaulting to
+// the current month.
+func reportPeriod(c echo.Context) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	if param := c.QueryParam("from"); param != "" {
+		parsed, err := time.Parse(reportDateLayout, param)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = parsed
+	}
+	if param := c.QueryParam("to"); param != "" {
+		parsed, err := time.Parse(reportDateLayout, param)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+func writeCSV(c echo.Context, filename string, write func(w *echo.Response) error) error {
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	c.Response().WriteHeader(http.StatusOK)
+	return write(c.Response())
+}
+
+// GetRevenue reports billed and collected revenue grouped by day, week, month,
+// doctor or specialization.
+func (rc *ReportController) GetRevenue(c echo.Context) error {
+	from, to, err := reportPeriod(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Dates must be formatted as YYYY-MM-DD",
+		})
+	}
+
+	groupBy := c.QueryParam("group_by")
+	if groupBy == "" {
+		groupBy = reports.GroupByMonth
+	}
+
+	report, err := rc.service.Revenue(from, to, groupBy)
+	if errors.Is(err, reports.ErrInvalidGroupBy) || errors.Is(err, reports.ErrInvalidPeriod) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to build revenue report",
+		})
+	}
+
+	if c.QueryParam("format") == "csv" {
+		return writeCSV(c, "revenue-by-"+groupBy+".csv", func(w *echo.Response) error {
+			return report.WriteCSV(w)
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Revenue report generated successfully",
+		"data":    report,
+	})
+}
+
+// GetReceivables reports outstanding patient balances in aging buckets.
+func (rc *ReportController) GetReceivables(c echo.Context) error {
+	asOf := time.Now().UTC()
+	if param := c.QueryParam("as_of"); param != "" {
+		parsed, err := time.Parse(reportDateLayout, param)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "as_of must be formatted as YYYY-MM-DD",
+			})
+		}
+		asOf = parsed.AddDate(0, 0, 1)
+	}
+
+	report, err := rc.service.Receivables(asOf)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to build receivables report",
+		})
+	}
+
+	if c.QueryParam("format") == "csv" {
+		return writeCSV(c, "receivables.csv", func(w *echo.Response) error {
+			return report.WriteCSV(w)
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Receivables report generated successfully",
+		"data":    report,
+	})
+}
+
+// GetConditions counts diagnoses per ICD-10 code, optionally only the codes
+// starting with the code parameter.
+func (rc *ReportController) GetConditions(c echo.Context) error {
+	from, to, err := reportPeriod(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Dates must be formatted as YYYY-MM-DD",
+		})
+	}
+
+	prefix := strings.ToUpper(strings.TrimSpace(c.QueryParam("code")))
+	report, err := rc.service.Conditions(from, to, prefix)
+	if errors.Is(err, reports.ErrInvalidPeriod) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to build condition report",
+		})
+	}
+
+	if c.QueryParam("format") == "csv" {
+		return writeCSV(c, "conditions.csv", func(w *echo.Response) error {
+			return report.WriteCSV(w)
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Condition report generated successfully",
+		"data":    report,
+	})
+}
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/history"
+	"Dedenruslan19/med-project/service/vitals"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type VitalController struct {
+	service            vitals.Service
+	appointmentService appointments.Service
+	historyService     history.Service
+	validate           *validator.Validate
+	logger             *slog.Logger
+}
+
+func NewVitalController(service vitals.Service, appointmentService appointments.Service, historyService history.Service, logger *slog.Logger) *VitalController {
+	return &VitalController{
+		service:            service,
+		appointmentService: appointmentService,
+		historyService:     historyService,
+		validate:           validator.New(),
+		logger:             logger,
+	}
+}
+
+// RecordVitalsRequest takes temperature in C or F, weight in kg or lb and
+// height in cm or in; the defaults are C, kg and cm.
+type RecordVitalsRequest struct {
+	SystolicBP      *float64   `json:"systolic_bp"`
+	DiastolicBP     *float64   `json:"diastolic_bp"`
+	HeartRate       *float64   `json:"heart_rate"`
+	Temperature     *float64   `json:"temperature"`
+	TemperatureUnit string     `json:"temperature_unit" validate:"omitempty,oneof=C F c f"`
+	SpO2            *float64   `json:"spo2"`
+	RespiratoryRate *float64   `json:"respiratory_rate"`
+	Weight          *float64   `json:"weight"`
+	WeightUnit      string     `json:"weight_unit" validate:"omitempty,oneof=kg lb"`
+	Height          *float64   `json:"height"`
+	HeightUnit      string     `json:"height_unit" validate:"omitempty,oneof=cm in"`
+	RecordedAt      *time.Time `json:"recorded_at"`
+	UpdateProfile   bool       `json:"update_profile"`
+}
+
+func (vc *VitalController) RecordVitals(c echo.Context) error {
+	doctorID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+
+	appointmentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid appointment ID",
+		})
+	}
+
+	var req RecordVitalsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if err := vc.validate.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	measurements := vitals.Measurements{
+		SystolicBP:      req.SystolicBP,
+		DiastolicBP:     req.DiastolicBP,
+		HeartRate:       req.HeartRate,
+		Temperature:     req.Temperature,
+		TemperatureUnit: req.TemperatureUnit,
+		SpO2:            req.SpO2,
+		RespiratoryRate: req.RespiratoryRate,
+		Weight:          req.Weight,
+		WeightUnit:      req.WeightUnit,
+		Height:          req.Height,
+		HeightUnit:      req.HeightUnit,
+	}
+	if req.RecordedAt != nil {
+		measurements.RecordedAt = *req.RecordedAt
+	}
+
+	auditAppointmentPatient(c, vc.appointmentService, appointmentID)
+	vital, err := vc.service.Record(doctorID, appointmentID, measurements, req.UpdateProfile)
+	switch {
+	case errors.Is(err, vitals.ErrAppointmentNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Appointment not found",
+		})
+	case errors.Is(err, vitals.ErrNotTreatingDoctor):
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, vitals.ErrNoMeasurements),
+		errors.Is(err, vitals.ErrInvalidUnit),
+		errors.Is(err, vitals.ErrOutOfRange),
+		errors.Is(err, vitals.ErrInvalidMeasurement):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to record vitals",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Vitals recorded successfully",
+		"data":    vital,
+	})
+}
+
+// GetAppointmentVitals lists the vitals of an appointment for its doctor and
+// its patient.
+func (vc *VitalController) GetAppointmentVitals(c echo.Context) error {
+	callerID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "Unauthorized",
+		})
+	}
+	role, _ := middleware.GetRole(c)
+
+	appointmentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid appointment ID",
+		})
+	}
+
+	appointment, err := vc.appointmentService.GetByID(appointmentID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Appointment not found",
+		})
+	}
+	middleware.SetAuditPatient(c, appointment.UserID)
+	if !(role == "doctor" && appointment.DoctorID == callerID) && !(role == "user" && appointment.UserID == callerID) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "You are not authorized to view vitals of this appointment",
+		})
+	}
+
+	list, err := vc.service.GetByAppointmentID(appointmentID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get vitals",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Vitals retrieved successfully",
+		"data":    list,
+	})
+}
+
+// GetVitalTrend returns the patient's measurements over time, for the patient
+// and the doctors who treat them. measurement selects the series, e.g.
+// measurement=systolic_bp,diastolic_bp; the period defaults to the last year.
+func (vc *VitalController) GetVitalTrend(c echo.Context) error {
+	patientID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid patient ID",
+		})
+	}
+
+	if ok, err := patientAccess(c, vc.historyService, patientID); !ok {
+		return err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to := today.AddDate(-1, 0, 0), today.AddDate(0, 0, 1)
+	if param := c.QueryParam("from"); param != "" {
+		if from, err = time.Parse(reportDateLayout, param); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Dates must be formatted as YYYY-MM-DD",
+			})
+		}
+	}
+	if param := c.QueryParam("to"); param != "" {
+		parsed, err := time.Parse(reportDateLayout, param)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Dates must be formatted as YYYY-MM-DD",
+			})
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	var measurements []string
+	for _, name := range strings.Split(c.QueryParam("measurement"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			measurements = append(measurements, name)
+		}
+	}
+
+	trend, err := vc.service.Trend(patientID, measurements, from, to)
+	if errors.Is(err, vitals.ErrInvalidMeasurement) || errors.Is(err, vitals.ErrInvalidPeriod) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get vital trend",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Vital trend retrieved successfully",
+		"data":    trend,
+	})
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
@@ -11,29 +12,67 @@ import (
 
 	"Dedenruslan19/med-project/cmd/echo-server/controller"
 	"Dedenruslan19/med-project/cmd/echo-server/middleware"
+	accountingRepository "Dedenruslan19/med-project/repository/accounting"
 	"Dedenruslan19/med-project/repository/appointment"
+	"Dedenruslan19/med-project/repository/attachment"
+	auditRepository "Dedenruslan19/med-project/repository/audit"
 	"Dedenruslan19/med-project/repository/billing"
 	"Dedenruslan19/med-project/repository/diagnose"
 	"Dedenruslan19/med-project/repository/doctor"
 	"Dedenruslan19/med-project/repository/exercise"
 	"Dedenruslan19/med-project/repository/gemini"
+	historyRepository "Dedenruslan19/med-project/repository/history"
+	icd10Repository "Dedenruslan19/med-project/repository/icd10"
+	idempotencyRepository "Dedenruslan19/med-project/repository/idempotency"
+	insuranceRepository "Dedenruslan19/med-project/repository/insurance"
 	"Dedenruslan19/med-project/repository/invoice"
 	"Dedenruslan19/med-project/repository/logs"
+	messageRepository "Dedenruslan19/med-project/repository/message"
 	"Dedenruslan19/med-project/repository/notification"
+	outboxRepository "Dedenruslan19/med-project/repository/outbox"
+	"Dedenruslan19/med-project/repository/preference"
+	"Dedenruslan19/med-project/repository/prescription"
+	privacyRepository "Dedenruslan19/med-project/repository/privacy"
+	"Dedenruslan19/med-project/repository/promo"
+	"Dedenruslan19/med-project/repository/pubsub"
 	"Dedenruslan19/med-project/repository/rapidAPI/bmi"
+	reconciliationRepository "Dedenruslan19/med-project/repository/reconciliation"
+	"Dedenruslan19/med-project/repository/report"
+	safetyRepository "Dedenruslan19/med-project/repository/safety"
+	"Dedenruslan19/med-project/repository/storage"
 	"Dedenruslan19/med-project/repository/user"
+	"Dedenruslan19/med-project/repository/video"
+	vitalsRepository "Dedenruslan19/med-project/repository/vitals"
 	"Dedenruslan19/med-project/repository/workout"
+	accountingService "Dedenruslan19/med-project/service/accounting"
 	appointmentService "Dedenruslan19/med-project/service/appointments"
+	attachmentService "Dedenruslan19/med-project/service/attachments"
+	auditService "Dedenruslan19/med-project/service/audit"
 	billingService "Dedenruslan19/med-project/service/billings"
 	diagnoseService "Dedenruslan19/med-project/service/diagnoses"
 	doctorService "Dedenruslan19/med-project/service/doctors"
 	exerciseService "Dedenruslan19/med-project/service/exercises"
+	historyService "Dedenruslan19/med-project/service/history"
+	icd10Service "Dedenruslan19/med-project/service/icd10"
+	idempotencyService "Dedenruslan19/med-project/service/idempotency"
+	insuranceService "Dedenruslan19/med-project/service/insurance"
 	invoiceService "Dedenruslan19/med-project/service/invoices"
 	logService "Dedenruslan19/med-project/service/logs"
+	messageService "Dedenruslan19/med-project/service/messages"
+	notificationService "Dedenruslan19/med-project/service/notifications"
+	outboxService "Dedenruslan19/med-project/service/outbox"
+	prescriptionService "Dedenruslan19/med-project/service/prescriptions"
+	pricingService "Dedenruslan19/med-project/service/pricing"
+	privacyService "Dedenruslan19/med-project/service/privacy"
+	reconciliationService "Dedenruslan19/med-project/service/reconciliation"
+	reportService "Dedenruslan19/med-project/service/reports"
+	safetyService "Dedenruslan19/med-project/service/safety"
 	userService "Dedenruslan19/med-project/service/users"
+	vitalsService "Dedenruslan19/med-project/service/vitals"
 	workoutService "Dedenruslan19/med-project/service/workouts"
 
 	"Dedenruslan19/med-project/util/database"
+	"Dedenruslan19/med-project/util/encryption"
 
 	"github.com/labstack/echo/v4"
 	mdw "github.com/labstack/echo/v4/middleware"
@@ -51,6 +90,7 @@ type Config struct {
 	AppDeploymentURL        string `env:"APP_DEPLOYMENT_URL"`
 	AppEmailVerificationKey string `env:"APP_EMAIL_VERIFICATION_KEY"`
 	AppJWTSecret            string `env:"APP_JWT_SECRET"`
+	AppAdminAPIKey          string `env:"APP_ADMIN_API_KEY"`
 
 	DBDriver string `env:"DB_DRIVER"`
 
@@ -73,6 +113,38 @@ type Config struct {
 
 	RapidAPIBMI string `env:"RAPIDAPI_BMI_API_KEY"`
 	GEMINI      string `env:"GEMINI_API_KEY"`
+
+	InvoiceSeries               string `env:"INVOICE_SERIES"`
+	InvoiceNumberPattern        string `env:"INVOICE_NUMBER_PATTERN"`
+	InvoiceFiscalYearStartMonth int    `env:"INVOICE_FISCAL_YEAR_START_MONTH" envDefault:"1"`
+	CreditNoteSeries            string `env:"CREDIT_NOTE_SERIES"`
+
+	PricingVATRate        float64  `env:"PRICING_VAT_RATE" envDefault:"0.11"`
+	PricingTaxExemptKinds []string `env:"PRICING_TAX_EXEMPT_KINDS" envDefault:"medication"`
+
+	ReconciliationDateToleranceDays int `env:"RECONCILIATION_DATE_TOLERANCE_DAYS" envDefault:"7"`
+
+	IdempotencyTTLHours int `env:"IDEMPOTENCY_TTL_HOURS" envDefault:"24"`
+
+	AttachmentStorage        string `env:"ATTACHMENT_STORAGE" envDefault:"local"`
+	AttachmentLocalDir       string `env:"ATTACHMENT_LOCAL_DIR" envDefault:"./data/attachments"`
+	AttachmentMaxSizeMB      int    `env:"ATTACHMENT_MAX_SIZE_MB" envDefault:"10"`
+	AttachmentLinkTTLMinutes int    `env:"ATTACHMENT_LINK_TTL_MINUTES" envDefault:"15"`
+	AttachmentSigningKey     string `env:"ATTACHMENT_SIGNING_KEY"`
+
+	MessageThreadCloseDays int `env:"MESSAGE_THREAD_CLOSE_DAYS" envDefault:"7"`
+
+	VideoBaseURL          string `env:"VIDEO_BASE_URL" envDefault:"https://meet.jit.si"`
+	VideoAppID            string `env:"VIDEO_APP_ID"`
+	VideoAppSecret        string `env:"VIDEO_APP_SECRET"`
+	VideoSlotMinutes      int    `env:"VIDEO_SLOT_MINUTES" envDefault:"30"`
+	VideoJoinEarlyMinutes int    `env:"VIDEO_JOIN_EARLY_MINUTES" envDefault:"10"`
+
+	EventsBufferSize       int `env:"EVENTS_BUFFER_SIZE" envDefault:"32"`
+	EventsKeepAliveSeconds int `env:"EVENTS_KEEPALIVE_SECONDS" envDefault:"25"`
+
+	EncryptionKeys        string `env:"ENCRYPTION_KEYS"`
+	EncryptionActiveKeyID string `env:"ENCRYPTION_ACTIVE_KEY_ID"`
 }
 
 func main() {
@@ -83,6 +155,17 @@ func main() {
 	}
 	logger.Info("Config loaded")
 
+	// Encryption of clinical notes at rest
+	if config.EncryptionKeys == "" {
+		logger.Warn("ENCRYPTION_KEYS is not set, clinical notes are stored unencrypted")
+	} else {
+		keyring, err := encryption.ParseKeyring(config.EncryptionKeys, config.EncryptionActiveKeyID)
+		if err != nil {
+			log.Fatalf("Failed to load encryption keys: %v", err)
+		}
+		encryption.Use(keyring)
+	}
+
 	databaseConfig := database.Config{
 		DBDriver:             config.DBDriver,
 		DBMySQLHost:          config.DBMySQLHost,
@@ -128,25 +211,145 @@ func main() {
 	doctorSvc := doctorService.NewService(logger, doctorRepo)
 	doctorController := controller.NewDoctorController(doctorSvc, logger)
 
+	accessLogRepo := auditRepository.NewAccessLogRepo(db, logger)
+	auditSvc := auditService.NewService(logger, accessLogRepo, doctorSvc)
+	auditController := controller.NewAuditController(auditSvc, logger)
+	audited := middleware.Audit(auditSvc)
+
+	outboxRepo := outboxRepository.NewOutboxRepo(db, logger)
+	outboxSvc := outboxService.NewService(logger, outboxRepo)
+	outboxController := controller.NewOutboxController(outboxSvc, logger)
+
+	// Notification channels, only the configured ones are enabled
+	notifiers := map[string]notification.Notifier{}
+	var emailSender notification.Notifier
+	if smtpSender, err := notification.NewSMTPSenderFromEnv(); err == nil {
+		emailSender = smtpSender
+		notifiers[notification.ChannelEmail] = smtpSender
+	}
+	if smsSender, err := notification.NewSMSSenderFromEnv(); err == nil {
+		notifiers[notification.ChannelSMS] = smsSender
+	}
+	if whatsAppSender, err := notification.NewWhatsAppSenderFromEnv(); err == nil {
+		notifiers[notification.ChannelWhatsApp] = whatsAppSender
+	}
+	if webhookSender, err := notification.NewWebhookSenderFromEnv(); err == nil {
+		notifiers[notification.ChannelWebhook] = webhookSender
+	}
+
+	preferenceRepo := preference.NewPreferenceRepo(db, logger)
+	notificationSvc := notificationService.NewService(logger, preferenceRepo, userSvc, outboxSvc, notifiers)
+	notificationController := controller.NewNotificationController(notificationSvc, logger)
+	for channel := range notifiers {
+		outboxSvc.Handle(notificationService.Topic(channel), notificationSvc.Deliver)
+	}
+
+	// in-process for now; a distributed pubsub.Broker can replace it when the
+	// API runs on more than one instance
+	eventBroker := pubsub.NewMemoryBroker(logger, config.EventsBufferSize)
+	eventController := controller.NewEventController(eventBroker,
+		time.Duration(config.EventsKeepAliveSeconds)*time.Second, logger)
+
 	appointmentRepo := appointment.NewAppointmentRepo(db, logger)
-	appointmentSvc := appointmentService.NewService(logger, appointmentRepo)
-	appointmentController := controller.NewAppointmentController(appointmentSvc, logger)
+	appointmentSvc := appointmentService.NewService(logger, appointmentRepo, eventBroker, appointmentService.Video{
+		Provider:   video.NewJitsiProvider(config.VideoBaseURL, config.VideoAppID, config.VideoAppSecret),
+		SlotLength: time.Duration(config.VideoSlotMinutes) * time.Minute,
+		JoinEarly:  time.Duration(config.VideoJoinEarlyMinutes) * time.Minute,
+	})
+	appointmentController := controller.NewAppointmentController(appointmentSvc, notificationSvc, logger)
+
+	promoRepo := promo.NewPromoRepo(db, logger)
+	pricingSvc := pricingService.NewService(logger, promoRepo,
+		pricingService.NewConfig(config.PricingVATRate, config.PricingTaxExemptKinds))
+	pricingController := controller.NewPricingController(pricingSvc, logger)
 
 	billingRepo := billing.NewBillingRepo(db, logger)
-	billingSvc := billingService.NewService(logger, billingRepo)
+	billingSvc := billingService.NewService(logger, billingRepo, appointmentSvc, userSvc, pricingSvc, eventBroker)
+
+	codeRepo := icd10Repository.NewCodeRepo(db, logger)
+	codeSvc := icd10Service.NewService(logger, codeRepo)
+	if _, err := codeSvc.LoadBundled(); err != nil {
+		logger.Error("Failed to load bundled ICD-10 catalogue", slog.Any("error", err))
+	}
+	codeController := controller.NewCodeController(codeSvc, logger)
+
+	historyRepo := historyRepository.NewHistoryRepo(db, logger)
+	historySvc := historyService.NewService(logger, historyRepo)
+	historyController := controller.NewHistoryController(historySvc, logger)
+
+	safetyRepo := safetyRepository.NewSafetyRepo(db, logger)
+	safetySvc := safetyService.NewService(logger, safetyRepo)
+	allergyController := controller.NewAllergyController(safetySvc, historySvc, logger)
+
+	vitalRepo := vitalsRepository.NewVitalRepo(db, logger)
+	vitalSvc := vitalsService.NewService(logger, vitalRepo, appointmentSvc, userSvc)
+	vitalController := controller.NewVitalController(vitalSvc, appointmentSvc, historySvc, logger)
 
 	diagnoseRepo := diagnose.NewDiagnoseRepo(db, logger)
-	diagnoseSvc := diagnoseService.NewService(logger, diagnoseRepo, appointmentSvc)
-	diagnoseController := controller.NewDiagnoseController(diagnoseSvc, appointmentSvc, billingSvc, logger)
+	diagnoseSvc := diagnoseService.NewService(logger, diagnoseRepo, appointmentSvc, codeSvc, eventBroker)
+	prescriptionRepo := prescription.NewPrescriptionRepo(db, logger)
+	prescriptionSvc := prescriptionService.NewService(logger, prescriptionRepo, diagnoseSvc, appointmentSvc, userSvc, doctorSvc, config.AppDeploymentURL)
+	diagnoseController := controller.NewDiagnoseController(diagnoseSvc, appointmentSvc, billingSvc, safetySvc, prescriptionSvc, logger)
+	prescriptionController := controller.NewPrescriptionController(prescriptionSvc, diagnoseSvc, appointmentSvc, logger)
+
+	// Attachment storage, local filesystem unless ATTACHMENT_STORAGE=s3
+	var attachmentStore storage.Storage
+	if config.AttachmentStorage == storage.BackendS3 {
+		attachmentStore, err = storage.NewS3StorageFromEnv()
+	} else {
+		attachmentStore, err = storage.NewLocalStorage(config.AttachmentLocalDir)
+	}
+	if err != nil {
+		log.Fatalf("Failed to set up attachment storage: %v", err)
+	}
+	if config.AttachmentSigningKey == "" {
+		logger.Warn("ATTACHMENT_SIGNING_KEY is not set, download links only work on this instance until it restarts")
+	}
+	attachmentRepo := attachment.NewAttachmentRepo(db, logger)
+	attachmentSvc := attachmentService.NewService(logger, attachmentRepo, attachmentStore, appointmentSvc, diagnoseSvc,
+		attachmentService.NewConfig(config.AttachmentMaxSizeMB, config.AttachmentLinkTTLMinutes, config.AttachmentSigningKey, config.AppDeploymentURL))
+	attachmentController := controller.NewAttachmentController(attachmentSvc, logger)
 
-	emailSender, _ := notification.NewSMTPSenderFromEnv()
+	messageRepo := messageRepository.NewMessageRepo(db, logger)
+	messageSvc := messageService.NewService(logger, messageRepo, appointmentSvc, attachmentSvc, doctorSvc, notificationSvc,
+		time.Duration(config.MessageThreadCloseDays)*24*time.Hour)
+	messageController := controller.NewMessageController(messageSvc, logger)
 
 	invoiceRepo := invoice.NewInvoiceRepo(db, logger)
-	invoiceSvc := invoiceService.NewService(logger, invoiceRepo, emailSender)
+	invoiceSvc := invoiceService.NewService(logger, invoiceRepo, emailSender,
+		invoiceService.NewNumbering(config.InvoiceSeries, config.InvoiceNumberPattern, config.InvoiceFiscalYearStartMonth).
+			WithCreditNoteSeries(config.CreditNoteSeries), eventBroker)
+	outboxSvc.Handle(invoiceService.TopicInvoiceEmail, invoiceSvc.DeliverInvoiceEmail)
+	billingSvc.SetInvoiceService(invoiceSvc)
+	outboxSvc.Handle(billingService.TopicInvoiceRequested, billingSvc.DeliverInvoice)
 	invoiceController := controller.NewInvoiceController(invoiceSvc, billingSvc, appointmentSvc, diagnoseSvc, userSvc, doctorSvc, logger)
 
 	// Create billing controller with invoice service and appointment service (for ownership checks)
-	billingController := controller.NewBillingController(billingSvc, invoiceSvc, appointmentSvc, logger)
+	billingController := controller.NewBillingController(billingSvc, invoiceSvc, appointmentSvc, notificationSvc, logger)
+
+	insuranceRepo := insuranceRepository.NewInsuranceRepo(db, logger)
+	insuranceSvc := insuranceService.NewService(logger, insuranceRepo, billingSvc, appointmentSvc, diagnoseSvc, userSvc, doctorSvc)
+	insuranceController := controller.NewInsuranceController(insuranceSvc, billingSvc, appointmentSvc, logger)
+
+	reportRepo := report.NewReportRepo(db, logger)
+	reportSvc := reportService.NewService(logger, reportRepo)
+	reportController := controller.NewReportController(reportSvc, logger)
+
+	accountingRepo := accountingRepository.NewAccountingRepo(db, logger)
+	accountingSvc := accountingService.NewService(logger, accountingRepo)
+	accountingController := controller.NewAccountingController(accountingSvc, logger)
+
+	reconciliationRepo := reconciliationRepository.NewReconciliationRepo(db, logger)
+	reconciliationSvc := reconciliationService.NewService(logger, reconciliationRepo, billingSvc, config.ReconciliationDateToleranceDays)
+	reconciliationController := controller.NewReconciliationController(reconciliationSvc, logger)
+
+	privacyRepo := privacyRepository.NewPrivacyRepo(db, logger)
+	privacySvc := privacyService.NewService(logger, privacyRepo, userSvc)
+	privacyController := controller.NewPrivacyController(privacySvc, logger)
+
+	idempotencyRepo := idempotencyRepository.NewIdempotencyRepo(db, logger)
+	idempotencySvc := idempotencyService.NewService(logger, idempotencyRepo, time.Duration(config.IdempotencyTTLHours)*time.Hour)
+	idempotent := middleware.Idempotency(idempotencySvc)
 
 	// Setup Echo
 	e := echo.New()
@@ -154,6 +357,7 @@ func main() {
 
 	// Middleware
 	e.Use(mdw.CORS())
+	e.Use(mdw.RequestID())
 	e.Use(mdw.LoggerWithConfig(mdw.LoggerConfig{
 		Skipper:          mdw.DefaultSkipper,
 		CustomTimeFormat: "2006-01-02 15:04:05.00000",
@@ -173,6 +377,21 @@ func main() {
 
 	userMiddleware := userGroup.Group("", middleware.JWTMiddleware(os.Getenv("JWT_SECRET")))
 	userMiddleware.GET("", userController.GetMe)
+	userMiddleware.GET("/notification-preferences", notificationController.GetPreferences)
+	userMiddleware.PUT("/notification-preferences", notificationController.UpdatePreferences, middleware.ValidateContentType)
+
+	// patient self-service
+	meGroup := userMiddleware.Group("/me", middleware.ACLMiddleware(map[string]bool{"user": true}))
+	meGroup.GET("/billings", billingController.GetMyBillings)
+	meGroup.POST("/billings/:id/pay", billingController.PayMyBilling, idempotent)
+	meGroup.POST("/billings/:id/promo-code", billingController.ApplyMyPromoCode, middleware.ValidateContentType)
+	meGroup.GET("/invoices", invoiceController.GetMyInvoices, audited(auditService.ResourceInvoice))
+	meGroup.GET("/invoices/:id", invoiceController.GetMyInvoiceByID, audited(auditService.ResourceInvoice))
+	meGroup.GET("/policies", insuranceController.GetMyPolicies)
+	meGroup.GET("/access-log", auditController.GetMyAccessLog)
+	meGroup.GET("/export", privacyController.ExportMyData, audited(auditService.ResourceExport))
+	meGroup.DELETE("", privacyController.DeleteMyAccount, middleware.ValidateContentType)
+	meGroup.POST("/policies", insuranceController.AddMyPolicy, middleware.ValidateContentType)
 
 	// doctors
 	doctorGroup := e.Group("/doctors")
@@ -200,27 +419,116 @@ func main() {
 	logGroup.POST("", logController.CreateLog)
 	logGroup.GET("", logController.GetAllLogs)
 
+	doctorOnly := middleware.ACLMiddleware(map[string]bool{"doctor": true})
+	// multipart overhead on top of the attachment size limit
+	uploadLimit := mdw.BodyLimit(fmt.Sprintf("%dM", config.AttachmentMaxSizeMB+1))
+
 	// appointments
 	appointmentGroup := e.Group("/appointments", middleware.JWTMiddleware(os.Getenv("JWT_SECRET")))
-	appointmentGroup.POST("", appointmentController.CreateAppointment, middleware.ValidateContentType)
-	appointmentGroup.GET("", appointmentController.GetAppointmentsByUser)
-	appointmentGroup.GET("/:id", appointmentController.GetAppointmentByID)
-
-	// diagnoses (doctors only)
-	diagnoseGroup := e.Group("/diagnoses", middleware.JWTMiddleware(os.Getenv("JWT_SECRET")), middleware.ACLMiddleware(map[string]bool{"doctor": true}))
-	diagnoseGroup.POST("", diagnoseController.CreateDiagnose, middleware.ValidateContentType)
+	appointmentGroup.POST("", appointmentController.CreateAppointment, audited(auditService.ResourceAppointment), middleware.ValidateContentType, idempotent)
+	appointmentGroup.GET("", appointmentController.GetAppointmentsByUser, audited(auditService.ResourceAppointment))
+	appointmentGroup.GET("/:id", appointmentController.GetAppointmentByID, audited(auditService.ResourceAppointment))
+	appointmentGroup.POST("/:id/confirm", appointmentController.ConfirmAppointment, audited(auditService.ResourceAppointment), doctorOnly)
+	appointmentGroup.GET("/:id/video", appointmentController.JoinVideo, audited(auditService.ResourceAppointment), middleware.ACLMiddleware(map[string]bool{"doctor": true, "user": true}))
+	appointmentGroup.POST("/:id/cancel", appointmentController.CancelAppointment, audited(auditService.ResourceAppointment), middleware.ACLMiddleware(map[string]bool{"doctor": true, "user": true}))
+	appointmentGroup.POST("/:id/vitals", vitalController.RecordVitals, audited(auditService.ResourceVitals), doctorOnly, middleware.ValidateContentType)
+	appointmentGroup.GET("/:id/vitals", vitalController.GetAppointmentVitals, audited(auditService.ResourceVitals))
+	appointmentGroup.POST("/:id/attachments", attachmentController.UploadAppointmentAttachment, audited(auditService.ResourceAttachment), uploadLimit)
+	appointmentGroup.GET("/:id/attachments", attachmentController.GetAppointmentAttachments, audited(auditService.ResourceAttachment))
+	appointmentGroup.GET("/:id/messages", messageController.GetAppointmentMessages, audited(auditService.ResourceMessage))
+	appointmentGroup.POST("/:id/messages", messageController.PostAppointmentMessage, audited(auditService.ResourceMessage), middleware.ValidateContentType)
+
+	// messages, one thread per appointment between its patient and doctor
+	messageGroup := e.Group("/messages", middleware.JWTMiddleware(os.Getenv("JWT_SECRET")))
+	messageGroup.GET("/threads", messageController.GetThreads, middleware.ACLMiddleware(map[string]bool{"doctor": true, "user": true}))
+
+	// events, pushed to the signed-in patient or doctor as Server-Sent Events
+	e.GET("/events", eventController.StreamEvents, middleware.JWTMiddleware(os.Getenv("JWT_SECRET")), middleware.ACLMiddleware(map[string]bool{"doctor": true, "user": true}))
+
+	// diagnoses, written by doctors and read by treating doctors and the patient
+	diagnoseGroup := e.Group("/diagnoses", middleware.JWTMiddleware(os.Getenv("JWT_SECRET")))
+	diagnoseGroup.POST("", diagnoseController.CreateDiagnose, audited(auditService.ResourceDiagnose), doctorOnly, middleware.ValidateContentType)
+	diagnoseGroup.GET("/:id", diagnoseController.GetDiagnoseByID, audited(auditService.ResourceDiagnose), middleware.ACLMiddleware(map[string]bool{"doctor": true, "user": true}))
+	diagnoseGroup.GET("/appointment/:appointment_id", diagnoseController.GetDiagnoseByAppointmentID, audited(auditService.ResourceDiagnose), middleware.ACLMiddleware(map[string]bool{"doctor": true, "user": true}))
+	diagnoseGroup.PUT("/:id", diagnoseController.UpdateDiagnose, audited(auditService.ResourceDiagnose), doctorOnly, middleware.ValidateContentType)
+	diagnoseGroup.POST("/:id/sign", diagnoseController.SignDiagnose, audited(auditService.ResourceDiagnose), doctorOnly)
+	diagnoseGroup.GET("/:id/versions", diagnoseController.GetDiagnoseVersions, audited(auditService.ResourceDiagnose), doctorOnly)
+	diagnoseGroup.GET("/:id/versions/:version", diagnoseController.GetDiagnoseVersion, audited(auditService.ResourceDiagnose), doctorOnly)
+	diagnoseGroup.GET("/:id/prescription-overrides", diagnoseController.GetPrescriptionOverrides, audited(auditService.ResourceDiagnose), doctorOnly)
+	diagnoseGroup.GET("/:id/prescription", prescriptionController.GetPrescription, audited(auditService.ResourcePrescription), middleware.ACLMiddleware(map[string]bool{"doctor": true, "user": true}))
+	diagnoseGroup.POST("/:id/attachments", attachmentController.UploadDiagnoseAttachment, audited(auditService.ResourceAttachment), middleware.ACLMiddleware(map[string]bool{"doctor": true, "user": true}), uploadLimit)
+	diagnoseGroup.GET("/:id/attachments", attachmentController.GetDiagnoseAttachments, audited(auditService.ResourceAttachment), middleware.ACLMiddleware(map[string]bool{"doctor": true, "user": true}))
+
+	// attachments, downloaded through short-lived signed links
+	attachmentGroup := e.Group("/attachments")
+	attachmentGroup.GET("/:id/download", attachmentController.DownloadAttachment)
+	attachmentGroup.GET("/:id", attachmentController.GetAttachment, middleware.JWTMiddleware(os.Getenv("JWT_SECRET")), audited(auditService.ResourceAttachment))
+	attachmentGroup.DELETE("/:id", attachmentController.DeleteAttachment, middleware.JWTMiddleware(os.Getenv("JWT_SECRET")), audited(auditService.ResourceAttachment))
+
+	// prescription verification (public), dispensing by registered pharmacies
+	e.GET("/prescriptions/verify/:code", prescriptionController.VerifyPrescription)
+	e.POST("/prescriptions/verify/:code/dispense", prescriptionController.DispensePrescription, middleware.PharmacyKeyMiddleware(prescriptionSvc))
+
+	// patient history (doctors who have or had an appointment with the patient)
+	e.GET("/patients/:id/history", historyController.GetPatientHistory, middleware.JWTMiddleware(os.Getenv("JWT_SECRET")), audited(auditService.ResourceHistory), doctorOnly)
+	e.GET("/patients/:id/vitals/trend", vitalController.GetVitalTrend, middleware.JWTMiddleware(os.Getenv("JWT_SECRET")), audited(auditService.ResourceVitals))
+
+	// allergies (the patient and their doctors)
+	allergyGroup := e.Group("/patients/:id/allergies", middleware.JWTMiddleware(os.Getenv("JWT_SECRET")))
+	allergyGroup.GET("", allergyController.GetAllergies, audited(auditService.ResourceAllergy))
+	allergyGroup.POST("", allergyController.AddAllergy, audited(auditService.ResourceAllergy), middleware.ValidateContentType)
+	allergyGroup.DELETE("/:allergy_id", allergyController.RemoveAllergy, audited(auditService.ResourceAllergy))
 
 	// billings (doctors only)
 	billingGroup := e.Group("/billings", middleware.JWTMiddleware(os.Getenv("JWT_SECRET")), middleware.ACLMiddleware(map[string]bool{"doctor": true}))
 	billingGroup.GET("/:id", billingController.GetBillingByID)
 	billingGroup.GET("/appointment/:appointment_id", billingController.GetBillingByAppointmentID)
-	billingGroup.POST("/:id/create-invoice", billingController.CreateInvoice, middleware.ValidateContentType)
-	billingGroup.PUT("/:id/payment-status", billingController.UpdatePaymentStatus, middleware.ValidateContentType)
+	billingGroup.POST("/:id/create-invoice", billingController.CreateInvoice, middleware.ValidateContentType, idempotent)
+	billingGroup.PUT("/:id/payment-status", billingController.UpdatePaymentStatus, middleware.ValidateContentType, idempotent)
+	billingGroup.PUT("/:id/pricing", billingController.ApplyPricing, middleware.ValidateContentType)
+	billingGroup.POST("/:id/claim", insuranceController.CreateClaim, middleware.ValidateContentType)
+
+	// ICD-10 catalogue
+	e.GET("/icd10/codes", codeController.SearchCodes, middleware.JWTMiddleware(os.Getenv("JWT_SECRET")))
+
+	// insurers
+	e.GET("/insurers", insuranceController.GetInsurers, middleware.JWTMiddleware(os.Getenv("JWT_SECRET")))
 
 	// invoices
 	invoiceGroup := e.Group("/invoices", middleware.JWTMiddleware(os.Getenv("JWT_SECRET")), middleware.ACLMiddleware(map[string]bool{"doctor": true}))
-	invoiceGroup.GET("/billing/:id", invoiceController.GetInvoiceByBillingID)
-	invoiceGroup.POST("/send", invoiceController.SendInvoice, middleware.ValidateContentType)
+	invoiceGroup.GET("/billing/:id", invoiceController.GetInvoiceByBillingID, audited(auditService.ResourceInvoice))
+	invoiceGroup.POST("/send", invoiceController.SendInvoice, audited(auditService.ResourceInvoice), middleware.ValidateContentType, idempotent)
+
+	// admin
+	adminGroup := e.Group("/admin", middleware.AdminKeyMiddleware(config.AppAdminAPIKey))
+	adminGroup.GET("/access-log", auditController.SearchAccessLog)
+	adminGroup.GET("/outbox", outboxController.GetMessages)
+	adminGroup.GET("/outbox/:id", outboxController.GetMessageByID)
+	adminGroup.POST("/outbox/:id/replay", outboxController.ReplayMessage)
+	adminGroup.GET("/promo-codes", pricingController.GetPromoCodes)
+	adminGroup.POST("/promo-codes", pricingController.CreatePromoCode, middleware.ValidateContentType)
+	adminGroup.GET("/insurers", insuranceController.GetInsurers)
+	adminGroup.POST("/insurers", insuranceController.CreateInsurer, middleware.ValidateContentType)
+	adminGroup.POST("/pharmacies", prescriptionController.RegisterPharmacy, middleware.ValidateContentType)
+	adminGroup.GET("/claims", insuranceController.GetClaims)
+	adminGroup.GET("/claims/export", insuranceController.ExportClaims)
+	adminGroup.GET("/claims/:id", insuranceController.GetClaimByID)
+	adminGroup.PUT("/claims/:id/decision", insuranceController.DecideClaim, middleware.ValidateContentType)
+	adminGroup.GET("/reports/revenue", reportController.GetRevenue)
+	adminGroup.GET("/reports/receivables", reportController.GetReceivables)
+	adminGroup.GET("/reports/conditions", reportController.GetConditions)
+	adminGroup.GET("/accounting/export", accountingController.ExportAccounting)
+	adminGroup.GET("/billings/:id/payments", billingController.GetPayments)
+	adminGroup.POST("/billings/:id/refund", billingController.RefundBilling, middleware.ValidateContentType, idempotent)
+	adminGroup.POST("/reconciliation/statements", reconciliationController.UploadStatement)
+	adminGroup.POST("/reconciliation/confirm", reconciliationController.ConfirmMatches, middleware.ValidateContentType, idempotent)
+
+	// Outbox dispatcher
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	defer stopDispatcher()
+	go outboxSvc.Run(dispatcherCtx, 5*time.Second)
+	go idempotencySvc.Run(dispatcherCtx, time.Hour)
+	go messageSvc.Run(dispatcherCtx, time.Hour)
 
 	// Detect port from Railway
 	port := os.Getenv("PORT")
@@ -244,6 +552,8 @@ func main() {
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 
+	stopDispatcher()
+
 	// a timeout of 10 seconds to shutdown the server
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"Dedenruslan19/med-project/service/idempotency"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotencyReplayed = "Idempotency-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// bodyRecorder keeps a copy of the response written by the handler.
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// principal identifies who sent the request, keys of different users never
+// collide.
+func principal(c echo.Context) string {
+	role, _ := c.Get("role").(string)
+	if id, ok := GetUserID(c); ok {
+		return role + ":" + strconv.FormatInt(id, 10)
+	}
+	return role
+}
+
+// Idempotency honours the Idempotency-Key header: the first response for a
+// key is stored and replayed for retries with the same body, while reusing
+// the key for a different request is a conflict. Requests without the header
+// are handled as usual. It must run after the authentication middleware.
+func Idempotency(service idempotency.Service) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Idempotency-Key must be at most 255 characters",
+				})
+			}
+
+			owner := principal(c)
+			if owner == "" {
+				return next(c)
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Invalid request body",
+				})
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+
+			method, path := c.Request().Method, c.Request().URL.Path
+			record, err := service.Begin(owner, key, method, path, hex.EncodeToString(sum[:]))
+			switch {
+			case errors.Is(err, idempotency.ErrKeyReused), errors.Is(err, idempotency.ErrRequestInProgress):
+				return c.JSON(http.StatusConflict, map[string]string{
+					"error": err.Error(),
+				})
+			case err != nil:
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to process Idempotency-Key",
+				})
+			case record != nil:
+				c.Response().Header().Set(HeaderIdempotencyReplayed, "true")
+				return c.Blob(record.StatusCode, record.ContentType, record.ResponseBody)
+			}
+
+			recorder := &bodyRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			// let the client retry a request that did not complete, also
+			// when the handler panics
+			completed := false
+			defer func() {
+				if !completed {
+					_ = service.Abandon(owner, key)
+				}
+			}()
+
+			err = next(c)
+			status := c.Response().Status
+			if err != nil || !c.Response().Committed || status >= http.StatusInternalServerError {
+				return err
+			}
+
+			// when the response cannot be stored the key is released too,
+			// rather than answering retries with a conflict until it expires
+			contentType := c.Response().Header().Get(echo.HeaderContentType)
+			completed = service.Complete(owner, key, status, contentType, recorder.body.Bytes()) == nil
+			return nil
+		}
+	}
+}
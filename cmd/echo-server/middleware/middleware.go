@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -59,9 +60,9 @@ func JWTMiddleware(jwtSign string) echo.MiddlewareFunc {
 				return forbiddenResponse(c)
 			}
 
-			userID, _ := claim["id"].(string)
+			// numeric claims are decoded as float64, GetUserID converts them
 			role, _ := claim["role"].(string)
-			c.Set("id", userID)
+			c.Set("id", claim["id"])
 			c.Set("role", role)
 
 			return next(c)
@@ -86,6 +87,26 @@ func ACLMiddleware(rolesMap map[string]bool) echo.MiddlewareFunc {
 	}
 }
 
+// AdminKeyMiddleware guards back-office endpoints with a shared API key sent in
+// the X-Admin-Key header. An empty key disables the endpoints entirely.
+func AdminKeyMiddleware(adminKey string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if adminKey == "" {
+				return forbiddenResponse(c)
+			}
+
+			given := c.Request().Header.Get("X-Admin-Key")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(adminKey)) != 1 {
+				return forbiddenResponse(c)
+			}
+
+			c.Set("role", "admin")
+			return next(c)
+		}
+	}
+}
+
 func JwtEchoMiddleware(jwtSign string) echo.MiddlewareFunc {
 	return echojwt.WithConfig(echojwt.Config{
 		SigningKey: []byte(jwtSign),
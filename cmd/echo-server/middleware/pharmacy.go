@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"Dedenruslan19/med-project/service/prescriptions"
+
+	"github.com/labstack/echo/v4"
+)
+
+const HeaderPharmacyKey = "X-Pharmacy-Key"
+
+// PharmacyKeyMiddleware lets registered pharmacies in with the API key they
+// were given, sent in the X-Pharmacy-Key header.
+func PharmacyKeyMiddleware(service prescriptions.Service) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			pharmacy, err := service.AuthenticatePharmacy(c.Request().Header.Get(HeaderPharmacyKey))
+			if err != nil {
+				return forbiddenResponse(c)
+			}
+
+			c.Set("role", "pharmacy")
+			c.Set("pharmacy", pharmacy)
+			return next(c)
+		}
+	}
+}
+
+// GetPharmacy returns the pharmacy authenticated by PharmacyKeyMiddleware.
+func GetPharmacy(c echo.Context) (*prescriptions.Pharmacy, bool) {
+	pharmacy, ok := c.Get("pharmacy").(*prescriptions.Pharmacy)
+	return pharmacy, ok
+}
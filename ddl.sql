@@ -5,6 +5,7 @@ CREATE TABLE users (
     password VARCHAR(255) NOT NULL,
     weight DECIMAL(5,2) NOT NULL,
     height DECIMAL(5,2) NOT NULL,
+    erased_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -45,6 +46,7 @@ CREATE TABLE doctors (
     email VARCHAR(255) NOT NULL UNIQUE,
     password VARCHAR(255) NOT NULL,
     specialization VARCHAR(255) NOT NULL,
+    license_number VARCHAR(50),
     is_available BOOLEAN DEFAULT true,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -56,7 +58,10 @@ CREATE TABLE appointments (
     appointment_date TIMESTAMP NOT NULL,
     status VARCHAR(50) DEFAULT 'pending', 
     notes TEXT,
+    modality VARCHAR(20) NOT NULL DEFAULT 'in_person',
+    video_room VARCHAR(255),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
     FOREIGN KEY (doctor_id) REFERENCES doctors(id) ON DELETE CASCADE
 );
@@ -67,6 +72,9 @@ CREATE TABLE diagnoses (
     doctor_id INTEGER NOT NULL,
     notes TEXT NOT NULL,
     prescribed_medications TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'signed')),
+    version INTEGER NOT NULL DEFAULT 1,
+    signed_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (appointment_id) REFERENCES appointments(id) ON DELETE CASCADE,
@@ -76,7 +84,16 @@ CREATE TABLE diagnoses (
 CREATE TABLE billings (
     id SERIAL PRIMARY KEY,
     appointment_id INTEGER NOT NULL UNIQUE,
+    consultation_fee DECIMAL(10,2) NOT NULL DEFAULT 0,
+    medication_fee DECIMAL(10,2) NOT NULL DEFAULT 0,
+    subtotal DECIMAL(10,2) NOT NULL DEFAULT 0,
+    discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
     total_amount DECIMAL(10,2) NOT NULL CHECK (total_amount >= 0),
+    insurance_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    patient_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    promo_code VARCHAR(50),
+    pricing_breakdown TEXT,
     payment_status VARCHAR(50) DEFAULT 'unpaid', 
     paid_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -89,13 +106,354 @@ CREATE TABLE invoices (
     invoice_number VARCHAR(100) NOT NULL UNIQUE,
     consultation_fee DECIMAL(10,2) NOT NULL DEFAULT 200000,
     medication_fee DECIMAL(10,2) NOT NULL DEFAULT 0,
+    discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
     total_amount DECIMAL(10,2) NOT NULL,
+    insurance_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    patient_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    pricing_breakdown TEXT,
     sent_to_email VARCHAR(255) NOT NULL,
     sent_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (billing_id) REFERENCES billings(id) ON DELETE CASCADE
 );
 
+CREATE TABLE invoice_sequences (
+    series VARCHAR(50) NOT NULL,
+    fiscal_year INTEGER NOT NULL,
+    last_value BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (series, fiscal_year)
+);
+
+CREATE TABLE outbox_messages (
+    id SERIAL PRIMARY KEY,
+    topic VARCHAR(100) NOT NULL,
+    aggregate_type VARCHAR(50),
+    aggregate_id INTEGER,
+    recipient VARCHAR(255),
+    subject VARCHAR(255),
+    payload TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 8,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE notification_preferences (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    category VARCHAR(50) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    destination VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE promo_codes (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    value DECIMAL(10,2) NOT NULL CHECK (value > 0),
+    valid_from TIMESTAMP,
+    valid_until TIMESTAMP,
+    max_redemptions INTEGER NOT NULL DEFAULT 0,
+    redemptions INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE insurers (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    contact_email VARCHAR(255),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE insurance_policies (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    insurer_id INTEGER NOT NULL,
+    policy_number VARCHAR(100) NOT NULL,
+    coverage_rate DECIMAL(5,4) NOT NULL CHECK (coverage_rate > 0 AND coverage_rate <= 1),
+    per_claim_limit DECIMAL(12,2) NOT NULL DEFAULT 0,
+    annual_limit DECIMAL(12,2) NOT NULL DEFAULT 0,
+    valid_from TIMESTAMP,
+    valid_until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (insurer_id) REFERENCES insurers(id)
+);
+
+CREATE TABLE insurance_claims (
+    id SERIAL PRIMARY KEY,
+    claim_number VARCHAR(100) NOT NULL UNIQUE,
+    billing_id INTEGER NOT NULL UNIQUE,
+    policy_id INTEGER NOT NULL,
+    insurer_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    doctor_id INTEGER NOT NULL,
+    diagnosis_id INTEGER NOT NULL,
+    policy_number VARCHAR(100) NOT NULL,
+    patient_name VARCHAR(255),
+    doctor_name VARCHAR(255),
+    service_date TIMESTAMP NOT NULL,
+    diagnosis_notes TEXT,
+    medications TEXT,
+    billed_amount DECIMAL(12,2) NOT NULL,
+    claimed_amount DECIMAL(12,2) NOT NULL,
+    approved_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    status VARCHAR(30) NOT NULL DEFAULT 'submitted' CHECK (status IN ('submitted', 'approved', 'partially_approved', 'rejected')),
+    decision_reason TEXT,
+    submitted_at TIMESTAMP NOT NULL,
+    decided_at TIMESTAMP,
+    FOREIGN KEY (billing_id) REFERENCES billings(id) ON DELETE CASCADE,
+    FOREIGN KEY (policy_id) REFERENCES insurance_policies(id),
+    FOREIGN KEY (insurer_id) REFERENCES insurers(id)
+);
+
+CREATE TABLE payments (
+    id SERIAL PRIMARY KEY,
+    billing_id INTEGER NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('payment', 'refund')),
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    method VARCHAR(50) NOT NULL,
+    reference VARCHAR(255),
+    note TEXT,
+    occurred_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (billing_id) REFERENCES billings(id) ON DELETE CASCADE
+);
+
+CREATE TABLE credit_notes (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL,
+    billing_id INTEGER NOT NULL,
+    credit_note_number VARCHAR(100) NOT NULL UNIQUE,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (invoice_id) REFERENCES invoices(id) ON DELETE CASCADE,
+    FOREIGN KEY (billing_id) REFERENCES billings(id) ON DELETE CASCADE
+);
+
+CREATE TABLE idempotency_keys (
+    principal VARCHAR(100) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(500) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    status_code INTEGER,
+    content_type VARCHAR(100),
+    response_body BYTEA,
+    locked_until TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (principal, idempotency_key)
+);
+
+CREATE TABLE diagnose_versions (
+    id SERIAL PRIMARY KEY,
+    diagnose_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('created', 'revised', 'amended')),
+    notes TEXT NOT NULL,
+    prescribed_medications TEXT,
+    codes TEXT,
+    author_id INTEGER NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (diagnose_id) REFERENCES diagnoses(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES doctors(id)
+);
+
+CREATE TABLE icd10_codes (
+    code VARCHAR(10) PRIMARY KEY,
+    title VARCHAR(500) NOT NULL,
+    category VARCHAR(3) NOT NULL
+);
+
+CREATE TABLE diagnose_codes (
+    id SERIAL PRIMARY KEY,
+    diagnose_id INTEGER NOT NULL,
+    code VARCHAR(10) NOT NULL,
+    title VARCHAR(500),
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (diagnose_id) REFERENCES diagnoses(id) ON DELETE CASCADE,
+    FOREIGN KEY (code) REFERENCES icd10_codes(code)
+);
+
+CREATE TABLE vitals (
+    id SERIAL PRIMARY KEY,
+    appointment_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    doctor_id INTEGER NOT NULL,
+    systolic_bp DECIMAL(5,1),
+    diastolic_bp DECIMAL(5,1),
+    heart_rate DECIMAL(5,1),
+    temperature DECIMAL(4,1),
+    spo2 DECIMAL(4,1),
+    respiratory_rate DECIMAL(4,1),
+    weight DECIMAL(5,1),
+    height DECIMAL(5,1),
+    bmi DECIMAL(4,1),
+    abnormal BOOLEAN NOT NULL DEFAULT FALSE,
+    flags TEXT,
+    recorded_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (appointment_id) REFERENCES appointments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (doctor_id) REFERENCES doctors(id)
+);
+
+CREATE TABLE allergies (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    substance VARCHAR(255) NOT NULL,
+    reaction TEXT,
+    severity VARCHAR(20) NOT NULL CHECK (severity IN ('mild', 'moderate', 'severe')),
+    recorded_by VARCHAR(20) NOT NULL,
+    recorded_by_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE drug_interactions (
+    id SERIAL PRIMARY KEY,
+    drug_a VARCHAR(255) NOT NULL,
+    drug_b VARCHAR(255) NOT NULL,
+    severity VARCHAR(20) NOT NULL CHECK (severity IN ('minor', 'moderate', 'major', 'contraindicated')),
+    description TEXT,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (drug_a < drug_b)
+);
+
+CREATE TABLE prescription_overrides (
+    id SERIAL PRIMARY KEY,
+    diagnose_id INTEGER NOT NULL,
+    doctor_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    medications TEXT NOT NULL,
+    alerts TEXT,
+    justification TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (diagnose_id) REFERENCES diagnoses(id) ON DELETE CASCADE,
+    FOREIGN KEY (doctor_id) REFERENCES doctors(id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE pharmacies (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE prescriptions (
+    id SERIAL PRIMARY KEY,
+    diagnose_id INTEGER NOT NULL,
+    diagnose_version INTEGER NOT NULL,
+    appointment_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    patient_name VARCHAR(255) NOT NULL,
+    doctor_id INTEGER NOT NULL,
+    doctor_name VARCHAR(255) NOT NULL,
+    specialization VARCHAR(255),
+    license_number VARCHAR(50) NOT NULL,
+    medications TEXT NOT NULL,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'issued' CHECK (status IN ('issued', 'dispensed', 'revoked')),
+    issued_at TIMESTAMP NOT NULL,
+    dispensed_at TIMESTAMP,
+    dispensed_by VARCHAR(255),
+    pharmacy_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (diagnose_id, diagnose_version),
+    FOREIGN KEY (diagnose_id) REFERENCES diagnoses(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (doctor_id) REFERENCES doctors(id),
+    FOREIGN KEY (pharmacy_id) REFERENCES pharmacies(id)
+);
+
+CREATE TABLE attachments (
+    id SERIAL PRIMARY KEY,
+    owner_type VARCHAR(20) NOT NULL CHECK (owner_type IN ('appointment', 'diagnose')),
+    owner_id INTEGER NOT NULL,
+    appointment_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    uploaded_by INTEGER NOT NULL,
+    uploader_role VARCHAR(20) NOT NULL,
+    category VARCHAR(20) NOT NULL CHECK (category IN ('lab_result', 'scan', 'referral', 'other')),
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (appointment_id) REFERENCES appointments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE access_logs (
+    id BIGSERIAL PRIMARY KEY,
+    principal_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    patient_id INTEGER,
+    resource VARCHAR(30) NOT NULL,
+    resource_id BIGINT,
+    action VARCHAR(20) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    status INTEGER NOT NULL,
+    ip VARCHAR(45),
+    request_id VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- the access log is append-only
+CREATE FUNCTION reject_access_log_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'access_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER access_logs_append_only
+    BEFORE UPDATE OR DELETE ON access_logs
+    FOR EACH ROW EXECUTE FUNCTION reject_access_log_change();
+
+CREATE TABLE message_threads (
+    id SERIAL PRIMARY KEY,
+    appointment_id INTEGER NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL,
+    doctor_id INTEGER NOT NULL,
+    last_message_at TIMESTAMP,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (appointment_id) REFERENCES appointments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (doctor_id) REFERENCES doctors(id) ON DELETE CASCADE
+);
+
+CREATE TABLE messages (
+    id BIGSERIAL PRIMARY KEY,
+    thread_id INTEGER NOT NULL,
+    sender_id INTEGER NOT NULL,
+    sender_role VARCHAR(20) NOT NULL CHECK (sender_role IN ('user', 'doctor')),
+    body TEXT NOT NULL,
+    attachment_ids TEXT,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (thread_id) REFERENCES message_threads(id) ON DELETE CASCADE
+);
+
 CREATE UNIQUE INDEX idx_users_email ON users (email);
 
 CREATE INDEX idx_workouts_user_id ON workouts (user_id);
@@ -122,3 +480,43 @@ CREATE INDEX idx_billings_payment_status ON billings (payment_status);
 CREATE UNIQUE INDEX idx_invoices_invoice_number ON invoices (invoice_number);
 CREATE INDEX idx_invoices_billing_id ON invoices (billing_id);
 CREATE INDEX idx_invoices_sent_at ON invoices (sent_at);
+
+CREATE INDEX idx_outbox_messages_status_next_attempt ON outbox_messages (status, next_attempt_at);
+CREATE INDEX idx_outbox_messages_topic ON outbox_messages (topic);
+
+CREATE UNIQUE INDEX idx_notification_preferences_unique ON notification_preferences (user_id, category, channel);
+
+CREATE INDEX idx_insurance_policies_user_id ON insurance_policies (user_id);
+CREATE INDEX idx_insurance_claims_insurer_status ON insurance_claims (insurer_id, status);
+CREATE INDEX idx_insurance_claims_policy_service_date ON insurance_claims (policy_id, service_date);
+
+CREATE INDEX idx_payments_billing_id ON payments (billing_id);
+CREATE INDEX idx_payments_occurred_at ON payments (occurred_at);
+CREATE INDEX idx_credit_notes_invoice_id ON credit_notes (invoice_id);
+CREATE INDEX idx_credit_notes_created_at ON credit_notes (created_at);
+CREATE INDEX idx_invoices_created_at ON invoices (created_at);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+CREATE UNIQUE INDEX idx_diagnose_versions_unique ON diagnose_versions (diagnose_id, version);
+
+CREATE INDEX idx_icd10_codes_category ON icd10_codes (category);
+CREATE INDEX idx_diagnose_codes_diagnose_id ON diagnose_codes (diagnose_id);
+CREATE INDEX idx_diagnose_codes_code ON diagnose_codes (code);
+CREATE INDEX idx_vitals_appointment_id ON vitals (appointment_id);
+CREATE INDEX idx_vitals_user_recorded_at ON vitals (user_id, recorded_at);
+CREATE INDEX idx_allergies_user_id ON allergies (user_id);
+CREATE UNIQUE INDEX idx_drug_interactions_pair ON drug_interactions (drug_a, drug_b);
+CREATE INDEX idx_prescription_overrides_diagnose_id ON prescription_overrides (diagnose_id);
+CREATE INDEX idx_prescriptions_diagnose_id ON prescriptions (diagnose_id);
+-- at most one prescription of a diagnosis can be dispensed
+CREATE UNIQUE INDEX idx_prescriptions_live ON prescriptions (diagnose_id) WHERE status = 'issued';
+CREATE INDEX idx_attachments_owner ON attachments (owner_type, owner_id);
+CREATE INDEX idx_attachments_appointment_id ON attachments (appointment_id);
+CREATE INDEX idx_attachments_user_id ON attachments (user_id);
+CREATE INDEX idx_access_logs_patient_id ON access_logs (patient_id, created_at);
+CREATE INDEX idx_access_logs_principal ON access_logs (principal_id, role, created_at);
+CREATE INDEX idx_access_logs_request_id ON access_logs (request_id);
+CREATE INDEX idx_message_threads_user_id ON message_threads (user_id, last_message_at);
+CREATE INDEX idx_message_threads_doctor_id ON message_threads (doctor_id, last_message_at);
+CREATE INDEX idx_messages_thread_id ON messages (thread_id, created_at);
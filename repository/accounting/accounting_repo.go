@@ -0,0 +1,73 @@
+package accounting
+
+import (
+	"Dedenruslan19/med-project/service/accounting"
+	"Dedenruslan19/med-project/service/invoices"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type accountingRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewAccountingRepo(db *gorm.DB, logger *slog.Logger) accounting.AccountingRepo {
+	return &accountingRepo{db: db, logger: logger}
+}
+
+func (r *accountingRepo) ListInvoices(from, to time.Time) ([]invoices.Invoice, error) {
+	var list []invoices.Invoice
+	err := r.db.Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at, id").
+		Find(&list).Error
+	if err != nil {
+		r.logger.Error("failed to list invoices for accounting export",
+			slog.Any("error", err),
+			slog.Time("from", from),
+			slog.Time("to", to),
+		)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *accountingRepo) ListCreditNotes(from, to time.Time) ([]accounting.CreditNoteRow, error) {
+	var rows []accounting.CreditNoteRow
+	err := r.db.Table("credit_notes").
+		Select("credit_notes.*, invoices.invoice_number").
+		Joins("JOIN invoices ON invoices.id = credit_notes.invoice_id").
+		Where("credit_notes.created_at >= ? AND credit_notes.created_at < ?", from, to).
+		Order("credit_notes.created_at, credit_notes.id").
+		Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("failed to list credit notes for accounting export",
+			slog.Any("error", err),
+			slog.Time("from", from),
+			slog.Time("to", to),
+		)
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *accountingRepo) ListPayments(from, to time.Time) ([]accounting.PaymentRow, error) {
+	var rows []accounting.PaymentRow
+	err := r.db.Table("payments").
+		Select("payments.*, invoices.invoice_number").
+		Joins("LEFT JOIN invoices ON invoices.billing_id = payments.billing_id").
+		Where("payments.occurred_at >= ? AND payments.occurred_at < ?", from, to).
+		Order("payments.occurred_at, payments.id").
+		Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("failed to list payments for accounting export",
+			slog.Any("error", err),
+			slog.Time("from", from),
+			slog.Time("to", to),
+		)
+		return nil, err
+	}
+	return rows, nil
+}
@@ -56,9 +56,13 @@ func (r *appointmentRepo) GetByUserID(userID int64) ([]appointments.Appointment,
 }
 
 func (r *appointmentRepo) UpdateStatus(id int64, status string) error {
+	updates := map[string]interface{}{"status": status}
+	if status == appointments.StatusCompleted {
+		updates["completed_at"] = time.Now().UTC()
+	}
 	result := r.db.Model(&appointments.Appointment{}).
 		Where("id = ?", id).
-		Update("status", status)
+		Updates(updates)
 	if result.Error != nil {
 		r.logger.Error("failed to update appointment status",
 			slog.Any("error", result.Error),
@@ -75,8 +79,8 @@ func (r *appointmentRepo) IsDoctorAvailable(doctorID int64, appointmentDate time
 
 	err := r.db.Model(&appointments.Appointment{}).
 		Joins("LEFT JOIN diagnoses d ON d.appointment_id = appointments.id").
-		Where("appointments.doctor_id = ? AND appointments.appointment_date = ? AND d.id IS NULL",
-			doctorID, appointmentDate).
+		Where("appointments.doctor_id = ? AND appointments.appointment_date = ? AND appointments.status <> ? AND d.id IS NULL",
+			doctorID, appointmentDate, appointments.StatusCancelled).
 		Count(&count).Error
 	if err != nil {
 		r.logger.Error("failed to check doctor availability",
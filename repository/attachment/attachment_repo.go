@@ -0,0 +1,44 @@
+package attachment
+
+import (
+	"Dedenruslan19/med-project/service/attachments"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type attachmentRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewAttachmentRepo(db *gorm.DB, logger *slog.Logger) attachments.AttachmentRepo {
+	return &attachmentRepo{db: db, logger: logger}
+}
+
+func (r *attachmentRepo) Create(attachment *attachments.Attachment) (int64, error) {
+	if err := r.db.Create(attachment).Error; err != nil {
+		return 0, err
+	}
+	return attachment.ID, nil
+}
+
+func (r *attachmentRepo) GetByID(id int64) (*attachments.Attachment, error) {
+	var attachment attachments.Attachment
+	if err := r.db.First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *attachmentRepo) ListByOwner(ownerType string, ownerID int64) ([]attachments.Attachment, error) {
+	var list []attachments.Attachment
+	err := r.db.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Order("created_at ASC, id ASC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *attachmentRepo) Delete(id int64) error {
+	return r.db.Delete(&attachments.Attachment{}, id).Error
+}
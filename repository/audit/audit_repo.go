@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"Dedenruslan19/med-project/service/audit"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type accessLogRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewAccessLogRepo(db *gorm.DB, logger *slog.Logger) audit.AccessLogRepo {
+	return &accessLogRepo{db: db, logger: logger}
+}
+
+func (r *accessLogRepo) Create(entry *audit.AccessLog) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *accessLogRepo) List(query audit.Query) ([]audit.AccessLog, int64, error) {
+	tx := r.db.Model(&audit.AccessLog{})
+	if query.PatientID != 0 {
+		tx = tx.Where("patient_id = ?", query.PatientID)
+	}
+	if query.PrincipalID != 0 {
+		tx = tx.Where("principal_id = ?", query.PrincipalID)
+	}
+	if query.Role != "" {
+		tx = tx.Where("role = ?", query.Role)
+	}
+	if query.Resource != "" {
+		tx = tx.Where("resource = ?", query.Resource)
+	}
+	if !query.From.IsZero() {
+		tx = tx.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		tx = tx.Where("created_at < ?", query.To)
+	}
+	if p := query.ExcludePrincipal; p != nil {
+		tx = tx.Where("NOT (principal_id = ? AND role = ?)", p.ID, p.Role)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []audit.AccessLog
+	err := tx.Order("created_at DESC, id DESC").
+		Offset((query.Page - 1) * query.PerPage).
+		Limit(query.PerPage).
+		Find(&entries).Error
+	return entries, total, err
+}
@@ -1,12 +1,68 @@
 package billing
 
 import (
+	invoiceRepo "Dedenruslan19/med-project/repository/invoice"
+	outboxRepo "Dedenruslan19/med-project/repository/outbox"
+	"Dedenruslan19/med-project/repository/promo"
 	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/outbox"
+	"Dedenruslan19/med-project/service/pricing"
+	"errors"
+	"fmt"
 	"log/slog"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// lockBilling reads the billing inside tx and keeps it locked until the
+// transaction ends (sqlite locks the whole database instead).
+func lockBilling(tx *gorm.DB, id int64) (*billings.Billing, error) {
+	var billing billings.Billing
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&billing, id).Error; err != nil {
+		return nil, err
+	}
+	return &billing, nil
+}
+
+func invoiced(tx *gorm.DB, billingID int64) (bool, error) {
+	var count int64
+	err := tx.Model(&invoices.Invoice{}).Where("billing_id = ?", billingID).Count(&count).Error
+	return count > 0, err
+}
+
+// ApplyInsurance moves the amount an insurer settled onto the billing inside
+// tx, so it is stored together with the claim decision. Once the billing is
+// paid or invoiced its amounts are final.
+func ApplyInsurance(tx *gorm.DB, id int64, amount float64) (*billings.Billing, error) {
+	billing, err := lockBilling(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !billings.Repriceable(billing.PaymentStatus) {
+		return nil, billings.ErrBillingLocked
+	}
+	done, err := invoiced(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		return nil, billings.ErrBillingInvoiced
+	}
+
+	if err := billing.ApplyInsurance(amount); err != nil {
+		return nil, err
+	}
+	err = tx.Model(billing).
+		Select("insurance_amount", "patient_amount", "pricing_breakdown").
+		Updates(billing).Error
+	if err != nil {
+		return nil, err
+	}
+	return billing, nil
+}
+
 type billingRepo struct {
 	db     *gorm.DB
 	logger *slog.Logger
@@ -54,14 +110,203 @@ func (r *billingRepo) GetByAppointmentID(appointmentID int64) (*billings.Billing
 	return &billing, nil
 }
 
-func (r *billingRepo) Update(billing *billings.Billing) error {
-	result := r.db.Save(billing)
+func (r *billingRepo) GetByUserID(userID int64) ([]billings.Billing, error) {
+	var list []billings.Billing
+	result := r.db.Joins("JOIN appointments ON appointments.id = billings.appointment_id").
+		Where("appointments.user_id = ?", userID).
+		Order("billings.id DESC").
+		Find(&list)
+	if result.Error != nil {
+		r.logger.Error("Failed to get billings by user ID",
+			slog.Any("error", result.Error),
+			slog.Int64("user_id", userID),
+		)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+func (r *billingRepo) UpdateStatus(billing *billings.Billing, from string) error {
+	result := r.db.Model(&billings.Billing{}).
+		Where("id = ? AND payment_status = ?", billing.ID, from).
+		Update("payment_status", billing.PaymentStatus)
 	if result.Error != nil {
-		r.logger.Error("Failed to update billing",
+		r.logger.Error("Failed to update billing status",
 			slog.Any("error", result.Error),
 			slog.Int64("billing_id", billing.ID),
 		)
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s changed meanwhile", billings.ErrInvalidTransition, from)
+	}
 	return nil
 }
+
+func (r *billingRepo) Reprice(billing *billings.Billing, promoCode string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockBilling(tx, billing.ID)
+		if err != nil {
+			return err
+		}
+		if !billings.Repriceable(current.PaymentStatus) {
+			return billings.ErrBillingLocked
+		}
+		done, err := invoiced(tx, billing.ID)
+		if err != nil {
+			return err
+		}
+		if done {
+			return billings.ErrBillingInvoiced
+		}
+
+		// a billing holds one redemption of its code, whichever way it is repriced
+		if promoCode != current.PromoCode {
+			if current.PromoCode != "" {
+				if err := promo.Release(tx, current.PromoCode); err != nil {
+					return err
+				}
+			}
+			if promoCode != "" {
+				redeemed, err := promo.Redeem(tx, promoCode)
+				if err != nil {
+					return err
+				}
+				if !redeemed {
+					return pricing.ErrPromoExhausted
+				}
+			}
+		}
+		// the status is left as locked above
+		return tx.Model(billing).
+			Select("consultation_fee", "medication_fee", "subtotal", "discount_amount", "tax_amount",
+				"total_amount", "insurance_amount", "patient_amount", "promo_code", "pricing_breakdown").
+			Updates(billing).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to reprice billing",
+			slog.Any("error", err),
+			slog.Int64("billing_id", billing.ID),
+		)
+		return err
+	}
+	return nil
+}
+
+func (r *billingRepo) RecordPayment(id int64, payment *billings.Payment, messages ...*outbox.Message) (*billings.Billing, string, error) {
+	var billing *billings.Billing
+	var previous string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		billing, err = lockBilling(tx, id)
+		if err != nil {
+			return err
+		}
+		previous = billing.PaymentStatus
+		if previous == billings.StatusPaid {
+			return billings.ErrAlreadyPaid
+		}
+		if !billings.CanTransition(previous, billings.StatusPaid) {
+			return fmt.Errorf("%w: %s to %s", billings.ErrInvalidTransition, previous, billings.StatusPaid)
+		}
+
+		billing.PaymentStatus = billings.StatusPaid
+		billing.PaidAt = &payment.OccurredAt
+		if err := tx.Model(billing).Select("payment_status", "paid_at").Updates(billing).Error; err != nil {
+			return err
+		}
+
+		payment.BillingID = id
+		payment.Amount = billing.AmountDue()
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		return outboxRepo.Insert(tx, messages...)
+	})
+	if err != nil {
+		if !errors.Is(err, billings.ErrAlreadyPaid) && !errors.Is(err, billings.ErrInvalidTransition) {
+			r.logger.Error("Failed to record payment",
+				slog.Any("error", err),
+				slog.Int64("billing_id", id),
+			)
+		}
+		return nil, "", err
+	}
+	return billing, previous, nil
+}
+
+func (r *billingRepo) Refund(id int64, refund *billings.Payment, numbering invoices.Numbering) (*billings.Billing, *invoices.CreditNote, error) {
+	var billing *billings.Billing
+	var note *invoices.CreditNote
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		billing, err = lockBilling(tx, id)
+		if err != nil {
+			return err
+		}
+		if billing.PaymentStatus != billings.StatusPaid {
+			return fmt.Errorf("%w: %s to %s", billings.ErrInvalidTransition, billing.PaymentStatus, billings.StatusRefunded)
+		}
+
+		var payments []billings.Payment
+		if err := tx.Where("billing_id = ?", id).Find(&payments).Error; err != nil {
+			return err
+		}
+		balance := billings.Balance(billing, payments)
+		if refund.Amount <= 0 || refund.Amount > balance+0.005 {
+			return billings.ErrInvalidRefund
+		}
+
+		var invoice invoices.Invoice
+		if err := tx.Where("billing_id = ?", id).First(&invoice).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return invoices.ErrInvoiceNotFound
+			}
+			return err
+		}
+		var credited []invoices.CreditNote
+		if err := tx.Where("invoice_id = ?", invoice.ID).Find(&credited).Error; err != nil {
+			return err
+		}
+		note, err = invoices.NewCreditNote(&invoice, credited, refund.Amount, refund.Note)
+		if err != nil {
+			return err
+		}
+		if err := invoiceRepo.CreateCreditNote(tx, note, numbering); err != nil {
+			return err
+		}
+
+		refund.BillingID = id
+		refund.Kind = billings.PaymentKindRefund
+		refund.Reference = note.CreditNoteNumber
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+
+		if balance-refund.Amount < 0.005 {
+			billing.PaymentStatus = billings.StatusRefunded
+			return tx.Model(billing).Update("payment_status", billing.PaymentStatus).Error
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, billings.ErrInvalidRefund) || errors.Is(err, billings.ErrInvalidTransition) ||
+			errors.Is(err, invoices.ErrInvalidCreditAmount) {
+			return nil, nil, err
+		}
+		r.logger.Error("Failed to record refund",
+			slog.Any("error", err),
+			slog.Int64("billing_id", id),
+		)
+		return nil, nil, err
+	}
+	return billing, note, nil
+}
+
+func (r *billingRepo) ListPaymentsByBillingID(billingID int64) ([]billings.Payment, error) {
+	var payments []billings.Payment
+	if err := r.db.Where("billing_id = ?", billingID).Order("occurred_at, id").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
@@ -0,0 +1,182 @@
+package billing_test
+
+import (
+	"Dedenruslan19/med-project/repository/billing"
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/outbox"
+	"Dedenruslan19/med-project/service/pricing"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	// the same lock settings as util/database uses for sqlite
+	dsn := filepath.Join(t.TempDir(), "billings.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&billings.Billing{}, &billings.Payment{}, &invoices.Invoice{},
+		&invoices.Sequence{}, &invoices.CreditNote{}, &pricing.PromoCode{}, &outbox.Message{}))
+	return db
+}
+
+func TestReprice_PromoAndAmountsAreAtomic(t *testing.T) {
+	db := openDB(t)
+	repo := billing.NewBillingRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	require.NoError(t, db.Create(&pricing.PromoCode{Code: "ONCE", DiscountType: pricing.DiscountFixed, Value: 10000, MaxRedemptions: 1, Redemptions: 1, Active: true}).Error)
+	require.NoError(t, db.Create(&billings.Billing{ID: 1, AppointmentID: 1, TotalAmount: 200000, PatientAmount: 200000, PaymentStatus: billings.StatusUnpaid}).Error)
+
+	err := repo.Reprice(&billings.Billing{ID: 1, AppointmentID: 1, TotalAmount: 190000, PatientAmount: 190000, PromoCode: "ONCE", PaymentStatus: billings.StatusUnpaid}, "ONCE")
+	assert.ErrorIs(t, err, pricing.ErrPromoExhausted)
+
+	stored, err := repo.GetByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, 200000.0, stored.TotalAmount)
+	assert.Empty(t, stored.PromoCode)
+}
+
+func TestReprice_HoldsOneRedemptionPerBilling(t *testing.T) {
+	db := openDB(t)
+	repo := billing.NewBillingRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	require.NoError(t, db.Create(&pricing.PromoCode{Code: "FIRST", DiscountType: pricing.DiscountFixed, Value: 10000, Active: true}).Error)
+	require.NoError(t, db.Create(&pricing.PromoCode{Code: "SECOND", DiscountType: pricing.DiscountFixed, Value: 20000, Active: true}).Error)
+	require.NoError(t, db.Create(&billings.Billing{ID: 1, AppointmentID: 1, TotalAmount: 200000, PaymentStatus: billings.StatusUnpaid}).Error)
+
+	redemptions := func(code string) int {
+		var promo pricing.PromoCode
+		require.NoError(t, db.Where("code = ?", code).First(&promo).Error)
+		return promo.Redemptions
+	}
+
+	for range 2 {
+		require.NoError(t, repo.Reprice(&billings.Billing{ID: 1, TotalAmount: 190000, PromoCode: "FIRST"}, "FIRST"))
+	}
+	assert.Equal(t, 1, redemptions("FIRST"))
+
+	require.NoError(t, repo.Reprice(&billings.Billing{ID: 1, TotalAmount: 180000, PromoCode: "SECOND"}, "SECOND"))
+	assert.Equal(t, 0, redemptions("FIRST"))
+	assert.Equal(t, 1, redemptions("SECOND"))
+
+	require.NoError(t, repo.Reprice(&billings.Billing{ID: 1, TotalAmount: 200000}, ""))
+	assert.Equal(t, 0, redemptions("SECOND"))
+}
+
+func TestReprice_LockedOnceInvoiced(t *testing.T) {
+	db := openDB(t)
+	repo := billing.NewBillingRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	// failed payments keep the invoice the patient was asked to pay
+	require.NoError(t, db.Create(&billings.Billing{ID: 1, AppointmentID: 1, TotalAmount: 200000, PaymentStatus: billings.StatusFailed}).Error)
+	require.NoError(t, db.Create(&invoices.Invoice{BillingID: 1, InvoiceNumber: "INV/2026/000001", TotalAmount: 200000, SentToEmail: "a@example.com"}).Error)
+
+	err := repo.Reprice(&billings.Billing{ID: 1, AppointmentID: 1, TotalAmount: 150000, PaymentStatus: billings.StatusFailed}, "")
+
+	assert.ErrorIs(t, err, billings.ErrBillingLocked)
+	stored, err := repo.GetByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, 200000.0, stored.TotalAmount)
+}
+
+func TestRecordPayment_ConcurrentPaymentsPayOnce(t *testing.T) {
+	db := openDB(t)
+	repo := billing.NewBillingRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	require.NoError(t, db.Create(&billings.Billing{ID: 1, AppointmentID: 1, TotalAmount: 250000, PaymentStatus: billings.StatusWaitingPayment}).Error)
+
+	const workers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payment := &billings.Payment{Kind: billings.PaymentKindPayment, Method: billings.PaymentMethodManual, OccurredAt: time.Now()}
+			request := &outbox.Message{Topic: billings.TopicInvoiceRequested, AggregateType: billings.AggregateType, AggregateID: 1}
+			_, _, err := repo.RecordPayment(1, payment, request)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	paid := 0
+	for err := range errs {
+		if err == nil {
+			paid++
+			continue
+		}
+		assert.ErrorIs(t, err, billings.ErrAlreadyPaid)
+	}
+	assert.Equal(t, 1, paid)
+
+	payments, err := repo.ListPaymentsByBillingID(1)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, 250000.0, payments[0].Amount)
+
+	var messages int64
+	require.NoError(t, db.Model(&outbox.Message{}).Count(&messages).Error)
+	assert.Equal(t, int64(1), messages)
+}
+
+func TestRefund_ConcurrentRefundsStayWithinBalance(t *testing.T) {
+	db := openDB(t)
+	repo := billing.NewBillingRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	numbering := invoices.DefaultNumbering().CreditNotes()
+
+	// paid before payments were recorded, so there is no payment row
+	paidAt := time.Date(2025, time.December, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Create(&billings.Billing{ID: 1, AppointmentID: 1, TotalAmount: 250000, PaymentStatus: billings.StatusPaid, PaidAt: &paidAt}).Error)
+	require.NoError(t, db.Create(&invoices.Invoice{BillingID: 1, InvoiceNumber: "INV/2025/000001", TotalAmount: 250000, SentToEmail: "a@example.com"}).Error)
+
+	const workers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			refund := &billings.Payment{Amount: 100000, Method: billings.PaymentMethodManual, OccurredAt: time.Now()}
+			_, _, err := repo.Refund(1, refund, numbering)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	refunded := 0
+	for err := range errs {
+		if err == nil {
+			refunded++
+			continue
+		}
+		assert.ErrorIs(t, err, billings.ErrInvalidRefund)
+	}
+	assert.Equal(t, 2, refunded)
+
+	var notes []invoices.CreditNote
+	require.NoError(t, db.Order("id").Find(&notes).Error)
+	payments, err := repo.ListPaymentsByBillingID(1)
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+	require.Len(t, payments, 2)
+	for i := range notes {
+		assert.Equal(t, notes[i].CreditNoteNumber, payments[i].Reference)
+	}
+
+	refund := &billings.Payment{Amount: 50000, Method: billings.PaymentMethodManual, OccurredAt: time.Now()}
+	settled, _, err := repo.Refund(1, refund, numbering)
+	require.NoError(t, err)
+	assert.Equal(t, billings.StatusRefunded, settled.PaymentStatus)
+}
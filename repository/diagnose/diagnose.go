@@ -1,8 +1,11 @@
 package diagnose
 
 import (
+	"Dedenruslan19/med-project/repository/prescription"
 	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/prescriptions"
 	"log/slog"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -16,21 +19,81 @@ func NewDiagnoseRepo(db *gorm.DB, logger *slog.Logger) diagnoses.DiagnoseRepo {
 	return &diagnoseRepo{db: db, logger: logger}
 }
 
-func (r *diagnoseRepo) Create(diagnose *diagnoses.Diagnose) (int64, error) {
-	result := r.db.Create(diagnose)
-	if result.Error != nil {
+func orderedCodes(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+func replaceCodes(tx *gorm.DB, diagnose *diagnoses.Diagnose) error {
+	if err := tx.Where("diagnose_id = ?", diagnose.ID).Delete(&diagnoses.DiagnoseCode{}).Error; err != nil {
+		return err
+	}
+	if len(diagnose.Codes) == 0 {
+		return nil
+	}
+	for i := range diagnose.Codes {
+		diagnose.Codes[i].ID = 0
+		diagnose.Codes[i].DiagnoseID = diagnose.ID
+	}
+	return tx.Create(&diagnose.Codes).Error
+}
+
+// createRecords stores the records built for the saved diagnosis.
+// Prescriptions are issued and revoked rather than simply inserted.
+func createRecords(tx *gorm.DB, diagnose *diagnoses.Diagnose, builders []diagnoses.RecordBuilder) error {
+	for _, build := range builders {
+		records, err := build(diagnose)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			switch record := record.(type) {
+			case *prescriptions.Prescription:
+				err = prescription.Issue(tx, record)
+			case *prescriptions.Revocation:
+				err = prescription.Revoke(tx, record.DiagnoseID)
+			default:
+				err = tx.Create(record).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *diagnoseRepo) Create(diagnose *diagnoses.Diagnose, records ...diagnoses.RecordBuilder) (int64, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(diagnose).Error; err != nil {
+			return err
+		}
+		err := tx.Create(&diagnoses.DiagnoseVersion{
+			DiagnoseID:            diagnose.ID,
+			Version:               diagnose.Version,
+			Kind:                  diagnoses.VersionCreated,
+			Notes:                 diagnose.Notes,
+			PrescribedMedications: diagnose.PrescribedMedications,
+			Codes:                 diagnose.CodeList(),
+			AuthorID:              diagnose.DoctorID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return createRecords(tx, diagnose, records)
+	})
+	if err != nil {
 		r.logger.Error("failed to create diagnose",
-			slog.Any("error", result.Error),
+			slog.Any("error", err),
 			slog.Int64("appointment_id", diagnose.AppointmentID),
 		)
-		return 0, result.Error
+		return 0, err
 	}
 	return diagnose.ID, nil
 }
 
 func (r *diagnoseRepo) GetByID(id int64) (*diagnoses.Diagnose, error) {
 	var diagnose diagnoses.Diagnose
-	result := r.db.Where("id = ?", id).First(&diagnose)
+	result := r.db.Preload("Codes", orderedCodes).Where("id = ?", id).First(&diagnose)
 	if result.Error != nil {
 		r.logger.Error("Failed to get diagnose by ID",
 			slog.Any("error", result.Error),
@@ -43,7 +106,7 @@ func (r *diagnoseRepo) GetByID(id int64) (*diagnoses.Diagnose, error) {
 
 func (r *diagnoseRepo) GetByAppointmentID(appointmentID int64) (*diagnoses.Diagnose, error) {
 	var diagnose diagnoses.Diagnose
-	result := r.db.Where("appointment_id = ?", appointmentID).First(&diagnose)
+	result := r.db.Preload("Codes", orderedCodes).Where("appointment_id = ?", appointmentID).First(&diagnose)
 	if result.Error != nil {
 		r.logger.Error("failed to get diagnose by appointment ID",
 			slog.Any("error", result.Error),
@@ -54,14 +117,60 @@ func (r *diagnoseRepo) GetByAppointmentID(appointmentID int64) (*diagnoses.Diagn
 	return &diagnose, nil
 }
 
-func (r *diagnoseRepo) Update(diagnose *diagnoses.Diagnose) error {
-	result := r.db.Save(diagnose)
-	if result.Error != nil {
+func (r *diagnoseRepo) Update(diagnose *diagnoses.Diagnose, previousVersion int, version *diagnoses.DiagnoseVersion,
+	records ...diagnoses.RecordBuilder) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// a struct rather than a map, so the encrypted columns go through
+		// their serializer
+		result := tx.Model(&diagnoses.Diagnose{}).
+			Where("id = ? AND version = ?", diagnose.ID, previousVersion).
+			Select("notes", "prescribed_medications", "status", "version", "signed_at", "updated_at").
+			Updates(&diagnoses.Diagnose{
+				Notes:                 diagnose.Notes,
+				PrescribedMedications: diagnose.PrescribedMedications,
+				Status:                diagnose.Status,
+				Version:               diagnose.Version,
+				SignedAt:              diagnose.SignedAt,
+				UpdatedAt:             time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return diagnoses.ErrVersionConflict
+		}
+		if version != nil {
+			if err := replaceCodes(tx, diagnose); err != nil {
+				return err
+			}
+			if err := tx.Create(version).Error; err != nil {
+				return err
+			}
+		}
+		return createRecords(tx, diagnose, records)
+	})
+	if err != nil {
 		r.logger.Error("failed to update diagnose",
-			slog.Any("error", result.Error),
+			slog.Any("error", err),
 			slog.Int64("diagnose_id", diagnose.ID),
 		)
-		return result.Error
+		return err
 	}
 	return nil
 }
+
+func (r *diagnoseRepo) ListVersions(diagnoseID int64) ([]diagnoses.DiagnoseVersion, error) {
+	var versions []diagnoses.DiagnoseVersion
+	if err := r.db.Where("diagnose_id = ?", diagnoseID).Order("version").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *diagnoseRepo) GetVersion(diagnoseID int64, version int) (*diagnoses.DiagnoseVersion, error) {
+	var v diagnoses.DiagnoseVersion
+	if err := r.db.Where("diagnose_id = ? AND version = ?", diagnoseID, version).First(&v).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
@@ -0,0 +1,118 @@
+package diagnose_test
+
+import (
+	"Dedenruslan19/med-project/repository/diagnose"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/prescriptions"
+	"Dedenruslan19/med-project/service/safety"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "Dedenruslan19/med-project/util/encryption"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCreate_StoresRecordsWithTheDiagnose(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "diagnose.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&diagnoses.Diagnose{}, &diagnoses.DiagnoseCode{}, &diagnoses.DiagnoseVersion{}, &safety.Override{}))
+
+	repo := diagnose.NewDiagnoseRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	override := func(d *diagnoses.Diagnose) ([]interface{}, error) {
+		return []interface{}{&safety.Override{DiagnoseID: d.ID, DoctorID: d.DoctorID, Justification: "reviewed with patient"}}, nil
+	}
+
+	id, err := repo.Create(&diagnoses.Diagnose{AppointmentID: 1, DoctorID: 2, Notes: "flu", Version: 1}, override)
+	require.NoError(t, err)
+
+	var overrides []safety.Override
+	require.NoError(t, db.Find(&overrides).Error)
+	require.Len(t, overrides, 1)
+	assert.Equal(t, id, overrides[0].DiagnoseID)
+
+	failing := func(*diagnoses.Diagnose) ([]interface{}, error) {
+		return nil, errors.New("cannot build")
+	}
+	_, err = repo.Create(&diagnoses.Diagnose{AppointmentID: 3, DoctorID: 2, Notes: "flu", Version: 1}, failing)
+	assert.Error(t, err)
+
+	var count int64
+	require.NoError(t, db.Model(&diagnoses.Diagnose{}).Where("appointment_id = ?", 3).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestUpdate_RolledBackWhenARecordCannotBeStored(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "diagnose.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn))
+	require.NoError(t, err)
+	// no overrides table, so storing the override fails
+	require.NoError(t, db.AutoMigrate(&diagnoses.Diagnose{}, &diagnoses.DiagnoseCode{}, &diagnoses.DiagnoseVersion{}))
+
+	repo := diagnose.NewDiagnoseRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	original := &diagnoses.Diagnose{AppointmentID: 1, DoctorID: 2, Notes: "flu", PrescribedMedications: "Paracetamol", Version: 1}
+	id, err := repo.Create(original)
+	require.NoError(t, err)
+
+	amended := *original
+	amended.PrescribedMedications = "Amoxicillin"
+	amended.Version = 2
+	override := func(d *diagnoses.Diagnose) ([]interface{}, error) {
+		return []interface{}{&safety.Override{DiagnoseID: d.ID, DoctorID: d.DoctorID, Justification: "reviewed with patient"}}, nil
+	}
+	err = repo.Update(&amended, 1, &diagnoses.DiagnoseVersion{DiagnoseID: id, Version: 2, Kind: diagnoses.VersionRevised,
+		Notes: amended.Notes, PrescribedMedications: amended.PrescribedMedications, AuthorID: 2}, override)
+	assert.Error(t, err)
+
+	stored, err := repo.GetByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Version)
+	assert.Equal(t, "Paracetamol", stored.PrescribedMedications)
+
+	versions, err := repo.ListVersions(id)
+	require.NoError(t, err)
+	assert.Len(t, versions, 1)
+}
+
+func TestUpdate_IssuesAndRevokesPrescriptions(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "diagnose.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&diagnoses.Diagnose{}, &diagnoses.DiagnoseCode{}, &diagnoses.DiagnoseVersion{}, &prescriptions.Prescription{}))
+
+	repo := diagnose.NewDiagnoseRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	d := &diagnoses.Diagnose{AppointmentID: 1, DoctorID: 2, Notes: "flu", PrescribedMedications: "Paracetamol", Version: 1}
+	id, err := repo.Create(d)
+	require.NoError(t, err)
+
+	d.Status = diagnoses.StatusSigned
+	issue := func(d *diagnoses.Diagnose) ([]interface{}, error) {
+		return []interface{}{&prescriptions.Prescription{DiagnoseID: d.ID, DiagnoseVersion: d.Version, PatientName: "Jane Patient",
+			DoctorName: "John Doctor", LicenseNumber: "SIP-123/2026", Medications: []string{"Paracetamol"},
+			Code: "K7QDM2XA9RTBHC4E", Status: prescriptions.StatusIssued, IssuedAt: time.Now()}}, nil
+	}
+	require.NoError(t, repo.Update(d, 1, nil, issue))
+
+	var issued prescriptions.Prescription
+	require.NoError(t, db.Where("diagnose_id = ?", id).First(&issued).Error)
+	assert.Equal(t, prescriptions.StatusIssued, issued.Status)
+
+	d.PrescribedMedications = ""
+	d.Version = 2
+	revoke := func(d *diagnoses.Diagnose) ([]interface{}, error) {
+		return []interface{}{&prescriptions.Revocation{DiagnoseID: d.ID}}, nil
+	}
+	require.NoError(t, repo.Update(d, 1, &diagnoses.DiagnoseVersion{DiagnoseID: id, Version: 2, Kind: diagnoses.VersionAmended,
+		Notes: d.Notes, AuthorID: 2, Reason: "not needed"}, revoke))
+
+	require.NoError(t, db.First(&issued, issued.ID).Error)
+	assert.Equal(t, prescriptions.StatusRevoked, issued.Status)
+}
@@ -13,6 +13,7 @@ type Doctor struct {
 	Email          string    `json:"email" gorm:"uniqueIndex;not null"`
 	Password       string    `json:"-" gorm:"not null"`
 	Specialization string    `json:"specialization" gorm:"not null"`
+	LicenseNumber  string    `json:"license_number" gorm:"type:varchar(50)"`
 	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
 	IsAvailable    bool      `json:"is_available" gorm:"default:true"`
 }
@@ -0,0 +1,75 @@
+package history
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/history"
+	"Dedenruslan19/med-project/service/vitals"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type historyRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewHistoryRepo(db *gorm.DB, logger *slog.Logger) history.HistoryRepo {
+	return &historyRepo{db: db, logger: logger}
+}
+
+func (r *historyRepo) HasAppointment(doctorID, userID int64) (bool, error) {
+	var count int64
+	err := r.db.Model(&appointments.Appointment{}).
+		Where("doctor_id = ? AND user_id = ?", doctorID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *historyRepo) ListAppointments(userID int64) ([]appointments.Appointment, error) {
+	var list []appointments.Appointment
+	if err := r.db.Where("user_id = ?", userID).Order("appointment_date, id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *historyRepo) ListDiagnoses(userID int64) ([]diagnoses.Diagnose, error) {
+	var list []diagnoses.Diagnose
+	err := r.db.Preload("Codes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).
+		Joins("JOIN appointments ON appointments.id = diagnoses.appointment_id").
+		Where("appointments.user_id = ?", userID).
+		Order("diagnoses.created_at, diagnoses.id").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *historyRepo) ListBillings(userID int64) ([]billings.Billing, error) {
+	var list []billings.Billing
+	err := r.db.Joins("JOIN appointments ON appointments.id = billings.appointment_id").
+		Where("appointments.user_id = ?", userID).
+		Order("billings.created_at, billings.id").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *historyRepo) ListVitals(userID int64) ([]vitals.Vital, error) {
+	var list []vitals.Vital
+	if err := r.db.Where("user_id = ?", userID).Order("recorded_at, id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,61 @@
+package icd10
+
+import (
+	"Dedenruslan19/med-project/service/icd10"
+	"log/slog"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type codeRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewCodeRepo(db *gorm.DB, logger *slog.Logger) icd10.CodeRepo {
+	return &codeRepo{db: db, logger: logger}
+}
+
+func (r *codeRepo) Upsert(codes []icd10.Code) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "category"}),
+	}).Create(&codes).Error
+	if err != nil {
+		r.logger.Error("failed to upsert ICD-10 codes", slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+func (r *codeRepo) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&icd10.Code{}).Count(&count).Error
+	return count, err
+}
+
+func (r *codeRepo) Search(query icd10.SearchQuery) ([]icd10.Code, error) {
+	db := r.db.Model(&icd10.Code{})
+	if query.Prefix != "" {
+		db = db.Where("code LIKE ?", query.Prefix+"%")
+	}
+	for _, word := range strings.Fields(query.Keyword) {
+		db = db.Where("LOWER(title) LIKE ?", "%"+word+"%")
+	}
+
+	var codes []icd10.Code
+	if err := db.Order("code").Limit(query.Limit).Find(&codes).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (r *codeRepo) GetByCodes(codes []string) ([]icd10.Code, error) {
+	var found []icd10.Code
+	if err := r.db.Where("code IN ?", codes).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	return found, nil
+}
@@ -0,0 +1,71 @@
+package idempotency
+
+import (
+	"Dedenruslan19/med-project/service/idempotency"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type idempotencyRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewIdempotencyRepo(db *gorm.DB, logger *slog.Logger) idempotency.IdempotencyRepo {
+	return &idempotencyRepo{db: db, logger: logger}
+}
+
+func isDuplicateKey(err error) bool {
+	return strings.Contains(err.Error(), "duplicate key value") ||
+		strings.Contains(err.Error(), "Duplicate entry") ||
+		strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func (r *idempotencyRepo) Reserve(record *idempotency.Record) error {
+	if err := r.db.Create(record).Error; err != nil {
+		if isDuplicateKey(err) {
+			return idempotency.ErrKeyExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *idempotencyRepo) Get(principal, key string) (*idempotency.Record, error) {
+	var record idempotency.Record
+	err := r.db.Where("principal = ? AND idempotency_key = ?", principal, key).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepo) Complete(principal, key string, statusCode int, contentType string, body []byte) error {
+	return r.db.Model(&idempotency.Record{}).
+		Where("principal = ? AND idempotency_key = ?", principal, key).
+		Updates(map[string]interface{}{
+			"completed":     true,
+			"status_code":   statusCode,
+			"content_type":  contentType,
+			"response_body": body,
+		}).Error
+}
+
+func (r *idempotencyRepo) Delete(principal, key string) error {
+	return r.db.Where("principal = ? AND idempotency_key = ?", principal, key).
+		Delete(&idempotency.Record{}).Error
+}
+
+func (r *idempotencyRepo) DeleteStale(principal, key string, now time.Time) error {
+	return r.db.Where("principal = ? AND idempotency_key = ?", principal, key).
+		Where("expires_at < ? OR (completed = ? AND (locked_until IS NULL OR locked_until < ?))", now, false, now).
+		Delete(&idempotency.Record{}).Error
+}
+
+func (r *idempotencyRepo) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", now).Delete(&idempotency.Record{})
+	return result.RowsAffected, result.Error
+}
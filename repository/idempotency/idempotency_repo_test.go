@@ -0,0 +1,45 @@
+package idempotency_test
+
+import (
+	"Dedenruslan19/med-project/repository/idempotency"
+	idempotencyService "Dedenruslan19/med-project/service/idempotency"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDeleteStale_KeepsLiveReservations(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "idempotency.db")))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&idempotencyService.Record{}))
+
+	repo := idempotency.NewIdempotencyRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	now := time.Now()
+	running, crashed := now.Add(time.Minute), now.Add(-time.Minute)
+
+	records := []*idempotencyService.Record{
+		{Principal: "user:1", Key: "running", LockedUntil: &running, ExpiresAt: now.Add(time.Hour)},
+		{Principal: "user:1", Key: "crashed", LockedUntil: &crashed, ExpiresAt: now.Add(time.Hour)},
+		{Principal: "user:1", Key: "done", Completed: true, LockedUntil: &crashed, ExpiresAt: now.Add(time.Hour)},
+		{Principal: "user:1", Key: "expired", Completed: true, ExpiresAt: now.Add(-time.Hour)},
+	}
+	for _, record := range records {
+		record.Method, record.Path, record.RequestHash = "POST", "/appointments", "abc"
+		require.NoError(t, repo.Reserve(record))
+	}
+
+	for _, record := range records {
+		require.NoError(t, repo.DeleteStale(record.Principal, record.Key, now))
+	}
+
+	var kept []string
+	require.NoError(t, db.Model(&idempotencyService.Record{}).Order("idempotency_key").Pluck("idempotency_key", &kept).Error)
+	assert.Equal(t, []string{"done", "running"}, kept)
+}
@@ -0,0 +1,156 @@
+package insurance
+
+import (
+	"Dedenruslan19/med-project/repository/billing"
+	"Dedenruslan19/med-project/service/insurance"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type insuranceRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewInsuranceRepo(db *gorm.DB, logger *slog.Logger) insurance.InsuranceRepo {
+	return &insuranceRepo{db: db, logger: logger}
+}
+
+func (r *insuranceRepo) CreateInsurer(insurer *insurance.Insurer) (int64, error) {
+	if err := r.db.Create(insurer).Error; err != nil {
+		r.logger.Error("failed to create insurer",
+			slog.Any("error", err),
+			slog.String("code", insurer.Code),
+		)
+		return 0, err
+	}
+	return insurer.ID, nil
+}
+
+func (r *insuranceRepo) GetInsurerByID(id int64) (*insurance.Insurer, error) {
+	var insurer insurance.Insurer
+	if err := r.db.First(&insurer, id).Error; err != nil {
+		return nil, err
+	}
+	return &insurer, nil
+}
+
+func (r *insuranceRepo) ListInsurers() ([]insurance.Insurer, error) {
+	var insurers []insurance.Insurer
+	if err := r.db.Order("name").Find(&insurers).Error; err != nil {
+		return nil, err
+	}
+	return insurers, nil
+}
+
+func (r *insuranceRepo) CreatePolicy(policy *insurance.Policy) (int64, error) {
+	if err := r.db.Create(policy).Error; err != nil {
+		r.logger.Error("failed to create insurance policy",
+			slog.Any("error", err),
+			slog.Int64("user_id", policy.UserID),
+		)
+		return 0, err
+	}
+	return policy.ID, nil
+}
+
+func (r *insuranceRepo) GetPolicyByID(id int64) (*insurance.Policy, error) {
+	var policy insurance.Policy
+	if err := r.db.First(&policy, id).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *insuranceRepo) ListPoliciesByUserID(userID int64) ([]insurance.Policy, error) {
+	var policies []insurance.Policy
+	if err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (r *insuranceRepo) CreateClaim(claim *insurance.Claim) (int64, error) {
+	if err := r.db.Create(claim).Error; err != nil {
+		r.logger.Error("failed to create insurance claim",
+			slog.Any("error", err),
+			slog.Int64("billing_id", claim.BillingID),
+		)
+		return 0, err
+	}
+	return claim.ID, nil
+}
+
+func (r *insuranceRepo) GetClaimByID(id int64) (*insurance.Claim, error) {
+	var claim insurance.Claim
+	if err := r.db.First(&claim, id).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *insuranceRepo) GetClaimByBillingID(billingID int64) (*insurance.Claim, error) {
+	var claim insurance.Claim
+	if err := r.db.Where("billing_id = ?", billingID).First(&claim).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *insuranceRepo) ListClaims(filter insurance.ClaimFilter) ([]insurance.Claim, error) {
+	query := r.db.Model(&insurance.Claim{})
+	if filter.InsurerID != 0 {
+		query = query.Where("insurer_id = ?", filter.InsurerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var claims []insurance.Claim
+	if err := query.Order("id").Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (r *insuranceRepo) ListClaimsByPolicySince(policyID int64, since time.Time) ([]insurance.Claim, error) {
+	var claims []insurance.Claim
+	err := r.db.Where("policy_id = ? AND service_date >= ?", policyID, since).Find(&claims).Error
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (r *insuranceRepo) DecideClaim(claim *insurance.Claim) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(claim).
+			Where("status = ?", insurance.ClaimSubmitted).
+			Select("status", "approved_amount", "decision_reason", "decided_at").
+			Updates(claim)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return insurance.ErrClaimDecided
+		}
+
+		if claim.ApprovedAmount == 0 {
+			// a rejection leaves the billing as it was priced
+			return nil
+		}
+		_, err := billing.ApplyInsurance(tx, claim.BillingID, claim.ApprovedAmount)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("failed to decide insurance claim",
+			slog.Any("error", err),
+			slog.Int64("claim_id", claim.ID),
+			slog.Int64("billing_id", claim.BillingID),
+		)
+		return err
+	}
+	return nil
+}
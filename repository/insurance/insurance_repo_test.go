@@ -0,0 +1,60 @@
+package insurance_test
+
+import (
+	"Dedenruslan19/med-project/repository/insurance"
+	"Dedenruslan19/med-project/service/billings"
+	insuranceService "Dedenruslan19/med-project/service/insurance"
+	"Dedenruslan19/med-project/service/invoices"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "Dedenruslan19/med-project/util/encryption"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDecideClaim_RolledBackWhenBillingIsInvoiced(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "insurance.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&billings.Billing{}, &invoices.Invoice{}, &insuranceService.Claim{}))
+
+	repo := insurance.NewInsuranceRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	require.NoError(t, db.Create(&billings.Billing{ID: 1, AppointmentID: 1, TotalAmount: 300000, PatientAmount: 300000, PaymentStatus: billings.StatusUnpaid}).Error)
+	require.NoError(t, db.Create(&billings.Billing{ID: 2, AppointmentID: 2, TotalAmount: 300000, PatientAmount: 300000, PaymentStatus: billings.StatusFailed}).Error)
+	require.NoError(t, db.Create(&invoices.Invoice{BillingID: 2, InvoiceNumber: "INV/2026/000001", TotalAmount: 300000, SentToEmail: "a@example.com"}).Error)
+	for _, billingID := range []int64{1, 2} {
+		require.NoError(t, db.Create(&insuranceService.Claim{ID: billingID, ClaimNumber: fmt.Sprintf("CLM-%d", billingID), BillingID: billingID,
+			PolicyNumber: "POL-1", ClaimedAmount: 240000, Status: insuranceService.ClaimSubmitted}).Error)
+	}
+
+	decide := func(id int64, amount float64) error {
+		now := time.Now()
+		return repo.DecideClaim(&insuranceService.Claim{ID: id, BillingID: id, Status: insuranceService.ClaimApproved, ApprovedAmount: amount, DecidedAt: &now})
+	}
+
+	require.NoError(t, decide(1, 240000))
+	assert.ErrorIs(t, decide(1, 240000), insuranceService.ErrClaimDecided)
+	assert.ErrorIs(t, decide(2, 240000), billings.ErrBillingInvoiced)
+
+	var paid billings.Billing
+	require.NoError(t, db.First(&paid, 1).Error)
+	assert.Equal(t, 240000.0, paid.InsuranceAmount)
+	assert.Equal(t, 60000.0, paid.PatientAmount)
+
+	// neither the claim nor the invoiced billing changed
+	claim, err := repo.GetClaimByID(2)
+	require.NoError(t, err)
+	assert.Equal(t, insuranceService.ClaimSubmitted, claim.Status)
+	var invoiced billings.Billing
+	require.NoError(t, db.First(&invoiced, 2).Error)
+	assert.Equal(t, 300000.0, invoiced.PatientAmount)
+}
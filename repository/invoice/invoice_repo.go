@@ -1,11 +1,16 @@
 package invoice
 
 import (
+	outboxRepo "Dedenruslan19/med-project/repository/outbox"
 	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/outbox"
+	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type invoiceRepository struct {
@@ -20,14 +25,125 @@ func NewInvoiceRepo(db *gorm.DB, logger *slog.Logger) invoices.InvoiceRepo {
 	}
 }
 
-func (r *invoiceRepository) Create(invoice *invoices.Invoice) (int64, error) {
-	if err := r.db.Create(invoice).Error; err != nil {
+// nextSequence increments the series counter for the fiscal year and returns
+// the new value. The UPDATE takes a row lock (a database lock on sqlite) that
+// is held until the surrounding transaction ends, so concurrent invoices are
+// serialised and a rolled back invoice gives its number back.
+func nextSequence(tx *gorm.DB, series string, fiscalYear int) (int64, error) {
+	increment := func() (int64, error) {
+		result := tx.Model(&invoices.Sequence{}).
+			Where("series = ? AND fiscal_year = ?", series, fiscalYear).
+			Update("last_value", gorm.Expr("last_value + 1"))
+		return result.RowsAffected, result.Error
+	}
+
+	affected, err := increment()
+	if err != nil {
+		return 0, err
+	}
+
+	if affected == 0 {
+		// first invoice of the series this year
+		err = tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&invoices.Sequence{Series: series, FiscalYear: fiscalYear}).Error
+		if err != nil {
+			return 0, err
+		}
+		if affected, err = increment(); err != nil {
+			return 0, err
+		}
+		if affected != 1 {
+			return 0, fmt.Errorf("invoice sequence %s/%d not found", series, fiscalYear)
+		}
+	}
+
+	var seq invoices.Sequence
+	err = tx.Where("series = ? AND fiscal_year = ?", series, fiscalYear).First(&seq).Error
+	if err != nil {
+		return 0, err
+	}
+	return seq.LastValue, nil
+}
+
+func (r *invoiceRepository) create(tx *gorm.DB, invoice *invoices.Invoice, numbering invoices.Numbering) error {
+	if invoice.CreatedAt.IsZero() {
+		invoice.CreatedAt = time.Now()
+	}
+
+	fiscalYear := numbering.FiscalYear(invoice.CreatedAt)
+	seq, err := nextSequence(tx, numbering.Series, fiscalYear)
+	if err != nil {
+		return err
+	}
+
+	invoice.InvoiceNumber = numbering.Format(fiscalYear, seq)
+	if err := tx.Create(invoice).Error; err != nil {
+		if isDuplicateKey(err) {
+			return invoices.ErrInvoiceExists
+		}
+		return err
+	}
+	return nil
+}
+
+// CreateCreditNote numbers the note in the credit note series of the fiscal
+// year it is issued in and stores it inside tx, so it is written together
+// with the refund it documents.
+func CreateCreditNote(tx *gorm.DB, note *invoices.CreditNote, numbering invoices.Numbering) error {
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = time.Now()
+	}
+
+	fiscalYear := numbering.FiscalYear(note.CreatedAt)
+	seq, err := nextSequence(tx, numbering.Series, fiscalYear)
+	if err != nil {
+		return err
+	}
+
+	note.CreditNoteNumber = numbering.Format(fiscalYear, seq)
+	return tx.Create(note).Error
+}
+
+func (r *invoiceRepository) ListCreditNotesByInvoiceID(invoiceID int64) ([]invoices.CreditNote, error) {
+	var notes []invoices.CreditNote
+	if err := r.db.Where("invoice_id = ?", invoiceID).Order("id").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func isDuplicateKey(err error) bool {
+	return strings.Contains(err.Error(), "duplicate key value") ||
+		strings.Contains(err.Error(), "Duplicate entry") ||
+		strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func (r *invoiceRepository) Create(invoice *invoices.Invoice, numbering invoices.Numbering) (int64, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return r.create(tx, invoice, numbering)
+	})
+	if err != nil {
 		r.logger.Error("failed to create invoice", slog.Any("error", err))
 		return 0, err
 	}
 	return invoice.ID, nil
 }
 
+func (r *invoiceRepository) EnqueueMessages(invoice *invoices.Invoice, messages []*outbox.Message) error {
+	for _, msg := range messages {
+		msg.AggregateType = invoices.AggregateType
+		msg.AggregateID = invoice.ID
+	}
+	if err := outboxRepo.Insert(r.db, messages...); err != nil {
+		r.logger.Error("failed to enqueue invoice messages",
+			slog.Any("error", err),
+			slog.Int64("invoice_id", invoice.ID),
+		)
+		return err
+	}
+	return nil
+}
+
 func (r *invoiceRepository) GetByID(id int64) (*invoices.Invoice, error) {
 	var invoice invoices.Invoice
 	if err := r.db.First(&invoice, id).Error; err != nil {
@@ -44,6 +160,45 @@ func (r *invoiceRepository) GetByBillingID(billingID int64) (*invoices.Invoice,
 	return &invoice, nil
 }
 
+// ownedBy scopes invoices to the patient of the billed appointment.
+func (r *invoiceRepository) ownedBy(userID int64) *gorm.DB {
+	return r.db.Joins("JOIN billings ON billings.id = invoices.billing_id").
+		Joins("JOIN appointments ON appointments.id = billings.appointment_id").
+		Where("appointments.user_id = ?", userID)
+}
+
+func (r *invoiceRepository) GetByUserID(userID int64) ([]invoices.Invoice, error) {
+	var list []invoices.Invoice
+	if err := r.ownedBy(userID).Order("invoices.id DESC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *invoiceRepository) GetByIDAndUserID(id, userID int64) (*invoices.Invoice, error) {
+	var invoice invoices.Invoice
+	if err := r.ownedBy(userID).Where("invoices.id = ?", id).First(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) GetOwnerID(id int64) (int64, error) {
+	var userIDs []int64
+	err := r.db.Model(&invoices.Invoice{}).
+		Joins("JOIN billings ON billings.id = invoices.billing_id").
+		Joins("JOIN appointments ON appointments.id = billings.appointment_id").
+		Where("invoices.id = ?", id).
+		Pluck("appointments.user_id", &userIDs).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(userIDs) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return userIDs[0], nil
+}
+
 func (r *invoiceRepository) UpdateSentAt(id int64) error {
 	now := time.Now()
 	return r.db.Model(&invoices.Invoice{}).Where("id = ?", id).Update("sent_at", now).Error
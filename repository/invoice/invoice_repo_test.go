@@ -0,0 +1,61 @@
+package invoice_test
+
+import (
+	"Dedenruslan19/med-project/repository/invoice"
+	"Dedenruslan19/med-project/service/invoices"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCreate_ConcurrentNumbersAreUniqueAndGapFree(t *testing.T) {
+	// the same lock settings as util/database uses for sqlite
+	dsn := filepath.Join(t.TempDir(), "invoices.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&invoices.Sequence{}, &invoices.Invoice{}))
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	repo := invoice.NewInvoiceRepo(db, logger)
+	numbering := invoices.DefaultNumbering()
+	createdAt := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(billingID int64) {
+			defer wg.Done()
+			_, err := repo.Create(&invoices.Invoice{BillingID: billingID, TotalAmount: 100, SentToEmail: "a@example.com", CreatedAt: createdAt}, numbering)
+			errs <- err
+			// a second invoice for the same billing is rolled back and must
+			// give its number back
+			_, err = repo.Create(&invoices.Invoice{BillingID: billingID, TotalAmount: 100, SentToEmail: "a@example.com", CreatedAt: createdAt}, numbering)
+			assert.ErrorIs(t, err, invoices.ErrInvoiceExists)
+		}(int64(i + 1))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	var numbers []string
+	require.NoError(t, db.Model(&invoices.Invoice{}).Order("invoice_number").Pluck("invoice_number", &numbers).Error)
+
+	fiscalYear := numbering.FiscalYear(createdAt)
+	expected := make([]string, 0, workers)
+	for seq := int64(1); seq <= workers; seq++ {
+		expected = append(expected, numbering.Format(fiscalYear, seq))
+	}
+	assert.Equal(t, expected, numbers)
+}
@@ -0,0 +1,107 @@
+package message
+
+import (
+	"log/slog"
+	"time"
+
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/messages"
+
+	"gorm.io/gorm"
+)
+
+type messageRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewMessageRepo(db *gorm.DB, logger *slog.Logger) messages.MessageRepo {
+	return &messageRepo{db: db, logger: logger}
+}
+
+func (r *messageRepo) GetOrCreateThread(thread *messages.Thread) (*messages.Thread, error) {
+	var found messages.Thread
+	err := r.db.Where(messages.Thread{AppointmentID: thread.AppointmentID}).
+		Attrs(messages.Thread{UserID: thread.UserID, DoctorID: thread.DoctorID}).
+		FirstOrCreate(&found).Error
+	if err != nil {
+		// a concurrent request may have created it in the meantime
+		if retry := r.db.Where("appointment_id = ?", thread.AppointmentID).First(&found).Error; retry != nil {
+			return nil, err
+		}
+	}
+	return &found, nil
+}
+
+func (r *messageRepo) ListThreads(role string, participantID int64) ([]messages.Thread, error) {
+	column := "user_id"
+	if role == messages.RoleDoctor {
+		column = "doctor_id"
+	}
+
+	var list []messages.Thread
+	err := r.db.Where(column+" = ?", participantID).
+		Order("last_message_at IS NULL, last_message_at DESC, id DESC").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *messageRepo) CountUnread(threadIDs []int64, readerRole string) (map[int64]int64, error) {
+	var rows []struct {
+		ThreadID int64
+		Unread   int64
+	}
+	err := r.db.Model(&messages.Message{}).
+		Select("thread_id, COUNT(*) AS unread").
+		Where("thread_id IN ? AND sender_role <> ? AND read_at IS NULL", threadIDs, readerRole).
+		Group("thread_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ThreadID] = row.Unread
+	}
+	return counts, nil
+}
+
+func (r *messageRepo) ListMessages(threadID int64) ([]messages.Message, error) {
+	var list []messages.Message
+	if err := r.db.Where("thread_id = ?", threadID).Order("created_at, id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *messageRepo) CreateMessage(message *messages.Message) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(&messages.Thread{}).
+			Where("id = ?", message.ThreadID).
+			Update("last_message_at", message.CreatedAt).Error
+	})
+}
+
+func (r *messageRepo) MarkRead(threadID int64, readerRole string, at time.Time) error {
+	return r.db.Model(&messages.Message{}).
+		Where("thread_id = ? AND sender_role <> ? AND read_at IS NULL", threadID, readerRole).
+		Update("read_at", at).Error
+}
+
+func (r *messageRepo) CloseExpired(completedBefore, at time.Time) (int64, error) {
+	completed := r.db.Model(&appointments.Appointment{}).
+		Select("id").
+		Where("status = ? AND completed_at < ?", appointments.StatusCompleted, completedBefore)
+
+	result := r.db.Model(&messages.Thread{}).
+		Where("closed_at IS NULL AND appointment_id IN (?)", completed).
+		Update("closed_at", at)
+	return result.RowsAffected, result.Error
+}
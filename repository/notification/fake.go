@@ -0,0 +1,39 @@
+package notification
+
+import "sync"
+
+type SentMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// FakeNotifier records messages instead of delivering them. Set Err to make
+// every Send fail.
+type FakeNotifier struct {
+	mu   sync.Mutex
+	Sent []SentMessage
+	Err  error
+}
+
+func NewFakeNotifier() *FakeNotifier {
+	return &FakeNotifier{}
+}
+
+func (f *FakeNotifier) Send(to, subject, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Sent = append(f.Sent, SentMessage{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+func (f *FakeNotifier) Messages() []SentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]SentMessage(nil), f.Sent...)
+}
@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	ChannelEmail    = "email"
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+	ChannelWebhook  = "webhook"
+)
+
+// Notifier delivers a message to a single recipient on one channel. The
+// recipient format depends on the channel: an email address, a phone number
+// in E.164 format or a webhook URL.
+type Notifier interface {
+	Send(to, subject, body string) error
+}
+
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+func postJSON(client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return post(client, url, headers, body)
+}
+
+func post(client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("unexpected status %d from %s: %s", res.StatusCode, url, string(respBody))
+	}
+	return nil
+}
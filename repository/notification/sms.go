@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"errors"
+	"net/http"
+
+	cfg "github.com/pobyzaarif/go-config"
+)
+
+// SMSSender talks to a generic HTTP SMS gateway that accepts
+// {"from", "to", "message"} as JSON with a bearer API key.
+type SMSSender struct {
+	GatewayURL string `env:"SMS_GATEWAY_URL"`
+	APIKey     string `env:"SMS_API_KEY"`
+	SenderID   string `env:"SMS_SENDER_ID"`
+	client     *http.Client
+}
+
+func NewSMSSenderFromEnv() (*SMSSender, error) {
+	s := &SMSSender{}
+	if err := cfg.LoadConfig(s); err != nil {
+		return nil, err
+	}
+	if s.GatewayURL == "" {
+		return nil, errors.New("SMS_GATEWAY_URL is not set")
+	}
+	s.client = defaultHTTPClient
+	return s, nil
+}
+
+func (s *SMSSender) Send(to, subject, body string) error {
+	message := body
+	if subject != "" {
+		message = subject + ": " + body
+	}
+
+	return postJSON(s.client, s.GatewayURL, map[string]string{
+		"Authorization": "Bearer " + s.APIKey,
+	}, map[string]string{
+		"from":    s.SenderID,
+		"to":      to,
+		"message": message,
+	})
+}
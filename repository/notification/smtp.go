@@ -7,6 +7,8 @@ import (
 	cfg "github.com/pobyzaarif/go-config"
 )
 
+var _ Notifier = (*SMTPSender)(nil)
+
 type SMTPSender struct {
 	Host     string `env:"SMTP_HOST"`
 	Port     string `env:"SMTP_PORT"`
@@ -0,0 +1,130 @@
+package notification
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	cfg "github.com/pobyzaarif/go-config"
+)
+
+var ErrUnsafeWebhookURL = errors.New("webhook URL must be https and resolve to a public address")
+
+// publicAddr reports whether addr may receive webhooks: loopback, private,
+// link-local (which includes cloud metadata endpoints), shared, multicast and
+// unspecified addresses are refused.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!netip.MustParsePrefix("100.64.0.0/10").Contains(addr)
+}
+
+// ValidateWebhookURL checks a subscriber URL when it is saved. The URL must
+// be https without credentials, and every address its host resolves to must
+// be public. Addresses are checked again when connecting, since DNS can
+// change in between.
+func ValidateWebhookURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return ErrUnsafeWebhookURL
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: %s does not resolve", ErrUnsafeWebhookURL, u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrUnsafeWebhookURL, u.Hostname(), addr)
+		}
+	}
+	return nil
+}
+
+// webhookClient only connects to public addresses, ignores proxy settings and
+// does not follow redirects, so a subscriber cannot point deliveries at
+// internal services.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				addrPort, err := netip.ParseAddrPort(address)
+				if err != nil || !publicAddr(addrPort.Addr()) {
+					return fmt.Errorf("%w: refusing to connect to %s", ErrUnsafeWebhookURL, address)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// WebhookSender posts notifications to subscriber URLs. Every request carries
+// an X-Webhook-Signature header of the form "t=<unix>,v1=<hex hmac>", where the
+// HMAC-SHA256 is computed over "<unix>.<body>" with the shared secret.
+type WebhookSender struct {
+	SigningSecret string `env:"WEBHOOK_SIGNING_SECRET"`
+	client        *http.Client
+	now           func() time.Time
+}
+
+func NewWebhookSenderFromEnv() (*WebhookSender, error) {
+	s := &WebhookSender{}
+	if err := cfg.LoadConfig(s); err != nil {
+		return nil, err
+	}
+	if s.SigningSecret == "" {
+		return nil, errors.New("WEBHOOK_SIGNING_SECRET is not set")
+	}
+	s.client = webhookClient
+	s.now = time.Now
+	return s, nil
+}
+
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + strconv.FormatInt(timestamp, 10) + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *WebhookSender) Send(to, subject, body string) error {
+	if u, err := url.Parse(to); err != nil || u.Scheme != "https" || u.User != nil {
+		return ErrUnsafeWebhookURL
+	}
+
+	timestamp := s.now().Unix()
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":     subject,
+		"message":   body,
+		"timestamp": timestamp,
+	})
+	if err != nil {
+		return err
+	}
+
+	return post(s.client, to, map[string]string{
+		"X-Webhook-Signature": SignWebhook(s.SigningSecret, timestamp, payload),
+	}, payload)
+}
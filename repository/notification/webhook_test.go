@@ -0,0 +1,26 @@
+package notification
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSender_RefusesInternalAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	sender := &WebhookSender{SigningSecret: "secret", client: webhookClient, now: time.Now}
+
+	err := sender.Send(server.URL, "billing.paid", "Your billing is paid")
+
+	assert.ErrorIs(t, err, ErrUnsafeWebhookURL)
+	assert.False(t, called)
+	assert.ErrorIs(t, sender.Send("http://hooks.example.com", "billing.paid", ""), ErrUnsafeWebhookURL)
+}
@@ -0,0 +1,48 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	cfg "github.com/pobyzaarif/go-config"
+)
+
+// WhatsAppSender sends text messages through a WhatsApp Business Cloud style
+// API: POST {APIURL}/{PhoneNumberID}/messages.
+type WhatsAppSender struct {
+	APIURL        string `env:"WHATSAPP_API_URL" envDefault:"https://graph.facebook.com/v19.0"`
+	PhoneNumberID string `env:"WHATSAPP_PHONE_NUMBER_ID"`
+	AccessToken   string `env:"WHATSAPP_ACCESS_TOKEN"`
+	client        *http.Client
+}
+
+func NewWhatsAppSenderFromEnv() (*WhatsAppSender, error) {
+	s := &WhatsAppSender{}
+	if err := cfg.LoadConfig(s); err != nil {
+		return nil, err
+	}
+	if s.PhoneNumberID == "" || s.AccessToken == "" {
+		return nil, errors.New("WHATSAPP_PHONE_NUMBER_ID and WHATSAPP_ACCESS_TOKEN must be set")
+	}
+	s.client = defaultHTTPClient
+	return s, nil
+}
+
+func (s *WhatsAppSender) Send(to, subject, body string) error {
+	text := body
+	if subject != "" {
+		text = "*" + subject + "*\n" + body
+	}
+
+	url := fmt.Sprintf("%s/%s/messages", strings.TrimRight(s.APIURL, "/"), s.PhoneNumberID)
+	return postJSON(s.client, url, map[string]string{
+		"Authorization": "Bearer " + s.AccessToken,
+	}, map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                strings.TrimPrefix(to, "+"),
+		"type":              "text",
+		"text":              map[string]string{"body": text},
+	})
+}
@@ -0,0 +1,136 @@
+package outbox
+
+import (
+	"Dedenruslan19/med-project/service/outbox"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type outboxRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewOutboxRepo(db *gorm.DB, logger *slog.Logger) outbox.OutboxRepo {
+	return &outboxRepo{db: db, logger: logger}
+}
+
+// Insert writes messages using the given handle, so callers can enqueue them
+// inside the same transaction as their business change.
+func Insert(tx *gorm.DB, messages ...*outbox.Message) error {
+	now := time.Now()
+	for _, msg := range messages {
+		outbox.Prepare(msg, now)
+		if err := tx.Create(msg).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *outboxRepo) Create(msg *outbox.Message) (int64, error) {
+	if err := Insert(r.db, msg); err != nil {
+		r.logger.Error("failed to create outbox message",
+			slog.Any("error", err),
+			slog.String("topic", msg.Topic),
+		)
+		return 0, err
+	}
+	return msg.ID, nil
+}
+
+func (r *outboxRepo) GetByID(id int64) (*outbox.Message, error) {
+	var msg outbox.Message
+	if err := r.db.First(&msg, id).Error; err != nil {
+		r.logger.Error("failed to get outbox message by ID",
+			slog.Any("error", err),
+			slog.Int64("message_id", id),
+		)
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (r *outboxRepo) ListByStatus(status string, limit int) ([]outbox.Message, error) {
+	var messages []outbox.Message
+	err := r.db.Where("status = ?", status).
+		Order("id DESC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		r.logger.Error("failed to list outbox messages",
+			slog.Any("error", err),
+			slog.String("status", status),
+		)
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *outboxRepo) FetchDue(now time.Time, limit int) ([]outbox.Message, error) {
+	var messages []outbox.Message
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", outbox.StatusPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		r.logger.Error("failed to fetch due outbox messages", slog.Any("error", err))
+		return nil, err
+	}
+	return messages, nil
+}
+
+// Claim pushes next_attempt_at forward so concurrent dispatchers skip the
+// message while it is being delivered. A crashed dispatcher releases it when
+// the lease runs out.
+func (r *outboxRepo) Claim(id int64, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.Model(&outbox.Message{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, outbox.StatusPending, now).
+		Update("next_attempt_at", leaseUntil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *outboxRepo) MarkDelivered(id int64, deliveredAt time.Time) error {
+	return r.db.Model(&outbox.Message{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       outbox.StatusDelivered,
+			"delivered_at": deliveredAt,
+			"last_error":   "",
+		}).Error
+}
+
+func (r *outboxRepo) MarkRetry(id int64, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return r.db.Model(&outbox.Message{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error
+}
+
+func (r *outboxRepo) MarkDead(id int64, attempts int, lastError string) error {
+	return r.db.Model(&outbox.Message{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     outbox.StatusDead,
+			"attempts":   attempts,
+			"last_error": lastError,
+		}).Error
+}
+
+func (r *outboxRepo) Requeue(id int64, nextAttemptAt time.Time) error {
+	return r.db.Model(&outbox.Message{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          outbox.StatusPending,
+			"attempts":        0,
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+}
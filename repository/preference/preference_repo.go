@@ -0,0 +1,65 @@
+package preference
+
+import (
+	"Dedenruslan19/med-project/service/notifications"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type preferenceRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPreferenceRepo(db *gorm.DB, logger *slog.Logger) notifications.PreferenceRepo {
+	return &preferenceRepo{db: db, logger: logger}
+}
+
+func (r *preferenceRepo) GetByUserID(userID int64) ([]notifications.Preference, error) {
+	var prefs []notifications.Preference
+	if err := r.db.Where("user_id = ?", userID).Order("category, channel").Find(&prefs).Error; err != nil {
+		r.logger.Error("failed to get notification preferences",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+		)
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (r *preferenceRepo) GetByUserAndCategory(userID int64, category string) ([]notifications.Preference, error) {
+	var prefs []notifications.Preference
+	err := r.db.Where("user_id = ? AND category = ?", userID, category).Find(&prefs).Error
+	if err != nil {
+		r.logger.Error("failed to get notification preferences by category",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+			slog.String("category", category),
+		)
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (r *preferenceRepo) ReplaceCategory(userID int64, category string, prefs []notifications.Preference) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND category = ?", userID, category).
+			Delete(&notifications.Preference{}).Error; err != nil {
+			return err
+		}
+		if len(prefs) == 0 {
+			return nil
+		}
+		return tx.Create(&prefs).Error
+	})
+	if err != nil {
+		r.logger.Error("failed to replace notification preferences",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+			slog.String("category", category),
+		)
+		return err
+	}
+	return nil
+}
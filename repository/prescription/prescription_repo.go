@@ -0,0 +1,104 @@
+package prescription
+
+import (
+	"Dedenruslan19/med-project/service/prescriptions"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type prescriptionRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPrescriptionRepo(db *gorm.DB, logger *slog.Logger) prescriptions.PrescriptionRepo {
+	return &prescriptionRepo{db: db, logger: logger}
+}
+
+// Issue stores the prescription of a diagnosis version inside tx and revokes
+// the one it replaces, so it is issued together with the diagnosis change.
+// A version that already has its prescription keeps it, and once a
+// prescription was dispensed no new one is issued: a second code would let
+// the pharmacy hand out the medication twice. In both cases prescription is
+// set to the existing one.
+func Issue(tx *gorm.DB, prescription *prescriptions.Prescription) error {
+	var latest prescriptions.Prescription
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("diagnose_id = ?", prescription.DiagnoseID).
+		Order("id DESC").
+		First(&latest).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil:
+		return err
+	case latest.DiagnoseVersion >= prescription.DiagnoseVersion, latest.Status == prescriptions.StatusDispensed:
+		*prescription = latest
+		return nil
+	default:
+		if err := Revoke(tx, prescription.DiagnoseID); err != nil {
+			return err
+		}
+	}
+	return tx.Create(prescription).Error
+}
+
+// Revoke withdraws the prescription of the diagnosis that can still be
+// dispensed, if there is one, inside tx.
+func Revoke(tx *gorm.DB, diagnoseID int64) error {
+	return tx.Model(&prescriptions.Prescription{}).
+		Where("diagnose_id = ? AND status = ?", diagnoseID, prescriptions.StatusIssued).
+		Update("status", prescriptions.StatusRevoked).Error
+}
+
+func (r *prescriptionRepo) Issue(prescription *prescriptions.Prescription) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return Issue(tx, prescription)
+	})
+}
+
+func (r *prescriptionRepo) GetByCode(code string) (*prescriptions.Prescription, error) {
+	var prescription prescriptions.Prescription
+	if err := r.db.Where("code = ?", code).First(&prescription).Error; err != nil {
+		return nil, err
+	}
+	return &prescription, nil
+}
+
+func (r *prescriptionRepo) GetLatestByDiagnoseID(diagnoseID int64) (*prescriptions.Prescription, error) {
+	var prescription prescriptions.Prescription
+	if err := r.db.Where("diagnose_id = ?", diagnoseID).Order("id DESC").First(&prescription).Error; err != nil {
+		return nil, err
+	}
+	return &prescription, nil
+}
+
+func (r *prescriptionRepo) MarkDispensed(id int64, pharmacy *prescriptions.Pharmacy, at time.Time) (bool, error) {
+	result := r.db.Model(&prescriptions.Prescription{}).
+		Where("id = ? AND status = ?", id, prescriptions.StatusIssued).
+		Updates(map[string]interface{}{
+			"status":       prescriptions.StatusDispensed,
+			"dispensed_at": at,
+			"dispensed_by": pharmacy.Name,
+			"pharmacy_id":  pharmacy.ID,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *prescriptionRepo) CreatePharmacy(pharmacy *prescriptions.Pharmacy) (int64, error) {
+	if err := r.db.Create(pharmacy).Error; err != nil {
+		return 0, err
+	}
+	return pharmacy.ID, nil
+}
+
+func (r *prescriptionRepo) GetPharmacyByKeyHash(keyHash string) (*prescriptions.Pharmacy, error) {
+	var pharmacy prescriptions.Pharmacy
+	if err := r.db.Where("key_hash = ?", keyHash).First(&pharmacy).Error; err != nil {
+		return nil, err
+	}
+	return &pharmacy, nil
+}
@@ -0,0 +1,82 @@
+package prescription_test
+
+import (
+	"Dedenruslan19/med-project/repository/prescription"
+	"Dedenruslan19/med-project/service/prescriptions"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "Dedenruslan19/med-project/util/encryption"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newPrescription(version int) *prescriptions.Prescription {
+	return &prescriptions.Prescription{DiagnoseID: 7, DiagnoseVersion: version, AppointmentID: 5, UserID: 1, PatientName: "Jane Patient",
+		DoctorID: 2, DoctorName: "John Doctor", LicenseNumber: "SIP-123/2026", Medications: []string{"Amoxicillin 500mg"},
+		Code: fmt.Sprintf("CODE%012d", version), Status: prescriptions.StatusIssued, IssuedAt: time.Now()}
+}
+
+func TestIssue_NotReissuedAfterDispensing(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "prescription.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&prescriptions.Prescription{}, &prescriptions.Pharmacy{}))
+
+	repo := prescription.NewPrescriptionRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	first := newPrescription(1)
+	require.NoError(t, repo.Issue(first))
+
+	again := newPrescription(1)
+	again.Code = "OTHERCODE0000001"
+	require.NoError(t, repo.Issue(again))
+	assert.Equal(t, first.ID, again.ID, "a version keeps its prescription")
+
+	amended := newPrescription(2)
+	require.NoError(t, repo.Issue(amended))
+	revoked, err := repo.GetByCode(first.Code)
+	require.NoError(t, err)
+	assert.Equal(t, prescriptions.StatusRevoked, revoked.Status)
+
+	pharmacy := &prescriptions.Pharmacy{Name: "Apotek Sehat", KeyHash: "hash"}
+	_, err = repo.CreatePharmacy(pharmacy)
+	require.NoError(t, err)
+	dispensed, err := repo.MarkDispensed(amended.ID, pharmacy, time.Now())
+	require.NoError(t, err)
+	assert.True(t, dispensed)
+
+	afterDispensing := newPrescription(3)
+	require.NoError(t, repo.Issue(afterDispensing))
+	assert.Equal(t, amended.ID, afterDispensing.ID)
+	assert.Equal(t, prescriptions.StatusDispensed, afterDispensing.Status)
+	assert.Equal(t, "Apotek Sehat", afterDispensing.DispensedBy)
+
+	var count int64
+	require.NoError(t, db.Model(&prescriptions.Prescription{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestIssue_OneLivePrescriptionPerDiagnose(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "prescription.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&prescriptions.Prescription{}))
+
+	require.NoError(t, db.Create(newPrescription(1)).Error)
+	// bypassing Issue, the indexes still refuse a second live prescription
+	// and a second prescription of the same version
+	assert.Error(t, db.Create(newPrescription(2)).Error)
+
+	duplicate := newPrescription(1)
+	duplicate.Code = "OTHERCODE0000001"
+	duplicate.Status = prescriptions.StatusRevoked
+	assert.Error(t, db.Create(duplicate).Error)
+}
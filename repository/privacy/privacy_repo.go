@@ -0,0 +1,172 @@
+package privacy
+
+import (
+	"log/slog"
+	"time"
+
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/exercises"
+	"Dedenruslan19/med-project/service/insurance"
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/logs"
+	"Dedenruslan19/med-project/service/notifications"
+	"Dedenruslan19/med-project/service/outbox"
+	"Dedenruslan19/med-project/service/prescriptions"
+	"Dedenruslan19/med-project/service/privacy"
+	"Dedenruslan19/med-project/service/users"
+	"Dedenruslan19/med-project/service/workouts"
+
+	"gorm.io/gorm"
+)
+
+type privacyRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPrivacyRepo(db *gorm.DB, logger *slog.Logger) privacy.PrivacyRepo {
+	return &privacyRepo{db: db, logger: logger}
+}
+
+func (r *privacyRepo) ListWorkouts(userID int64) ([]workouts.Workout, error) {
+	var list []workouts.Workout
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *privacyRepo) ListExercises(userID int64) ([]exercises.Exercise, error) {
+	var list []exercises.Exercise
+	err := r.db.Joins("JOIN workouts ON workouts.id = exercises.workout_id").
+		Where("workouts.user_id = ?", userID).
+		Order("exercises.id").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *privacyRepo) ListLogs(userID int64) ([]logs.ExerciseLog, error) {
+	var list []logs.ExerciseLog
+	if err := r.db.Where("user_id = ?", userID).Order("created_at, id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *privacyRepo) ListAppointments(userID int64) ([]appointments.Appointment, error) {
+	var list []appointments.Appointment
+	if err := r.db.Where("user_id = ?", userID).Order("appointment_date, id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *privacyRepo) ListDiagnoses(userID int64) ([]diagnoses.Diagnose, error) {
+	var list []diagnoses.Diagnose
+	err := r.db.Preload("Codes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).
+		Joins("JOIN appointments ON appointments.id = diagnoses.appointment_id").
+		Where("appointments.user_id = ?", userID).
+		Order("diagnoses.created_at, diagnoses.id").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *privacyRepo) ListInvoices(userID int64) ([]invoices.Invoice, error) {
+	var list []invoices.Invoice
+	err := r.db.Joins("JOIN billings ON billings.id = invoices.billing_id").
+		Joins("JOIN appointments ON appointments.id = billings.appointment_id").
+		Where("appointments.user_id = ?", userID).
+		Order("invoices.id").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// erasedError is kept on queued messages of an erased account, which are
+// never delivered.
+const erasedError = "account erased"
+
+// invoiceIDs selects the invoices of the user's appointments.
+func invoiceIDs(tx *gorm.DB, userID int64) *gorm.DB {
+	return tx.Model(&invoices.Invoice{}).Select("invoices.id").
+		Joins("JOIN billings ON billings.id = invoices.billing_id").
+		Joins("JOIN appointments ON appointments.id = billings.appointment_id").
+		Where("appointments.user_id = ?", userID)
+}
+
+// redactRecords overwrites the name and email copied into the records kept
+// for retention. Queued notifications and invoice emails of the user are
+// given up and their recipient and body cleared, delivered ones are cleared
+// as well.
+func redactRecords(tx *gorm.DB, userID int64, name, email string) error {
+	err := tx.Model(&invoices.Invoice{}).
+		Where("id IN (?)", invoiceIDs(tx, userID)).
+		Update("sent_to_email", email).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&prescriptions.Prescription{}).Where("user_id = ?", userID).Update("patient_name", name).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&insurance.Claim{}).Where("user_id = ?", userID).Update("patient_name", name).Error; err != nil {
+		return err
+	}
+
+	messages := func() *gorm.DB {
+		return tx.Model(&outbox.Message{}).
+			Where("(aggregate_type = ? AND aggregate_id = ?) OR (aggregate_type = ? AND aggregate_id IN (?))",
+				notifications.AggregateType, userID, invoices.AggregateType, invoiceIDs(tx, userID))
+	}
+	err = messages().Where("status = ?", outbox.StatusPending).
+		Updates(map[string]interface{}{"status": outbox.StatusDead, "last_error": erasedError}).Error
+	if err != nil {
+		return err
+	}
+	return messages().Updates(map[string]interface{}{"recipient": "", "payload": ""}).Error
+}
+
+// Erase deletes the child rows explicitly rather than relying on ON DELETE
+// CASCADE, since the users row they reference is kept.
+func (r *privacyRepo) Erase(userID int64, name, email string, erasedAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&logs.ExerciseLog{}).Error; err != nil {
+			return err
+		}
+		workoutIDs := tx.Model(&workouts.Workout{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("workout_id IN (?)", workoutIDs).Delete(&exercises.Exercise{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&workouts.Workout{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&notifications.Preference{}).Error; err != nil {
+			return err
+		}
+		if err := redactRecords(tx, userID, name, email); err != nil {
+			return err
+		}
+
+		result := tx.Model(&users.User{}).
+			Where("id = ? AND erased_at IS NULL", userID).
+			Select("full_name", "email", "password", "weight", "height", "erased_at").
+			Updates(&users.User{FullName: name, Email: email, ErasedAt: &erasedAt})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
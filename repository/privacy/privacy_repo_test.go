@@ -0,0 +1,92 @@
+package privacy_test
+
+import (
+	"Dedenruslan19/med-project/repository/privacy"
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/exercises"
+	"Dedenruslan19/med-project/service/insurance"
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/logs"
+	"Dedenruslan19/med-project/service/notifications"
+	"Dedenruslan19/med-project/service/outbox"
+	"Dedenruslan19/med-project/service/prescriptions"
+	privacyService "Dedenruslan19/med-project/service/privacy"
+	"Dedenruslan19/med-project/service/users"
+	"Dedenruslan19/med-project/service/workouts"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "Dedenruslan19/med-project/util/encryption"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestErase_RedactsCopiedNameAndEmail(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "privacy.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&users.User{}, &appointments.Appointment{}, &billings.Billing{}, &invoices.Invoice{},
+		&prescriptions.Prescription{}, &insurance.Claim{}, &outbox.Message{}, &workouts.Workout{}, &exercises.Exercise{},
+		&logs.ExerciseLog{}, &notifications.Preference{}))
+
+	repo := privacy.NewPrivacyRepo(db, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	const name, email = "Jane Patient", "jane@example.com"
+	now := time.Now()
+	require.NoError(t, db.Create(&users.User{ID: 1, FullName: name, Email: email, Password: "hash", Weight: 60, Height: 170}).Error)
+	require.NoError(t, db.Create(&appointments.Appointment{ID: 5, UserID: 1, DoctorID: 2, AppointmentDate: now}).Error)
+	require.NoError(t, db.Create(&billings.Billing{ID: 3, AppointmentID: 5, TotalAmount: 200000, PaymentStatus: billings.StatusPaid}).Error)
+	require.NoError(t, db.Create(&invoices.Invoice{ID: 4, BillingID: 3, InvoiceNumber: "INV/2026/000001", TotalAmount: 200000, SentToEmail: email}).Error)
+	require.NoError(t, db.Create(&prescriptions.Prescription{DiagnoseID: 7, DiagnoseVersion: 1, AppointmentID: 5, UserID: 1, PatientName: name,
+		DoctorID: 2, DoctorName: "John Doctor", LicenseNumber: "SIP-123/2026", Medications: []string{"Paracetamol"},
+		Code: "K7QDM2XA9RTBHC4E", Status: prescriptions.StatusIssued, IssuedAt: now}).Error)
+	require.NoError(t, db.Create(&insurance.Claim{ClaimNumber: "CLM-1", BillingID: 3, UserID: 1, PolicyNumber: "POL-1", PatientName: name,
+		BilledAmount: 200000, ClaimedAmount: 160000, Status: insurance.ClaimSubmitted}).Error)
+	messages := []*outbox.Message{
+		{Topic: "notification.email", AggregateType: notifications.AggregateType, AggregateID: 1, Recipient: email, Payload: "Dear " + name,
+			Status: outbox.StatusPending, NextAttemptAt: now},
+		{Topic: invoices.TopicInvoiceEmail, AggregateType: invoices.AggregateType, AggregateID: 4, Recipient: email, Payload: "Invoice for " + name,
+			Status: outbox.StatusDelivered, NextAttemptAt: now},
+		{Topic: "notification.email", AggregateType: notifications.AggregateType, AggregateID: 9, Recipient: "other@example.com", Payload: "Hello",
+			Status: outbox.StatusPending, NextAttemptAt: now},
+	}
+	require.NoError(t, db.Create(messages).Error)
+
+	require.NoError(t, repo.Erase(1, privacyService.ErasedName, privacyService.ErasedEmail(1), now))
+
+	var invoice invoices.Invoice
+	require.NoError(t, db.First(&invoice, 4).Error)
+	assert.Equal(t, privacyService.ErasedEmail(1), invoice.SentToEmail)
+
+	var prescription prescriptions.Prescription
+	require.NoError(t, db.First(&prescription).Error)
+	assert.Equal(t, privacyService.ErasedName, prescription.PatientName)
+
+	var claim insurance.Claim
+	require.NoError(t, db.First(&claim).Error)
+	assert.Equal(t, privacyService.ErasedName, claim.PatientName)
+
+	var stored []outbox.Message
+	require.NoError(t, db.Order("id").Find(&stored).Error)
+	require.Len(t, stored, 3)
+	assert.Equal(t, outbox.StatusDead, stored[0].Status)
+	assert.Equal(t, outbox.StatusDelivered, stored[1].Status)
+	for _, msg := range stored[:2] {
+		assert.Empty(t, msg.Recipient)
+		assert.Empty(t, msg.Payload)
+	}
+	assert.Equal(t, "other@example.com", stored[2].Recipient, "messages of other users are kept")
+	assert.Equal(t, outbox.StatusPending, stored[2].Status)
+
+	var user users.User
+	require.NoError(t, db.First(&user, 1).Error)
+	assert.Equal(t, privacyService.ErasedName, user.FullName)
+	assert.Equal(t, privacyService.ErasedEmail(1), user.Email)
+}
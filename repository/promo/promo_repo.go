@@ -0,0 +1,66 @@
+package promo
+
+import (
+	"Dedenruslan19/med-project/service/pricing"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type promoRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPromoRepo(db *gorm.DB, logger *slog.Logger) pricing.PromoRepo {
+	return &promoRepo{db: db, logger: logger}
+}
+
+func (r *promoRepo) Create(promo *pricing.PromoCode) (int64, error) {
+	if err := r.db.Create(promo).Error; err != nil {
+		r.logger.Error("failed to create promo code",
+			slog.Any("error", err),
+			slog.String("code", promo.Code),
+		)
+		return 0, err
+	}
+	return promo.ID, nil
+}
+
+func (r *promoRepo) GetByCode(code string) (*pricing.PromoCode, error) {
+	var promo pricing.PromoCode
+	if err := r.db.Where("code = ?", code).First(&promo).Error; err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoRepo) List() ([]pricing.PromoCode, error) {
+	var promos []pricing.PromoCode
+	if err := r.db.Order("id DESC").Find(&promos).Error; err != nil {
+		return nil, err
+	}
+	return promos, nil
+}
+
+// Redeem counts one use of code with the given handle, so callers can redeem
+// it in the same transaction as the billing it discounts. The counter is only
+// incremented while redemptions are left, so two concurrent redemptions of the
+// last use cannot both succeed.
+func Redeem(tx *gorm.DB, code string) (bool, error) {
+	result := tx.Model(&pricing.PromoCode{}).
+		Where("code = ? AND (max_redemptions = 0 OR redemptions < max_redemptions)", code).
+		Update("redemptions", gorm.Expr("redemptions + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Release gives back one use of code, e.g. when a billing it discounted is
+// repriced with another code or none.
+func Release(tx *gorm.DB, code string) error {
+	return tx.Model(&pricing.PromoCode{}).
+		Where("code = ? AND redemptions > 0", code).
+		Update("redemptions", gorm.Expr("redemptions - 1")).Error
+}
@@ -0,0 +1,65 @@
+package pubsub
+
+import (
+	"log/slog"
+	"sync"
+)
+
+type memoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[Principal]map[chan Event]struct{}
+	buffer      int
+	logger      *slog.Logger
+}
+
+// NewMemoryBroker fans events out within this process. Each subscription
+// buffers up to buffer events; when a client falls further behind, events
+// for it are dropped rather than blocking the publisher.
+func NewMemoryBroker(logger *slog.Logger, buffer int) Broker {
+	return &memoryBroker{
+		subscribers: map[Principal]map[chan Event]struct{}{},
+		buffer:      buffer,
+		logger:      logger,
+	}
+}
+
+func (b *memoryBroker) Publish(event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, recipient := range event.Recipients {
+		for ch := range b.subscribers[recipient] {
+			select {
+			case ch <- event:
+			default:
+				b.logger.Warn("dropped event for slow subscriber",
+					slog.String("event_type", event.Type),
+					slog.String("role", recipient.Role),
+					slog.Int64("principal_id", recipient.ID),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *memoryBroker) Subscribe(principal Principal) (*Subscription, error) {
+	ch := make(chan Event, b.buffer)
+
+	b.mu.Lock()
+	if b.subscribers[principal] == nil {
+		b.subscribers[principal] = map[chan Event]struct{}{}
+	}
+	b.subscribers[principal][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return NewSubscription(ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers[principal], ch)
+		if len(b.subscribers[principal]) == 0 {
+			delete(b.subscribers, principal)
+		}
+		close(ch)
+	}), nil
+}
@@ -0,0 +1,79 @@
+package pubsub
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const (
+	RoleUser   = "user"
+	RoleDoctor = "doctor"
+)
+
+// Principal is a patient ("user") or a doctor that receives events.
+type Principal struct {
+	Role string `json:"role"`
+	ID   int64  `json:"id"`
+}
+
+// Event is something that happened which the recipients should learn about
+// without polling. Data must marshal to JSON; it carries IDs and statuses,
+// never clinical notes.
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Data       interface{} `json:"data"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Recipients []Principal `json:"-"`
+}
+
+// NewEvent builds an event with a random ID, occurring now.
+func NewEvent(eventType string, data interface{}, recipients ...Principal) Event {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return Event{
+		ID:         hex.EncodeToString(id),
+		Type:       eventType,
+		Data:       data,
+		OccurredAt: time.Now().UTC(),
+		Recipients: recipients,
+	}
+}
+
+// Publisher is what services use to emit events.
+type Publisher interface {
+	Publish(event Event) error
+}
+
+// Broker fans events out to the subscriptions of their recipients. The
+// in-memory broker only reaches clients connected to this instance; a
+// distributed one (e.g. on Redis or NATS) can implement the same interface
+// when the server runs on several instances.
+type Broker interface {
+	Publisher
+	// Subscribe delivers the events addressed to principal until the
+	// subscription is closed.
+	Subscribe(principal Principal) (*Subscription, error)
+}
+
+// Subscription is one connected client.
+type Subscription struct {
+	Events <-chan Event
+
+	once   sync.Once
+	cancel func()
+}
+
+// NewSubscription wraps a channel of events; cancel is called once, by the
+// first Close.
+func NewSubscription(events <-chan Event, cancel func()) *Subscription {
+	return &Subscription{Events: events, cancel: cancel}
+}
+
+func (s *Subscription) Close() {
+	s.once.Do(s.cancel)
+}
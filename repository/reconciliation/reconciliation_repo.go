@@ -0,0 +1,58 @@
+package reconciliation
+
+import (
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/reconciliation"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type reconciliationRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewReconciliationRepo(db *gorm.DB, logger *slog.Logger) reconciliation.ReconciliationRepo {
+	return &reconciliationRepo{db: db, logger: logger}
+}
+
+func (r *reconciliationRepo) ListAwaitingPayment() ([]reconciliation.Candidate, error) {
+	var list []billings.Billing
+	if err := r.db.Where("payment_status = ?", billings.StatusWaitingPayment).Order("id").Find(&list).Error; err != nil {
+		r.logger.Error("failed to list billings awaiting payment", slog.Any("error", err))
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, 0, len(list))
+	for _, b := range list {
+		ids = append(ids, b.ID)
+	}
+	var invoiceList []invoices.Invoice
+	if err := r.db.Where("billing_id IN ?", ids).Find(&invoiceList).Error; err != nil {
+		r.logger.Error("failed to list invoices awaiting payment", slog.Any("error", err))
+		return nil, err
+	}
+	byBilling := make(map[int64]*invoices.Invoice, len(invoiceList))
+	for i := range invoiceList {
+		byBilling[invoiceList[i].BillingID] = &invoiceList[i]
+	}
+
+	candidates := make([]reconciliation.Candidate, 0, len(list))
+	for _, b := range list {
+		invoice, ok := byBilling[b.ID]
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, reconciliation.Candidate{
+			Billing:       b,
+			InvoiceNumber: invoice.InvoiceNumber,
+			InvoicedAt:    invoice.CreatedAt,
+		})
+	}
+	return candidates, nil
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"Dedenruslan19/med-project/service/reports"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type reportRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewReportRepo(db *gorm.DB, logger *slog.Logger) reports.ReportRepo {
+	return &reportRepo{db: db, logger: logger}
+}
+
+// billingRows selects billings with their doctor and invoice. Aggregation is
+// left to the service so the reports behave the same on every driver.
+func (r *reportRepo) billingRows() *gorm.DB {
+	return r.db.Table("billings").
+		Select(`billings.id AS billing_id, billings.appointment_id, appointments.doctor_id,
+			doctors.full_name AS doctor_name, doctors.specialization, invoices.invoice_number,
+			billings.consultation_fee, billings.medication_fee, billings.discount_amount,
+			billings.tax_amount, billings.total_amount, billings.insurance_amount,
+			billings.patient_amount, billings.payment_status, billings.paid_at, billings.created_at`).
+		Joins("JOIN appointments ON appointments.id = billings.appointment_id").
+		Joins("LEFT JOIN doctors ON doctors.id = appointments.doctor_id").
+		Joins("LEFT JOIN invoices ON invoices.billing_id = billings.id")
+}
+
+func (r *reportRepo) ListBillings(from, to time.Time) ([]reports.BillingRow, error) {
+	var rows []reports.BillingRow
+	err := r.billingRows().
+		Where("billings.created_at >= ? AND billings.created_at < ?", from, to).
+		Order("billings.created_at").
+		Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("failed to list billings for report",
+			slog.Any("error", err),
+			slog.Time("from", from),
+			slog.Time("to", to),
+		)
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *reportRepo) ListUnpaidBillings(asOf time.Time) ([]reports.BillingRow, error) {
+	var rows []reports.BillingRow
+	err := r.billingRows().
+		Where("billings.payment_status NOT IN ? AND billings.created_at <= ?", []string{"paid", "refunded"}, asOf).
+		Order("billings.created_at").
+		Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("failed to list unpaid billings for report",
+			slog.Any("error", err),
+			slog.Time("as_of", asOf),
+		)
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *reportRepo) ListDiagnoseCodes(from, to time.Time, prefix string) ([]reports.DiagnoseCodeRow, error) {
+	query := r.db.Table("diagnose_codes").
+		Select(`diagnose_codes.diagnose_id, diagnose_codes.code, diagnose_codes.title,
+			diagnose_codes.is_primary, appointments.user_id, diagnoses.created_at`).
+		Joins("JOIN diagnoses ON diagnoses.id = diagnose_codes.diagnose_id").
+		Joins("JOIN appointments ON appointments.id = diagnoses.appointment_id").
+		Where("diagnoses.created_at >= ? AND diagnoses.created_at < ?", from, to)
+	if prefix != "" {
+		query = query.Where("diagnose_codes.code LIKE ?", prefix+"%")
+	}
+
+	var rows []reports.DiagnoseCodeRow
+	if err := query.Order("diagnose_codes.code").Scan(&rows).Error; err != nil {
+		r.logger.Error("failed to list diagnose codes for report",
+			slog.Any("error", err),
+			slog.Time("from", from),
+			slog.Time("to", to),
+		)
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,70 @@
+package safety
+
+import (
+	"Dedenruslan19/med-project/service/safety"
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type safetyRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewSafetyRepo(db *gorm.DB, logger *slog.Logger) safety.SafetyRepo {
+	return &safetyRepo{db: db, logger: logger}
+}
+
+func (r *safetyRepo) CreateAllergy(allergy *safety.Allergy) (int64, error) {
+	if err := r.db.Create(allergy).Error; err != nil {
+		return 0, err
+	}
+	return allergy.ID, nil
+}
+
+func (r *safetyRepo) ListAllergies(userID int64) ([]safety.Allergy, error) {
+	var list []safety.Allergy
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *safetyRepo) DeleteAllergy(id, userID int64) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&safety.Allergy{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *safetyRepo) UpsertInteractions(rules []safety.Interaction) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "drug_a"}, {Name: "drug_b"}},
+		DoUpdates: clause.AssignmentColumns([]string{"severity", "description", "updated_at"}),
+	}).Create(&rules).Error
+	if err != nil {
+		r.logger.Error("failed to upsert drug interactions", slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+func (r *safetyRepo) FindInteractions(names []string) ([]safety.Interaction, error) {
+	var list []safety.Interaction
+	if len(names) == 0 {
+		return list, nil
+	}
+	err := r.db.Where("drug_a IN ? AND drug_b IN ?", names, names).Order("id").Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *safetyRepo) ListOverrides(diagnoseID int64) ([]safety.Override, error) {
+	var list []safety.Override
+	if err := r.db.Where("diagnose_id = ?", diagnoseID).Order("id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -76,3 +76,21 @@ func (r *userRepo) FindByID(id int64) (service.User, error) {
 	}
 	return u, nil
 }
+
+func (r *userRepo) UpdateMeasurements(id int64, weight, height float64) error {
+	updates := map[string]interface{}{}
+	if weight > 0 {
+		updates["weight"] = weight
+	}
+	if height > 0 {
+		updates["height"] = height
+	}
+
+	if err := r.db.Model(&service.User{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		r.logger.Error("failed to update user measurements",
+			"user_id", id,
+			"error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package video
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeProvider records the rooms it creates and returns links that encode
+// the participant and the window, for tests.
+type FakeProvider struct {
+	mu    sync.Mutex
+	Rooms []Room
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (p *FakeProvider) CreateRoom(startsAt, endsAt time.Time) (*Room, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	room := Room{
+		Name:     fmt.Sprintf("fake-room-%d", len(p.Rooms)+1),
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+	}
+	p.Rooms = append(p.Rooms, room)
+	return &room, nil
+}
+
+func (p *FakeProvider) JoinURL(room Room, participant Participant, notBefore, expiresAt time.Time) (string, error) {
+	return fmt.Sprintf("https://video.invalid/%s?participant=%s-%d&moderator=%t&nbf=%d&exp=%d",
+		room.Name, participant.Role, participant.ID, participant.Moderator, notBefore.Unix(), expiresAt.Unix()), nil
+}
@@ -0,0 +1,40 @@
+package video
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Room is a consultation room that is only meant to be used between
+// StartsAt and EndsAt.
+type Room struct {
+	Name     string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// Participant is who a join link is issued to. The doctor moderates the
+// room.
+type Participant struct {
+	Role      string
+	ID        int64
+	Moderator bool
+}
+
+// Provider creates video rooms and per-participant join links. Links must
+// not be accepted by the provider before notBefore or after expiresAt.
+type Provider interface {
+	CreateRoom(startsAt, endsAt time.Time) (*Room, error)
+	JoinURL(room Room, participant Participant, notBefore, expiresAt time.Time) (string, error)
+}
+
+// roomName returns an unguessable room name, since anyone who knows it can
+// reach the room on providers without token authentication.
+func roomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "consultation-" + hex.EncodeToString(b), nil
+}
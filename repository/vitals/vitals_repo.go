@@ -0,0 +1,44 @@
+package vitals
+
+import (
+	"Dedenruslan19/med-project/service/vitals"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type vitalRepo struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewVitalRepo(db *gorm.DB, logger *slog.Logger) vitals.VitalRepo {
+	return &vitalRepo{db: db, logger: logger}
+}
+
+func (r *vitalRepo) Create(vital *vitals.Vital) (int64, error) {
+	if err := r.db.Create(vital).Error; err != nil {
+		return 0, err
+	}
+	return vital.ID, nil
+}
+
+func (r *vitalRepo) ListByAppointmentID(appointmentID int64) ([]vitals.Vital, error) {
+	var list []vitals.Vital
+	if err := r.db.Where("appointment_id = ?", appointmentID).Order("recorded_at, id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *vitalRepo) ListByUserID(userID int64, from, to time.Time) ([]vitals.Vital, error) {
+	var list []vitals.Vital
+	err := r.db.Where("user_id = ? AND recorded_at >= ? AND recorded_at < ?", userID, from, to).
+		Order("recorded_at, id").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
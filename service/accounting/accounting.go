@@ -0,0 +1,84 @@
+package accounting
+
+import (
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/invoices"
+	"time"
+)
+
+const (
+	DocumentInvoice    = "invoice"
+	DocumentPayment    = "payment"
+	DocumentRefund     = "refund"
+	DocumentCreditNote = "credit_note"
+
+	FormatDocuments = "csv"
+	FormatJournal   = "journal"
+)
+
+// Account is an entry in the chart of accounts used by the journal.
+type Account struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+var (
+	AccountBank                = Account{"1000", "Bank"}
+	AccountReceivablePatients  = Account{"1100", "Accounts Receivable - Patients"}
+	AccountReceivableInsurers  = Account{"1150", "Accounts Receivable - Insurers"}
+	AccountVATPayable          = Account{"2100", "VAT Payable"}
+	AccountConsultationRevenue = Account{"4000", "Consultation Revenue"}
+	AccountMedicationRevenue   = Account{"4100", "Medication Revenue"}
+	AccountSalesReturns        = Account{"4900", "Sales Returns"}
+)
+
+// PaymentRow is a payment or refund with the invoice of its billing.
+type PaymentRow struct {
+	billings.Payment
+	InvoiceNumber string `json:"invoice_number"`
+}
+
+// CreditNoteRow is a credit note with the number of the invoice it credits.
+type CreditNoteRow struct {
+	invoices.CreditNote
+	InvoiceNumber string `json:"invoice_number"`
+}
+
+// Document is one exported invoice, payment, refund or credit note. ID only
+// depends on the source record, so re-exports of overlapping periods can be
+// de-duplicated on it.
+type Document struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	Number        string    `json:"number"`
+	Date          time.Time `json:"date"`
+	BillingID     int64     `json:"billing_id"`
+	InvoiceNumber string    `json:"invoice_number"`
+	NetAmount     float64   `json:"net_amount"`
+	TaxAmount     float64   `json:"tax_amount"`
+	GrossAmount   float64   `json:"gross_amount"`
+	Method        string    `json:"method,omitempty"`
+	Reference     string    `json:"reference,omitempty"`
+}
+
+// JournalLine is one side of a double-entry journal entry. The lines of an
+// entry share the EntryID of their document and always balance.
+type JournalLine struct {
+	EntryID        string    `json:"entry_id"`
+	Line           int       `json:"line"`
+	Date           time.Time `json:"date"`
+	DocumentType   string    `json:"document_type"`
+	DocumentNumber string    `json:"document_number"`
+	AccountCode    string    `json:"account_code"`
+	AccountName    string    `json:"account_name"`
+	Debit          float64   `json:"debit"`
+	Credit         float64   `json:"credit"`
+	Memo           string    `json:"memo"`
+}
+
+type Export struct {
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Documents []Document    `json:"documents"`
+	Journal   []JournalLine `json:"journal"`
+}
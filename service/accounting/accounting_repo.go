@@ -0,0 +1,15 @@
+package accounting
+
+import (
+	"Dedenruslan19/med-project/service/invoices"
+	"time"
+)
+
+type AccountingRepo interface {
+	// ListInvoices returns the invoices created in [from, to).
+	ListInvoices(from, to time.Time) ([]invoices.Invoice, error)
+	// ListCreditNotes returns the credit notes created in [from, to).
+	ListCreditNotes(from, to time.Time) ([]CreditNoteRow, error)
+	// ListPayments returns the payments and refunds that occurred in [from, to).
+	ListPayments(from, to time.Time) ([]PaymentRow, error)
+}
@@ -0,0 +1,223 @@
+package accounting
+
+import (
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/pricing"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+var ErrInvalidPeriod = errors.New("from must be before to")
+
+type service struct {
+	repo   AccountingRepo
+	logger *slog.Logger
+}
+
+type Service interface {
+	Export(from, to time.Time) (*Export, error)
+}
+
+func NewService(logger *slog.Logger, repo AccountingRepo) Service {
+	return &service{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func documentID(prefix string, id int64) string {
+	return fmt.Sprintf("%s-%d", prefix, id)
+}
+
+// Export collects the documents of [from, to) in date order together with
+// their journal entries.
+func (s *service) Export(from, to time.Time) (*Export, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidPeriod
+	}
+
+	invoiceList, err := s.repo.ListInvoices(from, to)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.repo.ListCreditNotes(from, to)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.repo.ListPayments(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type entry struct {
+		doc   Document
+		lines []JournalLine
+	}
+	entries := make([]entry, 0, len(invoiceList)+len(notes)+len(payments))
+
+	for i := range invoiceList {
+		doc, lines := invoiceEntry(&invoiceList[i])
+		entries = append(entries, entry{doc, lines})
+	}
+	for i := range notes {
+		doc, lines := creditNoteEntry(&notes[i])
+		entries = append(entries, entry{doc, lines})
+	}
+	for i := range payments {
+		doc, lines := paymentEntry(&payments[i])
+		entries = append(entries, entry{doc, lines})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].doc.Date.Equal(entries[j].doc.Date) {
+			return entries[i].doc.Date.Before(entries[j].doc.Date)
+		}
+		return entries[i].doc.ID < entries[j].doc.ID
+	})
+
+	export := &Export{
+		From:      from,
+		To:        to,
+		Documents: make([]Document, 0, len(entries)),
+		Journal:   []JournalLine{},
+	}
+	for _, e := range entries {
+		export.Documents = append(export.Documents, e.doc)
+		export.Journal = append(export.Journal, e.lines...)
+	}
+
+	s.logger.Info("accounting export built",
+		slog.Time("from", from),
+		slog.Time("to", to),
+		slog.Int("documents", len(export.Documents)),
+	)
+	return export, nil
+}
+
+// journal numbers the lines of one entry and drops zero amounts.
+type journal struct {
+	doc   *Document
+	lines []JournalLine
+}
+
+func (j *journal) add(account Account, debit, credit float64, memo string) {
+	debit, credit = round(debit), round(credit)
+	if debit == 0 && credit == 0 {
+		return
+	}
+	j.lines = append(j.lines, JournalLine{
+		EntryID:        j.doc.ID,
+		Line:           len(j.lines) + 1,
+		Date:           j.doc.Date,
+		DocumentType:   j.doc.Type,
+		DocumentNumber: j.doc.Number,
+		AccountCode:    account.Code,
+		AccountName:    account.Name,
+		Debit:          debit,
+		Credit:         credit,
+		Memo:           memo,
+	})
+}
+
+// invoiceEntry books the invoice total as receivable from the patient and the
+// insurer against revenue per line kind and VAT. Rounding differences end up
+// in consultation revenue so the entry always balances.
+func invoiceEntry(invoice *invoices.Invoice) (Document, []JournalLine) {
+	net := round(invoice.TotalAmount - invoice.TaxAmount)
+	doc := Document{
+		ID:            documentID("INV", invoice.ID),
+		Type:          DocumentInvoice,
+		Number:        invoice.InvoiceNumber,
+		Date:          invoice.CreatedAt,
+		BillingID:     invoice.BillingID,
+		InvoiceNumber: invoice.InvoiceNumber,
+		NetAmount:     net,
+		TaxAmount:     invoice.TaxAmount,
+		GrossAmount:   invoice.TotalAmount,
+	}
+
+	medication := invoice.MedicationFee
+	if invoice.PricingBreakdown != nil {
+		medication = 0
+		for _, line := range invoice.PricingBreakdown.Lines {
+			if line.Kind == pricing.KindMedication {
+				medication += line.Amount - line.Discount
+			}
+		}
+	}
+	medication = round(medication)
+
+	insurer := round(invoice.InsuranceAmount)
+	memo := "Invoice " + invoice.InvoiceNumber
+	j := journal{doc: &doc}
+	j.add(AccountReceivablePatients, invoice.TotalAmount-insurer, 0, memo)
+	j.add(AccountReceivableInsurers, insurer, 0, memo)
+	j.add(AccountConsultationRevenue, 0, net-medication, memo)
+	j.add(AccountMedicationRevenue, 0, medication, memo)
+	j.add(AccountVATPayable, 0, invoice.TaxAmount, memo)
+	return doc, j.lines
+}
+
+// creditNoteEntry reverses revenue and VAT against the patient's receivable.
+func creditNoteEntry(note *CreditNoteRow) (Document, []JournalLine) {
+	net := round(note.Amount - note.TaxAmount)
+	doc := Document{
+		ID:            documentID("CN", note.ID),
+		Type:          DocumentCreditNote,
+		Number:        note.CreditNoteNumber,
+		Date:          note.CreatedAt,
+		BillingID:     note.BillingID,
+		InvoiceNumber: note.InvoiceNumber,
+		NetAmount:     net,
+		TaxAmount:     note.TaxAmount,
+		GrossAmount:   note.Amount,
+		Reference:     note.Reason,
+	}
+
+	memo := fmt.Sprintf("Credit note %s for invoice %s", note.CreditNoteNumber, note.InvoiceNumber)
+	j := journal{doc: &doc}
+	j.add(AccountSalesReturns, net, 0, memo)
+	j.add(AccountVATPayable, note.TaxAmount, 0, memo)
+	j.add(AccountReceivablePatients, 0, note.Amount, memo)
+	return doc, j.lines
+}
+
+// paymentEntry moves money between the bank and the patient's receivable.
+func paymentEntry(payment *PaymentRow) (Document, []JournalLine) {
+	doc := Document{
+		ID:            documentID("PAY", payment.ID),
+		Type:          DocumentPayment,
+		Number:        documentID("PAY", payment.ID),
+		Date:          payment.OccurredAt,
+		BillingID:     payment.BillingID,
+		InvoiceNumber: payment.InvoiceNumber,
+		NetAmount:     payment.Amount,
+		GrossAmount:   payment.Amount,
+		Method:        payment.Method,
+		Reference:     payment.Reference,
+	}
+
+	j := journal{doc: &doc}
+	if payment.Kind == billings.PaymentKindRefund {
+		doc.ID = documentID("REF", payment.ID)
+		doc.Type = DocumentRefund
+		doc.Number = doc.ID
+		memo := "Refund for invoice " + payment.InvoiceNumber
+		j.add(AccountReceivablePatients, payment.Amount, 0, memo)
+		j.add(AccountBank, 0, payment.Amount, memo)
+	} else {
+		memo := "Payment for invoice " + payment.InvoiceNumber
+		j.add(AccountBank, payment.Amount, 0, memo)
+		j.add(AccountReceivablePatients, 0, payment.Amount, memo)
+	}
+	return doc, j.lines
+}
@@ -0,0 +1,115 @@
+package accounting_test
+
+import (
+	"Dedenruslan19/med-project/service/accounting"
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/pricing"
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func day(d int) time.Time {
+	return time.Date(2026, time.March, d, 10, 0, 0, 0, time.UTC)
+}
+
+func setup(t *testing.T) (*accounting.MockAccountingRepo, accounting.Service) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockRepo := accounting.NewMockAccountingRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	return mockRepo, accounting.NewService(logger, mockRepo)
+}
+
+func TestExport_JournalBalances(t *testing.T) {
+	mockRepo, service := setup(t)
+	from, to := day(1), day(31)
+
+	mockRepo.EXPECT().ListInvoices(from, to).Return([]invoices.Invoice{
+		{
+			ID: 7, BillingID: 3, InvoiceNumber: "INV/2026/000007", TaxAmount: 19800, TotalAmount: 299800,
+			InsuranceAmount: 100000, CreatedAt: day(2),
+			PricingBreakdown: &pricing.Breakdown{Lines: []pricing.LineResult{
+				{Line: pricing.Line{Kind: pricing.KindConsultation}, Amount: 200000, Discount: 20000},
+				{Line: pricing.Line{Kind: pricing.KindMedication}, Amount: 100000},
+			}},
+		},
+	}, nil)
+	mockRepo.EXPECT().ListCreditNotes(from, to).Return([]accounting.CreditNoteRow{
+		{CreditNote: invoices.CreditNote{ID: 2, InvoiceID: 7, BillingID: 3, CreditNoteNumber: "CN/2026/000001", Amount: 50000, TaxAmount: 3302.2, CreatedAt: day(5)}, InvoiceNumber: "INV/2026/000007"},
+	}, nil)
+	mockRepo.EXPECT().ListPayments(from, to).Return([]accounting.PaymentRow{
+		{Payment: billings.Payment{ID: 4, BillingID: 3, Kind: billings.PaymentKindPayment, Amount: 199800, Method: "manual", OccurredAt: day(3)}, InvoiceNumber: "INV/2026/000007"},
+		{Payment: billings.Payment{ID: 5, BillingID: 3, Kind: billings.PaymentKindRefund, Amount: 50000, Method: "manual", Reference: "CN/2026/000001", OccurredAt: day(5)}, InvoiceNumber: "INV/2026/000007"},
+	}, nil)
+
+	export, err := service.Export(from, to)
+
+	assert.NoError(t, err)
+	ids := []string{}
+	for _, doc := range export.Documents {
+		ids = append(ids, doc.ID)
+	}
+	assert.Equal(t, []string{"INV-7", "PAY-4", "CN-2", "REF-5"}, ids)
+
+	debit, credit := map[string]float64{}, map[string]float64{}
+	for _, line := range export.Journal {
+		debit[line.EntryID] += line.Debit
+		credit[line.EntryID] += line.Credit
+	}
+	for id := range debit {
+		assert.InDelta(t, debit[id], credit[id], 0.001, "entry %s does not balance", id)
+	}
+
+	revenue := map[string]float64{}
+	for _, line := range export.Journal {
+		if line.EntryID == "INV-7" {
+			revenue[line.AccountCode] += line.Credit - line.Debit
+		}
+	}
+	assert.Equal(t, 180000.0, revenue[accounting.AccountConsultationRevenue.Code])
+	assert.Equal(t, 100000.0, revenue[accounting.AccountMedicationRevenue.Code])
+	assert.Equal(t, 19800.0, revenue[accounting.AccountVATPayable.Code])
+	assert.Equal(t, -100000.0, revenue[accounting.AccountReceivableInsurers.Code])
+	assert.Equal(t, -199800.0, revenue[accounting.AccountReceivablePatients.Code])
+}
+
+func TestExport_JournalCSV(t *testing.T) {
+	mockRepo, service := setup(t)
+	from, to := day(1), day(31)
+
+	mockRepo.EXPECT().ListInvoices(from, to).Return([]invoices.Invoice{
+		{ID: 1, BillingID: 1, InvoiceNumber: "INV-1", ConsultationFee: 200000, MedicationFee: 50000, TotalAmount: 250000, CreatedAt: day(2)},
+	}, nil)
+	mockRepo.EXPECT().ListCreditNotes(from, to).Return(nil, nil)
+	mockRepo.EXPECT().ListPayments(from, to).Return(nil, nil)
+
+	export, err := service.Export(from, to)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, export.WriteCSV(&buf, accounting.FormatJournal))
+
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, rows, 4)
+	assert.True(t, strings.HasPrefix(rows[0], "entry_id,line,date"))
+	assert.Equal(t, "INV-1,1,2026-03-02,invoice,INV-1,1100,Accounts Receivable - Patients,250000.00,0.00,Invoice INV-1", rows[1])
+	assert.Contains(t, rows[3], "4100,Medication Revenue,0.00,50000.00")
+}
+
+func TestExport_InvalidPeriod(t *testing.T) {
+	_, service := setup(t)
+
+	export, err := service.Export(day(5), day(5))
+
+	assert.ErrorIs(t, err, accounting.ErrInvalidPeriod)
+	assert.Nil(t, export)
+}
@@ -0,0 +1,88 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+const dateLayout = "2006-01-02"
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// WriteDocumentsCSV writes one row per document.
+func (e *Export) WriteDocumentsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"id", "type", "number", "date", "billing_id", "invoice_number",
+		"net_amount", "tax_amount", "gross_amount", "method", "reference",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, doc := range e.Documents {
+		row := []string{
+			doc.ID,
+			doc.Type,
+			doc.Number,
+			doc.Date.UTC().Format(dateLayout),
+			strconv.FormatInt(doc.BillingID, 10),
+			doc.InvoiceNumber,
+			formatAmount(doc.NetAmount),
+			formatAmount(doc.TaxAmount),
+			formatAmount(doc.GrossAmount),
+			doc.Method,
+			doc.Reference,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJournalCSV writes one row per journal line.
+func (e *Export) WriteJournalCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"entry_id", "line", "date", "document_type", "document_number",
+		"account_code", "account_name", "debit", "credit", "memo",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, line := range e.Journal {
+		row := []string{
+			line.EntryID,
+			strconv.Itoa(line.Line),
+			line.Date.UTC().Format(dateLayout),
+			line.DocumentType,
+			line.DocumentNumber,
+			line.AccountCode,
+			line.AccountName,
+			formatAmount(line.Debit),
+			formatAmount(line.Credit),
+			line.Memo,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteCSV writes the export in format, FormatDocuments or FormatJournal.
+func (e *Export) WriteCSV(w io.Writer, format string) error {
+	if format == FormatJournal {
+		return e.WriteJournalCSV(w)
+	}
+	return e.WriteDocumentsCSV(w)
+}
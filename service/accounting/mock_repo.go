@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/accounting/accounting_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/accounting/accounting_repo.go -destination=service/accounting/mock_repo.go -package=accounting
+//
+
+// Package accounting is a generated GoMock package.
+package accounting
+
+import (
+	invoices "Dedenruslan19/med-project/service/invoices"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccountingRepo is a mock of AccountingRepo interface.
+type MockAccountingRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountingRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockAccountingRepoMockRecorder is the mock recorder for MockAccountingRepo.
+type MockAccountingRepoMockRecorder struct {
+	mock *MockAccountingRepo
+}
+
+// NewMockAccountingRepo creates a new mock instance.
+func NewMockAccountingRepo(ctrl *gomock.Controller) *MockAccountingRepo {
+	mock := &MockAccountingRepo{ctrl: ctrl}
+	mock.recorder = &MockAccountingRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountingRepo) EXPECT() *MockAccountingRepoMockRecorder {
+	return m.recorder
+}
+
+// ListCreditNotes mocks base method.
+func (m *MockAccountingRepo) ListCreditNotes(from, to time.Time) ([]CreditNoteRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCreditNotes", from, to)
+	ret0, _ := ret[0].([]CreditNoteRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCreditNotes indicates an expected call of ListCreditNotes.
+func (mr *MockAccountingRepoMockRecorder) ListCreditNotes(from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCreditNotes", reflect.TypeOf((*MockAccountingRepo)(nil).ListCreditNotes), from, to)
+}
+
+// ListInvoices mocks base method.
+func (m *MockAccountingRepo) ListInvoices(from, to time.Time) ([]invoices.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvoices", from, to)
+	ret0, _ := ret[0].([]invoices.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvoices indicates an expected call of ListInvoices.
+func (mr *MockAccountingRepoMockRecorder) ListInvoices(from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvoices", reflect.TypeOf((*MockAccountingRepo)(nil).ListInvoices), from, to)
+}
+
+// ListPayments mocks base method.
+func (m *MockAccountingRepo) ListPayments(from, to time.Time) ([]PaymentRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPayments", from, to)
+	ret0, _ := ret[0].([]PaymentRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPayments indicates an expected call of ListPayments.
+func (mr *MockAccountingRepoMockRecorder) ListPayments(from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPayments", reflect.TypeOf((*MockAccountingRepo)(nil).ListPayments), from, to)
+}
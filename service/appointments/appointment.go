@@ -2,12 +2,48 @@ package appointments
 
 import "time"
 
+const (
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+	StatusCancelled = "cancelled"
+	StatusCompleted = "completed"
+
+	EventCreated   = "appointment.created"
+	EventConfirmed = "appointment.confirmed"
+	EventCancelled = "appointment.cancelled"
+	EventCompleted = "appointment.completed"
+
+	ModalityInPerson = "in_person"
+	ModalityVideo    = "video"
+)
+
 type Appointment struct {
-	Status          string    `json:"status" gorm:"default:'pending'"`
-	Notes           string    `json:"notes"`
+	Status   string `json:"status" gorm:"default:'pending'"`
+	Notes    string `json:"notes" gorm:"type:text;serializer:encrypted"`
+	Modality string `json:"modality" gorm:"default:'in_person'"`
+	// VideoRoom is the provider's room of a video appointment. It is not
+	// exposed; participants get their own join link instead.
+	VideoRoom       string    `json:"-"`
 	ID              int64     `json:"id" gorm:"primaryKey;autoIncrement"`
 	UserID          int64     `json:"user_id" gorm:"not null;index"`
 	DoctorID        int64     `json:"doctor_id" gorm:"not null;index"`
 	AppointmentDate time.Time `json:"appointment_date" gorm:"not null"`
 	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+	// CompletedAt is set when the status becomes completed.
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// JoinLink is a participant's link to a video appointment's room, accepted
+// between NotBefore and ExpiresAt.
+type JoinLink struct {
+	URL       string    `json:"url"`
+	NotBefore time.Time `json:"not_before"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// EventData is the payload of appointment events.
+type EventData struct {
+	AppointmentID   int64     `json:"appointment_id"`
+	Status          string    `json:"status"`
+	AppointmentDate time.Time `json:"appointment_date"`
 }
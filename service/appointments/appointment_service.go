@@ -1,13 +1,46 @@
 package appointments
 
 import (
+	"Dedenruslan19/med-project/repository/pubsub"
+	"Dedenruslan19/med-project/repository/video"
 	errs "Dedenruslan19/med-project/service/errors"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"time"
 )
 
+var (
+	ErrForbidden         = errors.New("only the patient or the doctor of the appointment can change it")
+	ErrInvalidTransition = errors.New("invalid appointment status transition")
+	ErrInvalidModality   = errors.New("modality must be in_person or video")
+	ErrVideoUnavailable  = errors.New("video consultations are not available")
+	ErrNotVideo          = errors.New("appointment is not a video consultation")
+	ErrJoinNotOpen       = errors.New("video room is not open yet")
+	ErrJoinClosed        = errors.New("video room is closed")
+)
+
+// Video holds how video appointments get their rooms. A room is meant for
+// the slot of SlotLength starting at the appointment date; join links are
+// handed out from JoinEarly before the slot until it ends.
+type Video struct {
+	Provider   video.Provider
+	SlotLength time.Duration
+	JoinEarly  time.Duration
+}
+
+// window returns when participants may join a slot starting at start.
+func (v Video) window(start time.Time) (time.Time, time.Time) {
+	return start.Add(-v.JoinEarly), start.Add(v.SlotLength)
+}
+
 type service struct {
-	repo   AppointmentRepo
-	logger *slog.Logger
+	repo      AppointmentRepo
+	publisher pubsub.Publisher
+	video     Video
+	now       func() time.Time
+	logger    *slog.Logger
 }
 
 type Service interface {
@@ -15,16 +48,65 @@ type Service interface {
 	GetByID(id int64) (*Appointment, error)
 	GetByUserID(userID int64) ([]Appointment, error)
 	UpdateStatus(id int64, status string) error
+	// Confirm is done by the appointment's doctor while it is pending.
+	Confirm(id, doctorID int64) (*Appointment, error)
+	// Cancel is done by the patient or the doctor before the appointment is
+	// completed.
+	Cancel(id int64, role string, callerID int64) (*Appointment, error)
+	// JoinLink returns the patient's or the doctor's own link to a video
+	// appointment's room, while the room is open.
+	JoinLink(id int64, role string, callerID int64) (*JoinLink, error)
 }
 
-func NewService(logger *slog.Logger, repo AppointmentRepo) Service {
+// NewService publishes appointment events to the patient and the doctor; a
+// nil publisher disables them. Without a video provider only in-person
+// appointments can be booked.
+func NewService(logger *slog.Logger, repo AppointmentRepo, publisher pubsub.Publisher, video Video) Service {
 	return &service{
-		logger: logger,
-		repo:   repo,
+		logger:    logger,
+		repo:      repo,
+		publisher: publisher,
+		video:     video,
+		now:       time.Now,
+	}
+}
+
+// publish tells the patient and the doctor about a change. Failures are
+// logged, the change itself is already stored.
+func (s *service) publish(eventType string, appointment *Appointment) {
+	if s.publisher == nil {
+		return
+	}
+
+	event := pubsub.NewEvent(eventType, EventData{
+		AppointmentID:   appointment.ID,
+		Status:          appointment.Status,
+		AppointmentDate: appointment.AppointmentDate,
+	},
+		pubsub.Principal{Role: pubsub.RoleUser, ID: appointment.UserID},
+		pubsub.Principal{Role: pubsub.RoleDoctor, ID: appointment.DoctorID},
+	)
+	if err := s.publisher.Publish(event); err != nil {
+		s.logger.Warn("failed to publish appointment event",
+			slog.Any("error", err),
+			slog.String("event_type", eventType),
+			slog.Int64("appointment_id", appointment.ID),
+		)
 	}
 }
 
 func (s *service) Create(appointment *Appointment) (int64, error) {
+	switch appointment.Modality {
+	case "":
+		appointment.Modality = ModalityInPerson
+	case ModalityInPerson, ModalityVideo:
+	default:
+		return 0, ErrInvalidModality
+	}
+	if appointment.Modality == ModalityVideo && s.video.Provider == nil {
+		return 0, ErrVideoUnavailable
+	}
+
 	available, err := s.repo.IsDoctorAvailable(appointment.DoctorID, appointment.AppointmentDate)
 	if err != nil {
 		s.logger.Error("failed to check doctor availabilities",
@@ -42,6 +124,18 @@ func (s *service) Create(appointment *Appointment) (int64, error) {
 		return 0, errs.ErrDoctorBusy
 	}
 
+	if appointment.Modality == ModalityVideo {
+		room, err := s.video.Provider.CreateRoom(appointment.AppointmentDate, appointment.AppointmentDate.Add(s.video.SlotLength))
+		if err != nil {
+			s.logger.Error("failed to create video room",
+				slog.Any("error", err),
+				slog.Int64("doctor_id", appointment.DoctorID),
+			)
+			return 0, err
+		}
+		appointment.VideoRoom = room.Name
+	}
+
 	id, err := s.repo.Create(appointment)
 	if err != nil {
 		s.logger.Error("failed to create appointment",
@@ -50,6 +144,9 @@ func (s *service) Create(appointment *Appointment) (int64, error) {
 		)
 		return 0, err
 	}
+
+	appointment.ID = id
+	s.publish(EventCreated, appointment)
 	return id, nil
 }
 
@@ -87,5 +184,99 @@ func (s *service) UpdateStatus(id int64, status string) error {
 		)
 		return err
 	}
+
+	if status == StatusCompleted && s.publisher != nil {
+		if appointment, err := s.repo.GetByID(id); err == nil {
+			s.publish(EventCompleted, appointment)
+		}
+	}
 	return nil
 }
+
+func (s *service) Confirm(id, doctorID int64) (*Appointment, error) {
+	appointment, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if appointment.DoctorID != doctorID {
+		return nil, ErrForbidden
+	}
+	return s.transition(appointment, StatusConfirmed, EventConfirmed, StatusPending)
+}
+
+func (s *service) Cancel(id int64, role string, callerID int64) (*Appointment, error) {
+	appointment, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case role == pubsub.RoleUser && appointment.UserID == callerID:
+	case role == pubsub.RoleDoctor && appointment.DoctorID == callerID:
+	default:
+		return nil, ErrForbidden
+	}
+	return s.transition(appointment, StatusCancelled, EventCancelled, StatusPending, StatusConfirmed)
+}
+
+// transition moves the appointment to status when it is in one of from.
+func (s *service) transition(appointment *Appointment, status, eventType string, from ...string) (*Appointment, error) {
+	if !slices.Contains(from, appointment.Status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, appointment.Status, status)
+	}
+	if err := s.UpdateStatus(appointment.ID, status); err != nil {
+		return nil, err
+	}
+
+	appointment.Status = status
+	s.publish(eventType, appointment)
+	return appointment, nil
+}
+
+func (s *service) JoinLink(id int64, role string, callerID int64) (*JoinLink, error) {
+	appointment, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	participant := video.Participant{Role: role, ID: callerID}
+	switch {
+	case role == pubsub.RoleUser && appointment.UserID == callerID:
+	case role == pubsub.RoleDoctor && appointment.DoctorID == callerID:
+		participant.Moderator = true
+	default:
+		return nil, ErrForbidden
+	}
+	if appointment.Modality != ModalityVideo || appointment.VideoRoom == "" {
+		return nil, ErrNotVideo
+	}
+	if s.video.Provider == nil {
+		return nil, ErrVideoUnavailable
+	}
+
+	notBefore, expiresAt := s.video.window(appointment.AppointmentDate)
+	now := s.now()
+	switch {
+	case appointment.Status == StatusCancelled || appointment.Status == StatusCompleted:
+		return nil, ErrJoinClosed
+	case now.Before(notBefore):
+		return nil, fmt.Errorf("%w: it opens at %s", ErrJoinNotOpen, notBefore.Format(time.RFC3339))
+	case !now.Before(expiresAt):
+		return nil, ErrJoinClosed
+	}
+
+	room := video.Room{
+		Name:     appointment.VideoRoom,
+		StartsAt: appointment.AppointmentDate,
+		EndsAt:   appointment.AppointmentDate.Add(s.video.SlotLength),
+	}
+	url, err := s.video.Provider.JoinURL(room, participant, notBefore, expiresAt)
+	if err != nil {
+		s.logger.Error("failed to create video join link",
+			slog.Any("error", err),
+			slog.Int64("appointment_id", id),
+		)
+		return nil, err
+	}
+	return &JoinLink{URL: url, NotBefore: notBefore, ExpiresAt: expiresAt}, nil
+}
@@ -1,6 +1,8 @@
 package appointments_test
 
 import (
+	"Dedenruslan19/med-project/repository/pubsub"
+	"Dedenruslan19/med-project/repository/video"
 	"Dedenruslan19/med-project/service/appointments"
 	"errors"
 	"log/slog"
@@ -18,7 +20,7 @@ func TestGetByID_Success(t *testing.T) {
 
 	mockRepo := appointments.NewMockAppointmentRepo(ctrl)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	service := appointments.NewService(logger, mockRepo)
+	service := appointments.NewService(logger, mockRepo, nil, appointments.Video{})
 
 	appointmentDate := time.Date(2025, 11, 10, 14, 30, 0, 0, time.UTC)
 	expectedAppointment := &appointments.Appointment{
@@ -50,7 +52,7 @@ func TestGetByID_NotFound(t *testing.T) {
 
 	mockRepo := appointments.NewMockAppointmentRepo(ctrl)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	service := appointments.NewService(logger, mockRepo)
+	service := appointments.NewService(logger, mockRepo, nil, appointments.Video{})
 
 	mockRepo.EXPECT().
 		GetByID(int64(999)).
@@ -62,3 +64,139 @@ func TestGetByID_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
+
+func TestCancel_PublishesToPatientAndDoctor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := appointments.NewMockAppointmentRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	broker := pubsub.NewMemoryBroker(logger, 4)
+	service := appointments.NewService(logger, mockRepo, broker, appointments.Video{})
+
+	patient, err := broker.Subscribe(pubsub.Principal{Role: pubsub.RoleUser, ID: 1})
+	assert.NoError(t, err)
+	defer patient.Close()
+	doctor, err := broker.Subscribe(pubsub.Principal{Role: pubsub.RoleDoctor, ID: 2})
+	assert.NoError(t, err)
+	defer doctor.Close()
+
+	mockRepo.EXPECT().
+		GetByID(int64(1)).
+		Return(&appointments.Appointment{ID: 1, UserID: 1, DoctorID: 2, Status: appointments.StatusConfirmed}, nil).
+		Times(1)
+	mockRepo.EXPECT().
+		UpdateStatus(int64(1), appointments.StatusCancelled).
+		Return(nil).
+		Times(1)
+
+	result, err := service.Cancel(1, pubsub.RoleUser, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, appointments.StatusCancelled, result.Status)
+	for _, subscription := range []*pubsub.Subscription{patient, doctor} {
+		select {
+		case event := <-subscription.Events:
+			assert.Equal(t, appointments.EventCancelled, event.Type)
+			assert.Equal(t, int64(1), event.Data.(appointments.EventData).AppointmentID)
+		default:
+			t.Fatal("expected an appointment.cancelled event")
+		}
+	}
+}
+
+func TestConfirm_Rejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := appointments.NewMockAppointmentRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := appointments.NewService(logger, mockRepo, nil, appointments.Video{})
+
+	mockRepo.EXPECT().
+		GetByID(int64(1)).
+		Return(&appointments.Appointment{ID: 1, UserID: 1, DoctorID: 2, Status: appointments.StatusCancelled}, nil).
+		Times(2)
+
+	_, err := service.Confirm(1, 3)
+	assert.ErrorIs(t, err, appointments.ErrForbidden)
+
+	_, err = service.Confirm(1, 2)
+	assert.ErrorIs(t, err, appointments.ErrInvalidTransition)
+}
+
+func TestCreate_VideoRoom(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := appointments.NewMockAppointmentRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	provider := video.NewFakeProvider()
+	service := appointments.NewService(logger, mockRepo, nil, appointments.Video{
+		Provider:   provider,
+		SlotLength: 30 * time.Minute,
+		JoinEarly:  10 * time.Minute,
+	})
+
+	appointmentDate := time.Date(2025, 11, 10, 14, 30, 0, 0, time.UTC)
+	mockRepo.EXPECT().
+		IsDoctorAvailable(int64(2), appointmentDate).
+		Return(true, nil).
+		Times(1)
+	mockRepo.EXPECT().
+		Create(gomock.Any()).
+		DoAndReturn(func(appointment *appointments.Appointment) (int64, error) {
+			assert.Equal(t, "fake-room-1", appointment.VideoRoom)
+			return 1, nil
+		}).
+		Times(1)
+
+	_, err := service.Create(&appointments.Appointment{UserID: 1, DoctorID: 2, AppointmentDate: appointmentDate, Modality: appointments.ModalityVideo})
+
+	assert.NoError(t, err)
+	assert.Len(t, provider.Rooms, 1)
+	assert.Equal(t, appointmentDate.Add(30*time.Minute), provider.Rooms[0].EndsAt)
+
+	_, err = service.Create(&appointments.Appointment{UserID: 1, DoctorID: 2, AppointmentDate: appointmentDate, Modality: "phone"})
+	assert.ErrorIs(t, err, appointments.ErrInvalidModality)
+}
+
+func TestJoinLink_Window(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := appointments.NewMockAppointmentRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := appointments.NewService(logger, mockRepo, nil, appointments.Video{
+		Provider:   video.NewFakeProvider(),
+		SlotLength: 30 * time.Minute,
+		JoinEarly:  10 * time.Minute,
+	})
+
+	videoAppointment := func(start time.Time) *appointments.Appointment {
+		return &appointments.Appointment{ID: 1, UserID: 1, DoctorID: 2, AppointmentDate: start,
+			Status: appointments.StatusConfirmed, Modality: appointments.ModalityVideo, VideoRoom: "fake-room-1"}
+	}
+	now := time.Now()
+	gomock.InOrder(
+		mockRepo.EXPECT().GetByID(int64(1)).Return(videoAppointment(now.Add(5*time.Minute)), nil),
+		mockRepo.EXPECT().GetByID(int64(1)).Return(videoAppointment(now.Add(5*time.Minute)), nil),
+		mockRepo.EXPECT().GetByID(int64(1)).Return(videoAppointment(now.Add(time.Hour)), nil),
+		mockRepo.EXPECT().GetByID(int64(1)).Return(videoAppointment(now.Add(-time.Hour)), nil),
+	)
+
+	patient, err := service.JoinLink(1, pubsub.RoleUser, 1)
+	assert.NoError(t, err)
+	assert.Contains(t, patient.URL, "participant=user-1&moderator=false")
+
+	doctor, err := service.JoinLink(1, pubsub.RoleDoctor, 2)
+	assert.NoError(t, err)
+	assert.Contains(t, doctor.URL, "participant=doctor-2&moderator=true")
+	assert.NotEqual(t, patient.URL, doctor.URL)
+
+	_, err = service.JoinLink(1, pubsub.RoleUser, 1)
+	assert.ErrorIs(t, err, appointments.ErrJoinNotOpen)
+
+	_, err = service.JoinLink(1, pubsub.RoleUser, 1)
+	assert.ErrorIs(t, err, appointments.ErrJoinClosed)
+}
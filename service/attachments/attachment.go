@@ -0,0 +1,61 @@
+package attachments
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	OwnerAppointment = "appointment"
+	OwnerDiagnose    = "diagnose"
+
+	CategoryLabResult = "lab_result"
+	CategoryScan      = "scan"
+	CategoryReferral  = "referral"
+	CategoryOther     = "other"
+)
+
+var categories = map[string]bool{
+	CategoryLabResult: true,
+	CategoryScan:      true,
+	CategoryReferral:  true,
+	CategoryOther:     true,
+}
+
+// Attachment is a file uploaded to an appointment or a diagnosis. The
+// content type is sniffed from the file itself and Checksum is the hex
+// SHA-256 of its content. AppointmentID and UserID are copied from the owner
+// so access checks and the patient's list need no join.
+type Attachment struct {
+	ID            int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	OwnerType     string    `json:"owner_type" gorm:"type:varchar(20);not null"`
+	OwnerID       int64     `json:"owner_id" gorm:"not null"`
+	AppointmentID int64     `json:"appointment_id" gorm:"not null;index"`
+	UserID        int64     `json:"user_id" gorm:"not null;index"`
+	UploadedBy    int64     `json:"uploaded_by" gorm:"not null"`
+	UploaderRole  string    `json:"uploader_role" gorm:"type:varchar(20);not null"`
+	Category      string    `json:"category" gorm:"type:varchar(20);not null"`
+	FileName      string    `json:"file_name" gorm:"type:varchar(255);not null"`
+	ContentType   string    `json:"content_type" gorm:"type:varchar(100);not null"`
+	Size          int64     `json:"size" gorm:"not null"`
+	Checksum      string    `json:"checksum" gorm:"type:char(64);not null"`
+	StorageKey    string    `json:"-" gorm:"type:varchar(255);not null;uniqueIndex"`
+	CreatedAt     time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+
+	DownloadURL       string     `json:"download_url,omitempty" gorm:"-"`
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty" gorm:"-"`
+}
+
+// Caller is the authenticated doctor or patient making a request.
+type Caller struct {
+	ID   int64
+	Role string
+}
+
+// Upload is a file as received from the client. The client's content type
+// is not trusted and therefore not part of it.
+type Upload struct {
+	FileName string
+	Category string
+	Content  io.Reader
+}
@@ -0,0 +1,10 @@
+package attachments
+
+type AttachmentRepo interface {
+	Create(attachment *Attachment) (int64, error)
+	GetByID(id int64) (*Attachment, error)
+	// ListByOwner returns the attachments of an appointment or diagnosis,
+	// oldest first.
+	ListByOwner(ownerType string, ownerID int64) ([]Attachment, error)
+	Delete(id int64) error
+}
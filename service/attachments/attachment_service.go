@@ -0,0 +1,347 @@
+package attachments
+
+import (
+	"Dedenruslan19/med-project/repository/storage"
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidOwner       = errors.New("attachments belong to an appointment or a diagnose")
+	ErrOwnerNotFound      = errors.New("appointment or diagnose not found")
+	ErrForbidden          = errors.New("you are not authorized to access these attachments")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrNotUploader        = errors.New("only the uploader can delete an attachment")
+	ErrEmptyFile          = errors.New("file is empty")
+	ErrTooLarge           = errors.New("file is too large")
+	ErrUnsupportedType    = errors.New("unsupported file type")
+	ErrInvalidCategory    = errors.New("invalid category")
+	ErrLinkExpired        = errors.New("download link has expired")
+	ErrInvalidSignature   = errors.New("invalid download link")
+)
+
+// allowedTypes are the sniffed content types accepted for upload: documents,
+// photos and scans, and DICOM images.
+var allowedTypes = map[string]bool{
+	"application/pdf":   true,
+	"image/jpeg":        true,
+	"image/png":         true,
+	"image/gif":         true,
+	"image/webp":        true,
+	"image/bmp":         true,
+	"text/plain":        true,
+	"application/dicom": true,
+}
+
+// sniff detects the content type from the content, ignoring whatever the
+// client claims. DICOM files carry "DICM" after a 128 byte preamble, which
+// http.DetectContentType does not know about.
+func sniff(content []byte) string {
+	if len(content) >= 132 && string(content[128:132]) == "DICM" {
+		return "application/dicom"
+	}
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(content))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// Config holds the upload limit and how download links are signed.
+// Download links are <BaseURL>/attachments/<id>/download and stay valid for
+// LinkTTL.
+type Config struct {
+	MaxSize    int64
+	LinkTTL    time.Duration
+	SigningKey []byte
+	BaseURL    string
+}
+
+// NewConfig builds the attachment settings. Without a signing key a random
+// one is generated, so links only work on this instance until it restarts.
+func NewConfig(maxSizeMB, linkTTLMinutes int, signingKey, baseURL string) Config {
+	key := []byte(signingKey)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(err)
+		}
+	}
+	return Config{
+		MaxSize:    int64(maxSizeMB) << 20,
+		LinkTTL:    time.Duration(linkTTLMinutes) * time.Minute,
+		SigningKey: key,
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+	}
+}
+
+type service struct {
+	repo               AttachmentRepo
+	store              storage.Storage
+	appointmentService appointments.Service
+	diagnoseService    diagnoses.Service
+	config             Config
+	now                func() time.Time
+	logger             *slog.Logger
+}
+
+type Service interface {
+	Upload(caller Caller, ownerType string, ownerID int64, upload Upload) (*Attachment, error)
+	List(caller Caller, ownerType string, ownerID int64) ([]Attachment, error)
+	Get(caller Caller, id int64) (*Attachment, error)
+	Delete(caller Caller, id int64) error
+	// Open checks a signed download link and returns the attachment with its
+	// content, which the caller must close.
+	Open(id, expires int64, signature string) (*Attachment, io.ReadCloser, error)
+}
+
+func NewService(logger *slog.Logger, repo AttachmentRepo, store storage.Storage, appointmentService appointments.Service,
+	diagnoseService diagnoses.Service, config Config) Service {
+	return &service{
+		logger:             logger,
+		repo:               repo,
+		store:              store,
+		appointmentService: appointmentService,
+		diagnoseService:    diagnoseService,
+		config:             config,
+		now:                time.Now,
+	}
+}
+
+// authorize applies the diagnose rules to attachments: the appointment's
+// doctor and patient may read and upload to an appointment; a diagnose is
+// open to its author and the appointment's doctor, and read-only to the
+// patient once it is signed. It returns the appointment the owner belongs
+// to.
+func (s *service) authorize(caller Caller, ownerType string, ownerID int64, write bool) (*appointments.Appointment, error) {
+	var diagnose *diagnoses.Diagnose
+	appointmentID := ownerID
+	switch ownerType {
+	case OwnerAppointment:
+	case OwnerDiagnose:
+		var err error
+		if diagnose, err = s.diagnoseService.GetByID(ownerID); err != nil {
+			return nil, ErrOwnerNotFound
+		}
+		appointmentID = diagnose.AppointmentID
+	default:
+		return nil, ErrInvalidOwner
+	}
+
+	appointment, err := s.appointmentService.GetByID(appointmentID)
+	if err != nil {
+		return nil, ErrOwnerNotFound
+	}
+
+	switch caller.Role {
+	case "doctor":
+		if appointment.DoctorID == caller.ID || (diagnose != nil && diagnose.DoctorID == caller.ID) {
+			return appointment, nil
+		}
+	case "user":
+		if appointment.UserID != caller.ID {
+			break
+		}
+		if diagnose == nil {
+			return appointment, nil
+		}
+		// patients do not learn about draft diagnoses
+		if !diagnose.Signed() {
+			return nil, ErrOwnerNotFound
+		}
+		if !write {
+			return appointment, nil
+		}
+	}
+	return nil, ErrForbidden
+}
+
+func (s *service) Upload(caller Caller, ownerType string, ownerID int64, upload Upload) (*Attachment, error) {
+	category := upload.Category
+	if category == "" {
+		category = CategoryOther
+	}
+	if !categories[category] {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCategory, category)
+	}
+
+	appointment, err := s.authorize(caller, ownerType, ownerID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(upload.Content, s.config.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, ErrEmptyFile
+	}
+	if int64(len(content)) > s.config.MaxSize {
+		return nil, fmt.Errorf("%w: the limit is %d MB", ErrTooLarge, s.config.MaxSize>>20)
+	}
+	contentType := sniff(content)
+	if !allowedTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	checksum := sha256.Sum256(content)
+
+	fileName := filepath.Base(strings.ReplaceAll(upload.FileName, `\`, "/"))
+	if fileName == "." || fileName == "/" {
+		fileName = "attachment"
+	}
+	if len(fileName) > 255 {
+		fileName = fileName[len(fileName)-255:]
+	}
+
+	attachment := &Attachment{
+		OwnerType:     ownerType,
+		OwnerID:       ownerID,
+		AppointmentID: appointment.ID,
+		UserID:        appointment.UserID,
+		UploadedBy:    caller.ID,
+		UploaderRole:  caller.Role,
+		Category:      category,
+		FileName:      fileName,
+		ContentType:   contentType,
+		Size:          int64(len(content)),
+		Checksum:      hex.EncodeToString(checksum[:]),
+		StorageKey:    fmt.Sprintf("%ss/%d/%s", ownerType, ownerID, hex.EncodeToString(random)),
+	}
+
+	if err := s.store.Put(attachment.StorageKey, bytes.NewReader(content), attachment.Size, contentType); err != nil {
+		s.logger.Error("failed to store attachment",
+			slog.Any("error", err),
+			slog.String("storage_key", attachment.StorageKey),
+		)
+		return nil, err
+	}
+	if _, err := s.repo.Create(attachment); err != nil {
+		if delErr := s.store.Delete(attachment.StorageKey); delErr != nil {
+			s.logger.Error("failed to remove stored file of unsaved attachment",
+				slog.Any("error", delErr),
+				slog.String("storage_key", attachment.StorageKey),
+			)
+		}
+		return nil, err
+	}
+
+	s.sign(attachment)
+	return attachment, nil
+}
+
+func (s *service) List(caller Caller, ownerType string, ownerID int64) ([]Attachment, error) {
+	if _, err := s.authorize(caller, ownerType, ownerID, false); err != nil {
+		return nil, err
+	}
+
+	list, err := s.repo.ListByOwner(ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		s.sign(&list[i])
+	}
+	return list, nil
+}
+
+func (s *service) Get(caller Caller, id int64) (*Attachment, error) {
+	attachment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrAttachmentNotFound
+	}
+	if _, err := s.authorize(caller, attachment.OwnerType, attachment.OwnerID, false); err != nil {
+		if errors.Is(err, ErrOwnerNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+
+	s.sign(attachment)
+	return attachment, nil
+}
+
+// Delete removes an attachment uploaded by the caller. The record goes
+// first so a failure to remove the file never leaves a dangling download.
+func (s *service) Delete(caller Caller, id int64) error {
+	attachment, err := s.Get(caller, id)
+	if err != nil {
+		return err
+	}
+	if attachment.UploadedBy != caller.ID || attachment.UploaderRole != caller.Role {
+		return ErrNotUploader
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	if err := s.store.Delete(attachment.StorageKey); err != nil {
+		s.logger.Error("failed to remove stored file of deleted attachment",
+			slog.Any("error", err),
+			slog.Int64("attachment_id", id),
+			slog.String("storage_key", attachment.StorageKey),
+		)
+	}
+	return nil
+}
+
+func (s *service) signature(id, expires int64) string {
+	mac := hmac.New(sha256.New, s.config.SigningKey)
+	mac.Write([]byte(strconv.FormatInt(id, 10) + "." + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sign sets a download link that expires after the configured TTL.
+func (s *service) sign(attachment *Attachment) {
+	expiresAt := s.now().Add(s.config.LinkTTL).Truncate(time.Second)
+	expires := expiresAt.Unix()
+	attachment.DownloadURL = fmt.Sprintf("%s/attachments/%d/download?expires=%d&signature=%s",
+		s.config.BaseURL, attachment.ID, expires, s.signature(attachment.ID, expires))
+	attachment.DownloadExpiresAt = &expiresAt
+}
+
+func (s *service) Open(id, expires int64, signature string) (*Attachment, io.ReadCloser, error) {
+	if !hmac.Equal([]byte(signature), []byte(s.signature(id, expires))) {
+		return nil, nil, ErrInvalidSignature
+	}
+	if s.now().Unix() > expires {
+		return nil, nil, ErrLinkExpired
+	}
+
+	attachment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	content, err := s.store.Get(attachment.StorageKey)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		s.logger.Error("stored file of attachment is missing",
+			slog.Int64("attachment_id", id),
+			slog.String("storage_key", attachment.StorageKey),
+		)
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, content, nil
+}
@@ -0,0 +1,187 @@
+package attachments_test
+
+import (
+	"Dedenruslan19/med-project/repository/storage"
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/attachments"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type fakeAppointmentService struct {
+	appointments.Service
+}
+
+func (fakeAppointmentService) GetByID(id int64) (*appointments.Appointment, error) {
+	return &appointments.Appointment{ID: id, UserID: 1, DoctorID: 2}, nil
+}
+
+type fakeDiagnoseService struct {
+	diagnoses.Service
+	diagnose *diagnoses.Diagnose
+}
+
+func (f fakeDiagnoseService) GetByID(id int64) (*diagnoses.Diagnose, error) {
+	return f.diagnose, nil
+}
+
+type memoryStore map[string][]byte
+
+func (m memoryStore) Put(key string, content io.Reader, size int64, contentType string) error {
+	b, err := io.ReadAll(content)
+	m[key] = b
+	return err
+}
+
+func (m memoryStore) Get(key string) (io.ReadCloser, error) {
+	b, ok := m[key]
+	if !ok {
+		return nil, storage.ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (m memoryStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+var (
+	doctor  = attachments.Caller{ID: 2, Role: "doctor"}
+	patient = attachments.Caller{ID: 1, Role: "user"}
+	pngFile = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+)
+
+func setup(t *testing.T, diagnose *diagnoses.Diagnose, linkTTLMinutes int) (*attachments.MockAttachmentRepo, memoryStore, attachments.Service) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockRepo := attachments.NewMockAttachmentRepo(ctrl)
+	store := memoryStore{}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	return mockRepo, store, attachments.NewService(logger, mockRepo, store, fakeAppointmentService{},
+		fakeDiagnoseService{diagnose: diagnose}, attachments.NewConfig(1, linkTTLMinutes, "secret", "https://clinic.example"))
+}
+
+func TestUpload_SniffsTypeAndSignsDownloadLink(t *testing.T) {
+	mockRepo, store, service := setup(t, nil, 15)
+
+	var saved *attachments.Attachment
+	mockRepo.EXPECT().
+		Create(gomock.Any()).
+		DoAndReturn(func(a *attachments.Attachment) (int64, error) {
+			a.ID = 9
+			saved = a
+			return 9, nil
+		}).
+		Times(1)
+
+	attachment, err := service.Upload(patient, attachments.OwnerAppointment, 5, attachments.Upload{
+		FileName: `C:\scans\knee.pdf`,
+		Category: attachments.CategoryScan,
+		Content:  bytes.NewReader(pngFile),
+	})
+	assert.NoError(t, err)
+
+	checksum := sha256.Sum256(pngFile)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, "knee.pdf", attachment.FileName)
+	assert.Equal(t, hex.EncodeToString(checksum[:]), attachment.Checksum)
+	assert.Equal(t, int64(1), attachment.UserID)
+	assert.Equal(t, pngFile, store[attachment.StorageKey])
+	assert.True(t, strings.HasPrefix(attachment.DownloadURL, "https://clinic.example/attachments/9/download?"))
+
+	link, err := url.Parse(attachment.DownloadURL)
+	assert.NoError(t, err)
+	expires, _ := strconv.ParseInt(link.Query().Get("expires"), 10, 64)
+
+	mockRepo.EXPECT().GetByID(int64(9)).Return(saved, nil).Times(1)
+	_, content, err := service.Open(9, expires, link.Query().Get("signature"))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(content)
+	assert.Equal(t, pngFile, body)
+
+	_, _, err = service.Open(10, expires, link.Query().Get("signature"))
+	assert.ErrorIs(t, err, attachments.ErrInvalidSignature)
+}
+
+func TestUpload_RejectsOversizedAndUnsupportedFiles(t *testing.T) {
+	mockRepo, store, service := setup(t, nil, 15)
+
+	mockRepo.EXPECT().Create(gomock.Any()).Times(0)
+
+	_, err := service.Upload(doctor, attachments.OwnerAppointment, 5, attachments.Upload{
+		FileName: "huge.pdf",
+		Content:  bytes.NewReader(append([]byte("%PDF-1.4\n"), make([]byte, 1<<20)...)),
+	})
+	assert.ErrorIs(t, err, attachments.ErrTooLarge)
+
+	_, err = service.Upload(doctor, attachments.OwnerAppointment, 5, attachments.Upload{
+		FileName: "results.pdf",
+		Content:  strings.NewReader("PK\x03\x04 not really a pdf"),
+	})
+	assert.ErrorIs(t, err, attachments.ErrUnsupportedType)
+	assert.Empty(t, store)
+}
+
+func TestDiagnoseAttachments_FollowDiagnoseAccessRules(t *testing.T) {
+	draft := &diagnoses.Diagnose{ID: 7, AppointmentID: 5, DoctorID: 3, Status: diagnoses.StatusDraft}
+	mockRepo, _, service := setup(t, draft, 15)
+
+	mockRepo.EXPECT().ListByOwner(attachments.OwnerDiagnose, int64(7)).Return(nil, nil).Times(2)
+
+	// the author and the appointment's doctor
+	_, err := service.List(attachments.Caller{ID: 3, Role: "doctor"}, attachments.OwnerDiagnose, 7)
+	assert.NoError(t, err)
+	_, err = service.List(doctor, attachments.OwnerDiagnose, 7)
+	assert.NoError(t, err)
+
+	_, err = service.List(patient, attachments.OwnerDiagnose, 7)
+	assert.ErrorIs(t, err, attachments.ErrOwnerNotFound)
+	_, err = service.List(attachments.Caller{ID: 4, Role: "doctor"}, attachments.OwnerDiagnose, 7)
+	assert.ErrorIs(t, err, attachments.ErrForbidden)
+
+	draft.Status = diagnoses.StatusSigned
+	_, err = service.Upload(patient, attachments.OwnerDiagnose, 7, attachments.Upload{
+		FileName: "referral.png",
+		Content:  bytes.NewReader(pngFile),
+	})
+	assert.ErrorIs(t, err, attachments.ErrForbidden)
+}
+
+func TestOpen_ExpiredLink(t *testing.T) {
+	mockRepo, _, service := setup(t, nil, -1)
+
+	mockRepo.EXPECT().
+		Create(gomock.Any()).
+		DoAndReturn(func(a *attachments.Attachment) (int64, error) {
+			a.ID = 9
+			return 9, nil
+		}).
+		Times(1)
+	mockRepo.EXPECT().GetByID(gomock.Any()).Times(0)
+
+	attachment, err := service.Upload(doctor, attachments.OwnerAppointment, 5, attachments.Upload{
+		FileName: "lab.txt",
+		Category: attachments.CategoryLabResult,
+		Content:  strings.NewReader("HbA1c 6.1%"),
+	})
+	assert.NoError(t, err)
+
+	link, _ := url.Parse(attachment.DownloadURL)
+	expires, _ := strconv.ParseInt(link.Query().Get("expires"), 10, 64)
+	_, _, err = service.Open(9, expires, link.Query().Get("signature"))
+	assert.ErrorIs(t, err, attachments.ErrLinkExpired)
+}
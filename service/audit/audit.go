@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	ResourceDiagnose     = "diagnose"
+	ResourceAppointment  = "appointment"
+	ResourceInvoice      = "invoice"
+	ResourceHistory      = "history"
+	ResourceVitals       = "vitals"
+	ResourceAllergy      = "allergy"
+	ResourceAttachment   = "attachment"
+	ResourcePrescription = "prescription"
+	ResourceExport       = "export"
+	ResourceMessage      = "message"
+
+	ActionRead   = "read"
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// ActionOf tells the action of a request from its method.
+func ActionOf(method string) string {
+	switch method {
+	case http.MethodPost:
+		return ActionCreate
+	case http.MethodPut, http.MethodPatch:
+		return ActionUpdate
+	case http.MethodDelete:
+		return ActionDelete
+	}
+	return ActionRead
+}
+
+// AccessLog records one request to a patient's medical data, including
+// denied ones. Rows are never updated or deleted. PatientID is the patient
+// whose data was accessed, when it is known.
+type AccessLog struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	PrincipalID int64     `json:"principal_id" gorm:"not null;index"`
+	Role        string    `json:"role" gorm:"type:varchar(20);not null"`
+	PatientID   *int64    `json:"patient_id" gorm:"index"`
+	Resource    string    `json:"resource" gorm:"type:varchar(30);not null"`
+	ResourceID  *int64    `json:"resource_id"`
+	Action      string    `json:"action" gorm:"type:varchar(20);not null"`
+	Method      string    `json:"method" gorm:"type:varchar(10);not null"`
+	Path        string    `json:"path" gorm:"type:varchar(255);not null"`
+	Status      int       `json:"status" gorm:"not null"`
+	IP          string    `json:"ip" gorm:"column:ip;type:varchar(45)"`
+	RequestID   string    `json:"request_id" gorm:"type:varchar(64);index"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null;index"`
+
+	PrincipalName string `json:"principal_name,omitempty" gorm:"-"`
+}
+
+// Query filters the access log; zero fields do not filter. The period is
+// [From, To).
+type Query struct {
+	PatientID   int64
+	PrincipalID int64
+	Role        string
+	Resource    string
+	From        time.Time
+	To          time.Time
+	// ExcludePrincipal leaves out the requests of a principal, e.g. the
+	// patient's own.
+	ExcludePrincipal *Principal
+	Page             int
+	PerPage          int
+}
+
+type Principal struct {
+	ID   int64
+	Role string
+}
+
+type Page struct {
+	Entries []AccessLog `json:"entries"`
+	Page    int         `json:"page"`
+	PerPage int         `json:"per_page"`
+	Total   int64       `json:"total"`
+}
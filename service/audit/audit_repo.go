@@ -0,0 +1,9 @@
+package audit
+
+// AccessLogRepo is append-only: entries are never changed or removed.
+type AccessLogRepo interface {
+	Create(entry *AccessLog) error
+	// List returns a page of matching entries, newest first, and the number
+	// of matching entries.
+	List(query Query) ([]AccessLog, int64, error)
+}
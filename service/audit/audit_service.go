@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"Dedenruslan19/med-project/service/doctors"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultPerPage = 50
+	maxPerPage     = 200
+)
+
+var (
+	ErrInvalidPeriod = errors.New("from must be before to")
+	ErrInvalidRole   = errors.New("role must be doctor, user or admin")
+)
+
+var roles = map[string]bool{"doctor": true, "user": true, "admin": true}
+
+type service struct {
+	repo          AccessLogRepo
+	doctorService doctors.Service
+	now           func() time.Time
+	logger        *slog.Logger
+}
+
+type Service interface {
+	Record(entry *AccessLog) error
+	// ForPatient lists who accessed the patient's data, leaving out the
+	// patient's own requests.
+	ForPatient(patientID int64, page, perPage int) (*Page, error)
+	Search(query Query) (*Page, error)
+}
+
+func NewService(logger *slog.Logger, repo AccessLogRepo, doctorService doctors.Service) Service {
+	return &service{
+		logger:        logger,
+		repo:          repo,
+		doctorService: doctorService,
+		now:           time.Now,
+	}
+}
+
+func (s *service) Record(entry *AccessLog) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = s.now().UTC()
+	}
+	if err := s.repo.Create(entry); err != nil {
+		s.logger.Error("failed to write access log",
+			slog.Any("error", err),
+			slog.String("resource", entry.Resource),
+			slog.String("request_id", entry.RequestID),
+		)
+		return err
+	}
+	return nil
+}
+
+func (s *service) ForPatient(patientID int64, page, perPage int) (*Page, error) {
+	return s.Search(Query{
+		PatientID:        patientID,
+		ExcludePrincipal: &Principal{ID: patientID, Role: "user"},
+		Page:             page,
+		PerPage:          perPage,
+	})
+}
+
+func (s *service) Search(query Query) (*Page, error) {
+	if query.Role != "" && !roles[query.Role] {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRole, query.Role)
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return nil, ErrInvalidPeriod
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PerPage < 1 {
+		query.PerPage = defaultPerPage
+	}
+	if query.PerPage > maxPerPage {
+		query.PerPage = maxPerPage
+	}
+
+	entries, total, err := s.repo.List(query)
+	if err != nil {
+		s.logger.Error("failed to list access log",
+			slog.Any("error", err),
+		)
+		return nil, err
+	}
+	s.name(entries)
+
+	return &Page{Entries: entries, Page: query.Page, PerPage: query.PerPage, Total: total}, nil
+}
+
+// name sets the names of the doctors in entries, so patients see who looked
+// at their records rather than an ID.
+func (s *service) name(entries []AccessLog) {
+	names := map[int64]string{}
+	for i := range entries {
+		entry := &entries[i]
+		switch entry.Role {
+		case "admin":
+			entry.PrincipalName = "Administrator"
+		case "doctor":
+			name, ok := names[entry.PrincipalID]
+			if !ok {
+				if doctor, err := s.doctorService.GetByID(entry.PrincipalID); err == nil {
+					name = doctor.FullName
+				}
+				names[entry.PrincipalID] = name
+			}
+			entry.PrincipalName = name
+		}
+	}
+}
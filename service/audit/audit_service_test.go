@@ -0,0 +1,103 @@
+package audit_test
+
+import (
+	"Dedenruslan19/med-project/service/audit"
+	"Dedenruslan19/med-project/service/doctors"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type fakeDoctorService struct {
+	doctors.Service
+	lookups map[int64]int
+}
+
+func (f *fakeDoctorService) GetByID(id int64) (*doctors.Doctor, error) {
+	f.lookups[id]++
+	if id == 99 {
+		return nil, errors.New("record not found")
+	}
+	return &doctors.Doctor{ID: id, FullName: "Dr. House"}, nil
+}
+
+func setup(t *testing.T) (*audit.MockAccessLogRepo, *fakeDoctorService, audit.Service) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockRepo := audit.NewMockAccessLogRepo(ctrl)
+	doctorService := &fakeDoctorService{lookups: map[int64]int{}}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	return mockRepo, doctorService, audit.NewService(logger, mockRepo, doctorService)
+}
+
+func TestForPatient_LeavesOutOwnAccessAndNamesDoctors(t *testing.T) {
+	mockRepo, doctorService, service := setup(t)
+
+	mockRepo.EXPECT().
+		List(audit.Query{
+			PatientID:        1,
+			ExcludePrincipal: &audit.Principal{ID: 1, Role: "user"},
+			Page:             1,
+			PerPage:          50,
+		}).
+		Return([]audit.AccessLog{
+			{ID: 3, PrincipalID: 2, Role: "doctor", Resource: audit.ResourceDiagnose},
+			{ID: 2, PrincipalID: 2, Role: "doctor", Resource: audit.ResourceHistory},
+			{ID: 1, PrincipalID: 99, Role: "doctor", Resource: audit.ResourceAppointment},
+		}, int64(3), nil).
+		Times(1)
+
+	page, err := service.ForPatient(1, 0, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Equal(t, "Dr. House", page.Entries[0].PrincipalName)
+	assert.Equal(t, "Dr. House", page.Entries[1].PrincipalName)
+	assert.Empty(t, page.Entries[2].PrincipalName)
+	assert.Equal(t, 1, doctorService.lookups[2])
+}
+
+func TestSearch_ValidatesFilters(t *testing.T) {
+	mockRepo, _, service := setup(t)
+
+	mockRepo.EXPECT().
+		List(audit.Query{PrincipalID: 2, Role: "doctor", Page: 3, PerPage: 200}).
+		Return(nil, int64(0), nil).
+		Times(1)
+
+	page, err := service.Search(audit.Query{PrincipalID: 2, Role: "doctor", Page: 3, PerPage: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, 200, page.PerPage)
+
+	_, err = service.Search(audit.Query{Role: "nurse"})
+	assert.ErrorIs(t, err, audit.ErrInvalidRole)
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	_, err = service.Search(audit.Query{From: day, To: day})
+	assert.ErrorIs(t, err, audit.ErrInvalidPeriod)
+}
+
+func TestRecord_StampsTime(t *testing.T) {
+	mockRepo, _, service := setup(t)
+
+	mockRepo.EXPECT().
+		Create(gomock.Any()).
+		DoAndReturn(func(entry *audit.AccessLog) error {
+			assert.False(t, entry.CreatedAt.IsZero())
+			return nil
+		}).
+		Times(1)
+
+	patientID := int64(1)
+	err := service.Record(&audit.AccessLog{
+		PrincipalID: 2, Role: "doctor", PatientID: &patientID,
+		Resource: audit.ResourceDiagnose, Action: audit.ActionOf("GET"),
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/audit/audit_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/audit/audit_repo.go -destination=service/audit/mock_repo.go -package=audit
+//
+
+// Package audit is a generated GoMock package.
+package audit
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccessLogRepo is a mock of AccessLogRepo interface.
+type MockAccessLogRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccessLogRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockAccessLogRepoMockRecorder is the mock recorder for MockAccessLogRepo.
+type MockAccessLogRepoMockRecorder struct {
+	mock *MockAccessLogRepo
+}
+
+// NewMockAccessLogRepo creates a new mock instance.
+func NewMockAccessLogRepo(ctrl *gomock.Controller) *MockAccessLogRepo {
+	mock := &MockAccessLogRepo{ctrl: ctrl}
+	mock.recorder = &MockAccessLogRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccessLogRepo) EXPECT() *MockAccessLogRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAccessLogRepo) Create(entry *AccessLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAccessLogRepoMockRecorder) Create(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAccessLogRepo)(nil).Create), entry)
+}
+
+// List mocks base method.
+func (m *MockAccessLogRepo) List(query Query) ([]AccessLog, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", query)
+	ret0, _ := ret[0].([]AccessLog)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockAccessLogRepoMockRecorder) List(query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAccessLogRepo)(nil).List), query)
+}
@@ -1,12 +1,149 @@
 package billings
 
-import "time"
+import (
+	"Dedenruslan19/med-project/service/pricing"
+	"time"
+)
 
 type Billing struct {
-	ID            int64      `json:"id" gorm:"primaryKey;autoIncrement"`
-	AppointmentID int64      `json:"appointment_id" gorm:"not null;index"`
-	TotalAmount   float64    `json:"total_amount" gorm:"type:decimal(10,2);not null"`
-	PaymentStatus string     `json:"payment_status" gorm:"type:varchar(50);default:'unpaid'"`
-	PaidAt        *time.Time `json:"paid_at"`
-	CreatedAt     time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	ID               int64              `json:"id" gorm:"primaryKey;autoIncrement"`
+	AppointmentID    int64              `json:"appointment_id" gorm:"not null;index"`
+	ConsultationFee  float64            `json:"consultation_fee" gorm:"type:decimal(10,2);not null;default:0"`
+	MedicationFee    float64            `json:"medication_fee" gorm:"type:decimal(10,2);not null;default:0"`
+	Subtotal         float64            `json:"subtotal" gorm:"type:decimal(10,2);not null;default:0"`
+	DiscountAmount   float64            `json:"discount_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	TaxAmount        float64            `json:"tax_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	TotalAmount      float64            `json:"total_amount" gorm:"type:decimal(10,2);not null"`
+	InsuranceAmount  float64            `json:"insurance_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	PatientAmount    float64            `json:"patient_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	PromoCode        string             `json:"promo_code,omitempty" gorm:"type:varchar(50)"`
+	PricingBreakdown *pricing.Breakdown `json:"pricing_breakdown,omitempty" gorm:"type:text;serializer:json"`
+	PaymentStatus    string             `json:"payment_status" gorm:"type:varchar(50);default:'unpaid'"`
+	PaidAt           *time.Time         `json:"paid_at"`
+	CreatedAt        time.Time          `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// AmountDue is what the patient pays. Billings priced before insurance
+// coverage existed have no patient amount and are due in full.
+func (b *Billing) AmountDue() float64 {
+	if b.PricingBreakdown == nil && b.InsuranceAmount == 0 {
+		return b.TotalAmount
+	}
+	return b.PatientAmount
+}
+
+// Lines returns the priced lines of the billing, rebuilt from the fees for
+// billings created before the breakdown was stored.
+func (b *Billing) Lines() []pricing.Line {
+	if b.PricingBreakdown != nil {
+		lines := make([]pricing.Line, 0, len(b.PricingBreakdown.Lines))
+		for _, l := range b.PricingBreakdown.Lines {
+			lines = append(lines, l.Line)
+		}
+		return lines
+	}
+
+	consultationFee := b.ConsultationFee
+	if consultationFee == 0 && b.MedicationFee == 0 {
+		consultationFee = b.TotalAmount
+	}
+	lines := []pricing.Line{{Kind: pricing.KindConsultation, Description: "Consultation", Quantity: 1, UnitPrice: consultationFee}}
+	if b.MedicationFee > 0 {
+		lines = append(lines, pricing.Line{Kind: pricing.KindMedication, Description: "Medication", Quantity: 1, UnitPrice: b.MedicationFee})
+	}
+	return lines
+}
+
+// ApplyBreakdown copies the priced amounts onto the billing.
+func (b *Billing) ApplyBreakdown(breakdown *pricing.Breakdown) {
+	b.ConsultationFee, b.MedicationFee = 0, 0
+	for _, l := range breakdown.Lines {
+		switch l.Kind {
+		case pricing.KindConsultation:
+			b.ConsultationFee += l.Amount
+		case pricing.KindMedication:
+			b.MedicationFee += l.Amount
+		}
+	}
+	b.Subtotal = breakdown.Subtotal
+	b.DiscountAmount = breakdown.DiscountAmount
+	b.TaxAmount = breakdown.TaxAmount
+	b.TotalAmount = breakdown.Total
+	b.InsuranceAmount = breakdown.InsuranceAmount
+	b.PatientAmount = breakdown.PatientAmount
+	b.PromoCode = breakdown.PromoCode
+	b.PricingBreakdown = breakdown
+}
+
+// ApplyInsurance records the amount an insurer settled for the billing; the
+// patient owes the rest of the total. The caller checks that the billing may
+// still be repriced.
+func (b *Billing) ApplyInsurance(amount float64) error {
+	if amount < 0 || amount > b.TotalAmount {
+		return ErrInvalidInsuranceAmount
+	}
+	b.InsuranceAmount = amount
+	b.PatientAmount = b.TotalAmount - amount
+	if breakdown := b.PricingBreakdown; breakdown != nil {
+		breakdown.InsuranceAmount = b.InsuranceAmount
+		breakdown.PatientAmount = b.PatientAmount
+		if breakdown.Total > 0 {
+			breakdown.InsuranceCoverage = amount / breakdown.Total
+		}
+	}
+	return nil
+}
+
+const (
+	StatusUnpaid         = "unpaid"
+	StatusWaitingPayment = "waiting_payment"
+	StatusPaid           = "paid"
+	StatusFailed         = "failed"
+	StatusRefunded       = "refunded"
+
+	EventStatusChanged = "billing.status_changed"
+
+	AggregateType = "billing"
+
+	// TopicInvoiceRequested is enqueued with a payment, so the invoice of a
+	// paid billing is generated even when generating it right away fails.
+	TopicInvoiceRequested = "billing.invoice_requested"
+)
+
+// EventData is the payload of billing events.
+type EventData struct {
+	BillingID      int64   `json:"billing_id"`
+	AppointmentID  int64   `json:"appointment_id"`
+	PaymentStatus  string  `json:"payment_status"`
+	PreviousStatus string  `json:"previous_status"`
+	PatientAmount  float64 `json:"patient_amount"`
+}
+
+// transitions lists the payment statuses reachable from each status. A paid
+// billing can only be refunded, and a refunded one is final.
+var transitions = map[string][]string{
+	StatusUnpaid:         {StatusWaitingPayment, StatusPaid},
+	StatusWaitingPayment: {StatusPaid, StatusFailed, StatusUnpaid},
+	StatusFailed:         {StatusWaitingPayment, StatusUnpaid},
+	StatusPaid:           {StatusRefunded},
+}
+
+// Repriceable reports whether a billing in status may still change its
+// amounts; it must not have an invoice either.
+func Repriceable(status string) bool {
+	return status == StatusUnpaid || status == StatusFailed
+}
+
+// CanTransition reports whether a billing may move from one payment status to
+// another. Repeating the current status is always allowed.
+func CanTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
 }
@@ -1,8 +1,33 @@
 package billings
 
+import (
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/outbox"
+)
+
 type BillingRepo interface {
 	Create(billing *Billing) (int64, error)
 	GetByID(id int64) (*Billing, error)
 	GetByAppointmentID(appointmentID int64) (*Billing, error)
-	Update(billing *Billing) error
+	GetByUserID(userID int64) ([]Billing, error)
+	// UpdateStatus moves the billing from status from to its current status.
+	// It returns ErrInvalidTransition when the status changed meanwhile.
+	UpdateStatus(billing *Billing, from string) error
+	// Reprice saves the new amounts of a billing that is still unpaid or
+	// failed and has no invoice. When promoCode differs from the code the
+	// billing carried, it is redeemed and the old code released, all in one
+	// transaction. It returns ErrBillingLocked when the billing can no longer
+	// be repriced.
+	Reprice(billing *Billing, promoCode string) error
+	// RecordPayment locks the billing and, while it can still be paid, marks
+	// it paid at payment.OccurredAt and stores the payment for its amount due
+	// with the outbox messages, all in one transaction. It returns the paid
+	// billing and its previous status, or ErrAlreadyPaid.
+	RecordPayment(id int64, payment *Payment, messages ...*outbox.Message) (*Billing, string, error)
+	// Refund locks the paid billing, checks refund.Amount against what is
+	// left of its payments, and stores the refund with a credit note against
+	// its invoice in one transaction. Refunding the whole balance moves the
+	// billing to refunded.
+	Refund(id int64, refund *Payment, numbering invoices.Numbering) (*Billing, *invoices.CreditNote, error)
+	ListPaymentsByBillingID(billingID int64) ([]Payment, error)
 }
@@ -1,29 +1,93 @@
 package billings
 
 import (
+	"Dedenruslan19/med-project/repository/pubsub"
+	"Dedenruslan19/med-project/service/appointments"
 	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/outbox"
+	"Dedenruslan19/med-project/service/pricing"
+	"Dedenruslan19/med-project/service/users"
+	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 )
 
+var (
+	ErrInvoiceServiceNotConfigured = errors.New("invoice service not configured")
+	ErrInvalidTransition           = errors.New("invalid payment status transition")
+	ErrBillingLocked               = errors.New("billing can no longer be repriced")
+	ErrBillingInvoiced             = fmt.Errorf("%w: it has been invoiced", ErrBillingLocked)
+	ErrInvalidRefund               = errors.New("refund must be positive and at most the amount paid")
+	ErrAlreadyPaid                 = errors.New("billing is already paid")
+	ErrInvalidInsuranceAmount      = errors.New("insurance amount must be between 0 and the billing total")
+)
+
 type service struct {
-	repo           BillingRepo
-	invoiceService invoices.Service
-	logger         *slog.Logger
+	repo               BillingRepo
+	invoiceService     invoices.Service
+	appointmentService appointments.Service
+	userService        users.Service
+	pricingService     pricing.Service
+	publisher          pubsub.Publisher
+	logger             *slog.Logger
 }
 
 type Service interface {
 	Create(billing *Billing) (int64, error)
+	CreateFromLines(appointmentID int64, lines []pricing.Line) (*Billing, error)
+	Reprice(id int64, adjustments pricing.Adjustments) (*Billing, error)
+	ReplaceLines(id int64, lines []pricing.Line) (*Billing, error)
+	ApplyPromoCode(id int64, code string) (*Billing, error)
 	GetByID(id int64) (*Billing, error)
 	GetByAppointmentID(appointmentID int64) (*Billing, error)
+	GetByUserID(userID int64) ([]Billing, error)
 	UpdatePaymentStatus(id int64, status string) error
+	RecordPayment(id int64, method, reference string, paidAt time.Time) (*Payment, error)
+	Refund(id int64, amount float64, reason string) (*Payment, *invoices.CreditNote, error)
+	GetPayments(id int64) ([]Payment, error)
+	StartPayment(id int64) (*invoices.Invoice, error)
 	SetInvoiceService(invoiceService invoices.Service)
+	GenerateInvoice(billingID int64, email string) (*invoices.Invoice, error)
+	DeliverInvoice(msg *outbox.Message) error
 }
 
-func NewService(logger *slog.Logger, repo BillingRepo) Service {
+// NewService publishes payment status changes to the patient through
+// publisher; nil disables them.
+func NewService(logger *slog.Logger, repo BillingRepo, appointmentService appointments.Service, userService users.Service, pricingService pricing.Service,
+	publisher pubsub.Publisher) Service {
 	return &service{
-		logger: logger,
-		repo:   repo,
+		logger:             logger,
+		repo:               repo,
+		appointmentService: appointmentService,
+		userService:        userService,
+		pricingService:     pricingService,
+		publisher:          publisher,
+	}
+}
+
+// publishStatus tells the patient that the payment status moved from
+// previous. Failures are logged, the change itself is already stored.
+func (s *service) publishStatus(billing *Billing, previous string) {
+	if s.publisher == nil || billing.PaymentStatus == previous {
+		return
+	}
+
+	appointment, err := s.appointmentService.GetByID(billing.AppointmentID)
+	if err == nil {
+		err = s.publisher.Publish(pubsub.NewEvent(EventStatusChanged, EventData{
+			BillingID:      billing.ID,
+			AppointmentID:  billing.AppointmentID,
+			PaymentStatus:  billing.PaymentStatus,
+			PreviousStatus: previous,
+			PatientAmount:  billing.PatientAmount,
+		}, pubsub.Principal{Role: pubsub.RoleUser, ID: appointment.UserID}))
+	}
+	if err != nil {
+		s.logger.Warn("failed to publish billing event",
+			slog.Any("error", err),
+			slog.Int64("billing_id", billing.ID),
+		)
 	}
 }
 
@@ -43,6 +107,95 @@ func (s *service) Create(billing *Billing) (int64, error) {
 	return id, nil
 }
 
+// CreateFromLines prices the lines with the configured tax rules and stores
+// the billing together with its breakdown.
+func (s *service) CreateFromLines(appointmentID int64, lines []pricing.Line) (*Billing, error) {
+	breakdown, err := s.pricingService.Quote(pricing.Request{Lines: lines})
+	if err != nil {
+		return nil, err
+	}
+
+	billing := &Billing{
+		AppointmentID: appointmentID,
+		PaymentStatus: StatusUnpaid,
+	}
+	billing.ApplyBreakdown(breakdown)
+
+	id, err := s.Create(billing)
+	if err != nil {
+		return nil, err
+	}
+	billing.ID = id
+	return billing, nil
+}
+
+// Reprice applies discounts, a promo code and insurance coverage to an unpaid
+// billing. The adjustments replace the previous ones; a promo code is only
+// redeemed when it changes, and the code it replaces is given back. Once the billing is
+// invoiced its amounts are final, since the invoice is never reissued.
+func (s *service) Reprice(id int64, adjustments pricing.Adjustments) (*Billing, error) {
+	billing, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.reprice(billing, billing.Lines(), adjustments)
+}
+
+// ReplaceLines recalculates an unpaid billing for new lines, e.g. after the
+// medications of its diagnosis changed, keeping its adjustments.
+func (s *service) ReplaceLines(id int64, lines []pricing.Line) (*Billing, error) {
+	billing, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var adjustments pricing.Adjustments
+	if billing.PricingBreakdown != nil {
+		adjustments = billing.PricingBreakdown.Adjustments
+	}
+	return s.reprice(billing, lines, adjustments)
+}
+
+func (s *service) reprice(billing *Billing, lines []pricing.Line, adjustments pricing.Adjustments) (*Billing, error) {
+	if !Repriceable(billing.PaymentStatus) {
+		return nil, ErrBillingLocked
+	}
+
+	breakdown, err := s.pricingService.Quote(pricing.Request{Lines: lines, Adjustments: adjustments, HeldPromoCode: billing.PromoCode})
+	if err != nil {
+		return nil, err
+	}
+
+	billing.ApplyBreakdown(breakdown)
+	if err := s.repo.Reprice(billing, breakdown.PromoCode); err != nil {
+		if errors.Is(err, ErrBillingLocked) || errors.Is(err, pricing.ErrPromoExhausted) {
+			return nil, err
+		}
+		s.logger.Error("failed to reprice billing",
+			slog.Any("error", err),
+			slog.Int64("billing_id", billing.ID),
+		)
+		return nil, err
+	}
+	return billing, nil
+}
+
+// ApplyPromoCode reprices the billing with code, keeping its other adjustments.
+func (s *service) ApplyPromoCode(id int64, code string) (*Billing, error) {
+	billing, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var adjustments pricing.Adjustments
+	if billing.PricingBreakdown != nil {
+		adjustments = billing.PricingBreakdown.Adjustments
+	}
+	adjustments.PromoCode = code
+
+	return s.Reprice(id, adjustments)
+}
+
 func (s *service) GetByID(id int64) (*Billing, error) {
 	billing, err := s.repo.GetByID(id)
 	if err != nil {
@@ -67,28 +220,201 @@ func (s *service) GetByAppointmentID(appointmentID int64) (*Billing, error) {
 	return billing, nil
 }
 
+func (s *service) GetByUserID(userID int64) ([]Billing, error) {
+	billings, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		s.logger.Error("failed to get billings by user ID",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+		)
+		return nil, err
+	}
+	return billings, nil
+}
+
+// UpdatePaymentStatus stores the new status. Marking a billing as paid records
+// a manual payment and generates its invoice; repeating the update returns the
+// same invoice.
 func (s *service) UpdatePaymentStatus(id int64, status string) error {
+	if status == StatusPaid {
+		_, err := s.RecordPayment(id, PaymentMethodManual, "", time.Now())
+		return err
+	}
+
 	billing, err := s.repo.GetByID(id)
 	if err != nil {
 		return err
 	}
 
+	if !CanTransition(billing.PaymentStatus, status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, billing.PaymentStatus, status)
+	}
+	if billing.PaymentStatus == status {
+		return nil
+	}
+
+	previous := billing.PaymentStatus
 	billing.PaymentStatus = status
 
-	// If status is paid, set paid_at timestamp
-	if status == "paid" {
-		now := time.Now()
-		billing.PaidAt = &now
+	if err := s.repo.UpdateStatus(billing, previous); err != nil {
+		if !errors.Is(err, ErrInvalidTransition) {
+			s.logger.Error("failed to update payment status",
+				slog.Any("error", err),
+				slog.Int64("billing_id", id),
+				slog.String("status", status),
+			)
+		}
+		return err
 	}
 
-	err = s.repo.Update(billing)
+	s.publishStatus(billing, previous)
+	return nil
+}
+
+// RecordPayment marks the billing as paid by the patient's amount due and
+// generates its invoice. The payment is stored with an outbox message that
+// requests the invoice, so when generating it right away fails the dispatcher
+// retries instead of leaving a paid billing without an invoice. Paying an
+// already paid billing records nothing and returns a nil payment.
+func (s *service) RecordPayment(id int64, method, reference string, paidAt time.Time) (*Payment, error) {
+	payment := &Payment{
+		Kind:       PaymentKindPayment,
+		Method:     method,
+		Reference:  reference,
+		OccurredAt: paidAt,
+	}
+	request := &outbox.Message{
+		Topic:         TopicInvoiceRequested,
+		AggregateType: AggregateType,
+		AggregateID:   id,
+	}
+
+	billing, previous, err := s.repo.RecordPayment(id, payment, request)
+	if errors.Is(err, ErrAlreadyPaid) {
+		if s.invoiceService != nil {
+			if _, err := s.GenerateInvoice(id, ""); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
 	if err != nil {
-		s.logger.Error("failed to update payment status",
+		return nil, err
+	}
+	s.publishStatus(billing, previous)
+
+	if s.invoiceService != nil {
+		if _, err := s.GenerateInvoice(id, ""); err != nil {
+			s.logger.Warn("invoice of paid billing left to the outbox",
+				slog.Any("error", err),
+				slog.Int64("billing_id", id),
+			)
+		}
+	}
+	return payment, nil
+}
+
+// DeliverInvoice is the outbox handler for TopicInvoiceRequested. Generating
+// the invoice is idempotent, so a message for an invoiced billing is a no-op.
+func (s *service) DeliverInvoice(msg *outbox.Message) error {
+	_, err := s.GenerateInvoice(msg.AggregateID, "")
+	return err
+}
+
+// Refund returns money to the patient and issues a credit note against the
+// billing's invoice. Refunding everything that was paid moves the billing to
+// refunded.
+func (s *service) Refund(id int64, amount float64, reason string) (*Payment, *invoices.CreditNote, error) {
+	if s.invoiceService == nil {
+		return nil, nil, ErrInvoiceServiceNotConfigured
+	}
+
+	refund := &Payment{
+		Amount:     amount,
+		Method:     PaymentMethodManual,
+		Note:       reason,
+		OccurredAt: time.Now(),
+	}
+	billing, note, err := s.repo.Refund(id, refund, s.invoiceService.CreditNoteNumbering())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.publishStatus(billing, StatusPaid)
+	return refund, note, nil
+}
+
+func (s *service) GetPayments(id int64) ([]Payment, error) {
+	payments, err := s.repo.ListPaymentsByBillingID(id)
+	if err != nil {
+		s.logger.Error("failed to get payments",
 			slog.Any("error", err),
 			slog.Int64("billing_id", id),
-			slog.String("status", status),
 		)
-		return err
+		return nil, err
 	}
-	return nil
+	return payments, nil
+}
+
+// StartPayment moves a billing to waiting_payment and returns the invoice the
+// patient pays against, addressed to the appointment's patient.
+func (s *service) StartPayment(id int64) (*invoices.Invoice, error) {
+	if err := s.UpdatePaymentStatus(id, StatusWaitingPayment); err != nil {
+		return nil, err
+	}
+	return s.GenerateInvoice(id, "")
+}
+
+// GenerateInvoice creates the invoice of a billing from its itemised fees.
+// When email is empty the invoice is addressed to the appointment's patient.
+// It is idempotent, an existing invoice for the billing is returned as is.
+func (s *service) GenerateInvoice(billingID int64, email string) (*invoices.Invoice, error) {
+	if s.invoiceService == nil {
+		return nil, ErrInvoiceServiceNotConfigured
+	}
+
+	billing, err := s.GetByID(billingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if email == "" {
+		appointment, err := s.appointmentService.GetByID(billing.AppointmentID)
+		if err != nil {
+			return nil, err
+		}
+
+		patient, err := s.userService.GetUserByID(appointment.UserID)
+		if err != nil {
+			return nil, err
+		}
+		email = patient.Email
+	}
+
+	draft := &invoices.Invoice{
+		BillingID:        billingID,
+		ConsultationFee:  billing.ConsultationFee,
+		MedicationFee:    billing.MedicationFee,
+		DiscountAmount:   billing.DiscountAmount,
+		TaxAmount:        billing.TaxAmount,
+		TotalAmount:      billing.TotalAmount,
+		InsuranceAmount:  billing.InsuranceAmount,
+		PatientAmount:    billing.AmountDue(),
+		PricingBreakdown: billing.PricingBreakdown,
+		SentToEmail:      email,
+	}
+	if draft.ConsultationFee == 0 && draft.MedicationFee == 0 {
+		// billings created before fees were itemised
+		draft.ConsultationFee = billing.TotalAmount
+	}
+
+	invoice, err := s.invoiceService.CreateInvoice(draft)
+	if err != nil {
+		s.logger.Error("failed to generate invoice for billing",
+			slog.Any("error", err),
+			slog.Int64("billing_id", billingID),
+		)
+		return nil, err
+	}
+	return invoice, nil
 }
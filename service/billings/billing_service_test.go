@@ -2,10 +2,12 @@ package billings_test
 
 import (
 	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/outbox"
 	"errors"
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -17,7 +19,7 @@ func TestGetByID_Success(t *testing.T) {
 
 	mockRepo := billings.NewMockBillingRepo(ctrl)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	service := billings.NewService(logger, mockRepo)
+	service := billings.NewService(logger, mockRepo, nil, nil, nil, nil)
 
 	expectedBilling := &billings.Billing{
 		ID:            1,
@@ -46,7 +48,7 @@ func TestGetByID_NotFound(t *testing.T) {
 
 	mockRepo := billings.NewMockBillingRepo(ctrl)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	service := billings.NewService(logger, mockRepo)
+	service := billings.NewService(logger, mockRepo, nil, nil, nil, nil)
 
 	mockRepo.EXPECT().
 		GetByID(int64(999)).
@@ -58,3 +60,76 @@ func TestGetByID_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
+
+func TestUpdatePaymentStatus_InvalidTransition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := billings.NewMockBillingRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := billings.NewService(logger, mockRepo, nil, nil, nil, nil)
+
+	mockRepo.EXPECT().
+		GetByID(int64(1)).
+		Return(&billings.Billing{ID: 1, PaymentStatus: billings.StatusPaid}, nil).
+		Times(1)
+	mockRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).Times(0)
+
+	err := service.UpdatePaymentStatus(1, billings.StatusWaitingPayment)
+
+	assert.ErrorIs(t, err, billings.ErrInvalidTransition)
+}
+
+func TestRecordPayment_RequestsInvoiceWithPayment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := billings.NewMockBillingRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := billings.NewService(logger, mockRepo, nil, nil, nil, nil)
+
+	gomock.InOrder(
+		mockRepo.EXPECT().
+			RecordPayment(int64(4), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(id int64, p *billings.Payment, messages ...*outbox.Message) (*billings.Billing, string, error) {
+				assert.Equal(t, billings.PaymentKindPayment, p.Kind)
+				// the invoice is requested in the same transaction as the payment
+				assert.Len(t, messages, 1)
+				assert.Equal(t, billings.TopicInvoiceRequested, messages[0].Topic)
+				assert.Equal(t, int64(4), messages[0].AggregateID)
+				p.Amount = 250000
+				return &billings.Billing{ID: 4, TotalAmount: 250000, PaymentStatus: billings.StatusPaid}, billings.StatusWaitingPayment, nil
+			}),
+		mockRepo.EXPECT().
+			RecordPayment(int64(4), gomock.Any(), gomock.Any()).
+			Return(nil, "", billings.ErrAlreadyPaid),
+	)
+
+	payment, err := service.RecordPayment(4, billings.PaymentMethodBankTransfer, "TRX-1", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "TRX-1", payment.Reference)
+	assert.Equal(t, 250000.0, payment.Amount)
+
+	payment, err = service.RecordPayment(4, billings.PaymentMethodBankTransfer, "TRX-1", time.Now())
+	assert.NoError(t, err)
+	assert.Nil(t, payment)
+}
+
+func TestReplaceLines_PaidBillingIsLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := billings.NewMockBillingRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := billings.NewService(logger, mockRepo, nil, nil, nil, nil)
+
+	mockRepo.EXPECT().
+		GetByID(int64(2)).
+		Return(&billings.Billing{ID: 2, TotalAmount: 250000, PaymentStatus: billings.StatusPaid}, nil).
+		Times(1)
+
+	billing, err := service.ReplaceLines(2, nil)
+
+	assert.ErrorIs(t, err, billings.ErrBillingLocked)
+	assert.Nil(t, billing)
+}
@@ -10,6 +10,8 @@
 package billings
 
 import (
+	invoices "Dedenruslan19/med-project/service/invoices"
+	outbox "Dedenruslan19/med-project/service/outbox"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -84,16 +86,97 @@ func (mr *MockBillingRepoMockRecorder) GetByID(id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockBillingRepo)(nil).GetByID), id)
 }
 
-// Update mocks base method.
-func (m *MockBillingRepo) Update(billing *Billing) error {
+// GetByUserID mocks base method.
+func (m *MockBillingRepo) GetByUserID(userID int64) ([]Billing, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", billing)
+	ret := m.ctrl.Call(m, "GetByUserID", userID)
+	ret0, _ := ret[0].([]Billing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserID indicates an expected call of GetByUserID.
+func (mr *MockBillingRepoMockRecorder) GetByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockBillingRepo)(nil).GetByUserID), userID)
+}
+
+// ListPaymentsByBillingID mocks base method.
+func (m *MockBillingRepo) ListPaymentsByBillingID(billingID int64) ([]Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPaymentsByBillingID", billingID)
+	ret0, _ := ret[0].([]Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPaymentsByBillingID indicates an expected call of ListPaymentsByBillingID.
+func (mr *MockBillingRepoMockRecorder) ListPaymentsByBillingID(billingID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPaymentsByBillingID", reflect.TypeOf((*MockBillingRepo)(nil).ListPaymentsByBillingID), billingID)
+}
+
+// RecordPayment mocks base method.
+func (m *MockBillingRepo) RecordPayment(id int64, payment *Payment, messages ...*outbox.Message) (*Billing, string, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{id, payment}
+	for _, a := range messages {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RecordPayment", varargs...)
+	ret0, _ := ret[0].(*Billing)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RecordPayment indicates an expected call of RecordPayment.
+func (mr *MockBillingRepoMockRecorder) RecordPayment(id, payment any, messages ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{id, payment}, messages...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPayment", reflect.TypeOf((*MockBillingRepo)(nil).RecordPayment), varargs...)
+}
+
+// Refund mocks base method.
+func (m *MockBillingRepo) Refund(id int64, refund *Payment, numbering invoices.Numbering) (*Billing, *invoices.CreditNote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", id, refund, numbering)
+	ret0, _ := ret[0].(*Billing)
+	ret1, _ := ret[1].(*invoices.CreditNote)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockBillingRepoMockRecorder) Refund(id, refund, numbering any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockBillingRepo)(nil).Refund), id, refund, numbering)
+}
+
+// Reprice mocks base method.
+func (m *MockBillingRepo) Reprice(billing *Billing, promoCode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reprice", billing, promoCode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reprice indicates an expected call of Reprice.
+func (mr *MockBillingRepoMockRecorder) Reprice(billing, promoCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reprice", reflect.TypeOf((*MockBillingRepo)(nil).Reprice), billing, promoCode)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockBillingRepo) UpdateStatus(billing *Billing, from string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", billing, from)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Update indicates an expected call of Update.
-func (mr *MockBillingRepoMockRecorder) Update(billing any) *gomock.Call {
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockBillingRepoMockRecorder) UpdateStatus(billing, from any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockBillingRepo)(nil).Update), billing)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockBillingRepo)(nil).UpdateStatus), billing, from)
 }
@@ -0,0 +1,44 @@
+package billings
+
+import "time"
+
+const (
+	PaymentKindPayment = "payment"
+	PaymentKindRefund  = "refund"
+
+	PaymentMethodManual       = "manual"
+	PaymentMethodBankTransfer = "bank_transfer"
+)
+
+// Payment is money received for a billing, or returned to the patient when
+// Kind is refund. Amounts are always positive.
+type Payment struct {
+	ID         int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	BillingID  int64     `json:"billing_id" gorm:"not null;index"`
+	Kind       string    `json:"kind" gorm:"type:varchar(20);not null"`
+	Amount     float64   `json:"amount" gorm:"type:decimal(10,2);not null"`
+	Method     string    `json:"method" gorm:"type:varchar(50);not null"`
+	Reference  string    `json:"reference" gorm:"type:varchar(255)"`
+	Note       string    `json:"note,omitempty" gorm:"type:text"`
+	OccurredAt time.Time `json:"occurred_at" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// Balance is what the patient paid for the billing minus what was refunded.
+// Billings paid before payments were recorded have no payment rows, their
+// amount due counts as paid.
+func Balance(billing *Billing, payments []Payment) float64 {
+	balance, paid := 0.0, false
+	for _, p := range payments {
+		if p.Kind == PaymentKindRefund {
+			balance -= p.Amount
+		} else {
+			balance += p.Amount
+			paid = true
+		}
+	}
+	if !paid && billing.PaidAt != nil {
+		balance += billing.AmountDue()
+	}
+	return balance
+}
@@ -1,12 +1,147 @@
 package diagnoses
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
+const (
+	StatusDraft  = "draft"
+	StatusSigned = "signed"
+
+	VersionCreated = "created"
+	VersionRevised = "revised"
+	VersionAmended = "amended"
+
+	EventCreated = "diagnose.created"
+	EventSigned  = "diagnose.signed"
+)
+
+// EventData is the payload of diagnose events; notes and medications stay
+// behind the API.
+type EventData struct {
+	DiagnoseID    int64  `json:"diagnose_id"`
+	AppointmentID int64  `json:"appointment_id"`
+	Status        string `json:"status"`
+	Version       int    `json:"version"`
+}
+
+// Diagnose holds the current version of a diagnosis. Once signed it can only
+// change through amendments, and every version is kept in DiagnoseVersion.
 type Diagnose struct {
+	ID                    int64          `json:"id" gorm:"primaryKey;autoIncrement"`
+	AppointmentID         int64          `json:"appointment_id" gorm:"not null;index"`
+	DoctorID              int64          `json:"doctor_id" gorm:"not null"`
+	Notes                 string         `json:"notes" gorm:"type:text;not null;serializer:encrypted"`
+	PrescribedMedications string         `json:"prescribed_medications" gorm:"type:text;serializer:encrypted"`
+	Status                string         `json:"status" gorm:"type:varchar(20);not null;default:draft"`
+	Version               int            `json:"version" gorm:"not null;default:1"`
+	Codes                 []DiagnoseCode `json:"codes" gorm:"foreignKey:DiagnoseID"`
+	SignedAt              *time.Time     `json:"signed_at"`
+	CreatedAt             time.Time      `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+}
+
+// DiagnoseCode is an ICD-10 code of a diagnosis. A coded diagnosis has one
+// primary code, listed first, and any number of secondary codes.
+type DiagnoseCode struct {
+	ID         int64  `json:"-" gorm:"primaryKey;autoIncrement"`
+	DiagnoseID int64  `json:"-" gorm:"not null;index"`
+	Code       string `json:"code" gorm:"type:varchar(10);not null;index"`
+	Title      string `json:"title" gorm:"type:varchar(500)"`
+	Primary    bool   `json:"primary" gorm:"column:is_primary;not null;default:false"`
+	Position   int    `json:"-" gorm:"not null;default:0"`
+}
+
+// NewCodes lists primary followed by the secondary codes.
+func NewCodes(primary string, secondary []string) []DiagnoseCode {
+	codes := make([]DiagnoseCode, 0, len(secondary)+1)
+	if primary != "" {
+		codes = append(codes, DiagnoseCode{Code: primary, Primary: true})
+	}
+	for _, code := range secondary {
+		codes = append(codes, DiagnoseCode{Code: code})
+	}
+	return codes
+}
+
+// CodeList renders the codes for a version snapshot, e.g. "J06.9,R50.9".
+func (d *Diagnose) CodeList() string {
+	list := make([]string, 0, len(d.Codes))
+	for _, code := range d.Codes {
+		list = append(list, code.Code)
+	}
+	return strings.Join(list, ",")
+}
+
+// MedicationList splits the prescribed medications, e.g. "Paracetamol 500mg,
+// Cetirizine" into its two medications.
+func (d *Diagnose) MedicationList() []string {
+	var medications []string
+	for _, med := range strings.Split(d.PrescribedMedications, ",") {
+		if med = strings.TrimSpace(med); med != "" {
+			medications = append(medications, med)
+		}
+	}
+	return medications
+}
+
+func (d *Diagnose) Signed() bool {
+	return d.Status == StatusSigned
+}
+
+// DiagnoseVersion is a snapshot of a diagnosis as written by AuthorID.
+type DiagnoseVersion struct {
 	ID                    int64     `json:"id" gorm:"primaryKey;autoIncrement"`
-	AppointmentID         int64     `json:"appointment_id" gorm:"not null;index"`
-	DoctorID              int64     `json:"doctor_id" gorm:"not null"`
-	Notes                 string    `json:"notes" gorm:"type:text;not null"`
-	PrescribedMedications string    `json:"prescribed_medications" gorm:"type:text"`
+	DiagnoseID            int64     `json:"diagnose_id" gorm:"not null;uniqueIndex:idx_diagnose_versions_unique"`
+	Version               int       `json:"version" gorm:"not null;uniqueIndex:idx_diagnose_versions_unique"`
+	Kind                  string    `json:"kind" gorm:"type:varchar(20);not null"`
+	Notes                 string    `json:"notes" gorm:"type:text;not null;serializer:encrypted"`
+	PrescribedMedications string    `json:"prescribed_medications" gorm:"type:text;serializer:encrypted"`
+	Codes                 string    `json:"codes" gorm:"type:text"`
+	AuthorID              int64     `json:"author_id" gorm:"not null"`
+	Reason                string    `json:"reason,omitempty" gorm:"type:text"`
 	CreatedAt             time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
 }
+
+// Amendment is a change to the notes, medications or codes of a diagnosis.
+// Empty fields are left unchanged. A reason is required once the diagnosis is
+// signed.
+type Amendment struct {
+	Notes                 string
+	PrescribedMedications string
+	Codes                 []DiagnoseCode
+	Reason                string
+}
+
+// PatientDiagnose is the view of a signed diagnosis shown to the patient,
+// without the internal drafting and versioning details.
+type PatientDiagnose struct {
+	ID            int64          `json:"id"`
+	AppointmentID int64          `json:"appointment_id"`
+	DoctorID      int64          `json:"doctor_id"`
+	Notes         string         `json:"notes"`
+	Medications   []string       `json:"medications"`
+	Codes         []DiagnoseCode `json:"codes"`
+	SignedAt      *time.Time     `json:"signed_at"`
+	Amended       bool           `json:"amended"`
+}
+
+func (d *Diagnose) PatientView() PatientDiagnose {
+	medications := []string{}
+	for _, med := range strings.Split(d.PrescribedMedications, ",") {
+		if med = strings.TrimSpace(med); med != "" {
+			medications = append(medications, med)
+		}
+	}
+	return PatientDiagnose{
+		ID:            d.ID,
+		AppointmentID: d.AppointmentID,
+		DoctorID:      d.DoctorID,
+		Notes:         d.Notes,
+		Medications:   medications,
+		Codes:         d.Codes,
+		SignedAt:      d.SignedAt,
+		Amended:       d.Version > 1 && d.SignedAt != nil && d.UpdatedAt.After(*d.SignedAt),
+	}
+}
@@ -1,8 +1,20 @@
 package diagnoses
 
+// RecordBuilder returns the records to store together with a diagnosis once
+// it has been saved, such as the justification of an overridden safety
+// alert. If they cannot be built or stored the diagnosis is not saved either.
+type RecordBuilder func(diagnose *Diagnose) ([]interface{}, error)
+
 type DiagnoseRepo interface {
-	Create(diagnose *Diagnose) (int64, error)
+	// Create stores the diagnosis with its first version and the records
+	// built for it.
+	Create(diagnose *Diagnose, records ...RecordBuilder) (int64, error)
 	GetByID(id int64) (*Diagnose, error)
 	GetByAppointmentID(appointmentID int64) (*Diagnose, error)
-	Update(diagnose *Diagnose) error
+	// Update saves the diagnosis if it is still at previousVersion, together
+	// with the version snapshot when one is given and the records built for
+	// it, or returns ErrVersionConflict.
+	Update(diagnose *Diagnose, previousVersion int, version *DiagnoseVersion, records ...RecordBuilder) error
+	ListVersions(diagnoseID int64) ([]DiagnoseVersion, error)
+	GetVersion(diagnoseID int64, version int) (*DiagnoseVersion, error)
 }
@@ -1,41 +1,164 @@
 package diagnoses
 
 import (
+	"errors"
 	"log/slog"
 	"strings"
+	"time"
 
+	"Dedenruslan19/med-project/repository/pubsub"
 	"Dedenruslan19/med-project/service/appointments"
 	errs "Dedenruslan19/med-project/service/errors"
+	"Dedenruslan19/med-project/service/icd10"
+	"Dedenruslan19/med-project/service/pricing"
+)
+
+var (
+	ErrDiagnoseNotFound        = errors.New("diagnose not found")
+	ErrNotAuthor               = errors.New("only the authoring doctor can change this diagnosis")
+	ErrAlreadySigned           = errors.New("diagnosis is already signed")
+	ErrAmendmentReasonRequired = errors.New("a reason is required to amend a signed diagnosis")
+	ErrNoChanges               = errors.New("amendment does not change the diagnosis")
+	ErrVersionConflict         = errors.New("diagnosis was changed concurrently, reload and retry")
+	ErrVersionNotFound         = errors.New("diagnosis version not found")
+	ErrPrimaryCodeRequired     = errors.New("a coded diagnosis needs exactly one primary code")
+	ErrDuplicateCode           = errors.New("a code is listed more than once")
 )
 
 type service struct {
 	repo               DiagnoseRepo
 	appointmentService appointments.Service
+	codeService        icd10.Service
+	publisher          pubsub.Publisher
 	logger             *slog.Logger
 }
 
 type Service interface {
-	Create(diagnose *Diagnose) (int64, error)
+	Create(diagnose *Diagnose, records ...RecordBuilder) (int64, error)
 	GetByID(id int64) (*Diagnose, error)
 	GetByAppointmentID(appointmentID int64) (*Diagnose, error)
-	Update(diagnose *Diagnose) error
+	Amend(id, authorID int64, amendment Amendment, records ...RecordBuilder) (*Diagnose, error)
+	Sign(id, doctorID int64, records ...RecordBuilder) (*Diagnose, error)
+	GetVersions(id int64) ([]DiagnoseVersion, error)
+	GetVersion(id int64, version int) (*DiagnoseVersion, error)
 	CalculateTotalAmount(diagnose *Diagnose) float64
+	CalculateFees(diagnose *Diagnose) (consultationFee, medicationFee float64)
+	PricingLines(diagnose *Diagnose) []pricing.Line
 }
 
-func NewService(logger *slog.Logger, repo DiagnoseRepo, appointmentService appointments.Service) Service {
+// NewService publishes diagnose events through publisher; nil disables them.
+func NewService(logger *slog.Logger, repo DiagnoseRepo, appointmentService appointments.Service, codeService icd10.Service,
+	publisher pubsub.Publisher) Service {
 	return &service{
 		logger:             logger,
 		repo:               repo,
 		appointmentService: appointmentService,
+		codeService:        codeService,
+		publisher:          publisher,
+	}
+}
+
+// publish tells the doctor about a diagnose event, and the patient as well
+// once it is signed; patients do not learn about drafts.
+func (s *service) publish(eventType string, diagnose *Diagnose) {
+	if s.publisher == nil {
+		return
+	}
+
+	recipients := []pubsub.Principal{{Role: pubsub.RoleDoctor, ID: diagnose.DoctorID}}
+	if diagnose.Signed() {
+		appointment, err := s.appointmentService.GetByID(diagnose.AppointmentID)
+		if err == nil {
+			recipients = append(recipients, pubsub.Principal{Role: pubsub.RoleUser, ID: appointment.UserID})
+		}
+	}
+
+	event := pubsub.NewEvent(eventType, EventData{
+		DiagnoseID:    diagnose.ID,
+		AppointmentID: diagnose.AppointmentID,
+		Status:        diagnose.Status,
+		Version:       diagnose.Version,
+	}, recipients...)
+	if err := s.publisher.Publish(event); err != nil {
+		s.logger.Warn("failed to publish diagnose event",
+			slog.Any("error", err),
+			slog.String("event_type", eventType),
+			slog.Int64("diagnose_id", diagnose.ID),
+		)
+	}
+}
+
+// resolveCodes checks codes against the ICD-10 catalogue and returns them
+// normalized, titled and numbered with the primary code first.
+func (s *service) resolveCodes(codes []DiagnoseCode) ([]DiagnoseCode, error) {
+	if len(codes) == 0 {
+		return codes, nil
+	}
+
+	primaries := 0
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if code.Primary {
+			primaries++
+		}
+		normalized := icd10.Normalize(code.Code)
+		if seen[normalized] {
+			return nil, ErrDuplicateCode
+		}
+		seen[normalized] = true
+	}
+	if primaries != 1 {
+		return nil, ErrPrimaryCodeRequired
+	}
+
+	ordered := make([]DiagnoseCode, 0, len(codes))
+	for _, code := range codes {
+		if code.Primary {
+			ordered = append([]DiagnoseCode{code}, ordered...)
+		} else {
+			ordered = append(ordered, code)
+		}
+	}
+
+	names := make([]string, 0, len(ordered))
+	for _, code := range ordered {
+		names = append(names, code.Code)
+	}
+	entries := make([]icd10.Code, len(names))
+	if s.codeService != nil {
+		var err error
+		if entries, err = s.codeService.Lookup(names); err != nil {
+			return nil, err
+		}
 	}
+
+	resolved := make([]DiagnoseCode, 0, len(ordered))
+	for i, code := range ordered {
+		resolved = append(resolved, DiagnoseCode{
+			Code:     icd10.Normalize(code.Code),
+			Title:    entries[i].Title,
+			Primary:  code.Primary,
+			Position: i,
+		})
+	}
+	return resolved, nil
 }
 
-func (s *service) Create(diagnose *Diagnose) (int64, error) {
+// Create stores a draft diagnosis, together with the given records.
+func (s *service) Create(diagnose *Diagnose, records ...RecordBuilder) (int64, error) {
 	if diagnose.AppointmentID == 0 {
 		return 0, errs.ErrInvalidInput
 	}
 
-	id, err := s.repo.Create(diagnose)
+	codes, err := s.resolveCodes(diagnose.Codes)
+	if err != nil {
+		return 0, err
+	}
+	diagnose.Codes = codes
+	diagnose.Status = StatusDraft
+	diagnose.Version = 1
+
+	id, err := s.repo.Create(diagnose, records...)
 	if err != nil {
 		s.logger.Error("failed to create diagnose",
 			slog.Any("error", err),
@@ -44,7 +167,7 @@ func (s *service) Create(diagnose *Diagnose) (int64, error) {
 		return 0, err
 	}
 
-	err = s.appointmentService.UpdateStatus(diagnose.AppointmentID, "completed")
+	err = s.appointmentService.UpdateStatus(diagnose.AppointmentID, appointments.StatusCompleted)
 	if err != nil {
 		s.logger.Error("failed to update appointment status after diagnose",
 			slog.Any("error", err),
@@ -54,6 +177,8 @@ func (s *service) Create(diagnose *Diagnose) (int64, error) {
 			slog.Int64("appointment_id", diagnose.AppointmentID))
 	}
 
+	diagnose.ID = id
+	s.publish(EventCreated, diagnose)
 	return id, nil
 }
 
@@ -81,34 +206,169 @@ func (s *service) GetByAppointmentID(appointmentID int64) (*Diagnose, error) {
 	return diagnose, nil
 }
 
-func (s *service) Update(diagnose *Diagnose) error {
-	err := s.repo.Update(diagnose)
+// Amend changes the notes or medications of a diagnosis. Only the authoring
+// doctor may change it; a draft is revised freely while a signed diagnosis
+// needs a reason. Either way the new content is kept as a new version, saved
+// together with the given records.
+func (s *service) Amend(id, authorID int64, amendment Amendment, records ...RecordBuilder) (*Diagnose, error) {
+	diagnose, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrDiagnoseNotFound
+	}
+	if diagnose.DoctorID != authorID {
+		return nil, ErrNotAuthor
+	}
+
+	kind := VersionRevised
+	if diagnose.Signed() {
+		kind = VersionAmended
+		if strings.TrimSpace(amendment.Reason) == "" {
+			return nil, ErrAmendmentReasonRequired
+		}
+	}
+
+	changed := false
+	if amendment.Notes != "" && amendment.Notes != diagnose.Notes {
+		diagnose.Notes = amendment.Notes
+		changed = true
+	}
+	if amendment.PrescribedMedications != "" && amendment.PrescribedMedications != diagnose.PrescribedMedications {
+		diagnose.PrescribedMedications = amendment.PrescribedMedications
+		changed = true
+	}
+	if amendment.Codes != nil {
+		codes, err := s.resolveCodes(amendment.Codes)
+		if err != nil {
+			return nil, err
+		}
+		previous := diagnose.CodeList()
+		diagnose.Codes = codes
+		if diagnose.CodeList() != previous {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, ErrNoChanges
+	}
+
+	previous := diagnose.Version
+	diagnose.Version++
+	version := &DiagnoseVersion{
+		DiagnoseID:            diagnose.ID,
+		Version:               diagnose.Version,
+		Kind:                  kind,
+		Notes:                 diagnose.Notes,
+		PrescribedMedications: diagnose.PrescribedMedications,
+		Codes:                 diagnose.CodeList(),
+		AuthorID:              authorID,
+		Reason:                strings.TrimSpace(amendment.Reason),
+	}
+
+	if err := s.repo.Update(diagnose, previous, version, records...); err != nil {
+		s.logger.Error("failed to amend diagnose",
+			slog.Any("error", err),
+			slog.Int64("diagnose_id", id),
+		)
+		return nil, err
+	}
+	return diagnose, nil
+}
+
+// Sign finalises a diagnosis, after which it can only be amended. The given
+// records are saved with it.
+func (s *service) Sign(id, doctorID int64, records ...RecordBuilder) (*Diagnose, error) {
+	diagnose, err := s.repo.GetByID(id)
 	if err != nil {
-		s.logger.Error("failed to update diagnose",
+		return nil, ErrDiagnoseNotFound
+	}
+	if diagnose.DoctorID != doctorID {
+		return nil, ErrNotAuthor
+	}
+	if diagnose.Signed() {
+		return nil, ErrAlreadySigned
+	}
+
+	now := time.Now()
+	diagnose.Status = StatusSigned
+	diagnose.SignedAt = &now
+
+	if err := s.repo.Update(diagnose, diagnose.Version, nil, records...); err != nil {
+		s.logger.Error("failed to sign diagnose",
 			slog.Any("error", err),
-			slog.Int64("diagnose_id", diagnose.ID),
+			slog.Int64("diagnose_id", id),
+		)
+		return nil, err
+	}
+
+	s.publish(EventSigned, diagnose)
+	return diagnose, nil
+}
+
+func (s *service) GetVersions(id int64) ([]DiagnoseVersion, error) {
+	versions, err := s.repo.ListVersions(id)
+	if err != nil {
+		s.logger.Error("failed to get diagnose versions",
+			slog.Any("error", err),
+			slog.Int64("diagnose_id", id),
 		)
-		return err
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (s *service) GetVersion(id int64, version int) (*DiagnoseVersion, error) {
+	v, err := s.repo.GetVersion(id, version)
+	if err != nil {
+		return nil, ErrVersionNotFound
 	}
-	return nil
+	return v, nil
 }
 
 func (s *service) CalculateTotalAmount(diagnosis *Diagnose) float64 {
+	consultationFee, medicationFee := s.CalculateFees(diagnosis)
+	return consultationFee + medicationFee
+}
+
+func (s *service) CalculateFees(diagnosis *Diagnose) (float64, float64) {
+	consultationFee, medicationTotal := 0.0, 0.0
+	for _, line := range s.PricingLines(diagnosis) {
+		amount := float64(line.Quantity) * line.UnitPrice
+		if line.Kind == pricing.KindMedication {
+			medicationTotal += amount
+		} else {
+			consultationFee += amount
+		}
+	}
+	return consultationFee, medicationTotal
+}
+
+// PricingLines lists the billable items of a diagnosis: the consultation and
+// one line per prescribed medication.
+func (s *service) PricingLines(diagnosis *Diagnose) []pricing.Line {
 	const appointmentFee = 200000.0
 	const medicationFee = 50000.0
 
-	totalAmount := appointmentFee
+	lines := []pricing.Line{{
+		Kind:        pricing.KindConsultation,
+		Description: "Consultation",
+		Quantity:    1,
+		UnitPrice:   appointmentFee,
+	}}
 
 	if diagnosis.PrescribedMedications != "" {
-		medications := strings.Split(diagnosis.PrescribedMedications, ",")
-		medicationCount := 0
-		for _, med := range medications {
-			if strings.TrimSpace(med) != "" {
-				medicationCount++
+		for _, med := range strings.Split(diagnosis.PrescribedMedications, ",") {
+			med = strings.TrimSpace(med)
+			if med == "" {
+				continue
 			}
+			lines = append(lines, pricing.Line{
+				Kind:        pricing.KindMedication,
+				Description: med,
+				Quantity:    1,
+				UnitPrice:   medicationFee,
+			})
 		}
-		totalAmount += float64(medicationCount) * medicationFee
 	}
 
-	return totalAmount
+	return lines
 }
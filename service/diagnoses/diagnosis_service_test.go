@@ -17,7 +17,7 @@ func TestGetByID_Success(t *testing.T) {
 
 	mockRepo := diagnoses.NewMockDiagnoseRepo(ctrl)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	service := diagnoses.NewService(logger, mockRepo, nil)
+	service := diagnoses.NewService(logger, mockRepo, nil, nil, nil)
 
 	expectedDiagnosis := &diagnoses.Diagnose{
 		ID:                    1,
@@ -47,7 +47,7 @@ func TestGetByID_NotFound(t *testing.T) {
 
 	mockRepo := diagnoses.NewMockDiagnoseRepo(ctrl)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	service := diagnoses.NewService(logger, mockRepo, nil)
+	service := diagnoses.NewService(logger, mockRepo, nil, nil, nil)
 
 	mockRepo.EXPECT().
 		GetByID(int64(999)).
@@ -59,3 +59,55 @@ func TestGetByID_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
+
+func TestAmend_SignedDiagnoseKeepsVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := diagnoses.NewMockDiagnoseRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := diagnoses.NewService(logger, mockRepo, nil, nil, nil)
+
+	signed := func() *diagnoses.Diagnose {
+		return &diagnoses.Diagnose{ID: 1, DoctorID: 5, Notes: "Flu", PrescribedMedications: "Paracetamol", Status: diagnoses.StatusSigned, Version: 2}
+	}
+	mockRepo.EXPECT().GetByID(int64(1)).DoAndReturn(func(int64) (*diagnoses.Diagnose, error) { return signed(), nil }).Times(2)
+
+	_, err := service.Amend(1, 5, diagnoses.Amendment{Notes: "Influenza A"})
+	assert.ErrorIs(t, err, diagnoses.ErrAmendmentReasonRequired)
+
+	mockRepo.EXPECT().
+		Update(gomock.Any(), 2, gomock.Any()).
+		DoAndReturn(func(d *diagnoses.Diagnose, previous int, v *diagnoses.DiagnoseVersion, _ ...diagnoses.RecordBuilder) error {
+			assert.Equal(t, 3, d.Version)
+			assert.Equal(t, diagnoses.VersionAmended, v.Kind)
+			assert.Equal(t, "Influenza A", v.Notes)
+			assert.Equal(t, int64(5), v.AuthorID)
+			assert.Equal(t, "lab result", v.Reason)
+			return nil
+		}).
+		Times(1)
+
+	result, err := service.Amend(1, 5, diagnoses.Amendment{Notes: "Influenza A", Reason: "lab result"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Influenza A", result.Notes)
+}
+
+func TestAmend_OnlyAuthor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := diagnoses.NewMockDiagnoseRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := diagnoses.NewService(logger, mockRepo, nil, nil, nil)
+
+	mockRepo.EXPECT().
+		GetByID(int64(1)).
+		Return(&diagnoses.Diagnose{ID: 1, DoctorID: 5, Notes: "Flu", Status: diagnoses.StatusDraft, Version: 1}, nil).
+		Times(1)
+
+	result, err := service.Amend(1, 6, diagnoses.Amendment{Notes: "Cold"})
+
+	assert.ErrorIs(t, err, diagnoses.ErrNotAuthor)
+	assert.Nil(t, result)
+}
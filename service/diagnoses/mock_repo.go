@@ -40,18 +40,23 @@ func (m *MockDiagnoseRepo) EXPECT() *MockDiagnoseRepoMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockDiagnoseRepo) Create(diagnose *Diagnose) (int64, error) {
+func (m *MockDiagnoseRepo) Create(diagnose *Diagnose, records ...RecordBuilder) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", diagnose)
+	varargs := []any{diagnose}
+	for _, a := range records {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Create", varargs...)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockDiagnoseRepoMockRecorder) Create(diagnose any) *gomock.Call {
+func (mr *MockDiagnoseRepoMockRecorder) Create(diagnose any, records ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDiagnoseRepo)(nil).Create), diagnose)
+	varargs := append([]any{diagnose}, records...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDiagnoseRepo)(nil).Create), varargs...)
 }
 
 // GetByAppointmentID mocks base method.
@@ -84,16 +89,51 @@ func (mr *MockDiagnoseRepoMockRecorder) GetByID(id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockDiagnoseRepo)(nil).GetByID), id)
 }
 
+// GetVersion mocks base method.
+func (m *MockDiagnoseRepo) GetVersion(diagnoseID int64, version int) (*DiagnoseVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", diagnoseID, version)
+	ret0, _ := ret[0].(*DiagnoseVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockDiagnoseRepoMockRecorder) GetVersion(diagnoseID, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockDiagnoseRepo)(nil).GetVersion), diagnoseID, version)
+}
+
+// ListVersions mocks base method.
+func (m *MockDiagnoseRepo) ListVersions(diagnoseID int64) ([]DiagnoseVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVersions", diagnoseID)
+	ret0, _ := ret[0].([]DiagnoseVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVersions indicates an expected call of ListVersions.
+func (mr *MockDiagnoseRepoMockRecorder) ListVersions(diagnoseID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVersions", reflect.TypeOf((*MockDiagnoseRepo)(nil).ListVersions), diagnoseID)
+}
+
 // Update mocks base method.
-func (m *MockDiagnoseRepo) Update(diagnose *Diagnose) error {
+func (m *MockDiagnoseRepo) Update(diagnose *Diagnose, previousVersion int, version *DiagnoseVersion, records ...RecordBuilder) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", diagnose)
+	varargs := []any{diagnose, previousVersion, version}
+	for _, a := range records {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Update", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockDiagnoseRepoMockRecorder) Update(diagnose any) *gomock.Call {
+func (mr *MockDiagnoseRepoMockRecorder) Update(diagnose, previousVersion, version any, records ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockDiagnoseRepo)(nil).Update), diagnose)
+	varargs := append([]any{diagnose, previousVersion, version}, records...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockDiagnoseRepo)(nil).Update), varargs...)
 }
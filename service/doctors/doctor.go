@@ -9,6 +9,7 @@ type Doctor struct {
 	FullName       string    `json:"full_name"`
 	Email          string    `json:"email"`
 	Specialization string    `json:"specialization"`
+	LicenseNumber  string    `json:"license_number"`
 	CreatedAt      time.Time `json:"created_at"`
 	IsAvailable    bool      `json:"is_available"`
 }
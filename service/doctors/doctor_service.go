@@ -17,7 +17,7 @@ type service struct {
 type Service interface {
 	GetAll() ([]Doctor, error)
 	GetByID(id int64) (*Doctor, error)
-	Register(fullName, email, password, specialization string) (*Doctor, error)
+	Register(fullName, email, password, specialization, licenseNumber string) (*Doctor, error)
 	Login(email, password string) (*Doctor, error)
 }
 
@@ -42,6 +42,7 @@ func (s *service) GetAll() ([]Doctor, error) {
 			FullName:       d.FullName,
 			Email:          d.Email,
 			Specialization: d.Specialization,
+			LicenseNumber:  d.LicenseNumber,
 			CreatedAt:      d.CreatedAt,
 			IsAvailable:    d.IsAvailable,
 		}
@@ -62,6 +63,7 @@ func (s *service) GetByID(id int64) (*Doctor, error) {
 		FullName:       doctorRepo.FullName,
 		Email:          doctorRepo.Email,
 		Specialization: doctorRepo.Specialization,
+		LicenseNumber:  doctorRepo.LicenseNumber,
 		CreatedAt:      doctorRepo.CreatedAt,
 		IsAvailable:    doctorRepo.IsAvailable,
 	}
@@ -86,6 +88,7 @@ func (s *service) Login(email, password string) (*Doctor, error) {
 		FullName:       doctorRepo.FullName,
 		Email:          doctorRepo.Email,
 		Specialization: doctorRepo.Specialization,
+		LicenseNumber:  doctorRepo.LicenseNumber,
 		CreatedAt:      doctorRepo.CreatedAt,
 		IsAvailable:    doctorRepo.IsAvailable,
 	}
@@ -93,7 +96,7 @@ func (s *service) Login(email, password string) (*Doctor, error) {
 	return doctor, nil
 }
 
-func (s *service) Register(fullName, email, password, specialization string) (*Doctor, error) {
+func (s *service) Register(fullName, email, password, specialization, licenseNumber string) (*Doctor, error) {
 	// Check if email already exists
 	existingDoctor, _ := s.repo.GetByEmail(email)
 	if existingDoctor != nil {
@@ -112,6 +115,7 @@ func (s *service) Register(fullName, email, password, specialization string) (*D
 		Email:          email,
 		Password:       string(hashedPassword),
 		Specialization: specialization,
+		LicenseNumber:  licenseNumber,
 		IsAvailable:    true,
 	}
 
@@ -126,6 +130,7 @@ func (s *service) Register(fullName, email, password, specialization string) (*D
 		FullName:       fullName,
 		Email:          email,
 		Specialization: specialization,
+		LicenseNumber:  licenseNumber,
 		IsAvailable:    true,
 	}
 
@@ -0,0 +1,75 @@
+package history
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/vitals"
+	"time"
+)
+
+const (
+	EventAppointment  = "appointment"
+	EventDiagnosis    = "diagnosis"
+	EventPrescription = "prescription"
+	EventBilling      = "billing"
+	EventVitals       = "vitals"
+)
+
+// eventTypes lists the timeline event types, in the order events of the same
+// moment are shown.
+var eventTypes = []string{EventBilling, EventPrescription, EventDiagnosis, EventVitals, EventAppointment}
+
+// Event is one entry of a patient's timeline. Exactly one of the detail
+// fields is set, matching Type.
+type Event struct {
+	Type          string                    `json:"type"`
+	OccurredAt    time.Time                 `json:"occurred_at"`
+	AppointmentID int64                     `json:"appointment_id"`
+	Appointment   *appointments.Appointment `json:"appointment,omitempty"`
+	Diagnosis     *diagnoses.Diagnose       `json:"diagnosis,omitempty"`
+	Prescription  *Prescription             `json:"prescription,omitempty"`
+	Billing       *BillingStatus            `json:"billing,omitempty"`
+	Vitals        *vitals.Vital             `json:"vitals,omitempty"`
+}
+
+// Prescription is the medication prescribed with a diagnosis.
+type Prescription struct {
+	DiagnoseID  int64    `json:"diagnose_id"`
+	DoctorID    int64    `json:"doctor_id"`
+	Medications []string `json:"medications"`
+}
+
+// BillingStatus is the payment state of an appointment's billing.
+type BillingStatus struct {
+	ID            int64      `json:"id"`
+	TotalAmount   float64    `json:"total_amount"`
+	AmountDue     float64    `json:"amount_due"`
+	PaymentStatus string     `json:"payment_status"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+}
+
+func newBillingStatus(b *billings.Billing) *BillingStatus {
+	return &BillingStatus{
+		ID:            b.ID,
+		TotalAmount:   b.TotalAmount,
+		AmountDue:     b.AmountDue(),
+		PaymentStatus: b.PaymentStatus,
+		PaidAt:        b.PaidAt,
+	}
+}
+
+// Query selects a page of the timeline, optionally only some event types.
+type Query struct {
+	Types   []string
+	Page    int
+	PerPage int
+}
+
+type Timeline struct {
+	PatientID int64   `json:"patient_id"`
+	Page      int     `json:"page"`
+	PerPage   int     `json:"per_page"`
+	Total     int     `json:"total"`
+	Events    []Event `json:"events"`
+}
@@ -0,0 +1,20 @@
+package history
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/vitals"
+)
+
+type HistoryRepo interface {
+	// HasAppointment tells whether the doctor has or had an appointment with
+	// the patient.
+	HasAppointment(doctorID, userID int64) (bool, error)
+	ListAppointments(userID int64) ([]appointments.Appointment, error)
+	// ListDiagnoses returns the diagnoses of the patient's appointments with
+	// their codes.
+	ListDiagnoses(userID int64) ([]diagnoses.Diagnose, error)
+	ListBillings(userID int64) ([]billings.Billing, error)
+	ListVitals(userID int64) ([]vitals.Vital, error)
+}
@@ -0,0 +1,233 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+var (
+	ErrNoRelationship = errors.New("doctor has no appointment with this patient")
+	ErrInvalidType    = errors.New("invalid event type")
+)
+
+type service struct {
+	repo   HistoryRepo
+	logger *slog.Logger
+}
+
+type Service interface {
+	Authorize(doctorID, patientID int64) error
+	Timeline(doctorID, patientID int64, query Query) (*Timeline, error)
+}
+
+func NewService(logger *slog.Logger, repo HistoryRepo) Service {
+	return &service{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+// ParseTypes reads a comma separated list of event types, e.g.
+// "diagnosis,prescription". An empty list selects every type.
+func ParseTypes(list string) ([]string, error) {
+	var types []string
+	for _, t := range strings.Split(list, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if rank(t) < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidType, t)
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+func rank(eventType string) int {
+	for i, t := range eventTypes {
+		if t == eventType {
+			return i
+		}
+	}
+	return -1
+}
+
+// Authorize returns ErrNoRelationship unless the doctor has or had an
+// appointment with the patient.
+func (s *service) Authorize(doctorID, patientID int64) error {
+	related, err := s.repo.HasAppointment(doctorID, patientID)
+	if err != nil {
+		s.logger.Error("failed to check doctor patient relationship",
+			slog.Any("error", err),
+			slog.Int64("doctor_id", doctorID),
+			slog.Int64("patient_id", patientID),
+		)
+		return err
+	}
+	if !related {
+		return ErrNoRelationship
+	}
+	return nil
+}
+
+// Timeline merges the patient's appointments, diagnoses, prescriptions,
+// vitals and billings into one list, newest first. Only doctors who have or had an
+// appointment with the patient may read it, and they see signed diagnoses
+// and their own drafts.
+func (s *service) Timeline(doctorID, patientID int64, query Query) (*Timeline, error) {
+	for _, t := range query.Types {
+		if rank(t) < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidType, t)
+		}
+	}
+
+	if err := s.Authorize(doctorID, patientID); err != nil {
+		return nil, err
+	}
+
+	events, err := s.collect(doctorID, patientID, query.Types)
+	if err != nil {
+		s.logger.Error("failed to build patient history",
+			slog.Any("error", err),
+			slog.Int64("patient_id", patientID),
+		)
+		return nil, err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].OccurredAt.Equal(events[j].OccurredAt) {
+			return events[i].OccurredAt.After(events[j].OccurredAt)
+		}
+		return rank(events[i].Type) < rank(events[j].Type)
+	})
+
+	page, perPage := query.Page, query.PerPage
+	if page <= 0 {
+		page = 1
+	}
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	timeline := &Timeline{PatientID: patientID, Page: page, PerPage: perPage, Total: len(events), Events: []Event{}}
+	start := (page - 1) * perPage
+	if start < len(events) {
+		end := min(start+perPage, len(events))
+		timeline.Events = events[start:end]
+	}
+	return timeline, nil
+}
+
+func (s *service) collect(doctorID, patientID int64, types []string) ([]Event, error) {
+	wanted := func(eventType string) bool {
+		if len(types) == 0 {
+			return true
+		}
+		for _, t := range types {
+			if t == eventType {
+				return true
+			}
+		}
+		return false
+	}
+
+	var events []Event
+
+	if wanted(EventAppointment) {
+		list, err := s.repo.ListAppointments(patientID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			events = append(events, Event{
+				Type:          EventAppointment,
+				OccurredAt:    list[i].AppointmentDate,
+				AppointmentID: list[i].ID,
+				Appointment:   &list[i],
+			})
+		}
+	}
+
+	if wanted(EventDiagnosis) || wanted(EventPrescription) {
+		list, err := s.repo.ListDiagnoses(patientID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			d := &list[i]
+			if !d.Signed() && d.DoctorID != doctorID {
+				continue
+			}
+			if wanted(EventDiagnosis) {
+				events = append(events, Event{
+					Type:          EventDiagnosis,
+					OccurredAt:    d.CreatedAt,
+					AppointmentID: d.AppointmentID,
+					Diagnosis:     d,
+				})
+			}
+			if medications := splitMedications(d.PrescribedMedications); wanted(EventPrescription) && len(medications) > 0 {
+				events = append(events, Event{
+					Type:          EventPrescription,
+					OccurredAt:    d.CreatedAt,
+					AppointmentID: d.AppointmentID,
+					Prescription:  &Prescription{DiagnoseID: d.ID, DoctorID: d.DoctorID, Medications: medications},
+				})
+			}
+		}
+	}
+
+	if wanted(EventVitals) {
+		list, err := s.repo.ListVitals(patientID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			events = append(events, Event{
+				Type:          EventVitals,
+				OccurredAt:    list[i].RecordedAt,
+				AppointmentID: list[i].AppointmentID,
+				Vitals:        &list[i],
+			})
+		}
+	}
+
+	if wanted(EventBilling) {
+		list, err := s.repo.ListBillings(patientID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			events = append(events, Event{
+				Type:          EventBilling,
+				OccurredAt:    list[i].CreatedAt,
+				AppointmentID: list[i].AppointmentID,
+				Billing:       newBillingStatus(&list[i]),
+			})
+		}
+	}
+
+	return events, nil
+}
+
+func splitMedications(list string) []string {
+	var medications []string
+	for _, med := range strings.Split(list, ",") {
+		if med = strings.TrimSpace(med); med != "" {
+			medications = append(medications, med)
+		}
+	}
+	return medications
+}
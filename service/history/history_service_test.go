@@ -0,0 +1,93 @@
+package history_test
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/history"
+	"Dedenruslan19/med-project/service/vitals"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func day(d int) time.Time {
+	return time.Date(2026, time.April, d, 9, 0, 0, 0, time.UTC)
+}
+
+func setup(t *testing.T) (*history.MockHistoryRepo, history.Service) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockRepo := history.NewMockHistoryRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	return mockRepo, history.NewService(logger, mockRepo)
+}
+
+func TestTimeline_MergesNewestFirst(t *testing.T) {
+	mockRepo, service := setup(t)
+
+	mockRepo.EXPECT().HasAppointment(int64(2), int64(1)).Return(true, nil).Times(1)
+	mockRepo.EXPECT().ListAppointments(int64(1)).Return([]appointments.Appointment{
+		{ID: 10, UserID: 1, DoctorID: 2, AppointmentDate: day(1)},
+		{ID: 11, UserID: 1, DoctorID: 3, AppointmentDate: day(8)},
+	}, nil).Times(1)
+	mockRepo.EXPECT().ListDiagnoses(int64(1)).Return([]diagnoses.Diagnose{
+		{ID: 20, AppointmentID: 10, DoctorID: 2, Status: diagnoses.StatusSigned, PrescribedMedications: "Paracetamol, Ibuprofen", CreatedAt: day(2)},
+		// another doctor's draft stays hidden
+		{ID: 21, AppointmentID: 11, DoctorID: 3, Status: diagnoses.StatusDraft, PrescribedMedications: "Amoxicillin", CreatedAt: day(9)},
+	}, nil).Times(1)
+	mockRepo.EXPECT().ListBillings(int64(1)).Return([]billings.Billing{
+		{ID: 30, AppointmentID: 10, TotalAmount: 300000, PaymentStatus: "paid", CreatedAt: day(2)},
+	}, nil).Times(1)
+
+	heartRate := 88.0
+	mockRepo.EXPECT().ListVitals(int64(1)).Return([]vitals.Vital{
+		{ID: 40, AppointmentID: 10, HeartRate: &heartRate, RecordedAt: day(1).Add(time.Hour)},
+	}, nil).Times(1)
+
+	timeline, err := service.Timeline(2, 1, history.Query{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, timeline.Total)
+	var types []string
+	for _, event := range timeline.Events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{"appointment", "billing", "prescription", "diagnosis", "vitals", "appointment"}, types)
+	assert.Equal(t, []string{"Paracetamol", "Ibuprofen"}, timeline.Events[2].Prescription.Medications)
+}
+
+func TestTimeline_FilterAndPaginate(t *testing.T) {
+	mockRepo, service := setup(t)
+
+	mockRepo.EXPECT().HasAppointment(int64(2), int64(1)).Return(true, nil).Times(1)
+	mockRepo.EXPECT().ListAppointments(int64(1)).Return([]appointments.Appointment{
+		{ID: 10, AppointmentDate: day(1)},
+		{ID: 11, AppointmentDate: day(2)},
+		{ID: 12, AppointmentDate: day(3)},
+	}, nil).Times(1)
+
+	types, err := history.ParseTypes("appointment")
+	assert.NoError(t, err)
+	timeline, err := service.Timeline(2, 1, history.Query{Types: types, Page: 2, PerPage: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, timeline.Total)
+	assert.Len(t, timeline.Events, 1)
+	assert.Equal(t, int64(10), timeline.Events[0].AppointmentID)
+}
+
+func TestTimeline_DoctorWithoutAppointment(t *testing.T) {
+	mockRepo, service := setup(t)
+
+	mockRepo.EXPECT().HasAppointment(int64(5), int64(1)).Return(false, nil).Times(1)
+
+	_, err := service.Timeline(5, 1, history.Query{})
+
+	assert.ErrorIs(t, err, history.ErrNoRelationship)
+}
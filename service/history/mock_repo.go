@@ -0,0 +1,119 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/history/history_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/history/history_repo.go -destination=service/history/mock_repo.go -package=history
+//
+
+// Package history is a generated GoMock package.
+package history
+
+import (
+	appointments "Dedenruslan19/med-project/service/appointments"
+	billings "Dedenruslan19/med-project/service/billings"
+	diagnoses "Dedenruslan19/med-project/service/diagnoses"
+	vitals "Dedenruslan19/med-project/service/vitals"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockHistoryRepo is a mock of HistoryRepo interface.
+type MockHistoryRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistoryRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockHistoryRepoMockRecorder is the mock recorder for MockHistoryRepo.
+type MockHistoryRepoMockRecorder struct {
+	mock *MockHistoryRepo
+}
+
+// NewMockHistoryRepo creates a new mock instance.
+func NewMockHistoryRepo(ctrl *gomock.Controller) *MockHistoryRepo {
+	mock := &MockHistoryRepo{ctrl: ctrl}
+	mock.recorder = &MockHistoryRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistoryRepo) EXPECT() *MockHistoryRepoMockRecorder {
+	return m.recorder
+}
+
+// HasAppointment mocks base method.
+func (m *MockHistoryRepo) HasAppointment(doctorID, userID int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasAppointment", doctorID, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasAppointment indicates an expected call of HasAppointment.
+func (mr *MockHistoryRepoMockRecorder) HasAppointment(doctorID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasAppointment", reflect.TypeOf((*MockHistoryRepo)(nil).HasAppointment), doctorID, userID)
+}
+
+// ListAppointments mocks base method.
+func (m *MockHistoryRepo) ListAppointments(userID int64) ([]appointments.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppointments", userID)
+	ret0, _ := ret[0].([]appointments.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppointments indicates an expected call of ListAppointments.
+func (mr *MockHistoryRepoMockRecorder) ListAppointments(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppointments", reflect.TypeOf((*MockHistoryRepo)(nil).ListAppointments), userID)
+}
+
+// ListBillings mocks base method.
+func (m *MockHistoryRepo) ListBillings(userID int64) ([]billings.Billing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBillings", userID)
+	ret0, _ := ret[0].([]billings.Billing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBillings indicates an expected call of ListBillings.
+func (mr *MockHistoryRepoMockRecorder) ListBillings(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBillings", reflect.TypeOf((*MockHistoryRepo)(nil).ListBillings), userID)
+}
+
+// ListDiagnoses mocks base method.
+func (m *MockHistoryRepo) ListDiagnoses(userID int64) ([]diagnoses.Diagnose, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDiagnoses", userID)
+	ret0, _ := ret[0].([]diagnoses.Diagnose)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDiagnoses indicates an expected call of ListDiagnoses.
+func (mr *MockHistoryRepoMockRecorder) ListDiagnoses(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDiagnoses", reflect.TypeOf((*MockHistoryRepo)(nil).ListDiagnoses), userID)
+}
+
+// ListVitals mocks base method.
+func (m *MockHistoryRepo) ListVitals(userID int64) ([]vitals.Vital, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVitals", userID)
+	ret0, _ := ret[0].([]vitals.Vital)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVitals indicates an expected call of ListVitals.
+func (mr *MockHistoryRepoMockRecorder) ListVitals(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVitals", reflect.TypeOf((*MockHistoryRepo)(nil).ListVitals), userID)
+}
@@ -0,0 +1,46 @@
+package icd10
+
+import (
+	"embed"
+	"strings"
+)
+
+// bundled holds the CSV of common ICD-10 codes shipped with the
+// service. A complete catalogue can be imported from a file in the same
+// format.
+//
+//go:embed data/icd10.csv
+var bundled embed.FS
+
+const bundledPath = "data/icd10.csv"
+
+// Code is an entry of the ICD-10 catalogue. Category is the three character
+// category the code belongs to, e.g. J06 for J06.9.
+type Code struct {
+	Code     string `json:"code" gorm:"type:varchar(10);primaryKey"`
+	Title    string `json:"title" gorm:"type:varchar(500);not null"`
+	Category string `json:"category" gorm:"type:varchar(3);not null;index"`
+}
+
+func (Code) TableName() string {
+	return "icd10_codes"
+}
+
+// Normalize upper-cases a code and adds the dot after the category, so
+// j069 and J06.9 are the same code.
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) > 3 && !strings.Contains(code, ".") {
+		code = code[:3] + "." + code[3:]
+	}
+	return code
+}
+
+// SearchQuery matches codes starting with Prefix and titles containing
+// every word of Keyword.
+type SearchQuery struct {
+	Prefix  string
+	Keyword string
+	Limit   int
+}
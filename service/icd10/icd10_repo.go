@@ -0,0 +1,10 @@
+package icd10
+
+type CodeRepo interface {
+	// Upsert inserts the codes or updates the titles of existing ones.
+	Upsert(codes []Code) error
+	Count() (int64, error)
+	Search(query SearchQuery) ([]Code, error)
+	// GetByCodes returns the known codes among codes.
+	GetByCodes(codes []string) ([]Code, error)
+}
@@ -0,0 +1,185 @@
+package icd10
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	importBatchSize    = 500
+)
+
+var (
+	ErrInvalidCatalogue = errors.New("invalid ICD-10 catalogue")
+	ErrUnknownCode      = errors.New("unknown ICD-10 code")
+	ErrEmptyQuery       = errors.New("search query is required")
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z][0-9]{2}(\.[0-9A-Z]{1,4})?$`)
+
+// codePrefixPattern recognises queries that look like the start of a code.
+var codePrefixPattern = regexp.MustCompile(`^[A-Za-z][0-9]`)
+
+type service struct {
+	repo   CodeRepo
+	logger *slog.Logger
+}
+
+type Service interface {
+	Import(r io.Reader) (int, error)
+	LoadBundled() (int, error)
+	Search(q string, limit int) ([]Code, error)
+	Lookup(codes []string) ([]Code, error)
+}
+
+func NewService(logger *slog.Logger, repo CodeRepo) Service {
+	return &service{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+// Import reads a catalogue CSV with a code,title header and stores it,
+// replacing the titles of codes already present.
+func (s *service) Import(r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidCatalogue, err)
+	}
+	if len(header) < 2 || strings.ToLower(strings.TrimSpace(header[0])) != "code" {
+		return 0, fmt.Errorf("%w: header must be code,title", ErrInvalidCatalogue)
+	}
+
+	imported := 0
+	batch := make([]Code, 0, importBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.repo.Upsert(batch); err != nil {
+			return err
+		}
+		imported += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, fmt.Errorf("%w: %v", ErrInvalidCatalogue, err)
+		}
+
+		code := Normalize(record[0])
+		title := strings.TrimSpace(record[1])
+		if !codePattern.MatchString(code) || title == "" {
+			return imported, fmt.Errorf("%w: row %d has an invalid code or title", ErrInvalidCatalogue, row)
+		}
+		batch = append(batch, Code{Code: code, Title: title, Category: code[:3]})
+
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return imported, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return imported, err
+	}
+
+	s.logger.Info("ICD-10 catalogue imported", slog.Int("codes", imported))
+	return imported, nil
+}
+
+// LoadBundled imports the bundled catalogue when no codes are loaded yet.
+func (s *service) LoadBundled() (int, error) {
+	count, err := s.repo.Count()
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	file, err := bundled.Open(bundledPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return s.Import(file)
+}
+
+// Search finds codes by code prefix when q looks like a code, e.g. "J0" or
+// "e11.9", and by the words of their title otherwise.
+func (s *service) Search(q string, limit int) ([]Code, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, ErrEmptyQuery
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	query := SearchQuery{Limit: limit}
+	if codePrefixPattern.MatchString(q) && !strings.Contains(q, " ") {
+		query.Prefix = Normalize(q)
+	} else {
+		query.Keyword = strings.ToLower(q)
+	}
+
+	codes, err := s.repo.Search(query)
+	if err != nil {
+		s.logger.Error("failed to search ICD-10 codes",
+			slog.Any("error", err),
+			slog.String("query", q),
+		)
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Lookup returns the catalogue entries of codes in the given order, or
+// ErrUnknownCode naming the first code that is not in the catalogue.
+func (s *service) Lookup(codes []string) ([]Code, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(codes))
+	for _, code := range codes {
+		normalized = append(normalized, Normalize(code))
+	}
+
+	found, err := s.repo.GetByCodes(normalized)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]Code, len(found))
+	for _, code := range found {
+		byCode[code.Code] = code
+	}
+
+	result := make([]Code, 0, len(normalized))
+	for _, code := range normalized {
+		entry, ok := byCode[code]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownCode, code)
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
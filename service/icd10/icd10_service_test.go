@@ -0,0 +1,70 @@
+package icd10_test
+
+import (
+	"Dedenruslan19/med-project/service/icd10"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setup(t *testing.T) (*icd10.MockCodeRepo, icd10.Service) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockRepo := icd10.NewMockCodeRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	return mockRepo, icd10.NewService(logger, mockRepo)
+}
+
+func TestSearch_CodePrefixOrKeyword(t *testing.T) {
+	mockRepo, service := setup(t)
+
+	mockRepo.EXPECT().
+		Search(icd10.SearchQuery{Prefix: "E11.9", Limit: 20}).
+		Return([]icd10.Code{{Code: "E11.9", Title: "Type 2 diabetes mellitus without complications", Category: "E11"}}, nil).
+		Times(1)
+	mockRepo.EXPECT().
+		Search(icd10.SearchQuery{Keyword: "upper respiratory", Limit: 100}).
+		Return(nil, nil).
+		Times(1)
+
+	codes, err := service.Search(" e119 ", 0)
+	assert.NoError(t, err)
+	assert.Len(t, codes, 1)
+
+	_, err = service.Search("Upper Respiratory", 500)
+	assert.NoError(t, err)
+
+	_, err = service.Search("  ", 10)
+	assert.ErrorIs(t, err, icd10.ErrEmptyQuery)
+}
+
+func TestLookup_UnknownCode(t *testing.T) {
+	mockRepo, service := setup(t)
+
+	mockRepo.EXPECT().
+		GetByCodes([]string{"J06.9", "Z99.99"}).
+		Return([]icd10.Code{{Code: "J06.9", Title: "Acute upper respiratory infection, unspecified", Category: "J06"}}, nil).
+		Times(1)
+
+	_, err := service.Lookup([]string{"j06.9", "Z99.99"})
+
+	assert.ErrorIs(t, err, icd10.ErrUnknownCode)
+	assert.Contains(t, err.Error(), "Z99.99")
+}
+
+func TestImport_RejectsInvalidRow(t *testing.T) {
+	mockRepo, service := setup(t)
+
+	mockRepo.EXPECT().Upsert(gomock.Any()).Times(0)
+
+	catalogue := "code,title\nI10,Essential (primary) hypertension\n10X,Not a code\n"
+	_, err := service.Import(strings.NewReader(catalogue))
+
+	assert.ErrorIs(t, err, icd10.ErrInvalidCatalogue)
+	assert.Contains(t, err.Error(), "row 3")
+}
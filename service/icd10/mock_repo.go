@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/icd10/icd10_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/icd10/icd10_repo.go -destination=service/icd10/mock_repo.go -package=icd10
+//
+
+// Package icd10 is a generated GoMock package.
+package icd10
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCodeRepo is a mock of CodeRepo interface.
+type MockCodeRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockCodeRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockCodeRepoMockRecorder is the mock recorder for MockCodeRepo.
+type MockCodeRepoMockRecorder struct {
+	mock *MockCodeRepo
+}
+
+// NewMockCodeRepo creates a new mock instance.
+func NewMockCodeRepo(ctrl *gomock.Controller) *MockCodeRepo {
+	mock := &MockCodeRepo{ctrl: ctrl}
+	mock.recorder = &MockCodeRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCodeRepo) EXPECT() *MockCodeRepoMockRecorder {
+	return m.recorder
+}
+
+// Count mocks base method.
+func (m *MockCodeRepo) Count() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockCodeRepoMockRecorder) Count() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockCodeRepo)(nil).Count))
+}
+
+// GetByCodes mocks base method.
+func (m *MockCodeRepo) GetByCodes(codes []string) ([]Code, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCodes", codes)
+	ret0, _ := ret[0].([]Code)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCodes indicates an expected call of GetByCodes.
+func (mr *MockCodeRepoMockRecorder) GetByCodes(codes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCodes", reflect.TypeOf((*MockCodeRepo)(nil).GetByCodes), codes)
+}
+
+// Search mocks base method.
+func (m *MockCodeRepo) Search(query SearchQuery) ([]Code, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", query)
+	ret0, _ := ret[0].([]Code)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockCodeRepoMockRecorder) Search(query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockCodeRepo)(nil).Search), query)
+}
+
+// Upsert mocks base method.
+func (m *MockCodeRepo) Upsert(codes []Code) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", codes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockCodeRepoMockRecorder) Upsert(codes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockCodeRepo)(nil).Upsert), codes)
+}
@@ -0,0 +1,35 @@
+package idempotency
+
+import "time"
+
+// Record is the stored outcome of a request made with an Idempotency-Key.
+// Completed is false while the first request is still being handled; if it
+// is not completed by LockedUntil, e.g. because the server crashed, the key
+// can be claimed again.
+type Record struct {
+	Principal    string     `json:"principal" gorm:"type:varchar(100);primaryKey"`
+	Key          string     `json:"key" gorm:"column:idempotency_key;type:varchar(255);primaryKey"`
+	Method       string     `json:"method" gorm:"type:varchar(10);not null"`
+	Path         string     `json:"path" gorm:"type:varchar(500);not null"`
+	RequestHash  string     `json:"request_hash" gorm:"type:varchar(64);not null"`
+	Completed    bool       `json:"completed" gorm:"not null;default:false"`
+	StatusCode   int        `json:"status_code"`
+	ContentType  string     `json:"content_type" gorm:"type:varchar(100)"`
+	ResponseBody []byte     `json:"-"`
+	LockedUntil  *time.Time `json:"locked_until"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (Record) TableName() string {
+	return "idempotency_keys"
+}
+
+// Stale reports whether the record no longer holds its key at now: it expired,
+// or the request that reserved it was never completed.
+func (r *Record) Stale(now time.Time) bool {
+	if r.ExpiresAt.Before(now) {
+		return true
+	}
+	return !r.Completed && (r.LockedUntil == nil || r.LockedUntil.Before(now))
+}
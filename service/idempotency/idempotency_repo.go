@@ -0,0 +1,16 @@
+package idempotency
+
+import "time"
+
+type IdempotencyRepo interface {
+	// Reserve inserts the record, or returns ErrKeyExists when the principal
+	// already used the key.
+	Reserve(record *Record) error
+	Get(principal, key string) (*Record, error)
+	Complete(principal, key string, statusCode int, contentType string, body []byte) error
+	Delete(principal, key string) error
+	// DeleteStale deletes the record only while it is still stale at now, so
+	// a key claimed again in the meantime is kept.
+	DeleteStale(principal, key string, now time.Time) error
+	DeleteExpired(now time.Time) (int64, error)
+}
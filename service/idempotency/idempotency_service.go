@@ -0,0 +1,143 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrKeyExists         = errors.New("idempotency key already used")
+	ErrKeyReused         = errors.New("idempotency key was already used for a different request")
+	ErrRequestInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// lease is how long a request may run before its key is considered
+// abandoned, e.g. after a crash.
+const lease = time.Minute
+
+type service struct {
+	repo   IdempotencyRepo
+	ttl    time.Duration
+	logger *slog.Logger
+}
+
+type Service interface {
+	Begin(principal, key, method, path, requestHash string) (*Record, error)
+	Complete(principal, key string, statusCode int, contentType string, body []byte) error
+	Abandon(principal, key string) error
+	Purge() (int64, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// NewService keeps stored responses for ttl.
+func NewService(logger *slog.Logger, repo IdempotencyRepo, ttl time.Duration) Service {
+	return &service{
+		logger: logger,
+		repo:   repo,
+		ttl:    ttl,
+	}
+}
+
+// Begin claims the key for a new request and returns nil, or returns the
+// completed record whose response must be replayed. A key is scoped to its
+// principal and expires after the TTL; a request that is not completed within
+// the lease gives up its key.
+func (s *service) Begin(principal, key, method, path, requestHash string) (*Record, error) {
+	now := time.Now()
+	lockedUntil := now.Add(lease)
+	record := &Record{
+		Principal:   principal,
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: requestHash,
+		LockedUntil: &lockedUntil,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	// a second attempt covers a stale record deleted in between
+	for attempt := 0; attempt < 2; attempt++ {
+		err := s.repo.Reserve(record)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, ErrKeyExists) {
+			s.logger.Error("failed to reserve idempotency key",
+				slog.Any("error", err),
+				slog.String("principal", principal),
+			)
+			return nil, err
+		}
+
+		existing, err := s.repo.Get(principal, key)
+		if err != nil {
+			return nil, err
+		}
+		if existing.Stale(now) {
+			if err := s.repo.DeleteStale(principal, key, now); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if existing.RequestHash != requestHash || existing.Method != method || existing.Path != path {
+			return nil, ErrKeyReused
+		}
+		if !existing.Completed {
+			return nil, ErrRequestInProgress
+		}
+		return existing, nil
+	}
+	return nil, ErrRequestInProgress
+}
+
+func (s *service) Complete(principal, key string, statusCode int, contentType string, body []byte) error {
+	err := s.repo.Complete(principal, key, statusCode, contentType, body)
+	if err != nil {
+		s.logger.Error("failed to store idempotent response",
+			slog.Any("error", err),
+			slog.String("principal", principal),
+		)
+		return err
+	}
+	return nil
+}
+
+// Abandon releases the key of a request that failed, so a retry runs again.
+func (s *service) Abandon(principal, key string) error {
+	err := s.repo.Delete(principal, key)
+	if err != nil {
+		s.logger.Error("failed to release idempotency key",
+			slog.Any("error", err),
+			slog.String("principal", principal),
+		)
+		return err
+	}
+	return nil
+}
+
+func (s *service) Purge() (int64, error) {
+	deleted, err := s.repo.DeleteExpired(time.Now())
+	if err != nil {
+		s.logger.Error("failed to purge idempotency keys", slog.Any("error", err))
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// Run purges expired keys every interval until ctx is cancelled.
+func (s *service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.Purge()
+		}
+	}
+}
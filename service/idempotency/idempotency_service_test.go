@@ -0,0 +1,119 @@
+package idempotency_test
+
+import (
+	"Dedenruslan19/med-project/service/idempotency"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBegin_ReplaysCompletedResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := idempotency.NewMockIdempotencyRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := idempotency.NewService(logger, mockRepo, time.Hour)
+
+	stored := &idempotency.Record{
+		Principal: "user:7", Key: "k1", Method: "POST", Path: "/appointments", RequestHash: "abc",
+		Completed: true, StatusCode: 201, ResponseBody: []byte(`{"id":1}`), ExpiresAt: time.Now().Add(time.Minute),
+	}
+	mockRepo.EXPECT().Reserve(gomock.Any()).Return(idempotency.ErrKeyExists).Times(1)
+	mockRepo.EXPECT().Get("user:7", "k1").Return(stored, nil).Times(1)
+
+	record, err := service.Begin("user:7", "k1", "POST", "/appointments", "abc")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 201, record.StatusCode)
+	assert.Equal(t, `{"id":1}`, string(record.ResponseBody))
+}
+
+func TestBegin_DifferentBodyConflicts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := idempotency.NewMockIdempotencyRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := idempotency.NewService(logger, mockRepo, time.Hour)
+
+	stored := &idempotency.Record{
+		Principal: "user:7", Key: "k1", Method: "POST", Path: "/appointments", RequestHash: "abc",
+		Completed: true, ExpiresAt: time.Now().Add(time.Minute),
+	}
+	mockRepo.EXPECT().Reserve(gomock.Any()).Return(idempotency.ErrKeyExists).Times(1)
+	mockRepo.EXPECT().Get("user:7", "k1").Return(stored, nil).Times(1)
+
+	record, err := service.Begin("user:7", "k1", "POST", "/appointments", "def")
+
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+	assert.Nil(t, record)
+}
+
+func TestBegin_ExpiredKeyIsReused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := idempotency.NewMockIdempotencyRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := idempotency.NewService(logger, mockRepo, time.Hour)
+
+	stored := &idempotency.Record{
+		Principal: "user:7", Key: "k1", Method: "POST", Path: "/appointments", RequestHash: "abc",
+		Completed: true, ExpiresAt: time.Now().Add(-time.Minute),
+	}
+	gomock.InOrder(
+		mockRepo.EXPECT().Reserve(gomock.Any()).Return(idempotency.ErrKeyExists),
+		mockRepo.EXPECT().Get("user:7", "k1").Return(stored, nil),
+		mockRepo.EXPECT().DeleteStale("user:7", "k1", gomock.Any()).Return(nil),
+		mockRepo.EXPECT().Reserve(gomock.Any()).Return(nil),
+	)
+
+	record, err := service.Begin("user:7", "k1", "POST", "/appointments", "def")
+
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestBegin_AbandonedRequestGivesUpItsKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := idempotency.NewMockIdempotencyRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := idempotency.NewService(logger, mockRepo, time.Hour)
+
+	running := time.Now().Add(30 * time.Second)
+	crashed := time.Now().Add(-time.Second)
+	inProgress := &idempotency.Record{
+		Principal: "user:7", Key: "k1", Method: "POST", Path: "/appointments", RequestHash: "abc",
+		LockedUntil: &running, ExpiresAt: time.Now().Add(time.Hour),
+	}
+	abandoned := *inProgress
+	abandoned.LockedUntil = &crashed
+
+	gomock.InOrder(
+		mockRepo.EXPECT().Reserve(gomock.Any()).Return(idempotency.ErrKeyExists),
+		mockRepo.EXPECT().Get("user:7", "k1").Return(inProgress, nil),
+		mockRepo.EXPECT().Reserve(gomock.Any()).Return(idempotency.ErrKeyExists),
+		mockRepo.EXPECT().Get("user:7", "k1").Return(&abandoned, nil),
+		mockRepo.EXPECT().DeleteStale("user:7", "k1", gomock.Any()).Return(nil),
+		mockRepo.EXPECT().
+			Reserve(gomock.Any()).
+			DoAndReturn(func(record *idempotency.Record) error {
+				assert.WithinDuration(t, time.Now().Add(time.Minute), *record.LockedUntil, 5*time.Second)
+				return nil
+			}),
+	)
+
+	_, err := service.Begin("user:7", "k1", "POST", "/appointments", "abc")
+	assert.ErrorIs(t, err, idempotency.ErrRequestInProgress)
+
+	record, err := service.Begin("user:7", "k1", "POST", "/appointments", "abc")
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+}
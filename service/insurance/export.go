@@ -0,0 +1,88 @@
+package insurance
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ClaimBatch is the claim submission file sent to one insurer.
+type ClaimBatch struct {
+	BatchID      string    `json:"batch_id"`
+	InsurerCode  string    `json:"insurer_code"`
+	InsurerName  string    `json:"insurer_name"`
+	GeneratedAt  time.Time `json:"generated_at"`
+	ClaimCount   int       `json:"claim_count"`
+	TotalClaimed float64   `json:"total_claimed"`
+	Claims       []Claim   `json:"claims"`
+}
+
+var batchCSVHeader = []string{
+	"batch_id", "claim_number", "policy_number", "patient_name", "doctor_name",
+	"service_date", "diagnosis_notes", "medications", "billed_amount", "claimed_amount", "status",
+}
+
+// ExportBatch collects the insurer's claims in the given status, submitted
+// ones by default.
+func (s *service) ExportBatch(insurerID int64, status string) (*ClaimBatch, error) {
+	if status == "" {
+		status = ClaimSubmitted
+	}
+
+	insurer, err := s.repo.GetInsurerByID(insurerID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.ListClaims(ClaimFilter{InsurerID: insurerID, Status: status})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	batch := &ClaimBatch{
+		BatchID:     fmt.Sprintf("%s-%s", insurer.Code, now.Format("20060102150405")),
+		InsurerCode: insurer.Code,
+		InsurerName: insurer.Name,
+		GeneratedAt: now,
+		ClaimCount:  len(claims),
+		Claims:      claims,
+	}
+	for i := range claims {
+		batch.TotalClaimed += claims[i].ClaimedAmount
+	}
+	batch.TotalClaimed = round(batch.TotalClaimed)
+	return batch, nil
+}
+
+// WriteCSV writes the batch with one row per claim.
+func (b *ClaimBatch) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(batchCSVHeader); err != nil {
+		return err
+	}
+
+	for _, c := range b.Claims {
+		row := []string{
+			b.BatchID,
+			c.ClaimNumber,
+			c.PolicyNumber,
+			c.PatientName,
+			c.DoctorName,
+			c.ServiceDate.Format("2006-01-02"),
+			c.DiagnosisNotes,
+			c.Medications,
+			strconv.FormatFloat(c.BilledAmount, 'f', 2, 64),
+			strconv.FormatFloat(c.ClaimedAmount, 'f', 2, 64),
+			c.Status,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
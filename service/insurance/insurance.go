@@ -0,0 +1,95 @@
+package insurance
+
+import "time"
+
+const (
+	ClaimSubmitted         = "submitted"
+	ClaimApproved          = "approved"
+	ClaimPartiallyApproved = "partially_approved"
+	ClaimRejected          = "rejected"
+)
+
+type Insurer struct {
+	ID           int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Code         string    `json:"code" gorm:"type:varchar(50);not null;unique"`
+	Name         string    `json:"name" gorm:"type:varchar(255);not null"`
+	ContactEmail string    `json:"contact_email" gorm:"type:varchar(255)"`
+	Active       bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt    time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// Policy is a patient's cover with an insurer. A zero limit means unlimited.
+type Policy struct {
+	ID            int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID        int64      `json:"user_id" gorm:"not null;index"`
+	InsurerID     int64      `json:"insurer_id" gorm:"not null;index"`
+	PolicyNumber  string     `json:"policy_number" gorm:"type:varchar(100);not null"`
+	CoverageRate  float64    `json:"coverage_rate" gorm:"type:decimal(5,4);not null"`
+	PerClaimLimit float64    `json:"per_claim_limit" gorm:"type:decimal(12,2);not null;default:0"`
+	AnnualLimit   float64    `json:"annual_limit" gorm:"type:decimal(12,2);not null;default:0"`
+	ValidFrom     *time.Time `json:"valid_from"`
+	ValidUntil    *time.Time `json:"valid_until"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (Policy) TableName() string {
+	return "insurance_policies"
+}
+
+// Covers reports whether the policy is in force at t.
+func (p *Policy) Covers(t time.Time) bool {
+	if p.ValidFrom != nil && t.Before(*p.ValidFrom) {
+		return false
+	}
+	if p.ValidUntil != nil && t.After(*p.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// Claim asks the insurer to pay its share of one billing. The diagnosis and
+// service details are copied when the claim is generated so the exported
+// batch does not change afterwards.
+type Claim struct {
+	ID             int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ClaimNumber    string     `json:"claim_number" gorm:"type:varchar(100);not null;unique"`
+	BillingID      int64      `json:"billing_id" gorm:"not null;unique"`
+	PolicyID       int64      `json:"policy_id" gorm:"not null;index"`
+	InsurerID      int64      `json:"insurer_id" gorm:"not null;index"`
+	UserID         int64      `json:"user_id" gorm:"not null;index"`
+	DoctorID       int64      `json:"doctor_id" gorm:"not null"`
+	DiagnosisID    int64      `json:"diagnosis_id" gorm:"not null"`
+	PolicyNumber   string     `json:"policy_number" gorm:"type:varchar(100);not null"`
+	PatientName    string     `json:"patient_name" gorm:"type:varchar(255)"`
+	DoctorName     string     `json:"doctor_name" gorm:"type:varchar(255)"`
+	ServiceDate    time.Time  `json:"service_date"`
+	DiagnosisNotes string     `json:"diagnosis_notes" gorm:"type:text;serializer:encrypted"`
+	Medications    string     `json:"medications" gorm:"type:text;serializer:encrypted"`
+	BilledAmount   float64    `json:"billed_amount" gorm:"type:decimal(12,2);not null"`
+	ClaimedAmount  float64    `json:"claimed_amount" gorm:"type:decimal(12,2);not null"`
+	ApprovedAmount float64    `json:"approved_amount" gorm:"type:decimal(12,2);not null;default:0"`
+	Status         string     `json:"status" gorm:"type:varchar(30);not null;default:'submitted'"`
+	DecisionReason string     `json:"decision_reason,omitempty" gorm:"type:text"`
+	SubmittedAt    time.Time  `json:"submitted_at"`
+	DecidedAt      *time.Time `json:"decided_at"`
+}
+
+func (Claim) TableName() string {
+	return "insurance_claims"
+}
+
+// Committed is the amount the claim holds against the policy limits.
+func (c *Claim) Committed() float64 {
+	switch c.Status {
+	case ClaimSubmitted:
+		return c.ClaimedAmount
+	case ClaimRejected:
+		return 0
+	}
+	return c.ApprovedAmount
+}
+
+type ClaimFilter struct {
+	InsurerID int64
+	Status    string
+}
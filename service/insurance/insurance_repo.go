@@ -0,0 +1,22 @@
+package insurance
+
+import "time"
+
+type InsuranceRepo interface {
+	CreateInsurer(insurer *Insurer) (int64, error)
+	GetInsurerByID(id int64) (*Insurer, error)
+	ListInsurers() ([]Insurer, error)
+
+	CreatePolicy(policy *Policy) (int64, error)
+	GetPolicyByID(id int64) (*Policy, error)
+	ListPoliciesByUserID(userID int64) ([]Policy, error)
+
+	CreateClaim(claim *Claim) (int64, error)
+	GetClaimByID(id int64) (*Claim, error)
+	GetClaimByBillingID(billingID int64) (*Claim, error)
+	ListClaims(filter ClaimFilter) ([]Claim, error)
+	ListClaimsByPolicySince(policyID int64, since time.Time) ([]Claim, error)
+	// DecideClaim stores the decision of a submitted claim and moves its
+	// approved amount onto the billing in the same transaction.
+	DecideClaim(claim *Claim) error
+}
@@ -0,0 +1,302 @@
+package insurance
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/doctors"
+	"Dedenruslan19/med-project/service/users"
+)
+
+var (
+	ErrInsurerInactive       = errors.New("insurer is not active")
+	ErrInvalidCoverageRate   = errors.New("coverage rate must be greater than 0 and at most 1")
+	ErrPolicyNotOwned        = errors.New("policy does not belong to the patient of this billing")
+	ErrPolicyNotInForce      = errors.New("policy is not in force on the service date")
+	ErrDiagnosisRequired     = errors.New("billing has no completed diagnosis")
+	ErrClaimExists           = errors.New("billing has already been claimed")
+	ErrLimitExhausted        = errors.New("policy limit is exhausted")
+	ErrClaimDecided          = errors.New("claim has already been decided")
+	ErrInvalidDecision       = errors.New("invalid claim decision")
+	ErrInvalidApprovedAmount = errors.New("approved amount does not match the decision")
+)
+
+type service struct {
+	repo               InsuranceRepo
+	billingService     billings.Service
+	appointmentService appointments.Service
+	diagnoseService    diagnoses.Service
+	userService        users.Service
+	doctorService      doctors.Service
+	logger             *slog.Logger
+	now                func() time.Time
+}
+
+type Service interface {
+	CreateInsurer(insurer *Insurer) (int64, error)
+	ListInsurers() ([]Insurer, error)
+	AddPolicy(policy *Policy) (int64, error)
+	GetPolicies(userID int64) ([]Policy, error)
+	GenerateClaim(billingID, policyID int64) (*Claim, error)
+	GetClaimByID(id int64) (*Claim, error)
+	ListClaims(filter ClaimFilter) ([]Claim, error)
+	DecideClaim(id int64, status string, approvedAmount float64, reason string) (*Claim, error)
+	ExportBatch(insurerID int64, status string) (*ClaimBatch, error)
+}
+
+func NewService(
+	logger *slog.Logger,
+	repo InsuranceRepo,
+	billingService billings.Service,
+	appointmentService appointments.Service,
+	diagnoseService diagnoses.Service,
+	userService users.Service,
+	doctorService doctors.Service,
+) Service {
+	return &service{
+		logger:             logger,
+		repo:               repo,
+		billingService:     billingService,
+		appointmentService: appointmentService,
+		diagnoseService:    diagnoseService,
+		userService:        userService,
+		doctorService:      doctorService,
+		now:                time.Now,
+	}
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func (s *service) CreateInsurer(insurer *Insurer) (int64, error) {
+	insurer.Code = strings.ToUpper(strings.TrimSpace(insurer.Code))
+	id, err := s.repo.CreateInsurer(insurer)
+	if err != nil {
+		s.logger.Error("failed to create insurer",
+			slog.Any("error", err),
+			slog.String("code", insurer.Code),
+		)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *service) ListInsurers() ([]Insurer, error) {
+	insurers, err := s.repo.ListInsurers()
+	if err != nil {
+		s.logger.Error("failed to list insurers", slog.Any("error", err))
+		return nil, err
+	}
+	return insurers, nil
+}
+
+func (s *service) AddPolicy(policy *Policy) (int64, error) {
+	if policy.CoverageRate <= 0 || policy.CoverageRate > 1 {
+		return 0, ErrInvalidCoverageRate
+	}
+
+	insurer, err := s.repo.GetInsurerByID(policy.InsurerID)
+	if err != nil {
+		return 0, err
+	}
+	if !insurer.Active {
+		return 0, ErrInsurerInactive
+	}
+
+	id, err := s.repo.CreatePolicy(policy)
+	if err != nil {
+		s.logger.Error("failed to create insurance policy",
+			slog.Any("error", err),
+			slog.Int64("user_id", policy.UserID),
+		)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *service) GetPolicies(userID int64) ([]Policy, error) {
+	policies, err := s.repo.ListPoliciesByUserID(userID)
+	if err != nil {
+		s.logger.Error("failed to get insurance policies",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+		)
+		return nil, err
+	}
+	return policies, nil
+}
+
+// GenerateClaim builds a claim for the insurer's share of a diagnosed billing.
+// The claimed amount is the policy's coverage of the billing total, capped by
+// the per-claim limit and by what is left of the annual limit in the year of
+// the service.
+func (s *service) GenerateClaim(billingID, policyID int64) (*Claim, error) {
+	if _, err := s.repo.GetClaimByBillingID(billingID); err == nil {
+		return nil, ErrClaimExists
+	}
+
+	billing, err := s.billingService.GetByID(billingID)
+	if err != nil {
+		return nil, err
+	}
+
+	appointment, err := s.appointmentService.GetByID(billing.AppointmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.repo.GetPolicyByID(policyID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.UserID != appointment.UserID {
+		return nil, ErrPolicyNotOwned
+	}
+	if !policy.Covers(appointment.AppointmentDate) {
+		return nil, ErrPolicyNotInForce
+	}
+
+	insurer, err := s.repo.GetInsurerByID(policy.InsurerID)
+	if err != nil {
+		return nil, err
+	}
+	if !insurer.Active {
+		return nil, ErrInsurerInactive
+	}
+
+	diagnosis, err := s.diagnoseService.GetByAppointmentID(appointment.ID)
+	if err != nil || !diagnosis.Signed() {
+		return nil, ErrDiagnosisRequired
+	}
+
+	claimed := round(billing.TotalAmount * policy.CoverageRate)
+	if policy.PerClaimLimit > 0 && claimed > policy.PerClaimLimit {
+		claimed = policy.PerClaimLimit
+	}
+	if policy.AnnualLimit > 0 {
+		yearStart := time.Date(appointment.AppointmentDate.Year(), time.January, 1, 0, 0, 0, 0, appointment.AppointmentDate.Location())
+		previous, err := s.repo.ListClaimsByPolicySince(policy.ID, yearStart)
+		if err != nil {
+			return nil, err
+		}
+		remaining := policy.AnnualLimit
+		for i := range previous {
+			remaining -= previous[i].Committed()
+		}
+		if claimed > remaining {
+			claimed = round(math.Max(remaining, 0))
+		}
+	}
+	if claimed <= 0 {
+		return nil, ErrLimitExhausted
+	}
+
+	claim := &Claim{
+		ClaimNumber:    fmt.Sprintf("CLM-%d-%06d", appointment.AppointmentDate.Year(), billingID),
+		BillingID:      billingID,
+		PolicyID:       policy.ID,
+		InsurerID:      insurer.ID,
+		UserID:         appointment.UserID,
+		DoctorID:       appointment.DoctorID,
+		DiagnosisID:    diagnosis.ID,
+		PolicyNumber:   policy.PolicyNumber,
+		ServiceDate:    appointment.AppointmentDate,
+		DiagnosisNotes: diagnosis.Notes,
+		Medications:    diagnosis.PrescribedMedications,
+		BilledAmount:   billing.TotalAmount,
+		ClaimedAmount:  claimed,
+		Status:         ClaimSubmitted,
+		SubmittedAt:    s.now(),
+	}
+	if patient, err := s.userService.GetUserByID(appointment.UserID); err == nil {
+		claim.PatientName = patient.FullName
+	}
+	if doctor, err := s.doctorService.GetByID(appointment.DoctorID); err == nil {
+		claim.DoctorName = doctor.FullName
+	}
+
+	id, err := s.repo.CreateClaim(claim)
+	if err != nil {
+		s.logger.Error("failed to create insurance claim",
+			slog.Any("error", err),
+			slog.Int64("billing_id", billingID),
+		)
+		return nil, err
+	}
+	claim.ID = id
+	return claim, nil
+}
+
+func (s *service) GetClaimByID(id int64) (*Claim, error) {
+	claim, err := s.repo.GetClaimByID(id)
+	if err != nil {
+		s.logger.Error("failed to get insurance claim",
+			slog.Any("error", err),
+			slog.Int64("claim_id", id),
+		)
+		return nil, err
+	}
+	return claim, nil
+}
+
+func (s *service) ListClaims(filter ClaimFilter) ([]Claim, error) {
+	claims, err := s.repo.ListClaims(filter)
+	if err != nil {
+		s.logger.Error("failed to list insurance claims", slog.Any("error", err))
+		return nil, err
+	}
+	return claims, nil
+}
+
+// DecideClaim records the insurer's answer and moves the approved amount onto
+// the billing, so the patient owes the remainder. An approval without an
+// amount approves the full claim; a rejection keeps the billing as priced.
+// The billing must not be paid or invoiced yet.
+func (s *service) DecideClaim(id int64, status string, approvedAmount float64, reason string) (*Claim, error) {
+	claim, err := s.repo.GetClaimByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Status != ClaimSubmitted {
+		return nil, ErrClaimDecided
+	}
+
+	switch status {
+	case ClaimApproved:
+		if approvedAmount == 0 {
+			approvedAmount = claim.ClaimedAmount
+		}
+		if approvedAmount != claim.ClaimedAmount {
+			return nil, ErrInvalidApprovedAmount
+		}
+	case ClaimPartiallyApproved:
+		if approvedAmount <= 0 || approvedAmount >= claim.ClaimedAmount {
+			return nil, ErrInvalidApprovedAmount
+		}
+	case ClaimRejected:
+		if approvedAmount != 0 {
+			return nil, ErrInvalidApprovedAmount
+		}
+	default:
+		return nil, ErrInvalidDecision
+	}
+
+	now := s.now()
+	claim.Status = status
+	claim.ApprovedAmount = round(approvedAmount)
+	claim.DecisionReason = reason
+	claim.DecidedAt = &now
+
+	if err := s.repo.DecideClaim(claim); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
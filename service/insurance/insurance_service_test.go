@@ -0,0 +1,152 @@
+package insurance_test
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/doctors"
+	"Dedenruslan19/med-project/service/insurance"
+	"Dedenruslan19/med-project/service/users"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type fakeUserService struct {
+	users.Service
+}
+
+func (fakeUserService) GetUserByID(userID int64) (users.User, error) {
+	return users.User{ID: userID, FullName: "Jane Patient"}, nil
+}
+
+func TestGenerateClaim_CappedByAnnualLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := insurance.NewMockInsuranceRepo(ctrl)
+	mockBillingRepo := billings.NewMockBillingRepo(ctrl)
+	mockAppointmentRepo := appointments.NewMockAppointmentRepo(ctrl)
+	mockDiagnoseRepo := diagnoses.NewMockDiagnoseRepo(ctrl)
+	mockDoctorRepo := doctors.NewMockDoctorRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	appointmentService := appointments.NewService(logger, mockAppointmentRepo, nil, appointments.Video{})
+	service := insurance.NewService(logger, mockRepo,
+		billings.NewService(logger, mockBillingRepo, appointmentService, nil, nil, nil),
+		appointmentService,
+		diagnoses.NewService(logger, mockDiagnoseRepo, appointmentService, nil, nil),
+		fakeUserService{},
+		doctors.NewService(logger, mockDoctorRepo),
+	)
+
+	serviceDate := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+
+	mockRepo.EXPECT().GetClaimByBillingID(int64(10)).Return(nil, errors.New("record not found")).Times(1)
+	mockBillingRepo.EXPECT().GetByID(int64(10)).Return(&billings.Billing{ID: 10, AppointmentID: 3, TotalAmount: 300000}, nil).Times(1)
+	mockAppointmentRepo.EXPECT().GetByID(int64(3)).Return(&appointments.Appointment{ID: 3, UserID: 1, DoctorID: 2, AppointmentDate: serviceDate}, nil).Times(1)
+	mockRepo.EXPECT().GetPolicyByID(int64(5)).Return(&insurance.Policy{ID: 5, UserID: 1, InsurerID: 7, PolicyNumber: "POL-1", CoverageRate: 0.8, AnnualLimit: 450000}, nil).Times(1)
+	mockRepo.EXPECT().GetInsurerByID(int64(7)).Return(&insurance.Insurer{ID: 7, Code: "ACME", Active: true}, nil).Times(1)
+	mockDiagnoseRepo.EXPECT().GetByAppointmentID(int64(3)).Return(&diagnoses.Diagnose{ID: 4, AppointmentID: 3, Notes: "Flu", Status: diagnoses.StatusSigned}, nil).Times(1)
+	mockRepo.EXPECT().
+		ListClaimsByPolicySince(int64(5), time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)).
+		Return([]insurance.Claim{
+			{ClaimedAmount: 200000, ApprovedAmount: 150000, Status: insurance.ClaimPartiallyApproved},
+			{ClaimedAmount: 100000, Status: insurance.ClaimSubmitted},
+			{ClaimedAmount: 90000, Status: insurance.ClaimRejected},
+		}, nil).
+		Times(1)
+	mockDoctorRepo.EXPECT().GetByID(int64(2)).Return(nil, errors.New("record not found")).Times(1)
+	mockRepo.EXPECT().CreateClaim(gomock.Any()).Return(int64(1), nil).Times(1)
+
+	claim, err := service.GenerateClaim(10, 5)
+
+	// 80% of 300000 is 240000, but only 450000 - 150000 - 100000 is left
+	assert.NoError(t, err)
+	assert.Equal(t, 200000.0, claim.ClaimedAmount)
+	assert.Equal(t, "CLM-2026-000010", claim.ClaimNumber)
+	assert.Equal(t, "Jane Patient", claim.PatientName)
+	assert.Equal(t, insurance.ClaimSubmitted, claim.Status)
+}
+
+func TestGenerateClaim_UnsignedDiagnosis(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := insurance.NewMockInsuranceRepo(ctrl)
+	mockBillingRepo := billings.NewMockBillingRepo(ctrl)
+	mockAppointmentRepo := appointments.NewMockAppointmentRepo(ctrl)
+	mockDiagnoseRepo := diagnoses.NewMockDiagnoseRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	appointmentService := appointments.NewService(logger, mockAppointmentRepo, nil, appointments.Video{})
+	service := insurance.NewService(logger, mockRepo,
+		billings.NewService(logger, mockBillingRepo, appointmentService, nil, nil, nil),
+		appointmentService,
+		diagnoses.NewService(logger, mockDiagnoseRepo, appointmentService, nil, nil),
+		fakeUserService{},
+		nil,
+	)
+
+	mockRepo.EXPECT().GetClaimByBillingID(int64(10)).Return(nil, errors.New("record not found")).Times(1)
+	mockBillingRepo.EXPECT().GetByID(int64(10)).Return(&billings.Billing{ID: 10, AppointmentID: 3, TotalAmount: 300000}, nil).Times(1)
+	mockAppointmentRepo.EXPECT().GetByID(int64(3)).Return(&appointments.Appointment{ID: 3, UserID: 1, DoctorID: 2, AppointmentDate: time.Now()}, nil).Times(1)
+	mockRepo.EXPECT().GetPolicyByID(int64(5)).Return(&insurance.Policy{ID: 5, UserID: 1, InsurerID: 7, CoverageRate: 0.8}, nil).Times(1)
+	mockRepo.EXPECT().GetInsurerByID(int64(7)).Return(&insurance.Insurer{ID: 7, Active: true}, nil).Times(1)
+	mockDiagnoseRepo.EXPECT().GetByAppointmentID(int64(3)).Return(&diagnoses.Diagnose{ID: 4, AppointmentID: 3, Status: diagnoses.StatusDraft}, nil).Times(1)
+	mockRepo.EXPECT().CreateClaim(gomock.Any()).Times(0)
+
+	_, err := service.GenerateClaim(10, 5)
+
+	assert.ErrorIs(t, err, insurance.ErrDiagnosisRequired)
+}
+
+func TestDecideClaim_PartialApproval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := insurance.NewMockInsuranceRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := insurance.NewService(logger, mockRepo, nil, nil, nil, fakeUserService{}, nil)
+
+	mockRepo.EXPECT().
+		GetClaimByID(int64(1)).
+		Return(&insurance.Claim{ID: 1, BillingID: 10, ClaimedAmount: 240000, Status: insurance.ClaimSubmitted}, nil).
+		Times(1)
+	mockRepo.EXPECT().
+		DecideClaim(gomock.Any()).
+		DoAndReturn(func(claim *insurance.Claim) error {
+			assert.Equal(t, insurance.ClaimPartiallyApproved, claim.Status)
+			assert.Equal(t, 200000.0, claim.ApprovedAmount)
+			return nil
+		}).
+		Times(1)
+
+	claim, err := service.DecideClaim(1, insurance.ClaimPartiallyApproved, 200000, "co-payment for medication")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200000.0, claim.ApprovedAmount)
+	assert.NotNil(t, claim.DecidedAt)
+}
+
+func TestDecideClaim_AlreadyDecided(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := insurance.NewMockInsuranceRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := insurance.NewService(logger, mockRepo, nil, nil, nil, fakeUserService{}, nil)
+
+	mockRepo.EXPECT().
+		GetClaimByID(int64(2)).
+		Return(&insurance.Claim{ID: 2, BillingID: 11, ClaimedAmount: 100000, Status: insurance.ClaimRejected}, nil).
+		Times(1)
+	mockRepo.EXPECT().DecideClaim(gomock.Any()).Times(0)
+
+	_, err := service.DecideClaim(2, insurance.ClaimApproved, 0, "")
+
+	assert.ErrorIs(t, err, insurance.ErrClaimDecided)
+}
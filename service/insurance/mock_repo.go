@@ -0,0 +1,220 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/insurance/insurance_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/insurance/insurance_repo.go -destination=service/insurance/mock_repo.go -package=insurance
+//
+
+// Package insurance is a generated GoMock package.
+package insurance
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInsuranceRepo is a mock of InsuranceRepo interface.
+type MockInsuranceRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockInsuranceRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockInsuranceRepoMockRecorder is the mock recorder for MockInsuranceRepo.
+type MockInsuranceRepoMockRecorder struct {
+	mock *MockInsuranceRepo
+}
+
+// NewMockInsuranceRepo creates a new mock instance.
+func NewMockInsuranceRepo(ctrl *gomock.Controller) *MockInsuranceRepo {
+	mock := &MockInsuranceRepo{ctrl: ctrl}
+	mock.recorder = &MockInsuranceRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInsuranceRepo) EXPECT() *MockInsuranceRepoMockRecorder {
+	return m.recorder
+}
+
+// CreateClaim mocks base method.
+func (m *MockInsuranceRepo) CreateClaim(claim *Claim) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClaim", claim)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateClaim indicates an expected call of CreateClaim.
+func (mr *MockInsuranceRepoMockRecorder) CreateClaim(claim any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClaim", reflect.TypeOf((*MockInsuranceRepo)(nil).CreateClaim), claim)
+}
+
+// CreateInsurer mocks base method.
+func (m *MockInsuranceRepo) CreateInsurer(insurer *Insurer) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInsurer", insurer)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInsurer indicates an expected call of CreateInsurer.
+func (mr *MockInsuranceRepoMockRecorder) CreateInsurer(insurer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInsurer", reflect.TypeOf((*MockInsuranceRepo)(nil).CreateInsurer), insurer)
+}
+
+// CreatePolicy mocks base method.
+func (m *MockInsuranceRepo) CreatePolicy(policy *Policy) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePolicy", policy)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePolicy indicates an expected call of CreatePolicy.
+func (mr *MockInsuranceRepoMockRecorder) CreatePolicy(policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePolicy", reflect.TypeOf((*MockInsuranceRepo)(nil).CreatePolicy), policy)
+}
+
+// DecideClaim mocks base method.
+func (m *MockInsuranceRepo) DecideClaim(claim *Claim) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecideClaim", claim)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecideClaim indicates an expected call of DecideClaim.
+func (mr *MockInsuranceRepoMockRecorder) DecideClaim(claim any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecideClaim", reflect.TypeOf((*MockInsuranceRepo)(nil).DecideClaim), claim)
+}
+
+// GetClaimByBillingID mocks base method.
+func (m *MockInsuranceRepo) GetClaimByBillingID(billingID int64) (*Claim, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClaimByBillingID", billingID)
+	ret0, _ := ret[0].(*Claim)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClaimByBillingID indicates an expected call of GetClaimByBillingID.
+func (mr *MockInsuranceRepoMockRecorder) GetClaimByBillingID(billingID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClaimByBillingID", reflect.TypeOf((*MockInsuranceRepo)(nil).GetClaimByBillingID), billingID)
+}
+
+// GetClaimByID mocks base method.
+func (m *MockInsuranceRepo) GetClaimByID(id int64) (*Claim, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClaimByID", id)
+	ret0, _ := ret[0].(*Claim)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClaimByID indicates an expected call of GetClaimByID.
+func (mr *MockInsuranceRepoMockRecorder) GetClaimByID(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClaimByID", reflect.TypeOf((*MockInsuranceRepo)(nil).GetClaimByID), id)
+}
+
+// GetInsurerByID mocks base method.
+func (m *MockInsuranceRepo) GetInsurerByID(id int64) (*Insurer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInsurerByID", id)
+	ret0, _ := ret[0].(*Insurer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInsurerByID indicates an expected call of GetInsurerByID.
+func (mr *MockInsuranceRepoMockRecorder) GetInsurerByID(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInsurerByID", reflect.TypeOf((*MockInsuranceRepo)(nil).GetInsurerByID), id)
+}
+
+// GetPolicyByID mocks base method.
+func (m *MockInsuranceRepo) GetPolicyByID(id int64) (*Policy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPolicyByID", id)
+	ret0, _ := ret[0].(*Policy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPolicyByID indicates an expected call of GetPolicyByID.
+func (mr *MockInsuranceRepoMockRecorder) GetPolicyByID(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPolicyByID", reflect.TypeOf((*MockInsuranceRepo)(nil).GetPolicyByID), id)
+}
+
+// ListClaims mocks base method.
+func (m *MockInsuranceRepo) ListClaims(filter ClaimFilter) ([]Claim, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClaims", filter)
+	ret0, _ := ret[0].([]Claim)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClaims indicates an expected call of ListClaims.
+func (mr *MockInsuranceRepoMockRecorder) ListClaims(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClaims", reflect.TypeOf((*MockInsuranceRepo)(nil).ListClaims), filter)
+}
+
+// ListClaimsByPolicySince mocks base method.
+func (m *MockInsuranceRepo) ListClaimsByPolicySince(policyID int64, since time.Time) ([]Claim, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClaimsByPolicySince", policyID, since)
+	ret0, _ := ret[0].([]Claim)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClaimsByPolicySince indicates an expected call of ListClaimsByPolicySince.
+func (mr *MockInsuranceRepoMockRecorder) ListClaimsByPolicySince(policyID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClaimsByPolicySince", reflect.TypeOf((*MockInsuranceRepo)(nil).ListClaimsByPolicySince), policyID, since)
+}
+
+// ListInsurers mocks base method.
+func (m *MockInsuranceRepo) ListInsurers() ([]Insurer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInsurers")
+	ret0, _ := ret[0].([]Insurer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInsurers indicates an expected call of ListInsurers.
+func (mr *MockInsuranceRepoMockRecorder) ListInsurers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInsurers", reflect.TypeOf((*MockInsuranceRepo)(nil).ListInsurers))
+}
+
+// ListPoliciesByUserID mocks base method.
+func (m *MockInsuranceRepo) ListPoliciesByUserID(userID int64) ([]Policy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPoliciesByUserID", userID)
+	ret0, _ := ret[0].([]Policy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPoliciesByUserID indicates an expected call of ListPoliciesByUserID.
+func (mr *MockInsuranceRepoMockRecorder) ListPoliciesByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPoliciesByUserID", reflect.TypeOf((*MockInsuranceRepo)(nil).ListPoliciesByUserID), userID)
+}
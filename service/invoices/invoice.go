@@ -1,15 +1,81 @@
 package invoices
 
-import "time"
+import (
+	"Dedenruslan19/med-project/service/pricing"
+	"math"
+	"time"
+)
 
 type Invoice struct {
-	ID              int64      `json:"id" gorm:"primaryKey;autoIncrement"`
-	BillingID       int64      `json:"billing_id" gorm:"not null;unique;index"`
-	InvoiceNumber   string     `json:"invoice_number" gorm:"type:varchar(100);not null;unique"`
-	ConsultationFee float64    `json:"consultation_fee" gorm:"type:decimal(10,2);not null;default:200000"`
-	MedicationFee   float64    `json:"medication_fee" gorm:"type:decimal(10,2);not null;default:0"`
-	TotalAmount     float64    `json:"total_amount" gorm:"type:decimal(10,2);not null"`
-	SentToEmail     string     `json:"sent_to_email" gorm:"type:varchar(255);not null"`
-	SentAt          *time.Time `json:"sent_at"`
-	CreatedAt       time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	ID               int64              `json:"id" gorm:"primaryKey;autoIncrement"`
+	BillingID        int64              `json:"billing_id" gorm:"not null;unique;index"`
+	InvoiceNumber    string             `json:"invoice_number" gorm:"type:varchar(100);not null;unique"`
+	ConsultationFee  float64            `json:"consultation_fee" gorm:"type:decimal(10,2);not null;default:200000"`
+	MedicationFee    float64            `json:"medication_fee" gorm:"type:decimal(10,2);not null;default:0"`
+	DiscountAmount   float64            `json:"discount_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	TaxAmount        float64            `json:"tax_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	TotalAmount      float64            `json:"total_amount" gorm:"type:decimal(10,2);not null"`
+	InsuranceAmount  float64            `json:"insurance_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	PatientAmount    float64            `json:"patient_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	PricingBreakdown *pricing.Breakdown `json:"pricing_breakdown,omitempty" gorm:"type:text;serializer:json"`
+	SentToEmail      string             `json:"sent_to_email" gorm:"type:varchar(255);not null"`
+	SentAt           *time.Time         `json:"sent_at"`
+	CreatedAt        time.Time          `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// EventData is the payload of invoice events.
+type EventData struct {
+	InvoiceID     int64      `json:"invoice_id"`
+	BillingID     int64      `json:"billing_id"`
+	InvoiceNumber string     `json:"invoice_number"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+// CreditNote reduces what was invoiced, e.g. when a payment is refunded. The
+// tax share is kept so the VAT can be reversed in the books.
+type CreditNote struct {
+	ID               int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	InvoiceID        int64     `json:"invoice_id" gorm:"not null;index"`
+	BillingID        int64     `json:"billing_id" gorm:"not null;index"`
+	CreditNoteNumber string    `json:"credit_note_number" gorm:"type:varchar(100);not null;unique"`
+	Amount           float64   `json:"amount" gorm:"type:decimal(10,2);not null"`
+	TaxAmount        float64   `json:"tax_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	Reason           string    `json:"reason" gorm:"type:text"`
+	CreatedAt        time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// NewCreditNote credits amount of the invoice. Credits of one invoice,
+// including the previous ones, never exceed its total; the tax share follows
+// the invoice.
+func NewCreditNote(invoice *Invoice, previous []CreditNote, amount float64, reason string) (*CreditNote, error) {
+	credited := 0.0
+	for _, note := range previous {
+		credited += note.Amount
+	}
+	if amount <= 0 || amount > invoice.TotalAmount-credited+0.005 {
+		return nil, ErrInvalidCreditAmount
+	}
+
+	note := &CreditNote{
+		InvoiceID: invoice.ID,
+		BillingID: invoice.BillingID,
+		Amount:    amount,
+		Reason:    reason,
+	}
+	if invoice.TotalAmount > 0 {
+		note.TaxAmount = math.Round(amount*invoice.TaxAmount/invoice.TotalAmount*100) / 100
+	}
+	return note, nil
+}
+
+// Sequence is the counter behind one invoice number series in a fiscal year.
+type Sequence struct {
+	Series     string    `json:"series" gorm:"type:varchar(50);primaryKey"`
+	FiscalYear int       `json:"fiscal_year" gorm:"primaryKey;autoIncrement:false"`
+	LastValue  int64     `json:"last_value" gorm:"not null;default:0"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Sequence) TableName() string {
+	return "invoice_sequences"
 }
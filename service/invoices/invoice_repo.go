@@ -1,9 +1,17 @@
 package invoices
 
+import "Dedenruslan19/med-project/service/outbox"
+
 type InvoiceRepo interface {
-	Create(invoice *Invoice) (int64, error)
+	Create(invoice *Invoice, numbering Numbering) (int64, error)
+	EnqueueMessages(invoice *Invoice, messages []*outbox.Message) error
 	GetByID(id int64) (*Invoice, error)
 	GetByBillingID(billingID int64) (*Invoice, error)
+	GetByUserID(userID int64) ([]Invoice, error)
+	GetByIDAndUserID(id, userID int64) (*Invoice, error)
+	// GetOwnerID returns the patient the invoice was issued to.
+	GetOwnerID(id int64) (int64, error)
+	ListCreditNotesByInvoiceID(invoiceID int64) ([]CreditNote, error)
 	UpdateSentAt(id int64) error
 	SendInvoiceEmail(id int64, email string) error
 }
@@ -2,46 +2,80 @@ package invoices
 
 import (
 	"Dedenruslan19/med-project/repository/notification"
+	"Dedenruslan19/med-project/repository/pubsub"
+	"Dedenruslan19/med-project/service/outbox"
+	"errors"
 	"fmt"
 	"log/slog"
-	"time"
+	"strings"
+)
+
+const (
+	AggregateType = "invoice"
+
+	TopicInvoiceEmail = "invoice.email"
+
+	EventSent = "invoice.sent"
+)
+
+var (
+	ErrEmailSenderNotConfigured = errors.New("email sender not configured")
+	ErrInvoiceExists            = errors.New("invoice already exists for this billing")
+	ErrInvoiceNotFound          = errors.New("invoice not found")
+	ErrInvalidCreditAmount      = errors.New("credit amount must be positive and within the uncredited invoice total")
 )
 
 type service struct {
 	repo        InvoiceRepo
 	logger      *slog.Logger
-	emailSender *notification.SMTPSender
+	emailSender notification.Notifier
+	numbering   Numbering
+	publisher   pubsub.Publisher
 }
 
 type Service interface {
-	CreateInvoice(billingID int64, consultationFee, medicationFee, totalAmount float64, email string) (*Invoice, error)
+	CreateInvoice(draft *Invoice) (*Invoice, error)
 	GetByID(id int64) (*Invoice, error)
 	GetByBillingID(billingID int64) (*Invoice, error)
+	GetByUserID(userID int64) ([]Invoice, error)
+	GetByIDForUser(id, userID int64) (*Invoice, error)
+	CreditNoteNumbering() Numbering
+	GetCreditNotes(invoiceID int64) ([]CreditNote, error)
 	MarkAsSent(id int64) error
 	SendInvoice(billingID int64, email string) (*Invoice, error)
+	DeliverInvoiceEmail(msg *outbox.Message) error
 }
 
-func NewService(logger *slog.Logger, repo InvoiceRepo, emailSender *notification.SMTPSender) Service {
+// NewService publishes invoice events to the patient through publisher; nil
+// disables them.
+func NewService(logger *slog.Logger, repo InvoiceRepo, emailSender notification.Notifier, numbering Numbering, publisher pubsub.Publisher) Service {
 	return &service{
 		logger:      logger,
 		repo:        repo,
 		emailSender: emailSender,
+		numbering:   numbering,
+		publisher:   publisher,
 	}
 }
 
-func (s *service) CreateInvoice(billingID int64, consultationFee, medicationFee, totalAmount float64, email string) (*Invoice, error) {
-	invoiceNumber := fmt.Sprintf("INV-%d-%d", billingID, time.Now().Unix())
-
-	invoice := &Invoice{
-		BillingID:       billingID,
-		InvoiceNumber:   invoiceNumber,
-		ConsultationFee: consultationFee,
-		MedicationFee:   medicationFee,
-		TotalAmount:     totalAmount,
-		SentToEmail:     email,
+// CreateInvoice stores the draft under a new invoice number. It is idempotent
+// per billing: when the billing already has an invoice, that invoice is
+// returned instead of creating a second one.
+func (s *service) CreateInvoice(draft *Invoice) (*Invoice, error) {
+	billingID := draft.BillingID
+	if existing, err := s.repo.GetByBillingID(billingID); err == nil {
+		return existing, nil
 	}
 
-	id, err := s.repo.Create(invoice)
+	invoice := *draft
+	invoice.ID = 0
+	invoice.InvoiceNumber = ""
+
+	id, err := s.repo.Create(&invoice, s.numbering)
+	if errors.Is(err, ErrInvoiceExists) {
+		// lost a race against a concurrent request for the same billing
+		return s.repo.GetByBillingID(billingID)
+	}
 	if err != nil {
 		s.logger.Error("failed to create invoice",
 			slog.Any("error", err),
@@ -52,7 +86,7 @@ func (s *service) CreateInvoice(billingID int64, consultationFee, medicationFee,
 
 	invoice.ID = id
 
-	return invoice, nil
+	return &invoice, nil
 }
 
 func (s *service) GetByID(id int64) (*Invoice, error) {
@@ -79,6 +113,50 @@ func (s *service) GetByBillingID(billingID int64) (*Invoice, error) {
 	return invoice, nil
 }
 
+func (s *service) GetByUserID(userID int64) ([]Invoice, error) {
+	list, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		s.logger.Error("failed to get invoices by user ID",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+		)
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetByIDForUser returns the invoice only when it bills an appointment of the
+// given patient, otherwise ErrInvoiceNotFound.
+func (s *service) GetByIDForUser(id, userID int64) (*Invoice, error) {
+	invoice, err := s.repo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		s.logger.Warn("invoice not found for user",
+			slog.Any("error", err),
+			slog.Int64("invoice_id", id),
+			slog.Int64("user_id", userID),
+		)
+		return nil, ErrInvoiceNotFound
+	}
+	return invoice, nil
+}
+
+// CreditNoteNumbering returns the numbering credit notes are issued under.
+func (s *service) CreditNoteNumbering() Numbering {
+	return s.numbering.CreditNotes()
+}
+
+func (s *service) GetCreditNotes(invoiceID int64) ([]CreditNote, error) {
+	notes, err := s.repo.ListCreditNotesByInvoiceID(invoiceID)
+	if err != nil {
+		s.logger.Error("failed to get credit notes",
+			slog.Any("error", err),
+			slog.Int64("invoice_id", invoiceID),
+		)
+		return nil, err
+	}
+	return notes, nil
+}
+
 func (s *service) MarkAsSent(id int64) error {
 	err := s.repo.UpdateSentAt(id)
 	if err != nil {
@@ -88,49 +166,60 @@ func (s *service) MarkAsSent(id int64) error {
 		)
 		return err
 	}
+
+	s.publishSent(id)
 	return nil
 }
 
-func (s *service) SendInvoice(billingID int64, email string) (*Invoice, error) {
-	// Create a new invoice record when SendInvoice is called
-	consultationFee := 100.0
-	medicationFee := 50.0
-	totalAmount := consultationFee + medicationFee
+// publishSent tells the patient their invoice was emailed. Failures are
+// logged, the invoice is already marked as sent.
+func (s *service) publishSent(id int64) {
+	if s.publisher == nil {
+		return
+	}
 
-	invoice, err := s.CreateInvoice(billingID, consultationFee, medicationFee, totalAmount, email)
+	invoice, err := s.repo.GetByID(id)
+	if err == nil {
+		var userID int64
+		if userID, err = s.repo.GetOwnerID(id); err == nil {
+			err = s.publisher.Publish(pubsub.NewEvent(EventSent, EventData{
+				InvoiceID:     invoice.ID,
+				BillingID:     invoice.BillingID,
+				InvoiceNumber: invoice.InvoiceNumber,
+				SentAt:        invoice.SentAt,
+			}, pubsub.Principal{Role: pubsub.RoleUser, ID: userID}))
+		}
+	}
 	if err != nil {
-		s.logger.Error("failed to create invoice for sending",
+		s.logger.Warn("failed to publish invoice event",
 			slog.Any("error", err),
-			slog.Int64("billing_id", billingID),
+			slog.Int64("invoice_id", id),
 		)
-		return nil, err
 	}
+}
 
-	// Build email content
-	subject := fmt.Sprintf("Invoice %s", invoice.InvoiceNumber)
-	body := fmt.Sprintf("Dear Customer,\n\nPlease find your invoice details below:\n\nInvoice Number: %s\nConsultation Fee: %.2f\nMedication Fee: %.2f\nTotal Amount: %.2f\n\nThank you for your business.",
-		invoice.InvoiceNumber, invoice.ConsultationFee, invoice.MedicationFee, invoice.TotalAmount)
-
-	if s.emailSender == nil {
-		s.logger.Warn("email sender not configured, invoice created but not sent",
+// SendInvoice queues the billing's invoice for delivery to email. The email
+// goes through the outbox dispatcher, so an SMTP outage is retried later
+// instead of failing the request.
+func (s *service) SendInvoice(billingID int64, email string) (*Invoice, error) {
+	invoice, err := s.repo.GetByBillingID(billingID)
+	if err != nil {
+		s.logger.Error("failed to get invoice for sending",
+			slog.Any("error", err),
 			slog.Int64("billing_id", billingID),
-			slog.Int64("invoice_id", invoice.ID),
 		)
-		return invoice, nil
+		return nil, ErrInvoiceNotFound
 	}
 
-	if err := s.emailSender.Send(email, subject, body); err != nil {
-		s.logger.Error("failed to send invoice email",
-			slog.Any("error", err),
-			slog.Int64("billing_id", billingID),
-			slog.String("email", email),
-		)
-		return nil, err
+	message := &outbox.Message{
+		Topic:     TopicInvoiceEmail,
+		Recipient: email,
+		Subject:   fmt.Sprintf("Invoice %s", invoice.InvoiceNumber),
+		Payload:   emailBody(invoice),
 	}
 
-	// Mark invoice as sent
-	if err := s.MarkAsSent(invoice.ID); err != nil {
-		s.logger.Error("failed to mark invoice as sent after email",
+	if err := s.repo.EnqueueMessages(invoice, []*outbox.Message{message}); err != nil {
+		s.logger.Error("failed to queue invoice email",
 			slog.Any("error", err),
 			slog.Int64("invoice_id", invoice.ID),
 		)
@@ -139,3 +228,54 @@ func (s *service) SendInvoice(billingID int64, email string) (*Invoice, error) {
 
 	return invoice, nil
 }
+
+// DeliverInvoiceEmail is the outbox handler for TopicInvoiceEmail.
+func (s *service) DeliverInvoiceEmail(msg *outbox.Message) error {
+	if s.emailSender == nil {
+		return ErrEmailSenderNotConfigured
+	}
+
+	if err := s.emailSender.Send(msg.Recipient, msg.Subject, msg.Payload); err != nil {
+		s.logger.Error("failed to send invoice email",
+			slog.Any("error", err),
+			slog.Int64("invoice_id", msg.AggregateID),
+			slog.String("email", msg.Recipient),
+		)
+		return err
+	}
+
+	return s.MarkAsSent(msg.AggregateID)
+}
+
+func emailBody(invoice *Invoice) string {
+	var b strings.Builder
+	b.WriteString("Dear Customer,\n\nPlease find your invoice details below:\n\n")
+	fmt.Fprintf(&b, "Invoice Number: %s\n", invoice.InvoiceNumber)
+
+	if invoice.PricingBreakdown == nil {
+		fmt.Fprintf(&b, "Consultation Fee: %.2f\nMedication Fee: %.2f\nTotal Amount: %.2f\n",
+			invoice.ConsultationFee, invoice.MedicationFee, invoice.TotalAmount)
+	} else {
+		breakdown := invoice.PricingBreakdown
+		for _, line := range breakdown.Lines {
+			fmt.Fprintf(&b, "%s x%d: %.2f", line.Description, line.Quantity, line.Amount)
+			if line.TaxExempt {
+				b.WriteString(" (tax exempt)")
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Subtotal: %.2f\n", breakdown.Subtotal)
+		if breakdown.DiscountAmount > 0 {
+			fmt.Fprintf(&b, "Discount: -%.2f\n", breakdown.DiscountAmount)
+		}
+		fmt.Fprintf(&b, "VAT (%.0f%%): %.2f\n", breakdown.VATRate*100, breakdown.TaxAmount)
+		fmt.Fprintf(&b, "Total Amount: %.2f\n", breakdown.Total)
+		if breakdown.InsuranceAmount > 0 {
+			fmt.Fprintf(&b, "Covered by Insurance: -%.2f\n", breakdown.InsuranceAmount)
+		}
+		fmt.Fprintf(&b, "Amount Due: %.2f\n", breakdown.PatientAmount)
+	}
+
+	b.WriteString("\nThank you for your business.")
+	return b.String()
+}
@@ -1,63 +1,119 @@
 package invoices_test
 
-// import (
-// 	"Dedenruslan19/med-project/service/invoices"
-// 	"errors"
-// 	"log/slog"
-// 	"os"
-// 	"testing"
-
-// 	"github.com/stretchr/testify/assert"
-// 	"go.uber.org/mock/gomock"
-// )
-
-// func TestGetByID_Success(t *testing.T) {
-// 	ctrl := gomock.NewController(t)
-// 	defer ctrl.Finish()
-
-// 	mockRepo := invoices.NewMockInvoiceRepo(ctrl)
-// 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-// 	service := invoices.NewService(logger, mockRepo)
-
-// 	expectedInvoice := &invoices.Invoice{
-// 		ID:              1,
-// 		BillingID:       1,
-// 		InvoiceNumber:   "INV-1-1234567890",
-// 		ConsultationFee: 200000.0,
-// 		MedicationFee:   50000.0,
-// 		TotalAmount:     250000.0,
-// 		SentToEmail:     "user@example.com",
-// 	}
-
-// 	mockRepo.EXPECT().
-// 		GetByID(int64(1)).
-// 		Return(expectedInvoice, nil).
-// 		Times(1)
-
-// 	result, err := service.GetByID(1)
-
-// 	assert.NoError(t, err)
-// 	assert.NotNil(t, result)
-// 	assert.Equal(t, expectedInvoice.ID, result.ID)
-// 	assert.Equal(t, expectedInvoice.InvoiceNumber, result.InvoiceNumber)
-// 	assert.Equal(t, expectedInvoice.TotalAmount, result.TotalAmount)
-// }
-
-// func TestGetByID_NotFound(t *testing.T) {
-// 	ctrl := gomock.NewController(t)
-// 	defer ctrl.Finish()
-
-// 	mockRepo := invoices.NewMockInvoiceRepo(ctrl)
-// 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-// 	service := invoices.NewService(logger, mockRepo)
-
-// 	mockRepo.EXPECT().
-// 		GetByID(int64(999)).
-// 		Return(nil, errors.New("invoice not found")).
-// 		Times(1)
-
-// 	result, err := service.GetByID(999)
-
-// 	assert.Error(t, err)
-// 	assert.Nil(t, result)
-// }
+import (
+	"Dedenruslan19/med-project/service/invoices"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetByID_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := invoices.NewMockInvoiceRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := invoices.NewService(logger, mockRepo, nil, invoices.DefaultNumbering(), nil)
+
+	expectedInvoice := &invoices.Invoice{
+		ID:              1,
+		BillingID:       1,
+		InvoiceNumber:   "INV/2026/000001",
+		ConsultationFee: 200000.0,
+		MedicationFee:   50000.0,
+		TotalAmount:     250000.0,
+		SentToEmail:     "user@example.com",
+	}
+
+	mockRepo.EXPECT().
+		GetByID(int64(1)).
+		Return(expectedInvoice, nil).
+		Times(1)
+
+	result, err := service.GetByID(1)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, expectedInvoice.ID, result.ID)
+	assert.Equal(t, expectedInvoice.InvoiceNumber, result.InvoiceNumber)
+	assert.Equal(t, expectedInvoice.TotalAmount, result.TotalAmount)
+}
+
+func TestGetByID_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := invoices.NewMockInvoiceRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := invoices.NewService(logger, mockRepo, nil, invoices.DefaultNumbering(), nil)
+
+	mockRepo.EXPECT().
+		GetByID(int64(999)).
+		Return(nil, errors.New("invoice not found")).
+		Times(1)
+
+	result, err := service.GetByID(999)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestCreateInvoice_ReturnsExisting(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := invoices.NewMockInvoiceRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := invoices.NewService(logger, mockRepo, nil, invoices.DefaultNumbering(), nil)
+
+	existing := &invoices.Invoice{ID: 4, BillingID: 7, InvoiceNumber: "INV/2026/000004", TotalAmount: 250000.0}
+
+	mockRepo.EXPECT().
+		GetByBillingID(int64(7)).
+		Return(existing, nil).
+		Times(1)
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := service.CreateInvoice(&invoices.Invoice{BillingID: 7, ConsultationFee: 200000.0, MedicationFee: 50000.0, TotalAmount: 250000.0, SentToEmail: "user@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing, result)
+}
+
+func TestCreateInvoice_ConcurrentDuplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := invoices.NewMockInvoiceRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := invoices.NewService(logger, mockRepo, nil, invoices.DefaultNumbering(), nil)
+
+	existing := &invoices.Invoice{ID: 5, BillingID: 8, InvoiceNumber: "INV/2026/000005", TotalAmount: 250000.0}
+
+	gomock.InOrder(
+		mockRepo.EXPECT().GetByBillingID(int64(8)).Return(nil, errors.New("record not found")),
+		mockRepo.EXPECT().
+			Create(gomock.Any(), gomock.Any()).
+			Return(int64(0), invoices.ErrInvoiceExists),
+		mockRepo.EXPECT().GetByBillingID(int64(8)).Return(existing, nil),
+	)
+
+	result, err := service.CreateInvoice(&invoices.Invoice{BillingID: 8, ConsultationFee: 200000.0, MedicationFee: 50000.0, TotalAmount: 250000.0, SentToEmail: "user@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing.ID, result.ID)
+}
+
+func TestNumbering_Format(t *testing.T) {
+	numbering := invoices.NewNumbering("CLINIC-A", "{series}/{yy}/{seq:4}", 4)
+
+	assert.Equal(t, "INV/2026/000123", invoices.DefaultNumbering().Format(2026, 123))
+	assert.Equal(t, "CLINIC-A/26/0007", numbering.Format(2026, 7))
+	assert.Equal(t, 2025, numbering.FiscalYear(time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 2026, numbering.FiscalYear(time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)))
+}
@@ -10,6 +10,7 @@
 package invoices
 
 import (
+	outbox "Dedenruslan19/med-project/service/outbox"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,18 +41,32 @@ func (m *MockInvoiceRepo) EXPECT() *MockInvoiceRepoMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockInvoiceRepo) Create(invoice *Invoice) (int64, error) {
+func (m *MockInvoiceRepo) Create(invoice *Invoice, numbering Numbering) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", invoice)
+	ret := m.ctrl.Call(m, "Create", invoice, numbering)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockInvoiceRepoMockRecorder) Create(invoice any) *gomock.Call {
+func (mr *MockInvoiceRepoMockRecorder) Create(invoice, numbering any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockInvoiceRepo)(nil).Create), invoice)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockInvoiceRepo)(nil).Create), invoice, numbering)
+}
+
+// EnqueueMessages mocks base method.
+func (m *MockInvoiceRepo) EnqueueMessages(invoice *Invoice, messages []*outbox.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueMessages", invoice, messages)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnqueueMessages indicates an expected call of EnqueueMessages.
+func (mr *MockInvoiceRepoMockRecorder) EnqueueMessages(invoice, messages any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueMessages", reflect.TypeOf((*MockInvoiceRepo)(nil).EnqueueMessages), invoice, messages)
 }
 
 // GetByBillingID mocks base method.
@@ -84,6 +99,80 @@ func (mr *MockInvoiceRepoMockRecorder) GetByID(id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockInvoiceRepo)(nil).GetByID), id)
 }
 
+// GetByIDAndUserID mocks base method.
+func (m *MockInvoiceRepo) GetByIDAndUserID(id, userID int64) (*Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDAndUserID", id, userID)
+	ret0, _ := ret[0].(*Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDAndUserID indicates an expected call of GetByIDAndUserID.
+func (mr *MockInvoiceRepoMockRecorder) GetByIDAndUserID(id, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDAndUserID", reflect.TypeOf((*MockInvoiceRepo)(nil).GetByIDAndUserID), id, userID)
+}
+
+// GetByUserID mocks base method.
+func (m *MockInvoiceRepo) GetByUserID(userID int64) ([]Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserID", userID)
+	ret0, _ := ret[0].([]Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserID indicates an expected call of GetByUserID.
+func (mr *MockInvoiceRepoMockRecorder) GetByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockInvoiceRepo)(nil).GetByUserID), userID)
+}
+
+// GetOwnerID mocks base method.
+func (m *MockInvoiceRepo) GetOwnerID(id int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnerID", id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOwnerID indicates an expected call of GetOwnerID.
+func (mr *MockInvoiceRepoMockRecorder) GetOwnerID(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnerID", reflect.TypeOf((*MockInvoiceRepo)(nil).GetOwnerID), id)
+}
+
+// ListCreditNotesByInvoiceID mocks base method.
+func (m *MockInvoiceRepo) ListCreditNotesByInvoiceID(invoiceID int64) ([]CreditNote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCreditNotesByInvoiceID", invoiceID)
+	ret0, _ := ret[0].([]CreditNote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCreditNotesByInvoiceID indicates an expected call of ListCreditNotesByInvoiceID.
+func (mr *MockInvoiceRepoMockRecorder) ListCreditNotesByInvoiceID(invoiceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCreditNotesByInvoiceID", reflect.TypeOf((*MockInvoiceRepo)(nil).ListCreditNotesByInvoiceID), invoiceID)
+}
+
+// SendInvoiceEmail mocks base method.
+func (m *MockInvoiceRepo) SendInvoiceEmail(id int64, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendInvoiceEmail", id, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendInvoiceEmail indicates an expected call of SendInvoiceEmail.
+func (mr *MockInvoiceRepoMockRecorder) SendInvoiceEmail(id, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendInvoiceEmail", reflect.TypeOf((*MockInvoiceRepo)(nil).SendInvoiceEmail), id, email)
+}
+
 // UpdateSentAt mocks base method.
 func (m *MockInvoiceRepo) UpdateSentAt(id int64) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,123 @@
+package invoices
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSeries           = "INV"
+	DefaultCreditNoteSeries = "CN"
+	DefaultNumberPattern    = "{series}/{year}/{seq:6}"
+)
+
+// Numbering describes how invoice numbers are built. Pattern understands the
+// tokens {series}, {year}, {yy}, {seq} and {seq:N}, where N zero-pads the
+// sequence to N digits. Sequences restart every fiscal year, which begins on
+// the first day of FiscalYearStartMonth. Credit notes are numbered the same
+// way in their own series.
+type Numbering struct {
+	Series               string
+	CreditNoteSeries     string
+	Pattern              string
+	FiscalYearStartMonth time.Month
+}
+
+func DefaultNumbering() Numbering {
+	return Numbering{
+		Series:               DefaultSeries,
+		CreditNoteSeries:     DefaultCreditNoteSeries,
+		Pattern:              DefaultNumberPattern,
+		FiscalYearStartMonth: time.January,
+	}
+}
+
+// WithCreditNoteSeries returns the numbering with another credit note series,
+// keeping the default for an empty one.
+func (n Numbering) WithCreditNoteSeries(series string) Numbering {
+	if series != "" {
+		n.CreditNoteSeries = series
+	}
+	return n
+}
+
+// CreditNotes returns the numbering used for credit notes.
+func (n Numbering) CreditNotes() Numbering {
+	n.Series = n.CreditNoteSeries
+	if n.Series == "" {
+		n.Series = DefaultCreditNoteSeries
+	}
+	return n
+}
+
+// NewNumbering falls back to the defaults for empty or invalid values.
+func NewNumbering(series, pattern string, fiscalYearStartMonth int) Numbering {
+	n := DefaultNumbering()
+	if series != "" {
+		n.Series = series
+	}
+	if pattern != "" {
+		n.Pattern = pattern
+	}
+	if fiscalYearStartMonth >= 1 && fiscalYearStartMonth <= 12 {
+		n.FiscalYearStartMonth = time.Month(fiscalYearStartMonth)
+	}
+	return n
+}
+
+// FiscalYear returns the fiscal year t falls in, named after the calendar
+// year it starts in.
+func (n Numbering) FiscalYear(t time.Time) int {
+	start := n.FiscalYearStartMonth
+	if start == 0 {
+		start = time.January
+	}
+	if t.Month() < start {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+func (n Numbering) Format(fiscalYear int, seq int64) string {
+	var b strings.Builder
+	pattern := n.Pattern
+	for {
+		open := strings.IndexByte(pattern, '{')
+		if open < 0 {
+			b.WriteString(pattern)
+			break
+		}
+		end := strings.IndexByte(pattern[open:], '}')
+		if end < 0 {
+			b.WriteString(pattern)
+			break
+		}
+		b.WriteString(pattern[:open])
+		b.WriteString(n.token(pattern[open+1:open+end], fiscalYear, seq))
+		pattern = pattern[open+end+1:]
+	}
+	return b.String()
+}
+
+func (n Numbering) token(token string, fiscalYear int, seq int64) string {
+	switch {
+	case token == "series":
+		return n.Series
+	case token == "year":
+		return strconv.Itoa(fiscalYear)
+	case token == "yy":
+		return fmt.Sprintf("%02d", fiscalYear%100)
+	case token == "seq":
+		return strconv.FormatInt(seq, 10)
+	case strings.HasPrefix(token, "seq:"):
+		width, err := strconv.Atoi(strings.TrimPrefix(token, "seq:"))
+		if err != nil || width <= 0 {
+			return strconv.FormatInt(seq, 10)
+		}
+		return fmt.Sprintf("%0*d", width, seq)
+	default:
+		return "{" + token + "}"
+	}
+}
@@ -0,0 +1,70 @@
+package messages
+
+import (
+	"time"
+
+	"Dedenruslan19/med-project/service/attachments"
+)
+
+const (
+	RoleUser   = "user"
+	RoleDoctor = "doctor"
+
+	// AggregateType tags the notifications sent for new messages.
+	AggregateType = "message_thread"
+)
+
+// Thread is the conversation between the patient and the doctor of one
+// appointment. It is opened on first use and closed some days after the
+// appointment was completed.
+type Thread struct {
+	ID            int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	AppointmentID int64      `json:"appointment_id" gorm:"not null;uniqueIndex"`
+	UserID        int64      `json:"user_id" gorm:"not null;index"`
+	DoctorID      int64      `json:"doctor_id" gorm:"not null;index"`
+	LastMessageAt *time.Time `json:"last_message_at"`
+	ClosedAt      *time.Time `json:"closed_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+
+	// UnreadCount is the number of messages from the other party the caller
+	// has not read.
+	UnreadCount int64     `json:"unread_count" gorm:"-"`
+	Messages    []Message `json:"messages,omitempty" gorm:"-"`
+}
+
+func (Thread) TableName() string {
+	return "message_threads"
+}
+
+// Closed tells whether no more messages can be posted.
+func (t *Thread) Closed() bool {
+	return t.ClosedAt != nil
+}
+
+// Message is one post in a thread. ReadAt is the read receipt, set when the
+// other party first opens the thread after it was posted. Attachments are
+// uploaded to the appointment and referenced by ID.
+type Message struct {
+	ID            int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ThreadID      int64      `json:"thread_id" gorm:"not null;index"`
+	SenderID      int64      `json:"sender_id" gorm:"not null"`
+	SenderRole    string     `json:"sender_role" gorm:"type:varchar(20);not null"`
+	Body          string     `json:"body" gorm:"type:text;not null;serializer:encrypted"`
+	AttachmentIDs []int64    `json:"attachment_ids" gorm:"type:text;serializer:json"`
+	ReadAt        *time.Time `json:"read_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+
+	Attachments []attachments.Attachment `json:"attachments,omitempty" gorm:"-"`
+}
+
+// Caller is the authenticated patient or doctor.
+type Caller struct {
+	ID   int64
+	Role string
+}
+
+// Post is a new message.
+type Post struct {
+	Body          string
+	AttachmentIDs []int64
+}
@@ -0,0 +1,25 @@
+package messages
+
+import "time"
+
+type MessageRepo interface {
+	// GetOrCreateThread returns the thread of the appointment, creating it
+	// from thread when there is none yet.
+	GetOrCreateThread(thread *Thread) (*Thread, error)
+	// ListThreads returns the threads of a patient or doctor, most recently
+	// active first.
+	ListThreads(role string, participantID int64) ([]Thread, error)
+	// CountUnread counts, per thread, the messages not sent by readerRole
+	// that have not been read.
+	CountUnread(threadIDs []int64, readerRole string) (map[int64]int64, error)
+	ListMessages(threadID int64) ([]Message, error)
+	// CreateMessage stores the message and moves the thread's
+	// LastMessageAt, in one transaction.
+	CreateMessage(message *Message) error
+	// MarkRead sets ReadAt on the unread messages of the thread not sent by
+	// readerRole.
+	MarkRead(threadID int64, readerRole string, at time.Time) error
+	// CloseExpired closes the open threads whose appointment was completed
+	// before completedBefore.
+	CloseExpired(completedBefore, at time.Time) (int64, error)
+}
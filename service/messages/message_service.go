@@ -0,0 +1,296 @@
+package messages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/attachments"
+	"Dedenruslan19/med-project/service/doctors"
+	"Dedenruslan19/med-project/service/notifications"
+)
+
+// MaxBodyLength is the longest message accepted, in characters.
+const MaxBodyLength = 5000
+
+var (
+	ErrAppointmentNotFound = errors.New("appointment not found")
+	ErrForbidden           = errors.New("only the patient and the doctor of the appointment can use its messages")
+	ErrThreadClosed        = errors.New("message thread is closed")
+	ErrEmptyMessage        = errors.New("message needs a body or an attachment")
+	ErrMessageTooLong      = errors.New("message is too long")
+	ErrInvalidAttachment   = errors.New("attachments must be uploaded to the appointment first")
+)
+
+type service struct {
+	repo                MessageRepo
+	appointmentService  appointments.Service
+	attachmentService   attachments.Service
+	doctorService       doctors.Service
+	notificationService notifications.Service
+	closeAfter          time.Duration
+	now                 func() time.Time
+	logger              *slog.Logger
+}
+
+type Service interface {
+	Threads(caller Caller) ([]Thread, error)
+	// Thread returns the appointment's thread with its messages and marks
+	// the other party's messages as read.
+	Thread(caller Caller, appointmentID int64) (*Thread, error)
+	// Post adds a message to the appointment's thread and returns it with
+	// the thread.
+	Post(caller Caller, appointmentID int64, post Post) (*Thread, *Message, error)
+	CloseExpired() (int64, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// NewService closes threads closeAfter the completion of their appointment.
+func NewService(logger *slog.Logger, repo MessageRepo, appointmentService appointments.Service, attachmentService attachments.Service,
+	doctorService doctors.Service, notificationService notifications.Service, closeAfter time.Duration) Service {
+	return &service{
+		logger:              logger,
+		repo:                repo,
+		appointmentService:  appointmentService,
+		attachmentService:   attachmentService,
+		doctorService:       doctorService,
+		notificationService: notificationService,
+		closeAfter:          closeAfter,
+		now:                 time.Now,
+	}
+}
+
+// open loads the appointment's thread for one of its participants. A thread
+// past its closing time counts as closed before CloseExpired has run.
+func (s *service) open(caller Caller, appointmentID int64) (*appointments.Appointment, *Thread, error) {
+	appointment, err := s.appointmentService.GetByID(appointmentID)
+	if err != nil {
+		return nil, nil, ErrAppointmentNotFound
+	}
+
+	switch {
+	case caller.Role == RoleUser && appointment.UserID == caller.ID:
+	case caller.Role == RoleDoctor && appointment.DoctorID == caller.ID:
+	default:
+		return nil, nil, ErrForbidden
+	}
+
+	thread, err := s.repo.GetOrCreateThread(&Thread{
+		AppointmentID: appointment.ID,
+		UserID:        appointment.UserID,
+		DoctorID:      appointment.DoctorID,
+	})
+	if err != nil {
+		s.logger.Error("failed to open message thread",
+			slog.Any("error", err),
+			slog.Int64("appointment_id", appointmentID),
+		)
+		return nil, nil, err
+	}
+
+	if thread.ClosedAt == nil && appointment.Status == appointments.StatusCompleted && appointment.CompletedAt != nil {
+		closesAt := appointment.CompletedAt.Add(s.closeAfter)
+		if !s.now().Before(closesAt) {
+			thread.ClosedAt = &closesAt
+		}
+	}
+	return appointment, thread, nil
+}
+
+func (s *service) Threads(caller Caller) ([]Thread, error) {
+	if caller.Role != RoleUser && caller.Role != RoleDoctor {
+		return nil, ErrForbidden
+	}
+
+	threads, err := s.repo.ListThreads(caller.Role, caller.ID)
+	if err != nil {
+		s.logger.Error("failed to list message threads",
+			slog.Any("error", err),
+			slog.Int64("participant_id", caller.ID),
+			slog.String("role", caller.Role),
+		)
+		return nil, err
+	}
+	if len(threads) == 0 {
+		return threads, nil
+	}
+
+	ids := make([]int64, len(threads))
+	for i, thread := range threads {
+		ids[i] = thread.ID
+	}
+	unread, err := s.repo.CountUnread(ids, caller.Role)
+	if err != nil {
+		s.logger.Error("failed to count unread messages",
+			slog.Any("error", err),
+			slog.Int64("participant_id", caller.ID),
+		)
+		return nil, err
+	}
+	for i := range threads {
+		threads[i].UnreadCount = unread[threads[i].ID]
+	}
+	return threads, nil
+}
+
+func (s *service) Thread(caller Caller, appointmentID int64) (*Thread, error) {
+	_, thread, err := s.open(caller, appointmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.repo.ListMessages(thread.ID)
+	if err != nil {
+		s.logger.Error("failed to list messages",
+			slog.Any("error", err),
+			slog.Int64("thread_id", thread.ID),
+		)
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	unread := false
+	for i := range list {
+		if list[i].SenderRole != caller.Role && list[i].ReadAt == nil {
+			list[i].ReadAt = &now
+			unread = true
+		}
+		list[i].Attachments = s.attachments(caller, list[i].AttachmentIDs)
+	}
+	if unread {
+		if err := s.repo.MarkRead(thread.ID, caller.Role, now); err != nil {
+			s.logger.Error("failed to mark messages as read",
+				slog.Any("error", err),
+				slog.Int64("thread_id", thread.ID),
+			)
+			return nil, err
+		}
+	}
+
+	thread.Messages = list
+	return thread, nil
+}
+
+// attachments resolves attachment IDs with fresh download links, leaving out
+// the ones deleted since.
+func (s *service) attachments(caller Caller, ids []int64) []attachments.Attachment {
+	var list []attachments.Attachment
+	for _, id := range ids {
+		attachment, err := s.attachmentService.Get(attachments.Caller{ID: caller.ID, Role: caller.Role}, id)
+		if err != nil {
+			continue
+		}
+		list = append(list, *attachment)
+	}
+	return list
+}
+
+func (s *service) Post(caller Caller, appointmentID int64, post Post) (*Thread, *Message, error) {
+	body := strings.TrimSpace(post.Body)
+	if body == "" && len(post.AttachmentIDs) == 0 {
+		return nil, nil, ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(body) > MaxBodyLength {
+		return nil, nil, fmt.Errorf("%w: at most %d characters", ErrMessageTooLong, MaxBodyLength)
+	}
+
+	appointment, thread, err := s.open(caller, appointmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if thread.Closed() {
+		return nil, nil, ErrThreadClosed
+	}
+
+	message := &Message{
+		ThreadID:   thread.ID,
+		SenderID:   caller.ID,
+		SenderRole: caller.Role,
+		Body:       body,
+	}
+	seen := map[int64]bool{}
+	for _, id := range post.AttachmentIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		attachment, err := s.attachmentService.Get(attachments.Caller{ID: caller.ID, Role: caller.Role}, id)
+		if err != nil || attachment.OwnerType != attachments.OwnerAppointment || attachment.OwnerID != appointment.ID {
+			return nil, nil, fmt.Errorf("%w: %d", ErrInvalidAttachment, id)
+		}
+		message.AttachmentIDs = append(message.AttachmentIDs, id)
+		message.Attachments = append(message.Attachments, *attachment)
+	}
+
+	if err := s.repo.CreateMessage(message); err != nil {
+		s.logger.Error("failed to create message",
+			slog.Any("error", err),
+			slog.Int64("thread_id", thread.ID),
+		)
+		return nil, nil, err
+	}
+
+	s.notify(appointment, thread, caller.Role)
+	return thread, message, nil
+}
+
+// notify tells the other party about a new message without its content,
+// which stays behind the login. Failures are logged and do not fail the
+// post.
+func (s *service) notify(appointment *appointments.Appointment, thread *Thread, senderRole string) {
+	subject := "New message about your appointment"
+	body := fmt.Sprintf("You have a new message about the appointment on %s. Sign in to read it.",
+		appointment.AppointmentDate.Format("2006-01-02 15:04"))
+
+	var err error
+	if senderRole == RoleDoctor {
+		err = s.notificationService.Notify(appointment.UserID, notifications.CategoryMessage, subject, body)
+	} else {
+		var doctor *doctors.Doctor
+		if doctor, err = s.doctorService.GetByID(appointment.DoctorID); err == nil {
+			err = s.notificationService.NotifyEmail(AggregateType, thread.ID, doctor.Email, subject, body)
+		}
+	}
+	if err != nil {
+		s.logger.Warn("failed to notify about new message",
+			slog.Any("error", err),
+			slog.Int64("thread_id", thread.ID),
+		)
+	}
+}
+
+// CloseExpired closes the threads of appointments completed more than the
+// configured number of days ago.
+func (s *service) CloseExpired() (int64, error) {
+	now := s.now().UTC()
+	closed, err := s.repo.CloseExpired(now.Add(-s.closeAfter), now)
+	if err != nil {
+		s.logger.Error("failed to close expired message threads", slog.Any("error", err))
+		return 0, err
+	}
+	if closed > 0 {
+		s.logger.Info("closed expired message threads", slog.Int64("closed", closed))
+	}
+	return closed, nil
+}
+
+// Run closes expired threads every interval until ctx is cancelled.
+func (s *service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.CloseExpired()
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package messages_test
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/attachments"
+	"Dedenruslan19/med-project/service/doctors"
+	"Dedenruslan19/med-project/service/messages"
+	"Dedenruslan19/med-project/service/notifications"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type fakeAppointmentService struct {
+	appointments.Service
+	appointment *appointments.Appointment
+}
+
+func (f fakeAppointmentService) GetByID(id int64) (*appointments.Appointment, error) {
+	return f.appointment, nil
+}
+
+type fakeAttachmentService struct {
+	attachments.Service
+}
+
+func (fakeAttachmentService) Get(caller attachments.Caller, id int64) (*attachments.Attachment, error) {
+	switch id {
+	case 12:
+		return &attachments.Attachment{ID: 12, OwnerType: attachments.OwnerAppointment, OwnerID: 5}, nil
+	case 13:
+		return &attachments.Attachment{ID: 13, OwnerType: attachments.OwnerAppointment, OwnerID: 6}, nil
+	}
+	return nil, attachments.ErrAttachmentNotFound
+}
+
+type fakeDoctorService struct {
+	doctors.Service
+}
+
+func (fakeDoctorService) GetByID(id int64) (*doctors.Doctor, error) {
+	return &doctors.Doctor{ID: id, Email: "doctor@example.com"}, nil
+}
+
+type fakeNotificationService struct {
+	notifications.Service
+	sent *[]string
+}
+
+func (f fakeNotificationService) Notify(userID int64, category, subject, body string) error {
+	*f.sent = append(*f.sent, category)
+	return nil
+}
+
+func (f fakeNotificationService) NotifyEmail(aggregateType string, aggregateID int64, email, subject, body string) error {
+	*f.sent = append(*f.sent, email)
+	return nil
+}
+
+func setup(t *testing.T, appointment *appointments.Appointment) (*messages.MockMessageRepo, messages.Service, *[]string) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	sent := &[]string{}
+	mockRepo := messages.NewMockMessageRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	return mockRepo, messages.NewService(logger, mockRepo, fakeAppointmentService{appointment: appointment}, fakeAttachmentService{},
+		fakeDoctorService{}, fakeNotificationService{sent: sent}, 7*24*time.Hour), sent
+}
+
+func TestPost_NotifiesDoctor(t *testing.T) {
+	mockRepo, service, sent := setup(t, &appointments.Appointment{ID: 5, UserID: 1, DoctorID: 2, Status: "pending"})
+
+	mockRepo.EXPECT().GetOrCreateThread(gomock.Any()).Return(&messages.Thread{ID: 9, AppointmentID: 5, UserID: 1, DoctorID: 2}, nil).Times(1)
+	mockRepo.EXPECT().
+		CreateMessage(gomock.Any()).
+		DoAndReturn(func(message *messages.Message) error {
+			assert.Equal(t, "Is the rash normal?", message.Body)
+			assert.Equal(t, []int64{12}, message.AttachmentIDs)
+			assert.Equal(t, messages.RoleUser, message.SenderRole)
+			return nil
+		}).
+		Times(1)
+
+	thread, message, err := service.Post(messages.Caller{ID: 1, Role: messages.RoleUser}, 5, messages.Post{
+		Body:          "  Is the rash normal?  ",
+		AttachmentIDs: []int64{12, 12},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), thread.UserID)
+	assert.Len(t, message.Attachments, 1)
+	assert.Equal(t, []string{"doctor@example.com"}, *sent)
+}
+
+func TestPost_Rejected(t *testing.T) {
+	completedAt := time.Now().Add(-8 * 24 * time.Hour)
+	mockRepo, service, _ := setup(t, &appointments.Appointment{ID: 5, UserID: 1, DoctorID: 2, Status: appointments.StatusCompleted, CompletedAt: &completedAt})
+
+	_, _, err := service.Post(messages.Caller{ID: 3, Role: messages.RoleDoctor}, 5, messages.Post{Body: "hello"})
+	assert.ErrorIs(t, err, messages.ErrForbidden)
+
+	_, _, err = service.Post(messages.Caller{ID: 1, Role: messages.RoleUser}, 5, messages.Post{Body: " "})
+	assert.ErrorIs(t, err, messages.ErrEmptyMessage)
+
+	mockRepo.EXPECT().GetOrCreateThread(gomock.Any()).Return(&messages.Thread{ID: 9, AppointmentID: 5, UserID: 1, DoctorID: 2}, nil).Times(2)
+
+	_, _, err = service.Post(messages.Caller{ID: 2, Role: messages.RoleDoctor}, 5, messages.Post{Body: "follow-up"})
+	assert.ErrorIs(t, err, messages.ErrThreadClosed)
+
+	// closed threads stay readable
+	mockRepo.EXPECT().ListMessages(int64(9)).Return(nil, nil).Times(1)
+	thread, err := service.Thread(messages.Caller{ID: 2, Role: messages.RoleDoctor}, 5)
+	require.NoError(t, err)
+	assert.True(t, thread.Closed())
+}
+
+func TestThread_MarksOtherPartyRead(t *testing.T) {
+	mockRepo, service, _ := setup(t, &appointments.Appointment{ID: 5, UserID: 1, DoctorID: 2, Status: "pending"})
+
+	mockRepo.EXPECT().GetOrCreateThread(gomock.Any()).Return(&messages.Thread{ID: 9, AppointmentID: 5, UserID: 1, DoctorID: 2}, nil).Times(1)
+	mockRepo.EXPECT().ListMessages(int64(9)).Return([]messages.Message{
+		{ID: 1, ThreadID: 9, SenderID: 1, SenderRole: messages.RoleUser, Body: "question"},
+		{ID: 2, ThreadID: 9, SenderID: 2, SenderRole: messages.RoleDoctor, Body: "answer", AttachmentIDs: []int64{12, 99}},
+	}, nil).Times(1)
+	mockRepo.EXPECT().MarkRead(int64(9), messages.RoleUser, gomock.Any()).Return(nil).Times(1)
+
+	thread, err := service.Thread(messages.Caller{ID: 1, Role: messages.RoleUser}, 5)
+
+	require.NoError(t, err)
+	assert.Nil(t, thread.Messages[0].ReadAt)
+	assert.NotNil(t, thread.Messages[1].ReadAt)
+	assert.Len(t, thread.Messages[1].Attachments, 1)
+}
+
+func TestThreads_UnreadCounts(t *testing.T) {
+	mockRepo, service, _ := setup(t, nil)
+
+	mockRepo.EXPECT().ListThreads(messages.RoleDoctor, int64(2)).Return([]messages.Thread{{ID: 9}, {ID: 10}}, nil).Times(1)
+	mockRepo.EXPECT().CountUnread([]int64{9, 10}, messages.RoleDoctor).Return(map[int64]int64{10: 3}, nil).Times(1)
+
+	threads, err := service.Threads(messages.Caller{ID: 2, Role: messages.RoleDoctor})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), threads[0].UnreadCount)
+	assert.Equal(t, int64(3), threads[1].UnreadCount)
+
+	mockRepo.EXPECT().ListThreads(messages.RoleUser, int64(1)).Return(nil, errors.New("db down")).Times(1)
+	_, err = service.Threads(messages.Caller{ID: 1, Role: messages.RoleUser})
+	assert.Error(t, err)
+}
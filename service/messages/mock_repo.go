@@ -0,0 +1,144 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/messages/message_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/messages/message_repo.go -destination=service/messages/mock_repo.go -package=messages
+//
+
+// Package messages is a generated GoMock package.
+package messages
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageRepo is a mock of MessageRepo interface.
+type MockMessageRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockMessageRepoMockRecorder is the mock recorder for MockMessageRepo.
+type MockMessageRepoMockRecorder struct {
+	mock *MockMessageRepo
+}
+
+// NewMockMessageRepo creates a new mock instance.
+func NewMockMessageRepo(ctrl *gomock.Controller) *MockMessageRepo {
+	mock := &MockMessageRepo{ctrl: ctrl}
+	mock.recorder = &MockMessageRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageRepo) EXPECT() *MockMessageRepoMockRecorder {
+	return m.recorder
+}
+
+// CloseExpired mocks base method.
+func (m *MockMessageRepo) CloseExpired(completedBefore, at time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseExpired", completedBefore, at)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseExpired indicates an expected call of CloseExpired.
+func (mr *MockMessageRepoMockRecorder) CloseExpired(completedBefore, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseExpired", reflect.TypeOf((*MockMessageRepo)(nil).CloseExpired), completedBefore, at)
+}
+
+// CountUnread mocks base method.
+func (m *MockMessageRepo) CountUnread(threadIDs []int64, readerRole string) (map[int64]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnread", threadIDs, readerRole)
+	ret0, _ := ret[0].(map[int64]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnread indicates an expected call of CountUnread.
+func (mr *MockMessageRepoMockRecorder) CountUnread(threadIDs, readerRole any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnread", reflect.TypeOf((*MockMessageRepo)(nil).CountUnread), threadIDs, readerRole)
+}
+
+// CreateMessage mocks base method.
+func (m *MockMessageRepo) CreateMessage(message *Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMessage", message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMessage indicates an expected call of CreateMessage.
+func (mr *MockMessageRepoMockRecorder) CreateMessage(message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockMessageRepo)(nil).CreateMessage), message)
+}
+
+// GetOrCreateThread mocks base method.
+func (m *MockMessageRepo) GetOrCreateThread(thread *Thread) (*Thread, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrCreateThread", thread)
+	ret0, _ := ret[0].(*Thread)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrCreateThread indicates an expected call of GetOrCreateThread.
+func (mr *MockMessageRepoMockRecorder) GetOrCreateThread(thread any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrCreateThread", reflect.TypeOf((*MockMessageRepo)(nil).GetOrCreateThread), thread)
+}
+
+// ListMessages mocks base method.
+func (m *MockMessageRepo) ListMessages(threadID int64) ([]Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMessages", threadID)
+	ret0, _ := ret[0].([]Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMessages indicates an expected call of ListMessages.
+func (mr *MockMessageRepoMockRecorder) ListMessages(threadID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessages", reflect.TypeOf((*MockMessageRepo)(nil).ListMessages), threadID)
+}
+
+// ListThreads mocks base method.
+func (m *MockMessageRepo) ListThreads(role string, participantID int64) ([]Thread, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListThreads", role, participantID)
+	ret0, _ := ret[0].([]Thread)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListThreads indicates an expected call of ListThreads.
+func (mr *MockMessageRepoMockRecorder) ListThreads(role, participantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListThreads", reflect.TypeOf((*MockMessageRepo)(nil).ListThreads), role, participantID)
+}
+
+// MarkRead mocks base method.
+func (m *MockMessageRepo) MarkRead(threadID int64, readerRole string, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", threadID, readerRole, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockMessageRepoMockRecorder) MarkRead(threadID, readerRole, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockMessageRepo)(nil).MarkRead), threadID, readerRole, at)
+}
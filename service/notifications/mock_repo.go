@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/notifications/preference_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/notifications/preference_repo.go -destination=service/notifications/mock_repo.go -package=notifications
+//
+
+// Package notifications is a generated GoMock package.
+package notifications
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPreferenceRepo is a mock of PreferenceRepo interface.
+type MockPreferenceRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPreferenceRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockPreferenceRepoMockRecorder is the mock recorder for MockPreferenceRepo.
+type MockPreferenceRepoMockRecorder struct {
+	mock *MockPreferenceRepo
+}
+
+// NewMockPreferenceRepo creates a new mock instance.
+func NewMockPreferenceRepo(ctrl *gomock.Controller) *MockPreferenceRepo {
+	mock := &MockPreferenceRepo{ctrl: ctrl}
+	mock.recorder = &MockPreferenceRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPreferenceRepo) EXPECT() *MockPreferenceRepoMockRecorder {
+	return m.recorder
+}
+
+// GetByUserAndCategory mocks base method.
+func (m *MockPreferenceRepo) GetByUserAndCategory(userID int64, category string) ([]Preference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserAndCategory", userID, category)
+	ret0, _ := ret[0].([]Preference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserAndCategory indicates an expected call of GetByUserAndCategory.
+func (mr *MockPreferenceRepoMockRecorder) GetByUserAndCategory(userID, category any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserAndCategory", reflect.TypeOf((*MockPreferenceRepo)(nil).GetByUserAndCategory), userID, category)
+}
+
+// GetByUserID mocks base method.
+func (m *MockPreferenceRepo) GetByUserID(userID int64) ([]Preference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserID", userID)
+	ret0, _ := ret[0].([]Preference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserID indicates an expected call of GetByUserID.
+func (mr *MockPreferenceRepoMockRecorder) GetByUserID(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockPreferenceRepo)(nil).GetByUserID), userID)
+}
+
+// ReplaceCategory mocks base method.
+func (m *MockPreferenceRepo) ReplaceCategory(userID int64, category string, prefs []Preference) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceCategory", userID, category, prefs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceCategory indicates an expected call of ReplaceCategory.
+func (mr *MockPreferenceRepoMockRecorder) ReplaceCategory(userID, category, prefs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceCategory", reflect.TypeOf((*MockPreferenceRepo)(nil).ReplaceCategory), userID, category, prefs)
+}
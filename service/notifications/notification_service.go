@@ -0,0 +1,239 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"Dedenruslan19/med-project/repository/notification"
+	"Dedenruslan19/med-project/service/outbox"
+	"Dedenruslan19/med-project/service/users"
+)
+
+const (
+	AggregateType = "user"
+
+	topicPrefix = "notification."
+)
+
+var (
+	ErrUnsupportedCategory = errors.New("unsupported notification category")
+	ErrUnsupportedChannel  = errors.New("unsupported notification channel")
+	ErrDestinationRequired = errors.New("destination is required for this channel")
+)
+
+// Topic returns the outbox topic used for deliveries on channel.
+func Topic(channel string) string {
+	return topicPrefix + channel
+}
+
+type service struct {
+	repo          PreferenceRepo
+	userService   users.Service
+	outboxService outbox.Service
+	notifiers     map[string]notification.Notifier
+	logger        *slog.Logger
+}
+
+type Service interface {
+	GetPreferences(userID int64) ([]Preference, error)
+	SetPreferences(userID int64, category string, prefs []Preference) ([]Preference, error)
+	Messages(userID int64, category, subject, body string) ([]*outbox.Message, error)
+	Notify(userID int64, category, subject, body string) error
+	NotifyEmail(aggregateType string, aggregateID int64, email, subject, body string) error
+	Deliver(msg *outbox.Message) error
+	Channels() []string
+}
+
+// NewService wires the notifiers available for each channel. Channels without
+// a notifier are rejected when users set their preferences.
+func NewService(logger *slog.Logger, repo PreferenceRepo, userService users.Service, outboxService outbox.Service, notifiers map[string]notification.Notifier) Service {
+	return &service{
+		logger:        logger,
+		repo:          repo,
+		userService:   userService,
+		outboxService: outboxService,
+		notifiers:     notifiers,
+	}
+}
+
+func validCategory(category string) bool {
+	switch category {
+	case CategoryAppointment, CategoryBilling, CategoryInvoice, CategoryMessage:
+		return true
+	}
+	return false
+}
+
+func (s *service) Channels() []string {
+	channels := make([]string, 0, len(s.notifiers))
+	for _, ch := range []string{notification.ChannelEmail, notification.ChannelSMS, notification.ChannelWhatsApp, notification.ChannelWebhook} {
+		if _, ok := s.notifiers[ch]; ok {
+			channels = append(channels, ch)
+		}
+	}
+	return channels
+}
+
+func (s *service) GetPreferences(userID int64) ([]Preference, error) {
+	prefs, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		s.logger.Error("failed to get notification preferences",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+		)
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (s *service) SetPreferences(userID int64, category string, prefs []Preference) ([]Preference, error) {
+	if !validCategory(category) {
+		return nil, ErrUnsupportedCategory
+	}
+
+	seen := make(map[string]bool)
+	cleaned := make([]Preference, 0, len(prefs))
+	for _, p := range prefs {
+		channel := strings.ToLower(strings.TrimSpace(p.Channel))
+		if _, ok := s.notifiers[channel]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, p.Channel)
+		}
+		if seen[channel] {
+			continue
+		}
+		seen[channel] = true
+
+		destination := strings.TrimSpace(p.Destination)
+		if destination == "" {
+			if channel != notification.ChannelEmail {
+				return nil, fmt.Errorf("%w: %s", ErrDestinationRequired, channel)
+			}
+			user, err := s.userService.GetUserByID(userID)
+			if err != nil {
+				return nil, err
+			}
+			destination = user.Email
+		}
+		if channel == notification.ChannelWebhook {
+			if err := notification.ValidateWebhookURL(context.Background(), destination); err != nil {
+				return nil, err
+			}
+		}
+
+		cleaned = append(cleaned, Preference{
+			UserID:      userID,
+			Category:    category,
+			Channel:     channel,
+			Destination: destination,
+		})
+	}
+
+	// an explicit opt-out, so the category does not fall back to email
+	if len(cleaned) == 0 {
+		cleaned = append(cleaned, Preference{UserID: userID, Category: category, Channel: ChannelNone})
+	}
+
+	if err := s.repo.ReplaceCategory(userID, category, cleaned); err != nil {
+		s.logger.Error("failed to set notification preferences",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+			slog.String("category", category),
+		)
+		return nil, err
+	}
+	return cleaned, nil
+}
+
+// Messages builds one outbox message per preferred channel without storing
+// them, so callers can enqueue them in the same transaction as their change.
+// Users without preferences for the category are notified by email; users
+// who opted out get no messages.
+func (s *service) Messages(userID int64, category, subject, body string) ([]*outbox.Message, error) {
+	prefs, err := s.repo.GetByUserAndCategory(userID, category)
+	if err != nil {
+		s.logger.Error("failed to resolve notification preferences",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+			slog.String("category", category),
+		)
+		return nil, err
+	}
+
+	if len(prefs) == 0 {
+		user, err := s.userService.GetUserByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		prefs = []Preference{{UserID: userID, Category: category, Channel: notification.ChannelEmail, Destination: user.Email}}
+	}
+
+	messages := make([]*outbox.Message, 0, len(prefs))
+	for _, p := range prefs {
+		if p.Channel == ChannelNone {
+			continue
+		}
+		messages = append(messages, &outbox.Message{
+			Topic:         Topic(p.Channel),
+			AggregateType: AggregateType,
+			AggregateID:   userID,
+			Recipient:     p.Destination,
+			Subject:       subject,
+			Payload:       body,
+		})
+	}
+	return messages, nil
+}
+
+func (s *service) Notify(userID int64, category, subject, body string) error {
+	messages, err := s.Messages(userID, category, subject, body)
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range messages {
+		if _, err := s.outboxService.Enqueue(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyEmail emails a recipient that has no notification preferences, such
+// as a doctor.
+func (s *service) NotifyEmail(aggregateType string, aggregateID int64, email, subject, body string) error {
+	if _, ok := s.notifiers[notification.ChannelEmail]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedChannel, notification.ChannelEmail)
+	}
+
+	_, err := s.outboxService.Enqueue(&outbox.Message{
+		Topic:         Topic(notification.ChannelEmail),
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Recipient:     email,
+		Subject:       subject,
+		Payload:       body,
+	})
+	return err
+}
+
+// Deliver is the outbox handler for every notification topic.
+func (s *service) Deliver(msg *outbox.Message) error {
+	channel := strings.TrimPrefix(msg.Topic, topicPrefix)
+	notifier, ok := s.notifiers[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedChannel, channel)
+	}
+
+	if err := notifier.Send(msg.Recipient, msg.Subject, msg.Payload); err != nil {
+		s.logger.Error("failed to deliver notification",
+			slog.Any("error", err),
+			slog.String("channel", channel),
+			slog.Int64("message_id", msg.ID),
+		)
+		return err
+	}
+	return nil
+}
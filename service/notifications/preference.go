@@ -0,0 +1,29 @@
+package notifications
+
+import "time"
+
+const (
+	CategoryAppointment = "appointment"
+	CategoryBilling     = "billing"
+	CategoryInvoice     = "invoice"
+	CategoryMessage     = "message"
+
+	// ChannelNone is stored when a user turns a category off.
+	ChannelNone = "none"
+)
+
+// Preference routes one category of notifications for a user to a channel.
+// A user may have several preferences per category to fan out to more than
+// one channel.
+type Preference struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      int64     `json:"user_id" gorm:"not null;uniqueIndex:idx_notification_preferences_unique"`
+	Category    string    `json:"category" gorm:"type:varchar(50);not null;uniqueIndex:idx_notification_preferences_unique"`
+	Channel     string    `json:"channel" gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_preferences_unique"`
+	Destination string    `json:"destination" gorm:"type:varchar(500);not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (Preference) TableName() string {
+	return "notification_preferences"
+}
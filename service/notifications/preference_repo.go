@@ -0,0 +1,7 @@
+package notifications
+
+type PreferenceRepo interface {
+	GetByUserID(userID int64) ([]Preference, error)
+	GetByUserAndCategory(userID int64, category string) ([]Preference, error)
+	ReplaceCategory(userID int64, category string, prefs []Preference) error
+}
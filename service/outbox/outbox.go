@@ -0,0 +1,30 @@
+package outbox
+
+import "time"
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+type Message struct {
+	ID            int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Topic         string     `json:"topic" gorm:"type:varchar(100);not null;index"`
+	AggregateType string     `json:"aggregate_type" gorm:"type:varchar(50)"`
+	AggregateID   int64      `json:"aggregate_id"`
+	Recipient     string     `json:"recipient" gorm:"type:varchar(255)"`
+	Subject       string     `json:"subject" gorm:"type:varchar(255)"`
+	Payload       string     `json:"payload" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts   int        `json:"max_attempts" gorm:"not null;default:8"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null;index"`
+	LastError     string     `json:"last_error" gorm:"type:text"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (Message) TableName() string {
+	return "outbox_messages"
+}
@@ -0,0 +1,15 @@
+package outbox
+
+import "time"
+
+type OutboxRepo interface {
+	Create(msg *Message) (int64, error)
+	GetByID(id int64) (*Message, error)
+	ListByStatus(status string, limit int) ([]Message, error)
+	FetchDue(now time.Time, limit int) ([]Message, error)
+	Claim(id int64, now, leaseUntil time.Time) (bool, error)
+	MarkDelivered(id int64, deliveredAt time.Time) error
+	MarkRetry(id int64, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkDead(id int64, attempts int, lastError string) error
+	Requeue(id int64, nextAttemptAt time.Time) error
+}
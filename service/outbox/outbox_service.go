@@ -0,0 +1,270 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 8
+	baseRetryDelay     = 30 * time.Second
+	maxRetryDelay      = 1 * time.Hour
+	claimLease         = 2 * time.Minute
+	dispatchBatchSize  = 50
+)
+
+var (
+	ErrNotDeadLettered = errors.New("only dead-lettered messages can be replayed")
+	ErrNoHandler       = errors.New("no handler registered for topic")
+)
+
+// Handler delivers a single outbox message. Returning an error schedules a retry.
+type Handler func(msg *Message) error
+
+type service struct {
+	repo     OutboxRepo
+	logger   *slog.Logger
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	now      func() time.Time
+}
+
+type Service interface {
+	Enqueue(msg *Message) (int64, error)
+	Handle(topic string, handler Handler)
+	DispatchPending() (int, error)
+	Run(ctx context.Context, interval time.Duration)
+	GetByID(id int64) (*Message, error)
+	ListByStatus(status string, limit int) ([]Message, error)
+	Replay(id int64) error
+}
+
+func NewService(logger *slog.Logger, repo OutboxRepo) Service {
+	return &service{
+		logger:   logger,
+		repo:     repo,
+		handlers: make(map[string]Handler),
+		now:      time.Now,
+	}
+}
+
+// Backoff returns the delay before the given retry attempt (1-based),
+// doubling from 30s and capped at one hour.
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := baseRetryDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
+
+// Prepare fills the delivery defaults of a message before it is stored.
+func Prepare(msg *Message, now time.Time) {
+	if msg.Status == "" {
+		msg.Status = StatusPending
+	}
+	if msg.MaxAttempts == 0 {
+		msg.MaxAttempts = DefaultMaxAttempts
+	}
+	if msg.NextAttemptAt.IsZero() {
+		msg.NextAttemptAt = now
+	}
+}
+
+func (s *service) Enqueue(msg *Message) (int64, error) {
+	Prepare(msg, s.now())
+
+	id, err := s.repo.Create(msg)
+	if err != nil {
+		s.logger.Error("failed to enqueue outbox message",
+			slog.Any("error", err),
+			slog.String("topic", msg.Topic),
+		)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *service) Handle(topic string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[topic] = handler
+}
+
+func (s *service) handler(topic string) (Handler, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	h, ok := s.handlers[topic]
+	return h, ok
+}
+
+// DispatchPending delivers every due message once and returns how many were delivered.
+func (s *service) DispatchPending() (int, error) {
+	now := s.now()
+	messages, err := s.repo.FetchDue(now, dispatchBatchSize)
+	if err != nil {
+		s.logger.Error("failed to fetch due outbox messages", slog.Any("error", err))
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range messages {
+		msg := &messages[i]
+
+		claimed, err := s.repo.Claim(msg.ID, now, now.Add(claimLease))
+		if err != nil {
+			s.logger.Error("failed to claim outbox message",
+				slog.Any("error", err),
+				slog.Int64("message_id", msg.ID),
+			)
+			continue
+		}
+		if !claimed {
+			// another dispatcher picked it up
+			continue
+		}
+
+		if s.deliver(msg) {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+func (s *service) deliver(msg *Message) bool {
+	handler, ok := s.handler(msg.Topic)
+	if !ok {
+		// e.g. a channel that is not configured on this instance; keep the
+		// message so it is delivered once a handler is registered, or can be
+		// replayed from the dead letters
+		s.fail(msg, fmt.Errorf("%w: %s", ErrNoHandler, msg.Topic))
+		return false
+	}
+
+	if err := handler(msg); err != nil {
+		s.fail(msg, err)
+		return false
+	}
+
+	return s.markDelivered(msg)
+}
+
+func (s *service) markDelivered(msg *Message) bool {
+	if err := s.repo.MarkDelivered(msg.ID, s.now()); err != nil {
+		s.logger.Error("failed to mark outbox message as delivered",
+			slog.Any("error", err),
+			slog.Int64("message_id", msg.ID),
+		)
+		return false
+	}
+	return true
+}
+
+func (s *service) fail(msg *Message, cause error) {
+	attempts := msg.Attempts + 1
+	maxAttempts := msg.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	if attempts >= maxAttempts {
+		s.logger.Error("outbox message moved to dead letter",
+			slog.Any("error", cause),
+			slog.Int64("message_id", msg.ID),
+			slog.String("topic", msg.Topic),
+			slog.Int("attempts", attempts),
+		)
+		if err := s.repo.MarkDead(msg.ID, attempts, cause.Error()); err != nil {
+			s.logger.Error("failed to dead-letter outbox message",
+				slog.Any("error", err),
+				slog.Int64("message_id", msg.ID),
+			)
+		}
+		return
+	}
+
+	next := s.now().Add(Backoff(attempts))
+	s.logger.Warn("outbox delivery failed, retry scheduled",
+		slog.Any("error", cause),
+		slog.Int64("message_id", msg.ID),
+		slog.String("topic", msg.Topic),
+		slog.Int("attempts", attempts),
+		slog.Time("next_attempt_at", next),
+	)
+	if err := s.repo.MarkRetry(msg.ID, attempts, next, cause.Error()); err != nil {
+		s.logger.Error("failed to schedule outbox retry",
+			slog.Any("error", err),
+			slog.Int64("message_id", msg.ID),
+		)
+	}
+}
+
+// Run dispatches due messages every interval until ctx is cancelled.
+func (s *service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.DispatchPending()
+		}
+	}
+}
+
+func (s *service) GetByID(id int64) (*Message, error) {
+	msg, err := s.repo.GetByID(id)
+	if err != nil {
+		s.logger.Error("failed to get outbox message by ID",
+			slog.Any("error", err),
+			slog.Int64("message_id", id),
+		)
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *service) ListByStatus(status string, limit int) ([]Message, error) {
+	messages, err := s.repo.ListByStatus(status, limit)
+	if err != nil {
+		s.logger.Error("failed to list outbox messages",
+			slog.Any("error", err),
+			slog.String("status", status),
+		)
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (s *service) Replay(id int64) error {
+	msg, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if msg.Status != StatusDead {
+		return ErrNotDeadLettered
+	}
+
+	if err := s.repo.Requeue(id, s.now()); err != nil {
+		s.logger.Error("failed to replay outbox message",
+			slog.Any("error", err),
+			slog.Int64("message_id", id),
+		)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package outbox_test
+
+import (
+	"Dedenruslan19/med-project/service/outbox"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDispatchPending_RetryWithBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := outbox.NewMockOutboxRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := outbox.NewService(logger, mockRepo)
+
+	service.Handle("invoice.email", func(msg *outbox.Message) error {
+		return errors.New("smtp unavailable")
+	})
+
+	msg := outbox.Message{ID: 1, Topic: "invoice.email", Attempts: 1, MaxAttempts: 5, Status: outbox.StatusPending}
+
+	mockRepo.EXPECT().FetchDue(gomock.Any(), gomock.Any()).Return([]outbox.Message{msg}, nil).Times(1)
+	mockRepo.EXPECT().Claim(int64(1), gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+	mockRepo.EXPECT().
+		MarkRetry(int64(1), 2, gomock.Any(), "smtp unavailable").
+		DoAndReturn(func(id int64, attempts int, next time.Time, lastError string) error {
+			assert.WithinDuration(t, time.Now().Add(outbox.Backoff(2)), next, 5*time.Second)
+			return nil
+		}).
+		Times(1)
+
+	delivered, err := service.DispatchPending()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+}
+
+func TestDispatchPending_KeepsMessagesWithoutHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := outbox.NewMockOutboxRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := outbox.NewService(logger, mockRepo)
+
+	msg := outbox.Message{ID: 3, Topic: "notification.sms", MaxAttempts: 5, Status: outbox.StatusPending}
+
+	mockRepo.EXPECT().FetchDue(gomock.Any(), gomock.Any()).Return([]outbox.Message{msg}, nil).Times(1)
+	mockRepo.EXPECT().Claim(int64(3), gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+	mockRepo.EXPECT().MarkDelivered(gomock.Any(), gomock.Any()).Times(0)
+	mockRepo.EXPECT().
+		MarkRetry(int64(3), 1, gomock.Any(), "no handler registered for topic: notification.sms").
+		Return(nil).
+		Times(1)
+
+	delivered, err := service.DispatchPending()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+}
+
+func TestDispatchPending_DeadLetterAfterMaxAttempts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := outbox.NewMockOutboxRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := outbox.NewService(logger, mockRepo)
+
+	service.Handle("invoice.email", func(msg *outbox.Message) error {
+		return errors.New("smtp unavailable")
+	})
+
+	msg := outbox.Message{ID: 2, Topic: "invoice.email", Attempts: 4, MaxAttempts: 5, Status: outbox.StatusPending}
+
+	mockRepo.EXPECT().FetchDue(gomock.Any(), gomock.Any()).Return([]outbox.Message{msg}, nil).Times(1)
+	mockRepo.EXPECT().Claim(int64(2), gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+	mockRepo.EXPECT().MarkDead(int64(2), 5, "smtp unavailable").Return(nil).Times(1)
+
+	delivered, err := service.DispatchPending()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+}
+
+func TestReplay_OnlyDeadLetters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := outbox.NewMockOutboxRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := outbox.NewService(logger, mockRepo)
+
+	mockRepo.EXPECT().
+		GetByID(int64(3)).
+		Return(&outbox.Message{ID: 3, Status: outbox.StatusDelivered}, nil).
+		Times(1)
+
+	err := service.Replay(3)
+
+	assert.ErrorIs(t, err, outbox.ErrNotDeadLettered)
+}
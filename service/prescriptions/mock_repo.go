@@ -0,0 +1,130 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/prescriptions/prescription_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/prescriptions/prescription_repo.go -destination=service/prescriptions/mock_repo.go -package=prescriptions
+//
+
+// Package prescriptions is a generated GoMock package.
+package prescriptions
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPrescriptionRepo is a mock of PrescriptionRepo interface.
+type MockPrescriptionRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrescriptionRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockPrescriptionRepoMockRecorder is the mock recorder for MockPrescriptionRepo.
+type MockPrescriptionRepoMockRecorder struct {
+	mock *MockPrescriptionRepo
+}
+
+// NewMockPrescriptionRepo creates a new mock instance.
+func NewMockPrescriptionRepo(ctrl *gomock.Controller) *MockPrescriptionRepo {
+	mock := &MockPrescriptionRepo{ctrl: ctrl}
+	mock.recorder = &MockPrescriptionRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrescriptionRepo) EXPECT() *MockPrescriptionRepoMockRecorder {
+	return m.recorder
+}
+
+// CreatePharmacy mocks base method.
+func (m *MockPrescriptionRepo) CreatePharmacy(pharmacy *Pharmacy) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePharmacy", pharmacy)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePharmacy indicates an expected call of CreatePharmacy.
+func (mr *MockPrescriptionRepoMockRecorder) CreatePharmacy(pharmacy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePharmacy", reflect.TypeOf((*MockPrescriptionRepo)(nil).CreatePharmacy), pharmacy)
+}
+
+// GetByCode mocks base method.
+func (m *MockPrescriptionRepo) GetByCode(code string) (*Prescription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCode", code)
+	ret0, _ := ret[0].(*Prescription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCode indicates an expected call of GetByCode.
+func (mr *MockPrescriptionRepoMockRecorder) GetByCode(code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCode", reflect.TypeOf((*MockPrescriptionRepo)(nil).GetByCode), code)
+}
+
+// GetLatestByDiagnoseID mocks base method.
+func (m *MockPrescriptionRepo) GetLatestByDiagnoseID(diagnoseID int64) (*Prescription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestByDiagnoseID", diagnoseID)
+	ret0, _ := ret[0].(*Prescription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestByDiagnoseID indicates an expected call of GetLatestByDiagnoseID.
+func (mr *MockPrescriptionRepoMockRecorder) GetLatestByDiagnoseID(diagnoseID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestByDiagnoseID", reflect.TypeOf((*MockPrescriptionRepo)(nil).GetLatestByDiagnoseID), diagnoseID)
+}
+
+// GetPharmacyByKeyHash mocks base method.
+func (m *MockPrescriptionRepo) GetPharmacyByKeyHash(keyHash string) (*Pharmacy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPharmacyByKeyHash", keyHash)
+	ret0, _ := ret[0].(*Pharmacy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPharmacyByKeyHash indicates an expected call of GetPharmacyByKeyHash.
+func (mr *MockPrescriptionRepoMockRecorder) GetPharmacyByKeyHash(keyHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPharmacyByKeyHash", reflect.TypeOf((*MockPrescriptionRepo)(nil).GetPharmacyByKeyHash), keyHash)
+}
+
+// Issue mocks base method.
+func (m *MockPrescriptionRepo) Issue(prescription *Prescription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Issue", prescription)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Issue indicates an expected call of Issue.
+func (mr *MockPrescriptionRepoMockRecorder) Issue(prescription any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issue", reflect.TypeOf((*MockPrescriptionRepo)(nil).Issue), prescription)
+}
+
+// MarkDispensed mocks base method.
+func (m *MockPrescriptionRepo) MarkDispensed(id int64, pharmacy *Pharmacy, at time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDispensed", id, pharmacy, at)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkDispensed indicates an expected call of MarkDispensed.
+func (mr *MockPrescriptionRepoMockRecorder) MarkDispensed(id, pharmacy, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDispensed", reflect.TypeOf((*MockPrescriptionRepo)(nil).MarkDispensed), id, pharmacy, at)
+}
@@ -0,0 +1,142 @@
+package prescriptions
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The prescription is a single A4 page drawn with the standard Helvetica
+// fonts, which every PDF reader provides, so no fonts are embedded.
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 56.0
+)
+
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+// escapePDF encodes s as a PDF literal string in WinAnsi, replacing the
+// characters that encoding lacks.
+func escapePDF(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func (p *pdfPage) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, size, x, y, escapePDF(s))
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%.1f %.1f m %.1f %.1f l S\n", x1, y1, x2, y2)
+}
+
+func (p *pdfPage) rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%.1f %.1f %.1f %.1f re S\n", x, y, w, h)
+}
+
+// write lays out the catalog, page tree, fonts and content stream with a
+// cross-reference table.
+func (p *pdfPage) write(w io.Writer) error {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", pageWidth, pageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// renderPDF draws the prescription with its verification code and the URL a
+// pharmacy can check it at.
+func renderPDF(w io.Writer, p *Prescription, verifyURL string) error {
+	page := &pdfPage{}
+	y := pageHeight - margin
+
+	page.text(margin, y, 20, true, "PRESCRIPTION")
+	page.text(pageWidth-margin-150, y, 10, false, "Issued "+p.IssuedAt.Format("02 Jan 2006"))
+	y -= 14
+	page.line(margin, y, pageWidth-margin, y)
+
+	y -= 24
+	page.text(margin, y, 11, true, "Prescriber")
+	y -= 16
+	page.text(margin, y, 11, false, "Dr. "+p.DoctorName)
+	if p.Specialization != "" {
+		y -= 14
+		page.text(margin, y, 10, false, p.Specialization)
+	}
+	y -= 14
+	page.text(margin, y, 10, false, "License no. "+p.LicenseNumber)
+
+	y -= 28
+	page.text(margin, y, 11, true, "Patient")
+	y -= 16
+	page.text(margin, y, 11, false, p.PatientName)
+	y -= 14
+	page.text(margin, y, 10, false, "Patient no. "+strconv.FormatInt(p.UserID, 10)+
+		"    Appointment no. "+strconv.FormatInt(p.AppointmentID, 10))
+
+	y -= 36
+	page.text(margin, y, 22, true, "Rx")
+	for i, med := range p.Medications {
+		y -= 20
+		page.text(margin+16, y, 11, false, fmt.Sprintf("%d.  %s", i+1, med))
+	}
+
+	y -= 56
+	page.line(pageWidth-margin-200, y, pageWidth-margin, y)
+	y -= 12
+	page.text(pageWidth-margin-200, y, 9, false, "Signature of Dr. "+p.DoctorName)
+
+	// verification box at the bottom of the page
+	boxHeight := 84.0
+	page.rect(margin, margin, pageWidth-2*margin, boxHeight)
+	page.text(margin+14, margin+boxHeight-22, 10, true, "Verification code")
+	page.text(margin+14, margin+boxHeight-50, 20, true, p.DisplayCode())
+	page.text(margin+14, margin+26, 9, false, "Pharmacies: verify at "+verifyURL)
+	page.text(margin+14, margin+12, 9, false, "before dispensing. Valid for one dispensing only.")
+
+	return page.write(w)
+}
@@ -0,0 +1,103 @@
+package prescriptions
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	StatusIssued    = "issued"
+	StatusDispensed = "dispensed"
+	StatusRevoked   = "revoked"
+)
+
+// Prescription is the printable prescription of a signed diagnosis version.
+// The doctor and patient details are copied when it is issued so the printed
+// document and its verification always agree. A diagnosis has at most one
+// prescription per version and at most one that can still be dispensed.
+type Prescription struct {
+	ID              int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	DiagnoseID      int64      `json:"diagnose_id" gorm:"not null;index;uniqueIndex:idx_prescriptions_diagnose_version;uniqueIndex:idx_prescriptions_live,where:status = 'issued'"`
+	DiagnoseVersion int        `json:"diagnose_version" gorm:"not null;uniqueIndex:idx_prescriptions_diagnose_version"`
+	AppointmentID   int64      `json:"appointment_id" gorm:"not null"`
+	UserID          int64      `json:"user_id" gorm:"not null"`
+	PatientName     string     `json:"patient_name" gorm:"type:varchar(255);not null"`
+	DoctorID        int64      `json:"doctor_id" gorm:"not null"`
+	DoctorName      string     `json:"doctor_name" gorm:"type:varchar(255);not null"`
+	Specialization  string     `json:"specialization" gorm:"type:varchar(255)"`
+	LicenseNumber   string     `json:"license_number" gorm:"type:varchar(50);not null"`
+	Medications     []string   `json:"medications" gorm:"type:text;serializer:encrypted;not null"`
+	Code            string     `json:"code" gorm:"type:varchar(32);uniqueIndex;not null"`
+	Status          string     `json:"status" gorm:"type:varchar(20);not null;default:issued"`
+	IssuedAt        time.Time  `json:"issued_at" gorm:"not null"`
+	DispensedAt     *time.Time `json:"dispensed_at,omitempty"`
+	DispensedBy     string     `json:"dispensed_by,omitempty" gorm:"type:varchar(255)"`
+	PharmacyID      *int64     `json:"pharmacy_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// Revocation withdraws the prescription of a signed diagnosis that can still
+// be dispensed, when the amended diagnosis no longer prescribes anything.
+type Revocation struct {
+	DiagnoseID int64
+}
+
+// Pharmacy is a pharmacy allowed to dispense prescriptions. It authenticates
+// with the API key it was given when registered; only a hash of the key is
+// kept.
+type Pharmacy struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string    `json:"name" gorm:"type:varchar(255);not null"`
+	KeyHash   string    `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// DisplayCode groups the verification code in fours, e.g.
+// "K7QD-M2XA-9RTB-HC4E", for printing and reading out.
+func (p *Prescription) DisplayCode() string {
+	var groups []string
+	for i := 0; i < len(p.Code); i += 4 {
+		groups = append(groups, p.Code[i:min(i+4, len(p.Code))])
+	}
+	return strings.Join(groups, "-")
+}
+
+// Verification is what a pharmacy sees for a verification code: enough to
+// match the paper prescription, with the patient reduced to initials.
+type Verification struct {
+	Code            string     `json:"code"`
+	Genuine         bool       `json:"genuine"`
+	Dispensable     bool       `json:"dispensable"`
+	Status          string     `json:"status"`
+	IssuedAt        time.Time  `json:"issued_at"`
+	DispensedAt     *time.Time `json:"dispensed_at,omitempty"`
+	DispensedBy     string     `json:"dispensed_by,omitempty"`
+	DoctorName      string     `json:"doctor_name"`
+	LicenseNumber   string     `json:"license_number"`
+	PatientInitials string     `json:"patient_initials"`
+	Medications     []string   `json:"medications"`
+}
+
+func initials(name string) string {
+	var parts []string
+	for _, word := range strings.Fields(name) {
+		parts = append(parts, strings.ToUpper(string([]rune(word)[0]))+".")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p *Prescription) Verification() *Verification {
+	return &Verification{
+		Code:            p.DisplayCode(),
+		Genuine:         true,
+		Dispensable:     p.Status == StatusIssued,
+		Status:          p.Status,
+		IssuedAt:        p.IssuedAt,
+		DispensedAt:     p.DispensedAt,
+		DispensedBy:     p.DispensedBy,
+		DoctorName:      p.DoctorName,
+		LicenseNumber:   p.LicenseNumber,
+		PatientInitials: initials(p.PatientName),
+		Medications:     p.Medications,
+	}
+}
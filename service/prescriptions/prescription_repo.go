@@ -0,0 +1,20 @@
+package prescriptions
+
+import "time"
+
+type PrescriptionRepo interface {
+	// Issue stores the prescription of a diagnosis version and revokes the
+	// one it replaces. When the version already has its prescription, or the
+	// previous one was dispensed, nothing is issued and prescription is set
+	// to the existing one.
+	Issue(prescription *Prescription) error
+	GetByCode(code string) (*Prescription, error)
+	// GetLatestByDiagnoseID returns the most recently issued prescription of
+	// the diagnosis.
+	GetLatestByDiagnoseID(diagnoseID int64) (*Prescription, error)
+	// MarkDispensed dispenses an issued prescription and reports whether it
+	// was still issued, so it is dispensed at most once.
+	MarkDispensed(id int64, pharmacy *Pharmacy, at time.Time) (bool, error)
+	CreatePharmacy(pharmacy *Pharmacy) (int64, error)
+	GetPharmacyByKeyHash(keyHash string) (*Pharmacy, error)
+}
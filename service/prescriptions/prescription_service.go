@@ -0,0 +1,308 @@
+package prescriptions
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/doctors"
+	"Dedenruslan19/med-project/service/users"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDiagnoseNotFound     = errors.New("diagnose not found")
+	ErrNotSigned            = errors.New("the diagnosis must be signed before its prescription is issued")
+	ErrNoMedications        = errors.New("the diagnosis prescribes no medication")
+	ErrLicenseMissing       = errors.New("the doctor has no license number on file")
+	ErrPrescriptionNotFound = errors.New("prescription not found")
+	ErrAlreadyDispensed     = errors.New("prescription has already been dispensed")
+	ErrPrescriptionRevoked  = errors.New("prescription was replaced by an amended one")
+	ErrInvalidPharmacy      = errors.New("a pharmacy needs a name of at most 255 characters")
+	ErrUnknownPharmacy      = errors.New("unknown pharmacy key")
+)
+
+// codeEncoding leaves out the padding; 10 random bytes make 16 characters.
+var codeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var codeSeparators = regexp.MustCompile(`[^A-Z0-9]`)
+
+// NormalizeCode accepts a code as printed, typed in lower case or without
+// the dashes.
+func NormalizeCode(code string) string {
+	return codeSeparators.ReplaceAllString(strings.ToUpper(code), "")
+}
+
+// newPharmacyKey returns a random API key and the hash kept of it.
+func newPharmacyKey() (key, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = hex.EncodeToString(b)
+	return key, hashPharmacyKey(key), nil
+}
+
+func hashPharmacyKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func newCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return codeEncoding.EncodeToString(b), nil
+}
+
+type service struct {
+	repo               PrescriptionRepo
+	diagnoseService    diagnoses.Service
+	appointmentService appointments.Service
+	userService        users.Service
+	doctorService      doctors.Service
+	verifyBaseURL      string
+	logger             *slog.Logger
+}
+
+type Service interface {
+	Records() diagnoses.RecordBuilder
+	Issue(diagnoseID int64) (*Prescription, error)
+	Verify(code string) (*Verification, error)
+	Dispense(code string, pharmacy *Pharmacy) (*Verification, error)
+	RegisterPharmacy(name string) (pharmacy *Pharmacy, key string, err error)
+	AuthenticatePharmacy(key string) (*Pharmacy, error)
+	WritePDF(w io.Writer, prescription *Prescription) error
+}
+
+// NewService issues prescriptions whose printed verification link starts
+// with verifyBaseURL, the public address of the API.
+func NewService(logger *slog.Logger, repo PrescriptionRepo, diagnoseService diagnoses.Service, appointmentService appointments.Service,
+	userService users.Service, doctorService doctors.Service, verifyBaseURL string) Service {
+	return &service{
+		logger:             logger,
+		repo:               repo,
+		diagnoseService:    diagnoseService,
+		appointmentService: appointmentService,
+		userService:        userService,
+		doctorService:      doctorService,
+		verifyBaseURL:      strings.TrimRight(verifyBaseURL, "/"),
+	}
+}
+
+// prescribe builds the prescription of the current version of a signed
+// diagnosis, copying the patient and doctor details.
+func (s *service) prescribe(diagnose *diagnoses.Diagnose) (*Prescription, error) {
+	if !diagnose.Signed() {
+		return nil, ErrNotSigned
+	}
+	medications := diagnose.MedicationList()
+	if len(medications) == 0 {
+		return nil, ErrNoMedications
+	}
+
+	appointment, err := s.appointmentService.GetByID(diagnose.AppointmentID)
+	if err != nil {
+		return nil, err
+	}
+	patient, err := s.userService.GetUserByID(appointment.UserID)
+	if err != nil {
+		return nil, err
+	}
+	doctor, err := s.doctorService.GetByID(diagnose.DoctorID)
+	if err != nil {
+		return nil, err
+	}
+	if doctor.LicenseNumber == "" {
+		return nil, ErrLicenseMissing
+	}
+
+	code, err := newCode()
+	if err != nil {
+		return nil, err
+	}
+	return &Prescription{
+		DiagnoseID:      diagnose.ID,
+		DiagnoseVersion: diagnose.Version,
+		AppointmentID:   appointment.ID,
+		UserID:          patient.ID,
+		PatientName:     patient.FullName,
+		DoctorID:        doctor.ID,
+		DoctorName:      doctor.FullName,
+		Specialization:  doctor.Specialization,
+		LicenseNumber:   doctor.LicenseNumber,
+		Medications:     medications,
+		Code:            code,
+		Status:          StatusIssued,
+		IssuedAt:        time.Now(),
+	}, nil
+}
+
+// Records issues the prescription of a diagnosis when it is signed or
+// amended, in the same transaction. An amendment revokes the prescription it
+// replaces unless that one was dispensed already, and a signed diagnosis that
+// no longer prescribes anything has its prescription revoked. Drafts get no
+// prescription.
+func (s *service) Records() diagnoses.RecordBuilder {
+	return func(diagnose *diagnoses.Diagnose) ([]interface{}, error) {
+		if !diagnose.Signed() {
+			return nil, nil
+		}
+		prescription, err := s.prescribe(diagnose)
+		if errors.Is(err, ErrLicenseMissing) {
+			s.logger.Warn("prescription not issued, the doctor has no license number on file",
+				slog.Int64("diagnose_id", diagnose.ID),
+				slog.Int64("doctor_id", diagnose.DoctorID),
+			)
+		}
+		if errors.Is(err, ErrNoMedications) || errors.Is(err, ErrLicenseMissing) {
+			return []interface{}{&Revocation{DiagnoseID: diagnose.ID}}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{prescription}, nil
+	}
+}
+
+// Issue returns the prescription of a signed diagnosis. It is normally
+// issued when the diagnosis is signed; diagnoses signed before that, or
+// while the doctor had no license number on file, have theirs issued on
+// first request. Once a prescription was dispensed it stays the current one.
+func (s *service) Issue(diagnoseID int64) (*Prescription, error) {
+	diagnose, err := s.diagnoseService.GetByID(diagnoseID)
+	if err != nil {
+		return nil, ErrDiagnoseNotFound
+	}
+	if !diagnose.Signed() {
+		return nil, ErrNotSigned
+	}
+
+	latest, err := s.repo.GetLatestByDiagnoseID(diagnoseID)
+	if err == nil && (latest.DiagnoseVersion == diagnose.Version || latest.Status == StatusDispensed) {
+		return latest, nil
+	}
+
+	prescription, err := s.prescribe(diagnose)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Issue(prescription); err != nil {
+		// issued concurrently for the same version
+		if latest, latestErr := s.repo.GetLatestByDiagnoseID(diagnoseID); latestErr == nil && latest.DiagnoseVersion == diagnose.Version {
+			return latest, nil
+		}
+		s.logger.Error("failed to issue prescription",
+			slog.Any("error", err),
+			slog.Int64("diagnose_id", diagnoseID),
+		)
+		return nil, err
+	}
+	return prescription, nil
+}
+
+func (s *service) get(code string) (*Prescription, error) {
+	prescription, err := s.repo.GetByCode(NormalizeCode(code))
+	if err != nil {
+		return nil, ErrPrescriptionNotFound
+	}
+	return prescription, nil
+}
+
+// Verify tells a pharmacy whether a code belongs to a genuine prescription
+// and whether it can still be dispensed.
+func (s *service) Verify(code string) (*Verification, error) {
+	prescription, err := s.get(code)
+	if err != nil {
+		return nil, err
+	}
+	return prescription.Verification(), nil
+}
+
+// Dispense records that pharmacy handed out the medication. A prescription
+// is dispensed once.
+func (s *service) Dispense(code string, pharmacy *Pharmacy) (*Verification, error) {
+	prescription, err := s.get(code)
+	if err != nil {
+		return nil, err
+	}
+	switch prescription.Status {
+	case StatusDispensed:
+		return prescription.Verification(), ErrAlreadyDispensed
+	case StatusRevoked:
+		return prescription.Verification(), ErrPrescriptionRevoked
+	}
+
+	now := time.Now()
+	dispensed, err := s.repo.MarkDispensed(prescription.ID, pharmacy, now)
+	if err != nil {
+		s.logger.Error("failed to dispense prescription",
+			slog.Any("error", err),
+			slog.Int64("prescription_id", prescription.ID),
+		)
+		return nil, err
+	}
+	if !dispensed {
+		// dispensed or revoked concurrently
+		if prescription, err = s.get(code); err != nil {
+			return nil, err
+		}
+		if prescription.Status == StatusRevoked {
+			return prescription.Verification(), ErrPrescriptionRevoked
+		}
+		return prescription.Verification(), ErrAlreadyDispensed
+	}
+
+	prescription.Status = StatusDispensed
+	prescription.DispensedAt = &now
+	prescription.DispensedBy = pharmacy.Name
+	prescription.PharmacyID = &pharmacy.ID
+	return prescription.Verification(), nil
+}
+
+// RegisterPharmacy allows a pharmacy to dispense prescriptions. The returned
+// key is shown once; only its hash is stored.
+func (s *service) RegisterPharmacy(name string) (*Pharmacy, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 255 {
+		return nil, "", ErrInvalidPharmacy
+	}
+
+	key, hash, err := newPharmacyKey()
+	if err != nil {
+		return nil, "", err
+	}
+	pharmacy := &Pharmacy{Name: name, KeyHash: hash}
+	if _, err := s.repo.CreatePharmacy(pharmacy); err != nil {
+		s.logger.Error("failed to register pharmacy",
+			slog.Any("error", err),
+			slog.String("name", name),
+		)
+		return nil, "", err
+	}
+	return pharmacy, key, nil
+}
+
+// AuthenticatePharmacy returns the pharmacy an API key was issued to.
+func (s *service) AuthenticatePharmacy(key string) (*Pharmacy, error) {
+	if key == "" {
+		return nil, ErrUnknownPharmacy
+	}
+	pharmacy, err := s.repo.GetPharmacyByKeyHash(hashPharmacyKey(key))
+	if err != nil {
+		return nil, ErrUnknownPharmacy
+	}
+	return pharmacy, nil
+}
+
+func (s *service) WritePDF(w io.Writer, prescription *Prescription) error {
+	return renderPDF(w, prescription, s.verifyBaseURL+"/prescriptions/verify/"+prescription.DisplayCode())
+}
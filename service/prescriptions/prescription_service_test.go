@@ -0,0 +1,225 @@
+package prescriptions_test
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/doctors"
+	"Dedenruslan19/med-project/service/prescriptions"
+	"Dedenruslan19/med-project/service/users"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type fakeDiagnoseService struct {
+	diagnoses.Service
+	diagnose *diagnoses.Diagnose
+}
+
+func (f fakeDiagnoseService) GetByID(id int64) (*diagnoses.Diagnose, error) {
+	return f.diagnose, nil
+}
+
+type fakeAppointmentService struct {
+	appointments.Service
+}
+
+func (fakeAppointmentService) GetByID(id int64) (*appointments.Appointment, error) {
+	return &appointments.Appointment{ID: id, UserID: 1, DoctorID: 2}, nil
+}
+
+type fakeUserService struct {
+	users.Service
+}
+
+func (fakeUserService) GetUserByID(userID int64) (users.User, error) {
+	return users.User{ID: userID, FullName: "Jane Patient"}, nil
+}
+
+type fakeDoctorService struct {
+	doctors.Service
+}
+
+func (fakeDoctorService) GetByID(id int64) (*doctors.Doctor, error) {
+	return &doctors.Doctor{ID: id, FullName: "John Doctor", Specialization: "General Practice", LicenseNumber: "SIP-123/2026"}, nil
+}
+
+func setup(t *testing.T, diagnose *diagnoses.Diagnose) (*prescriptions.MockPrescriptionRepo, prescriptions.Service) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockRepo := prescriptions.NewMockPrescriptionRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	return mockRepo, prescriptions.NewService(logger, mockRepo, fakeDiagnoseService{diagnose: diagnose},
+		fakeAppointmentService{}, fakeUserService{}, fakeDoctorService{}, "https://clinic.example/")
+}
+
+func TestIssue_AmendedDiagnoseGetsNewPrescription(t *testing.T) {
+	mockRepo, service := setup(t, &diagnoses.Diagnose{
+		ID: 7, AppointmentID: 5, DoctorID: 2, Status: diagnoses.StatusSigned, Version: 3,
+		PrescribedMedications: "Amoxicillin 500mg, Paracetamol 500mg",
+	})
+
+	mockRepo.EXPECT().
+		GetLatestByDiagnoseID(int64(7)).
+		Return(&prescriptions.Prescription{ID: 4, DiagnoseVersion: 2, Status: prescriptions.StatusIssued}, nil).
+		Times(1)
+	mockRepo.EXPECT().Issue(gomock.Any()).Return(nil).Times(1)
+
+	prescription, err := service.Issue(7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, prescription.DiagnoseVersion)
+	assert.Equal(t, []string{"Amoxicillin 500mg", "Paracetamol 500mg"}, prescription.Medications)
+	assert.Equal(t, "SIP-123/2026", prescription.LicenseNumber)
+	assert.Len(t, prescription.Code, 16)
+	assert.Regexp(t, `^[A-Z2-7]{4}(-[A-Z2-7]{4}){3}$`, prescription.DisplayCode())
+}
+
+func TestIssue_DispensedPrescriptionIsNotReissued(t *testing.T) {
+	mockRepo, service := setup(t, &diagnoses.Diagnose{
+		ID: 7, AppointmentID: 5, DoctorID: 2, Status: diagnoses.StatusSigned, Version: 3,
+		PrescribedMedications: "Amoxicillin 500mg",
+	})
+	dispensed := &prescriptions.Prescription{ID: 4, DiagnoseVersion: 2, Status: prescriptions.StatusDispensed}
+
+	mockRepo.EXPECT().GetLatestByDiagnoseID(int64(7)).Return(dispensed, nil).Times(1)
+	mockRepo.EXPECT().Issue(gomock.Any()).Times(0)
+
+	prescription, err := service.Issue(7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, dispensed, prescription)
+}
+
+func TestRecords_IssueWithSignedDiagnose(t *testing.T) {
+	_, service := setup(t, nil)
+	diagnose := &diagnoses.Diagnose{ID: 7, AppointmentID: 5, DoctorID: 2, Status: diagnoses.StatusDraft, Version: 1,
+		PrescribedMedications: "Amoxicillin 500mg"}
+
+	records, err := service.Records()(diagnose)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	diagnose.Status = diagnoses.StatusSigned
+	records, err = service.Records()(diagnose)
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		prescription := records[0].(*prescriptions.Prescription)
+		assert.Equal(t, int64(7), prescription.DiagnoseID)
+		assert.Equal(t, "Jane Patient", prescription.PatientName)
+		assert.Equal(t, prescriptions.StatusIssued, prescription.Status)
+	}
+
+	diagnose.PrescribedMedications = ""
+	diagnose.Version = 2
+	records, err = service.Records()(diagnose)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{&prescriptions.Revocation{DiagnoseID: 7}}, records)
+}
+
+func TestIssue_DraftDiagnose(t *testing.T) {
+	_, service := setup(t, &diagnoses.Diagnose{ID: 7, Status: diagnoses.StatusDraft, PrescribedMedications: "Paracetamol"})
+
+	_, err := service.Issue(7)
+
+	assert.ErrorIs(t, err, prescriptions.ErrNotSigned)
+}
+
+func TestDispense_OnlyOnce(t *testing.T) {
+	mockRepo, service := setup(t, nil)
+	issued := &prescriptions.Prescription{ID: 4, Code: "K7QDM2XA9RTBHC4E", PatientName: "Jane Patient", Status: prescriptions.StatusIssued}
+	pharmacy := &prescriptions.Pharmacy{ID: 1, Name: "Apotek Sehat"}
+
+	mockRepo.EXPECT().GetByCode("K7QDM2XA9RTBHC4E").Return(issued, nil).Times(1)
+	mockRepo.EXPECT().MarkDispensed(int64(4), pharmacy, gomock.Any()).Return(true, nil).Times(1)
+
+	verification, err := service.Dispense("k7qd-m2xa-9rtb-hc4e", pharmacy)
+
+	assert.NoError(t, err)
+	assert.Equal(t, prescriptions.StatusDispensed, verification.Status)
+	assert.Equal(t, "Apotek Sehat", verification.DispensedBy)
+	assert.Equal(t, "J. P.", verification.PatientInitials)
+
+	mockRepo.EXPECT().GetByCode("K7QDM2XA9RTBHC4E").Return(issued, nil).Times(1)
+	_, err = service.Dispense("K7QD-M2XA-9RTB-HC4E", &prescriptions.Pharmacy{ID: 2, Name: "Apotek Lain"})
+	assert.ErrorIs(t, err, prescriptions.ErrAlreadyDispensed)
+
+	mockRepo.EXPECT().GetByCode("UNKNOWN").Return(nil, errors.New("record not found")).Times(1)
+	_, err = service.Verify("unknown")
+	assert.ErrorIs(t, err, prescriptions.ErrPrescriptionNotFound)
+}
+
+func TestAuthenticatePharmacy_ByRegisteredKey(t *testing.T) {
+	mockRepo, service := setup(t, nil)
+
+	var stored prescriptions.Pharmacy
+	mockRepo.EXPECT().
+		CreatePharmacy(gomock.Any()).
+		DoAndReturn(func(p *prescriptions.Pharmacy) (int64, error) {
+			p.ID = 1
+			stored = *p
+			return 1, nil
+		}).
+		Times(1)
+
+	pharmacy, key, err := service.RegisterPharmacy("  Apotek Sehat ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Apotek Sehat", pharmacy.Name)
+	assert.NotEmpty(t, key)
+	assert.NotContains(t, stored.KeyHash, key)
+
+	mockRepo.EXPECT().GetPharmacyByKeyHash(stored.KeyHash).Return(&stored, nil).Times(1)
+	authenticated, err := service.AuthenticatePharmacy(key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), authenticated.ID)
+
+	mockRepo.EXPECT().GetPharmacyByKeyHash(gomock.Any()).Return(nil, errors.New("record not found")).Times(1)
+	_, err = service.AuthenticatePharmacy("wrong")
+	assert.ErrorIs(t, err, prescriptions.ErrUnknownPharmacy)
+
+	_, err = service.AuthenticatePharmacy("")
+	assert.ErrorIs(t, err, prescriptions.ErrUnknownPharmacy)
+
+	_, _, err = service.RegisterPharmacy(" ")
+	assert.ErrorIs(t, err, prescriptions.ErrInvalidPharmacy)
+}
+
+func TestWritePDF_CrossReferencesObjects(t *testing.T) {
+	_, service := setup(t, nil)
+	prescription := &prescriptions.Prescription{
+		UserID: 1, PatientName: "Jane Patient", DoctorName: "John Doctor (GP)", LicenseNumber: "SIP-123/2026",
+		Medications: []string{"Amoxicillin 500mg"}, Code: "K7QDM2XA9RTBHC4E", IssuedAt: time.Now(),
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, service.WritePDF(&buf, prescription))
+	pdf := buf.Bytes()
+
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.Contains(t, buf.String(), "(K7QD-M2XA-9RTB-HC4E) Tj")
+	assert.Contains(t, buf.String(), `John Doctor \(GP\)`)
+	assert.Contains(t, buf.String(), "https://clinic.example/prescriptions/verify/K7QD-M2XA-9RTB-HC4E")
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	assert.NotNil(t, startxref)
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	assert.True(t, bytes.HasPrefix(pdf[xref:], []byte("xref\n")))
+
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	assert.Len(t, offsets, 6)
+	for i, match := range offsets {
+		offset, _ := strconv.Atoi(string(match[1]))
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
@@ -0,0 +1,75 @@
+package pricing
+
+import "math"
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Calculate prices the lines. Percentage discounts apply before fixed ones and
+// the total discount is spread over the lines in proportion to their amount,
+// so VAT is charged on the discounted price of taxable lines only. The
+// insurer's share is taken from the total including tax.
+func Calculate(cfg Config, req Request) *Breakdown {
+	b := &Breakdown{
+		Lines:       make([]LineResult, len(req.Lines)),
+		Adjustments: req.Adjustments,
+		VATRate:     cfg.VATRate,
+	}
+
+	for i, line := range req.Lines {
+		if line.Quantity == 0 {
+			line.Quantity = 1
+		}
+		if cfg.ExemptKinds[line.Kind] {
+			line.TaxExempt = true
+		}
+		b.Lines[i] = LineResult{Line: line, Amount: round(float64(line.Quantity) * line.UnitPrice)}
+		b.Subtotal += b.Lines[i].Amount
+	}
+	b.Subtotal = round(b.Subtotal)
+
+	remaining := b.Subtotal
+	for _, d := range req.Discounts {
+		if d.Type == DiscountPercentage {
+			remaining -= remaining * d.Value / 100
+		}
+	}
+	for _, d := range req.Discounts {
+		if d.Type == DiscountFixed {
+			remaining -= d.Value
+		}
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	b.DiscountAmount = round(b.Subtotal - remaining)
+
+	allocated := 0.0
+	for i := range b.Lines {
+		line := &b.Lines[i]
+		if i == len(b.Lines)-1 {
+			// the last line takes the rounding remainder
+			line.Discount = round(b.DiscountAmount - allocated)
+		} else if b.Subtotal > 0 {
+			line.Discount = round(b.DiscountAmount * line.Amount / b.Subtotal)
+		}
+		allocated += line.Discount
+
+		net := line.Amount - line.Discount
+		if !line.TaxExempt {
+			line.Tax = round(net * cfg.VATRate)
+		}
+		line.Total = round(net + line.Tax)
+
+		b.TaxAmount += line.Tax
+		b.Total += line.Total
+	}
+	b.TaxAmount = round(b.TaxAmount)
+	b.Total = round(b.Total)
+
+	b.InsuranceAmount = round(b.Total * req.InsuranceCoverage)
+	b.PatientAmount = round(b.Total - b.InsuranceAmount)
+
+	return b
+}
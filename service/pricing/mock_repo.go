@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/pricing/promo_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/pricing/promo_repo.go -destination=service/pricing/mock_repo.go -package=pricing
+//
+
+// Package pricing is a generated GoMock package.
+package pricing
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPromoRepo is a mock of PromoRepo interface.
+type MockPromoRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromoRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockPromoRepoMockRecorder is the mock recorder for MockPromoRepo.
+type MockPromoRepoMockRecorder struct {
+	mock *MockPromoRepo
+}
+
+// NewMockPromoRepo creates a new mock instance.
+func NewMockPromoRepo(ctrl *gomock.Controller) *MockPromoRepo {
+	mock := &MockPromoRepo{ctrl: ctrl}
+	mock.recorder = &MockPromoRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromoRepo) EXPECT() *MockPromoRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPromoRepo) Create(promo *PromoCode) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", promo)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPromoRepoMockRecorder) Create(promo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPromoRepo)(nil).Create), promo)
+}
+
+// GetByCode mocks base method.
+func (m *MockPromoRepo) GetByCode(code string) (*PromoCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCode", code)
+	ret0, _ := ret[0].(*PromoCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCode indicates an expected call of GetByCode.
+func (mr *MockPromoRepoMockRecorder) GetByCode(code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCode", reflect.TypeOf((*MockPromoRepo)(nil).GetByCode), code)
+}
+
+// List mocks base method.
+func (m *MockPromoRepo) List() ([]PromoCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]PromoCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockPromoRepoMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPromoRepo)(nil).List))
+}
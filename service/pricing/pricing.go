@@ -0,0 +1,116 @@
+package pricing
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	KindConsultation = "consultation"
+	KindMedication   = "medication"
+
+	DiscountPercentage = "percentage"
+	DiscountFixed      = "fixed"
+)
+
+// Line is one billable item, e.g. the consultation or a prescribed drug.
+type Line struct {
+	Kind        string  `json:"kind"`
+	Description string  `json:"description"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	TaxExempt   bool    `json:"tax_exempt"`
+}
+
+type Discount struct {
+	Type   string  `json:"type" validate:"required,oneof=percentage fixed"`
+	Value  float64 `json:"value" validate:"gt=0"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// Adjustments are applied on top of the lines of a billing.
+type Adjustments struct {
+	Discounts []Discount `json:"discounts,omitempty"`
+	PromoCode string     `json:"promo_code,omitempty"`
+	// InsuranceCoverage is the share of the total paid by the insurer, 0 to 1.
+	InsuranceCoverage float64 `json:"insurance_coverage"`
+}
+
+type Request struct {
+	Lines []Line
+	Adjustments
+	// HeldPromoCode is a code already redeemed for what is being priced, so
+	// its own redemption does not count against the limit.
+	HeldPromoCode string
+}
+
+type LineResult struct {
+	Line
+	Amount   float64 `json:"amount"`
+	Discount float64 `json:"discount"`
+	Tax      float64 `json:"tax"`
+	Total    float64 `json:"total"`
+}
+
+// Breakdown is the priced result stored with billings and invoices.
+type Breakdown struct {
+	Lines []LineResult `json:"lines"`
+	Adjustments
+	VATRate         float64 `json:"vat_rate"`
+	Subtotal        float64 `json:"subtotal"`
+	DiscountAmount  float64 `json:"discount_amount"`
+	TaxAmount       float64 `json:"tax_amount"`
+	Total           float64 `json:"total"`
+	InsuranceAmount float64 `json:"insurance_amount"`
+	PatientAmount   float64 `json:"patient_amount"`
+}
+
+// Config holds the tax rules. Lines whose kind is in ExemptKinds are never
+// taxed, in addition to lines flagged TaxExempt.
+type Config struct {
+	VATRate     float64
+	ExemptKinds map[string]bool
+}
+
+type PromoCode struct {
+	ID             int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Code           string     `json:"code" gorm:"type:varchar(50);not null;unique"`
+	DiscountType   string     `json:"discount_type" gorm:"type:varchar(20);not null"`
+	Value          float64    `json:"value" gorm:"type:decimal(10,2);not null"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	MaxRedemptions int        `json:"max_redemptions" gorm:"not null;default:0"`
+	Redemptions    int        `json:"redemptions" gorm:"not null;default:0"`
+	Active         bool       `json:"active" gorm:"not null;default:true"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (PromoCode) TableName() string {
+	return "promo_codes"
+}
+
+// Usable reports whether the code can be applied at the given time.
+func (p *PromoCode) Usable(now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.ValidFrom != nil && now.Before(*p.ValidFrom) {
+		return false
+	}
+	if p.ValidUntil != nil && now.After(*p.ValidUntil) {
+		return false
+	}
+	return p.MaxRedemptions == 0 || p.Redemptions < p.MaxRedemptions
+}
+
+// NewConfig builds the tax rules from the VAT rate and the kinds of lines
+// that are exempt from it.
+func NewConfig(vatRate float64, exemptKinds []string) Config {
+	exempt := make(map[string]bool, len(exemptKinds))
+	for _, kind := range exemptKinds {
+		if kind = strings.ToLower(strings.TrimSpace(kind)); kind != "" {
+			exempt[kind] = true
+		}
+	}
+	return Config{VATRate: vatRate, ExemptKinds: exempt}
+}
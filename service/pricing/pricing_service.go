@@ -0,0 +1,119 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidDiscount = errors.New("invalid discount")
+	ErrInvalidCoverage = errors.New("insurance coverage must be between 0 and 1")
+	ErrPromoNotFound   = errors.New("promo code not found")
+	ErrPromoNotUsable  = errors.New("promo code is not active or has expired")
+	ErrPromoExhausted  = errors.New("promo code has no redemptions left")
+)
+
+type service struct {
+	repo   PromoRepo
+	config Config
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+type Service interface {
+	Quote(req Request) (*Breakdown, error)
+	CreatePromoCode(promo *PromoCode) (int64, error)
+	ListPromoCodes() ([]PromoCode, error)
+}
+
+func NewService(logger *slog.Logger, repo PromoRepo, config Config) Service {
+	return &service{
+		logger: logger,
+		repo:   repo,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+func validDiscount(d Discount) bool {
+	switch d.Type {
+	case DiscountPercentage:
+		return d.Value > 0 && d.Value <= 100
+	case DiscountFixed:
+		return d.Value > 0
+	}
+	return false
+}
+
+// Quote validates the adjustments, resolves the promo code into a discount and
+// prices the lines. It does not redeem the promo code.
+func (s *service) Quote(req Request) (*Breakdown, error) {
+	if req.InsuranceCoverage < 0 || req.InsuranceCoverage > 1 {
+		return nil, ErrInvalidCoverage
+	}
+	for _, d := range req.Discounts {
+		if !validDiscount(d) {
+			return nil, fmt.Errorf("%w: %s %.2f", ErrInvalidDiscount, d.Type, d.Value)
+		}
+	}
+
+	discounts := append([]Discount(nil), req.Discounts...)
+	req.PromoCode = strings.ToUpper(strings.TrimSpace(req.PromoCode))
+	if req.PromoCode != "" {
+		promo, err := s.usablePromo(req.PromoCode, req.PromoCode == req.HeldPromoCode)
+		if err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, Discount{Type: promo.DiscountType, Value: promo.Value, Reason: "promo " + promo.Code})
+	}
+
+	priced := req
+	priced.Discounts = discounts
+	b := Calculate(s.config, priced)
+	// keep the requested discounts, the promo is recorded by its code
+	b.Discounts = req.Discounts
+	return b, nil
+}
+
+func (s *service) usablePromo(code string, held bool) (*PromoCode, error) {
+	promo, err := s.repo.GetByCode(code)
+	if err != nil {
+		return nil, ErrPromoNotFound
+	}
+	if held {
+		promo.Redemptions--
+	}
+	if !promo.Usable(s.now()) {
+		return nil, ErrPromoNotUsable
+	}
+	return promo, nil
+}
+
+func (s *service) CreatePromoCode(promo *PromoCode) (int64, error) {
+	promo.Code = strings.ToUpper(strings.TrimSpace(promo.Code))
+	if !validDiscount(Discount{Type: promo.DiscountType, Value: promo.Value}) {
+		return 0, ErrInvalidDiscount
+	}
+
+	id, err := s.repo.Create(promo)
+	if err != nil {
+		s.logger.Error("failed to create promo code",
+			slog.Any("error", err),
+			slog.String("code", promo.Code),
+		)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *service) ListPromoCodes() ([]PromoCode, error) {
+	promos, err := s.repo.List()
+	if err != nil {
+		s.logger.Error("failed to list promo codes", slog.Any("error", err))
+		return nil, err
+	}
+	return promos, nil
+}
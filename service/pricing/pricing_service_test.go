@@ -0,0 +1,117 @@
+package pricing_test
+
+import (
+	"Dedenruslan19/med-project/service/pricing"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func testLines() []pricing.Line {
+	return []pricing.Line{
+		{Kind: pricing.KindConsultation, Description: "Consultation", Quantity: 1, UnitPrice: 200000},
+		{Kind: pricing.KindMedication, Description: "Paracetamol", Quantity: 2, UnitPrice: 50000},
+	}
+}
+
+func TestQuote_TaxExemptMedication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := pricing.NewMockPromoRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := pricing.NewService(logger, mockRepo, pricing.NewConfig(0.11, []string{"medication"}))
+
+	result, err := service.Quote(pricing.Request{Lines: testLines()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 300000.0, result.Subtotal)
+	assert.Equal(t, 22000.0, result.TaxAmount)
+	assert.Equal(t, 322000.0, result.Total)
+	assert.Equal(t, 322000.0, result.PatientAmount)
+	assert.True(t, result.Lines[1].TaxExempt)
+}
+
+func TestQuote_DiscountsPromoAndCoverage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := pricing.NewMockPromoRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := pricing.NewService(logger, mockRepo, pricing.NewConfig(0.10, nil))
+
+	mockRepo.EXPECT().
+		GetByCode("WELCOME").
+		Return(&pricing.PromoCode{ID: 1, Code: "WELCOME", DiscountType: pricing.DiscountFixed, Value: 20000, Active: true}, nil).
+		Times(1)
+
+	result, err := service.Quote(pricing.Request{
+		Lines: testLines(),
+		Adjustments: pricing.Adjustments{
+			Discounts:         []pricing.Discount{{Type: pricing.DiscountPercentage, Value: 10}},
+			PromoCode:         " welcome ",
+			InsuranceCoverage: 0.5,
+		},
+	})
+
+	// 300000 - 10% - 20000 = 250000, plus 10% VAT
+	assert.NoError(t, err)
+	assert.Equal(t, 50000.0, result.DiscountAmount)
+	assert.Equal(t, 25000.0, result.TaxAmount)
+	assert.Equal(t, 275000.0, result.Total)
+	assert.Equal(t, 137500.0, result.InsuranceAmount)
+	assert.Equal(t, 137500.0, result.PatientAmount)
+	assert.Equal(t, "WELCOME", result.PromoCode)
+	assert.Len(t, result.Discounts, 1)
+}
+
+func TestQuote_ExpiredPromoCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := pricing.NewMockPromoRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := pricing.NewService(logger, mockRepo, pricing.NewConfig(0.11, nil))
+
+	expired := time.Now().Add(-time.Hour)
+	mockRepo.EXPECT().
+		GetByCode("OLD").
+		Return(&pricing.PromoCode{ID: 2, Code: "OLD", DiscountType: pricing.DiscountPercentage, Value: 10, Active: true, ValidUntil: &expired}, nil).
+		Times(1)
+
+	_, err := service.Quote(pricing.Request{Lines: testLines(), Adjustments: pricing.Adjustments{PromoCode: "OLD"}})
+	assert.ErrorIs(t, err, pricing.ErrPromoNotUsable)
+
+	mockRepo.EXPECT().GetByCode("MISSING").Return(nil, errors.New("record not found")).Times(1)
+
+	_, err = service.Quote(pricing.Request{Lines: testLines(), Adjustments: pricing.Adjustments{PromoCode: "MISSING"}})
+	assert.ErrorIs(t, err, pricing.ErrPromoNotFound)
+}
+
+func TestQuote_HeldPromoKeepsItsRedemption(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := pricing.NewMockPromoRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := pricing.NewService(logger, mockRepo, pricing.NewConfig(0.11, nil))
+
+	mockRepo.EXPECT().
+		GetByCode("LAST").
+		DoAndReturn(func(string) (*pricing.PromoCode, error) {
+			return &pricing.PromoCode{ID: 3, Code: "LAST", DiscountType: pricing.DiscountFixed, Value: 10000, Active: true, MaxRedemptions: 1, Redemptions: 1}, nil
+		}).
+		Times(2)
+
+	_, err := service.Quote(pricing.Request{Lines: testLines(), Adjustments: pricing.Adjustments{PromoCode: "LAST"}})
+	assert.ErrorIs(t, err, pricing.ErrPromoNotUsable)
+
+	result, err := service.Quote(pricing.Request{Lines: testLines(), Adjustments: pricing.Adjustments{PromoCode: "LAST"}, HeldPromoCode: "LAST"})
+	assert.NoError(t, err)
+	assert.Equal(t, "LAST", result.PromoCode)
+}
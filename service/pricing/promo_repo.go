@@ -0,0 +1,7 @@
+package pricing
+
+type PromoRepo interface {
+	Create(promo *PromoCode) (int64, error)
+	GetByCode(code string) (*PromoCode, error)
+	List() ([]PromoCode, error)
+}
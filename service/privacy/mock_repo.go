@@ -0,0 +1,151 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/privacy/privacy_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/privacy/privacy_repo.go -destination=service/privacy/mock_repo.go -package=privacy
+//
+
+// Package privacy is a generated GoMock package.
+package privacy
+
+import (
+	appointments "Dedenruslan19/med-project/service/appointments"
+	diagnoses "Dedenruslan19/med-project/service/diagnoses"
+	exercises "Dedenruslan19/med-project/service/exercises"
+	invoices "Dedenruslan19/med-project/service/invoices"
+	logs "Dedenruslan19/med-project/service/logs"
+	workouts "Dedenruslan19/med-project/service/workouts"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPrivacyRepo is a mock of PrivacyRepo interface.
+type MockPrivacyRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrivacyRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockPrivacyRepoMockRecorder is the mock recorder for MockPrivacyRepo.
+type MockPrivacyRepoMockRecorder struct {
+	mock *MockPrivacyRepo
+}
+
+// NewMockPrivacyRepo creates a new mock instance.
+func NewMockPrivacyRepo(ctrl *gomock.Controller) *MockPrivacyRepo {
+	mock := &MockPrivacyRepo{ctrl: ctrl}
+	mock.recorder = &MockPrivacyRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrivacyRepo) EXPECT() *MockPrivacyRepoMockRecorder {
+	return m.recorder
+}
+
+// Erase mocks base method.
+func (m *MockPrivacyRepo) Erase(userID int64, name, email string, erasedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Erase", userID, name, email, erasedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Erase indicates an expected call of Erase.
+func (mr *MockPrivacyRepoMockRecorder) Erase(userID, name, email, erasedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Erase", reflect.TypeOf((*MockPrivacyRepo)(nil).Erase), userID, name, email, erasedAt)
+}
+
+// ListAppointments mocks base method.
+func (m *MockPrivacyRepo) ListAppointments(userID int64) ([]appointments.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppointments", userID)
+	ret0, _ := ret[0].([]appointments.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppointments indicates an expected call of ListAppointments.
+func (mr *MockPrivacyRepoMockRecorder) ListAppointments(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppointments", reflect.TypeOf((*MockPrivacyRepo)(nil).ListAppointments), userID)
+}
+
+// ListDiagnoses mocks base method.
+func (m *MockPrivacyRepo) ListDiagnoses(userID int64) ([]diagnoses.Diagnose, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDiagnoses", userID)
+	ret0, _ := ret[0].([]diagnoses.Diagnose)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDiagnoses indicates an expected call of ListDiagnoses.
+func (mr *MockPrivacyRepoMockRecorder) ListDiagnoses(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDiagnoses", reflect.TypeOf((*MockPrivacyRepo)(nil).ListDiagnoses), userID)
+}
+
+// ListExercises mocks base method.
+func (m *MockPrivacyRepo) ListExercises(userID int64) ([]exercises.Exercise, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExercises", userID)
+	ret0, _ := ret[0].([]exercises.Exercise)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExercises indicates an expected call of ListExercises.
+func (mr *MockPrivacyRepoMockRecorder) ListExercises(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExercises", reflect.TypeOf((*MockPrivacyRepo)(nil).ListExercises), userID)
+}
+
+// ListInvoices mocks base method.
+func (m *MockPrivacyRepo) ListInvoices(userID int64) ([]invoices.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvoices", userID)
+	ret0, _ := ret[0].([]invoices.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvoices indicates an expected call of ListInvoices.
+func (mr *MockPrivacyRepoMockRecorder) ListInvoices(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvoices", reflect.TypeOf((*MockPrivacyRepo)(nil).ListInvoices), userID)
+}
+
+// ListLogs mocks base method.
+func (m *MockPrivacyRepo) ListLogs(userID int64) ([]logs.ExerciseLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLogs", userID)
+	ret0, _ := ret[0].([]logs.ExerciseLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLogs indicates an expected call of ListLogs.
+func (mr *MockPrivacyRepoMockRecorder) ListLogs(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLogs", reflect.TypeOf((*MockPrivacyRepo)(nil).ListLogs), userID)
+}
+
+// ListWorkouts mocks base method.
+func (m *MockPrivacyRepo) ListWorkouts(userID int64) ([]workouts.Workout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWorkouts", userID)
+	ret0, _ := ret[0].([]workouts.Workout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWorkouts indicates an expected call of ListWorkouts.
+func (mr *MockPrivacyRepoMockRecorder) ListWorkouts(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWorkouts", reflect.TypeOf((*MockPrivacyRepo)(nil).ListWorkouts), userID)
+}
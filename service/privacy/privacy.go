@@ -0,0 +1,74 @@
+package privacy
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/exercises"
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/logs"
+	"Dedenruslan19/med-project/service/users"
+	"Dedenruslan19/med-project/service/workouts"
+)
+
+// ErasedName replaces the full name of an erased account.
+const ErasedName = "Deleted user"
+
+// Export is a copy of the data a patient gave us or we recorded about them,
+// in a form they can take elsewhere.
+type Export struct {
+	Profile      users.User
+	Workouts     []workouts.Workout
+	Exercises    []exercises.Exercise
+	Logs         []logs.ExerciseLog
+	Appointments []appointments.Appointment
+	Diagnoses    []diagnoses.Diagnose
+	Invoices     []invoices.Invoice
+	GeneratedAt  time.Time
+}
+
+// WriteZIP writes the export as a ZIP archive with one JSON file per kind of
+// record.
+func (e *Export) WriteZIP(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", e.Profile},
+		{"workouts.json", nonNil(e.Workouts)},
+		{"exercises.json", nonNil(e.Exercises)},
+		{"logs.json", nonNil(e.Logs)},
+		{"appointments.json", nonNil(e.Appointments)},
+		{"diagnoses.json", nonNil(e.Diagnoses)},
+		{"invoices.json", nonNil(e.Invoices)},
+	}
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: e.GeneratedAt,
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// nonNil keeps empty lists as [] rather than null in the export.
+func nonNil[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
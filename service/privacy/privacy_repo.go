@@ -0,0 +1,31 @@
+package privacy
+
+import (
+	"time"
+
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/exercises"
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/logs"
+	"Dedenruslan19/med-project/service/workouts"
+)
+
+type PrivacyRepo interface {
+	ListWorkouts(userID int64) ([]workouts.Workout, error)
+	// ListExercises returns the exercises of the user's workouts.
+	ListExercises(userID int64) ([]exercises.Exercise, error)
+	ListLogs(userID int64) ([]logs.ExerciseLog, error)
+	ListAppointments(userID int64) ([]appointments.Appointment, error)
+	// ListDiagnoses returns the diagnoses of the user's appointments with
+	// their codes.
+	ListDiagnoses(userID int64) ([]diagnoses.Diagnose, error)
+	ListInvoices(userID int64) ([]invoices.Invoice, error)
+	// Erase deletes the user's workouts, exercises, logs and notification
+	// preferences and overwrites the profile with anonymised values, in one
+	// transaction. The users row itself stays so that the appointments,
+	// billings and invoices referencing it are not cascaded away; the name
+	// and email copied into invoices, prescriptions, insurance claims and
+	// queued messages are overwritten as well.
+	Erase(userID int64, name, email string, erasedAt time.Time) error
+}
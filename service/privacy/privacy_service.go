@@ -0,0 +1,103 @@
+package privacy
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	errs "Dedenruslan19/med-project/service/errors"
+	"Dedenruslan19/med-project/service/users"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type service struct {
+	repo        PrivacyRepo
+	userService users.Service
+	logger      *slog.Logger
+}
+
+type Service interface {
+	Export(userID int64) (*Export, error)
+	Erase(userID int64, password string) error
+}
+
+func NewService(logger *slog.Logger, repo PrivacyRepo, userService users.Service) Service {
+	return &service{
+		logger:      logger,
+		repo:        repo,
+		userService: userService,
+	}
+}
+
+// ErasedEmail is the placeholder address of an erased account. It is unique
+// per user and can never receive mail, which frees the original address for
+// a new registration.
+func ErasedEmail(userID int64) string {
+	return fmt.Sprintf("erased-%d@erased.invalid", userID)
+}
+
+// Export collects the user's profile, fitness data, appointments, diagnoses
+// and invoices.
+func (s *service) Export(userID int64) (*Export, error) {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &Export{Profile: user, GeneratedAt: time.Now().UTC()}
+	if export.Workouts, err = s.repo.ListWorkouts(userID); err != nil {
+		return nil, s.failed("workouts", userID, err)
+	}
+	if export.Exercises, err = s.repo.ListExercises(userID); err != nil {
+		return nil, s.failed("exercises", userID, err)
+	}
+	if export.Logs, err = s.repo.ListLogs(userID); err != nil {
+		return nil, s.failed("logs", userID, err)
+	}
+	if export.Appointments, err = s.repo.ListAppointments(userID); err != nil {
+		return nil, s.failed("appointments", userID, err)
+	}
+	if export.Diagnoses, err = s.repo.ListDiagnoses(userID); err != nil {
+		return nil, s.failed("diagnoses", userID, err)
+	}
+	if export.Invoices, err = s.repo.ListInvoices(userID); err != nil {
+		return nil, s.failed("invoices", userID, err)
+	}
+	return export, nil
+}
+
+func (s *service) failed(records string, userID int64, err error) error {
+	s.logger.Error("failed to export "+records,
+		slog.Int64("user_id", userID),
+		slog.Any("error", err),
+	)
+	return err
+}
+
+// Erase anonymises the account after the user confirmed their password. The
+// name, email, password and measurements are overwritten and the fitness
+// data and notification preferences are deleted. Appointments, diagnoses,
+// prescriptions, billings, payments, invoices and insurance claims are kept
+// for the legally required retention period; they stay linked to the
+// anonymised account and the name and email copied into them are replaced.
+// Notifications and invoice emails not sent yet are dropped.
+func (s *service) Erase(userID int64, password string) error {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return errs.ErrInvalidPass
+	}
+
+	if err := s.repo.Erase(userID, ErasedName, ErasedEmail(userID), time.Now().UTC()); err != nil {
+		s.logger.Error("failed to erase account",
+			slog.Int64("user_id", userID),
+			slog.Any("error", err),
+		)
+		return err
+	}
+	s.logger.Info("account erased", slog.Int64("user_id", userID))
+	return nil
+}
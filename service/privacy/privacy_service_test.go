@@ -0,0 +1,101 @@
+package privacy_test
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	errs "Dedenruslan19/med-project/service/errors"
+	"Dedenruslan19/med-project/service/invoices"
+	"Dedenruslan19/med-project/service/privacy"
+	"Dedenruslan19/med-project/service/users"
+	"Dedenruslan19/med-project/service/workouts"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeUserService struct {
+	users.Service
+	user users.User
+}
+
+func (f fakeUserService) GetUserByID(userID int64) (users.User, error) {
+	return f.user, nil
+}
+
+func setup(t *testing.T, user users.User) (*privacy.MockPrivacyRepo, privacy.Service) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockRepo := privacy.NewMockPrivacyRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	return mockRepo, privacy.NewService(logger, mockRepo, fakeUserService{user: user})
+}
+
+func TestExport_WritesOneFilePerRecordKind(t *testing.T) {
+	mockRepo, service := setup(t, users.User{ID: 1, FullName: "Jane Patient", Email: "jane@example.com", Password: "hash"})
+
+	mockRepo.EXPECT().ListWorkouts(int64(1)).Return([]workouts.Workout{{ID: 3, UserID: 1, Name: "Legs"}}, nil).Times(1)
+	mockRepo.EXPECT().ListExercises(int64(1)).Return(nil, nil).Times(1)
+	mockRepo.EXPECT().ListLogs(int64(1)).Return(nil, nil).Times(1)
+	mockRepo.EXPECT().ListAppointments(int64(1)).Return([]appointments.Appointment{{ID: 5, UserID: 1}}, nil).Times(1)
+	mockRepo.EXPECT().ListDiagnoses(int64(1)).Return(nil, nil).Times(1)
+	mockRepo.EXPECT().ListInvoices(int64(1)).Return([]invoices.Invoice{{ID: 9, InvoiceNumber: "INV-2026-000001"}}, nil).Times(1)
+
+	export, err := service.Export(1)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteZIP(&buf))
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		files[f.Name] = string(data)
+	}
+
+	assert.Len(t, files, 7)
+	assert.Contains(t, files["profile.json"], `"email": "jane@example.com"`)
+	assert.NotContains(t, files["profile.json"], "hash")
+	assert.Contains(t, files["invoices.json"], "INV-2026-000001")
+	assert.JSONEq(t, "[]", files["diagnoses.json"])
+
+	var list []workouts.Workout
+	require.NoError(t, json.Unmarshal([]byte(files["workouts.json"]), &list))
+	assert.Equal(t, "Legs", list[0].Name)
+}
+
+func TestErase_AnonymisesAccount(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	mockRepo, service := setup(t, users.User{ID: 1, FullName: "Jane Patient", Email: "jane@example.com", Password: string(hash)})
+
+	mockRepo.EXPECT().
+		Erase(int64(1), privacy.ErasedName, "erased-1@erased.invalid", gomock.Any()).
+		Return(nil).
+		Times(1)
+
+	assert.NoError(t, service.Erase(1, "secret123"))
+}
+
+func TestErase_WrongPassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	_, service := setup(t, users.User{ID: 1, Password: string(hash)})
+
+	err = service.Erase(1, "wrong")
+
+	assert.ErrorIs(t, err, errs.ErrInvalidPass)
+}
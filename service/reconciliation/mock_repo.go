@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/reconciliation/reconciliation_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/reconciliation/reconciliation_repo.go -destination=service/reconciliation/mock_repo.go -package=reconciliation
+//
+
+// Package reconciliation is a generated GoMock package.
+package reconciliation
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReconciliationRepo is a mock of ReconciliationRepo interface.
+type MockReconciliationRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconciliationRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockReconciliationRepoMockRecorder is the mock recorder for MockReconciliationRepo.
+type MockReconciliationRepoMockRecorder struct {
+	mock *MockReconciliationRepo
+}
+
+// NewMockReconciliationRepo creates a new mock instance.
+func NewMockReconciliationRepo(ctrl *gomock.Controller) *MockReconciliationRepo {
+	mock := &MockReconciliationRepo{ctrl: ctrl}
+	mock.recorder = &MockReconciliationRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciliationRepo) EXPECT() *MockReconciliationRepoMockRecorder {
+	return m.recorder
+}
+
+// ListAwaitingPayment mocks base method.
+func (m *MockReconciliationRepo) ListAwaitingPayment() ([]Candidate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAwaitingPayment")
+	ret0, _ := ret[0].([]Candidate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAwaitingPayment indicates an expected call of ListAwaitingPayment.
+func (mr *MockReconciliationRepoMockRecorder) ListAwaitingPayment() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAwaitingPayment", reflect.TypeOf((*MockReconciliationRepo)(nil).ListAwaitingPayment))
+}
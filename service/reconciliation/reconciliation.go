@@ -0,0 +1,59 @@
+package reconciliation
+
+import (
+	"Dedenruslan19/med-project/service/billings"
+	"time"
+)
+
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// StatementLine is one transaction of an uploaded bank statement. Row is the
+// line number in the file, header included.
+type StatementLine struct {
+	Row       int       `json:"row"`
+	Date      time.Time `json:"date"`
+	Reference string    `json:"reference"`
+	Amount    float64   `json:"amount"`
+}
+
+// Candidate is a billing waiting for a bank transfer, with the invoice the
+// patient was asked to pay.
+type Candidate struct {
+	Billing       billings.Billing
+	InvoiceNumber string
+	InvoicedAt    time.Time
+}
+
+// Match proposes that a statement line pays a billing. Nothing is recorded
+// until the match is confirmed.
+type Match struct {
+	Line          StatementLine `json:"line"`
+	BillingID     int64         `json:"billing_id"`
+	InvoiceNumber string        `json:"invoice_number"`
+	AmountDue     float64       `json:"amount_due"`
+	Confidence    string        `json:"confidence"`
+	Reasons       []string      `json:"reasons"`
+}
+
+type Unmatched struct {
+	Line   StatementLine `json:"line"`
+	Reason string        `json:"reason"`
+}
+
+type Result struct {
+	Lines     int         `json:"lines"`
+	Matches   []Match     `json:"matches"`
+	Unmatched []Unmatched `json:"unmatched"`
+}
+
+// Confirmation accepts a proposed match.
+type Confirmation struct {
+	BillingID int64     `json:"billing_id" validate:"required"`
+	Amount    float64   `json:"amount" validate:"gt=0"`
+	Reference string    `json:"reference"`
+	PaidAt    time.Time `json:"paid_at" validate:"required"`
+}
@@ -0,0 +1,6 @@
+package reconciliation
+
+type ReconciliationRepo interface {
+	// ListAwaitingPayment returns the waiting_payment billings that have an invoice.
+	ListAwaitingPayment() ([]Candidate, error)
+}
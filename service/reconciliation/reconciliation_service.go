@@ -0,0 +1,193 @@
+package reconciliation
+
+import (
+	"Dedenruslan19/med-project/service/billings"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	ErrNotAwaitingPayment = errors.New("billing is not waiting for payment")
+	ErrAmountMismatch     = errors.New("amount does not match the amount due")
+)
+
+var confidenceRank = map[string]int{
+	ConfidenceHigh:   0,
+	ConfidenceMedium: 1,
+	ConfidenceLow:    2,
+}
+
+type service struct {
+	repo           ReconciliationRepo
+	billingService billings.Service
+	dateTolerance  time.Duration
+	logger         *slog.Logger
+}
+
+type Service interface {
+	Reconcile(statement io.Reader) (*Result, error)
+	Confirm(confirmation Confirmation) (*billings.Payment, error)
+}
+
+// NewService matches statement lines dated within toleranceDays of the
+// invoice date.
+func NewService(logger *slog.Logger, repo ReconciliationRepo, billingService billings.Service, toleranceDays int) Service {
+	return &service{
+		logger:         logger,
+		repo:           repo,
+		billingService: billingService,
+		dateTolerance:  time.Duration(toleranceDays) * 24 * time.Hour,
+	}
+}
+
+// normalize drops everything but letters and digits, banks often mangle the
+// separators of a reference.
+func normalize(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, value)
+}
+
+func sameAmount(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Reconcile proposes a billing for each credit line of the statement. A line
+// naming an invoice number with the right amount is a high confidence match,
+// medium when it is outside the date tolerance. A line with only the right
+// amount and date is a low confidence match when a single billing fits. Each
+// billing is proposed at most once.
+func (s *service) Reconcile(statement io.Reader) (*Result, error) {
+	lines, err := ParseStatement(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.repo.ListAwaitingPayment()
+	if err != nil {
+		s.logger.Error("failed to list billings awaiting payment", slog.Any("error", err))
+		return nil, err
+	}
+
+	result := &Result{Lines: len(lines), Matches: []Match{}, Unmatched: []Unmatched{}}
+	var proposals []Match
+	for _, line := range lines {
+		if line.Amount <= 0 {
+			result.Unmatched = append(result.Unmatched, Unmatched{Line: line, Reason: "not an incoming transfer"})
+			continue
+		}
+		match, reason := s.propose(line, candidates)
+		if match == nil {
+			result.Unmatched = append(result.Unmatched, Unmatched{Line: line, Reason: reason})
+			continue
+		}
+		proposals = append(proposals, *match)
+	}
+
+	sort.SliceStable(proposals, func(i, j int) bool {
+		return confidenceRank[proposals[i].Confidence] < confidenceRank[proposals[j].Confidence]
+	})
+	claimedBy := map[int64]int{}
+	for _, match := range proposals {
+		if row, ok := claimedBy[match.BillingID]; ok {
+			result.Unmatched = append(result.Unmatched, Unmatched{
+				Line:   match.Line,
+				Reason: fmt.Sprintf("billing %d is already matched by row %d", match.BillingID, row),
+			})
+			continue
+		}
+		claimedBy[match.BillingID] = match.Line.Row
+		result.Matches = append(result.Matches, match)
+	}
+
+	sort.Slice(result.Matches, func(i, j int) bool { return result.Matches[i].Line.Row < result.Matches[j].Line.Row })
+	sort.Slice(result.Unmatched, func(i, j int) bool { return result.Unmatched[i].Line.Row < result.Unmatched[j].Line.Row })
+	return result, nil
+}
+
+func (s *service) propose(line StatementLine, candidates []Candidate) (*Match, string) {
+	reference := normalize(line.Reference)
+	var byAmount []*Candidate
+	for i := range candidates {
+		candidate := &candidates[i]
+		amountDue := candidate.Billing.AmountDue()
+		inTolerance := math.Abs(day(line.Date).Sub(day(candidate.InvoicedAt)).Hours()) <= s.dateTolerance.Hours()
+
+		invoice := normalize(candidate.InvoiceNumber)
+		if invoice != "" && strings.Contains(reference, invoice) {
+			if !sameAmount(line.Amount, amountDue) {
+				return nil, fmt.Sprintf("reference names invoice %s but the amount due is %.2f", candidate.InvoiceNumber, amountDue)
+			}
+			match := newMatch(line, candidate, ConfidenceHigh, "invoice number in reference", "amount")
+			if inTolerance {
+				match.Reasons = append(match.Reasons, "date")
+			} else {
+				match.Confidence = ConfidenceMedium
+			}
+			return match, ""
+		}
+
+		if sameAmount(line.Amount, amountDue) && inTolerance {
+			byAmount = append(byAmount, candidate)
+		}
+	}
+
+	switch len(byAmount) {
+	case 0:
+		return nil, "no billing awaiting payment matches"
+	case 1:
+		return newMatch(line, byAmount[0], ConfidenceLow, "amount", "date"), ""
+	default:
+		return nil, fmt.Sprintf("amount and date match %d billings", len(byAmount))
+	}
+}
+
+func newMatch(line StatementLine, candidate *Candidate, confidence string, reasons ...string) *Match {
+	return &Match{
+		Line:          line,
+		BillingID:     candidate.Billing.ID,
+		InvoiceNumber: candidate.InvoiceNumber,
+		AmountDue:     candidate.Billing.AmountDue(),
+		Confidence:    confidence,
+		Reasons:       reasons,
+	}
+}
+
+// Confirm records the bank transfer of a confirmed match as the billing's
+// payment, moving it to paid through the billing state machine.
+func (s *service) Confirm(confirmation Confirmation) (*billings.Payment, error) {
+	billing, err := s.billingService.GetByID(confirmation.BillingID)
+	if err != nil {
+		return nil, err
+	}
+	if billing.PaymentStatus != billings.StatusWaitingPayment {
+		return nil, fmt.Errorf("%w: billing %d is %s", ErrNotAwaitingPayment, billing.ID, billing.PaymentStatus)
+	}
+	if !sameAmount(confirmation.Amount, billing.AmountDue()) {
+		return nil, fmt.Errorf("%w: %.2f received, %.2f due", ErrAmountMismatch, confirmation.Amount, billing.AmountDue())
+	}
+
+	payment, err := s.billingService.RecordPayment(billing.ID, billings.PaymentMethodBankTransfer, confirmation.Reference, confirmation.PaidAt)
+	if err != nil {
+		s.logger.Error("failed to record reconciled payment",
+			slog.Any("error", err),
+			slog.Int64("billing_id", billing.ID),
+		)
+		return nil, err
+	}
+	return payment, nil
+}
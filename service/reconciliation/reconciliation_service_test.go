@@ -0,0 +1,101 @@
+package reconciliation_test
+
+import (
+	"Dedenruslan19/med-project/service/billings"
+	"Dedenruslan19/med-project/service/reconciliation"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type fakeBillingService struct {
+	billings.Service
+	billing  *billings.Billing
+	recorded []string
+}
+
+func (f *fakeBillingService) GetByID(id int64) (*billings.Billing, error) {
+	return f.billing, nil
+}
+
+func (f *fakeBillingService) RecordPayment(id int64, method, reference string, paidAt time.Time) (*billings.Payment, error) {
+	f.recorded = append(f.recorded, reference)
+	return &billings.Payment{BillingID: id, Method: method, Reference: reference, OccurredAt: paidAt}, nil
+}
+
+func day(d int) time.Time {
+	return time.Date(2026, time.March, d, 9, 0, 0, 0, time.UTC)
+}
+
+func TestReconcile_ProposesMatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := reconciliation.NewMockReconciliationRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := reconciliation.NewService(logger, mockRepo, nil, 3)
+
+	mockRepo.EXPECT().ListAwaitingPayment().Return([]reconciliation.Candidate{
+		{Billing: billings.Billing{ID: 1, TotalAmount: 250000}, InvoiceNumber: "INV/2026/000001", InvoicedAt: day(2)},
+		{Billing: billings.Billing{ID: 2, TotalAmount: 300000}, InvoiceNumber: "INV/2026/000002", InvoicedAt: day(2)},
+		{Billing: billings.Billing{ID: 3, TotalAmount: 180000}, InvoiceNumber: "INV/2026/000003", InvoicedAt: day(2)},
+		{Billing: billings.Billing{ID: 4, TotalAmount: 180000}, InvoiceNumber: "INV/2026/000004", InvoicedAt: day(3)},
+	}, nil).Times(1)
+
+	statement := strings.Join([]string{
+		"Date,Description,Amount",
+		"2026-03-03,TRF INV2026000001 BUDI,\"250,000.00\"",
+		"2026-03-20,transfer inv/2026/000002,300000",
+		"2026-03-04,TRANSFER,180000",
+		"2026-03-04,ADMIN FEE,-6500",
+		"2026-03-04,TRF INV/2026/000003,175000",
+	}, "\n")
+
+	result, err := service.Reconcile(strings.NewReader(statement))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Lines)
+	assert.Len(t, result.Matches, 2)
+	assert.Equal(t, int64(1), result.Matches[0].BillingID)
+	assert.Equal(t, reconciliation.ConfidenceHigh, result.Matches[0].Confidence)
+	assert.Equal(t, int64(2), result.Matches[1].BillingID)
+	assert.Equal(t, reconciliation.ConfidenceMedium, result.Matches[1].Confidence)
+
+	assert.Len(t, result.Unmatched, 3)
+	assert.Equal(t, "amount and date match 2 billings", result.Unmatched[0].Reason)
+	assert.Equal(t, "not an incoming transfer", result.Unmatched[1].Reason)
+	assert.Contains(t, result.Unmatched[2].Reason, "amount due is 180000.00")
+}
+
+func TestReconcile_InvalidStatement(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := reconciliation.NewMockReconciliationRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := reconciliation.NewService(logger, mockRepo, nil, 3)
+
+	result, err := service.Reconcile(strings.NewReader("Date,Amount\n2026-03-01,100"))
+
+	assert.ErrorIs(t, err, reconciliation.ErrInvalidStatement)
+	assert.Nil(t, result)
+}
+
+func TestConfirm_RecordsBankTransfer(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	billingSvc := &fakeBillingService{billing: &billings.Billing{ID: 1, TotalAmount: 250000, PaymentStatus: billings.StatusWaitingPayment}}
+	service := reconciliation.NewService(logger, nil, billingSvc, 3)
+
+	_, err := service.Confirm(reconciliation.Confirmation{BillingID: 1, Amount: 200000, Reference: "TRF", PaidAt: day(3)})
+	assert.ErrorIs(t, err, reconciliation.ErrAmountMismatch)
+
+	payment, err := service.Confirm(reconciliation.Confirmation{BillingID: 1, Amount: 250000, Reference: "TRF INV2026000001", PaidAt: day(3)})
+	assert.NoError(t, err)
+	assert.Equal(t, billings.PaymentMethodBankTransfer, payment.Method)
+	assert.Equal(t, []string{"TRF INV2026000001"}, billingSvc.recorded)
+}
@@ -0,0 +1,104 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidStatement = errors.New("invalid bank statement")
+
+var statementDateLayouts = []string{"2006-01-02", "2006-01-02 15:04:05", "02/01/2006", "02-01-2006"}
+
+// column names accepted for each statement field, lower case
+var (
+	dateColumns      = []string{"date", "transaction_date", "value_date"}
+	referenceColumns = []string{"reference", "description", "remarks", "narrative"}
+	amountColumns    = []string{"amount", "credit"}
+)
+
+func findColumn(header []string, names []string) int {
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		for _, name := range names {
+			if h == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseStatementDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range statementDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+// parseStatementAmount accepts plain amounts with an optional thousands
+// separator, e.g. 250000, 250,000.00 or -15000.
+func parseStatementAmount(value string) (float64, error) {
+	value = strings.NewReplacer(",", "", " ", "").Replace(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// ParseStatement reads a bank statement CSV with a header row naming a date,
+// a reference and an amount column.
+func ParseStatement(r io.Reader) ([]StatementLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStatement, err)
+	}
+	dateCol := findColumn(header, dateColumns)
+	referenceCol := findColumn(header, referenceColumns)
+	amountCol := findColumn(header, amountColumns)
+	if dateCol < 0 || referenceCol < 0 || amountCol < 0 {
+		return nil, fmt.Errorf("%w: header must name date, reference and amount columns", ErrInvalidStatement)
+	}
+
+	var lines []StatementLine
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidStatement, err)
+		}
+		if len(record) <= max(dateCol, referenceCol, amountCol) {
+			return nil, fmt.Errorf("%w: row %d has too few columns", ErrInvalidStatement, row)
+		}
+
+		date, err := parseStatementDate(record[dateCol])
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %v", ErrInvalidStatement, row, err)
+		}
+		amount, err := parseStatementAmount(record[amountCol])
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: invalid amount %q", ErrInvalidStatement, row, record[amountCol])
+		}
+
+		lines = append(lines, StatementLine{
+			Row:       row,
+			Date:      date,
+			Reference: strings.TrimSpace(record[referenceCol]),
+			Amount:    amount,
+		})
+	}
+	return lines, nil
+}
@@ -0,0 +1,110 @@
+package reports
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func revenueRecord(r RevenueRow) []string {
+	return []string{
+		r.Key,
+		r.Label,
+		strconv.Itoa(r.BillingCount),
+		strconv.Itoa(r.PaidCount),
+		strconv.Itoa(r.UnpaidCount),
+		formatAmount(r.Billed),
+		formatAmount(r.Collected),
+		formatAmount(r.Outstanding),
+		formatAmount(r.ConsultationFees),
+		formatAmount(r.MedicationRevenue),
+		formatAmount(r.Discounts),
+		formatAmount(r.Tax),
+	}
+}
+
+// WriteCSV writes one row per group followed by the totals.
+func (r *RevenueReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		r.GroupBy, "label", "billing_count", "paid_count", "unpaid_count", "billed", "collected",
+		"outstanding", "consultation_revenue", "medication_revenue", "discounts", "tax",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range r.Rows {
+		if err := writer.Write(revenueRecord(row)); err != nil {
+			return err
+		}
+	}
+	if err := writer.Write(revenueRecord(r.Totals)); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteCSV writes one row per outstanding billing.
+func (r *AgingReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"billing_id", "invoice_number", "doctor_name", "created_at", "payment_status",
+		"total_amount", "amount_due", "age_days", "bucket",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, rec := range r.Receivables {
+		row := []string{
+			strconv.FormatInt(rec.BillingID, 10),
+			rec.InvoiceNumber,
+			rec.DoctorName,
+			rec.CreatedAt.UTC().Format("2006-01-02"),
+			rec.PaymentStatus,
+			formatAmount(rec.TotalAmount),
+			formatAmount(rec.AmountDue),
+			strconv.Itoa(rec.AgeDays),
+			rec.Bucket,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteCSV writes one row per code.
+func (r *ConditionReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"code", "title", "diagnose_count", "primary_count", "secondary_count", "patient_count"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range r.Rows {
+		record := []string{
+			row.Code,
+			row.Title,
+			strconv.Itoa(row.DiagnoseCount),
+			strconv.Itoa(row.PrimaryCount),
+			strconv.Itoa(row.SecondaryCount),
+			strconv.Itoa(row.PatientCount),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/reports/report_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/reports/report_repo.go -destination=service/reports/mock_repo.go -package=reports
+//
+
+// Package reports is a generated GoMock package.
+package reports
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReportRepo is a mock of ReportRepo interface.
+type MockReportRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockReportRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockReportRepoMockRecorder is the mock recorder for MockReportRepo.
+type MockReportRepoMockRecorder struct {
+	mock *MockReportRepo
+}
+
+// NewMockReportRepo creates a new mock instance.
+func NewMockReportRepo(ctrl *gomock.Controller) *MockReportRepo {
+	mock := &MockReportRepo{ctrl: ctrl}
+	mock.recorder = &MockReportRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReportRepo) EXPECT() *MockReportRepoMockRecorder {
+	return m.recorder
+}
+
+// ListBillings mocks base method.
+func (m *MockReportRepo) ListBillings(from, to time.Time) ([]BillingRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBillings", from, to)
+	ret0, _ := ret[0].([]BillingRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBillings indicates an expected call of ListBillings.
+func (mr *MockReportRepoMockRecorder) ListBillings(from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBillings", reflect.TypeOf((*MockReportRepo)(nil).ListBillings), from, to)
+}
+
+// ListDiagnoseCodes mocks base method.
+func (m *MockReportRepo) ListDiagnoseCodes(from, to time.Time, prefix string) ([]DiagnoseCodeRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDiagnoseCodes", from, to, prefix)
+	ret0, _ := ret[0].([]DiagnoseCodeRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDiagnoseCodes indicates an expected call of ListDiagnoseCodes.
+func (mr *MockReportRepoMockRecorder) ListDiagnoseCodes(from, to, prefix any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDiagnoseCodes", reflect.TypeOf((*MockReportRepo)(nil).ListDiagnoseCodes), from, to, prefix)
+}
+
+// ListUnpaidBillings mocks base method.
+func (m *MockReportRepo) ListUnpaidBillings(asOf time.Time) ([]BillingRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnpaidBillings", asOf)
+	ret0, _ := ret[0].([]BillingRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnpaidBillings indicates an expected call of ListUnpaidBillings.
+func (mr *MockReportRepoMockRecorder) ListUnpaidBillings(asOf any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnpaidBillings", reflect.TypeOf((*MockReportRepo)(nil).ListUnpaidBillings), asOf)
+}
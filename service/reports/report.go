@@ -0,0 +1,110 @@
+package reports
+
+import "time"
+
+const (
+	GroupByDay            = "day"
+	GroupByWeek           = "week"
+	GroupByMonth          = "month"
+	GroupByDoctor         = "doctor"
+	GroupBySpecialization = "specialization"
+)
+
+// BillingRow is one billing with the doctor and invoice it belongs to, the
+// raw material of every report.
+type BillingRow struct {
+	BillingID       int64      `json:"billing_id"`
+	AppointmentID   int64      `json:"appointment_id"`
+	DoctorID        int64      `json:"doctor_id"`
+	DoctorName      string     `json:"doctor_name"`
+	Specialization  string     `json:"specialization"`
+	InvoiceNumber   string     `json:"invoice_number"`
+	ConsultationFee float64    `json:"consultation_fee"`
+	MedicationFee   float64    `json:"medication_fee"`
+	DiscountAmount  float64    `json:"discount_amount"`
+	TaxAmount       float64    `json:"tax_amount"`
+	TotalAmount     float64    `json:"total_amount"`
+	InsuranceAmount float64    `json:"insurance_amount"`
+	PatientAmount   float64    `json:"patient_amount"`
+	PaymentStatus   string     `json:"payment_status"`
+	PaidAt          *time.Time `json:"paid_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// AmountDue is the patient's share, the full total for billings without
+// insurance or pricing details.
+func (r *BillingRow) AmountDue() float64 {
+	if r.PatientAmount == 0 && r.InsuranceAmount == 0 {
+		return r.TotalAmount
+	}
+	return r.PatientAmount
+}
+
+type RevenueRow struct {
+	Key               string  `json:"key"`
+	Label             string  `json:"label"`
+	BillingCount      int     `json:"billing_count"`
+	PaidCount         int     `json:"paid_count"`
+	UnpaidCount       int     `json:"unpaid_count"`
+	Billed            float64 `json:"billed"`
+	Collected         float64 `json:"collected"`
+	Outstanding       float64 `json:"outstanding"`
+	ConsultationFees  float64 `json:"consultation_revenue"`
+	MedicationRevenue float64 `json:"medication_revenue"`
+	Discounts         float64 `json:"discounts"`
+	Tax               float64 `json:"tax"`
+}
+
+type RevenueReport struct {
+	From    time.Time    `json:"from"`
+	To      time.Time    `json:"to"`
+	GroupBy string       `json:"group_by"`
+	Rows    []RevenueRow `json:"rows"`
+	Totals  RevenueRow   `json:"totals"`
+}
+
+type AgingBucket struct {
+	Label  string  `json:"label"`
+	Count  int     `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+type Receivable struct {
+	BillingRow
+	AmountDue float64 `json:"amount_due"`
+	AgeDays   int     `json:"age_days"`
+	Bucket    string  `json:"bucket"`
+}
+
+type AgingReport struct {
+	AsOf        time.Time     `json:"as_of"`
+	Buckets     []AgingBucket `json:"buckets"`
+	Total       float64       `json:"total"`
+	Receivables []Receivable  `json:"receivables"`
+}
+
+// DiagnoseCodeRow is one ICD-10 code of a diagnosis with its patient.
+type DiagnoseCodeRow struct {
+	DiagnoseID int64     `json:"diagnose_id"`
+	Code       string    `json:"code"`
+	Title      string    `json:"title"`
+	Primary    bool      `json:"primary" gorm:"column:is_primary"`
+	UserID     int64     `json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ConditionRow struct {
+	Code           string `json:"code"`
+	Title          string `json:"title"`
+	DiagnoseCount  int    `json:"diagnose_count"`
+	PrimaryCount   int    `json:"primary_count"`
+	SecondaryCount int    `json:"secondary_count"`
+	PatientCount   int    `json:"patient_count"`
+}
+
+type ConditionReport struct {
+	From   time.Time      `json:"from"`
+	To     time.Time      `json:"to"`
+	Prefix string         `json:"prefix,omitempty"`
+	Rows   []ConditionRow `json:"rows"`
+}
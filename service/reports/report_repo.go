@@ -0,0 +1,13 @@
+package reports
+
+import "time"
+
+type ReportRepo interface {
+	// ListBillings returns the billings created in [from, to).
+	ListBillings(from, to time.Time) ([]BillingRow, error)
+	// ListUnpaidBillings returns the billings created before asOf that are not paid.
+	ListUnpaidBillings(asOf time.Time) ([]BillingRow, error)
+	// ListDiagnoseCodes returns the codes of diagnoses created in [from, to)
+	// that start with prefix.
+	ListDiagnoseCodes(from, to time.Time, prefix string) ([]DiagnoseCodeRow, error)
+}
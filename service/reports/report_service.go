@@ -0,0 +1,239 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const paidStatus = "paid"
+
+var (
+	ErrInvalidGroupBy = errors.New("group_by must be one of day, week, month, doctor, specialization")
+	ErrInvalidPeriod  = errors.New("from must be before to")
+)
+
+// agingBuckets are the receivable age ranges, the last one is open ended.
+var agingBuckets = []struct {
+	label   string
+	maxDays int
+}{
+	{"0-30", 30},
+	{"31-60", 60},
+	{"60+", math.MaxInt},
+}
+
+type service struct {
+	repo   ReportRepo
+	logger *slog.Logger
+}
+
+type Service interface {
+	Revenue(from, to time.Time, groupBy string) (*RevenueReport, error)
+	Receivables(asOf time.Time) (*AgingReport, error)
+	Conditions(from, to time.Time, prefix string) (*ConditionReport, error)
+}
+
+func NewService(logger *slog.Logger, repo ReportRepo) Service {
+	return &service{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func groupKey(row *BillingRow, groupBy string) (string, string, error) {
+	created := row.CreatedAt.UTC()
+	switch groupBy {
+	case GroupByDay:
+		key := created.Format("2006-01-02")
+		return key, key, nil
+	case GroupByWeek:
+		year, week := created.ISOWeek()
+		key := fmt.Sprintf("%d-W%02d", year, week)
+		return key, key, nil
+	case GroupByMonth:
+		key := created.Format("2006-01")
+		return key, key, nil
+	case GroupByDoctor:
+		return strconv.FormatInt(row.DoctorID, 10), row.DoctorName, nil
+	case GroupBySpecialization:
+		return row.Specialization, row.Specialization, nil
+	}
+	return "", "", ErrInvalidGroupBy
+}
+
+func (r *RevenueRow) add(row *BillingRow) {
+	r.BillingCount++
+	r.Billed += row.TotalAmount
+	r.ConsultationFees += row.ConsultationFee
+	r.MedicationRevenue += row.MedicationFee
+	r.Discounts += row.DiscountAmount
+	r.Tax += row.TaxAmount
+	if row.PaymentStatus == paidStatus {
+		r.PaidCount++
+		r.Collected += row.TotalAmount
+	} else {
+		r.UnpaidCount++
+		r.Outstanding += row.TotalAmount
+	}
+}
+
+func (r *RevenueRow) round() {
+	r.Billed = round(r.Billed)
+	r.Collected = round(r.Collected)
+	r.Outstanding = round(r.Outstanding)
+	r.ConsultationFees = round(r.ConsultationFees)
+	r.MedicationRevenue = round(r.MedicationRevenue)
+	r.Discounts = round(r.Discounts)
+	r.Tax = round(r.Tax)
+}
+
+// Revenue totals the billings created in [from, to) per period, doctor or
+// specialization. Billed amounts include tax; collected are the paid ones.
+func (s *service) Revenue(from, to time.Time, groupBy string) (*RevenueReport, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidPeriod
+	}
+	if _, _, err := groupKey(&BillingRow{}, groupBy); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListBillings(from, to)
+	if err != nil {
+		s.logger.Error("failed to list billings for revenue report",
+			slog.Any("error", err),
+			slog.Time("from", from),
+			slog.Time("to", to),
+		)
+		return nil, err
+	}
+
+	report := &RevenueReport{From: from, To: to, GroupBy: groupBy, Totals: RevenueRow{Key: "total", Label: "Total"}}
+	groups := make(map[string]*RevenueRow)
+	for i := range rows {
+		key, label, _ := groupKey(&rows[i], groupBy)
+		group, ok := groups[key]
+		if !ok {
+			group = &RevenueRow{Key: key, Label: label}
+			groups[key] = group
+		}
+		group.add(&rows[i])
+		report.Totals.add(&rows[i])
+	}
+
+	report.Rows = make([]RevenueRow, 0, len(groups))
+	for _, group := range groups {
+		group.round()
+		report.Rows = append(report.Rows, *group)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool { return report.Rows[i].Key < report.Rows[j].Key })
+	report.Totals.round()
+
+	return report, nil
+}
+
+// Receivables lists what patients still owe as of asOf, aged from the billing
+// date into 0-30, 31-60 and 60+ day buckets.
+func (s *service) Receivables(asOf time.Time) (*AgingReport, error) {
+	rows, err := s.repo.ListUnpaidBillings(asOf)
+	if err != nil {
+		s.logger.Error("failed to list unpaid billings for aging report",
+			slog.Any("error", err),
+			slog.Time("as_of", asOf),
+		)
+		return nil, err
+	}
+
+	report := &AgingReport{AsOf: asOf, Buckets: make([]AgingBucket, len(agingBuckets))}
+	for i, b := range agingBuckets {
+		report.Buckets[i].Label = b.label
+	}
+
+	for i := range rows {
+		due := rows[i].AmountDue()
+		if due <= 0 {
+			continue
+		}
+
+		age := int(asOf.Sub(rows[i].CreatedAt).Hours() / 24)
+		for j, b := range agingBuckets {
+			if age <= b.maxDays {
+				report.Buckets[j].Count++
+				report.Buckets[j].Amount += due
+				report.Receivables = append(report.Receivables, Receivable{
+					BillingRow: rows[i],
+					AmountDue:  due,
+					AgeDays:    age,
+					Bucket:     b.label,
+				})
+				break
+			}
+		}
+		report.Total += due
+	}
+
+	for i := range report.Buckets {
+		report.Buckets[i].Amount = round(report.Buckets[i].Amount)
+	}
+	report.Total = round(report.Total)
+
+	return report, nil
+}
+
+// Conditions counts the diagnoses created in [from, to) per ICD-10 code,
+// optionally only the codes starting with prefix. The most frequent codes
+// come first.
+func (s *service) Conditions(from, to time.Time, prefix string) (*ConditionReport, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidPeriod
+	}
+
+	rows, err := s.repo.ListDiagnoseCodes(from, to, prefix)
+	if err != nil {
+		s.logger.Error("failed to list diagnose codes for condition report",
+			slog.Any("error", err),
+			slog.Time("from", from),
+			slog.Time("to", to),
+		)
+		return nil, err
+	}
+
+	groups := make(map[string]*ConditionRow)
+	patients := make(map[string]map[int64]bool)
+	for _, row := range rows {
+		group, ok := groups[row.Code]
+		if !ok {
+			group = &ConditionRow{Code: row.Code, Title: row.Title}
+			groups[row.Code] = group
+			patients[row.Code] = make(map[int64]bool)
+		}
+		group.DiagnoseCount++
+		if row.Primary {
+			group.PrimaryCount++
+		} else {
+			group.SecondaryCount++
+		}
+		patients[row.Code][row.UserID] = true
+	}
+
+	report := &ConditionReport{From: from, To: to, Prefix: prefix, Rows: make([]ConditionRow, 0, len(groups))}
+	for code, group := range groups {
+		group.PatientCount = len(patients[code])
+		report.Rows = append(report.Rows, *group)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].DiagnoseCount != report.Rows[j].DiagnoseCount {
+			return report.Rows[i].DiagnoseCount > report.Rows[j].DiagnoseCount
+		}
+		return report.Rows[i].Code < report.Rows[j].Code
+	})
+	return report, nil
+}
@@ -0,0 +1,90 @@
+package reports_test
+
+import (
+	"Dedenruslan19/med-project/service/reports"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func day(d int) time.Time {
+	return time.Date(2026, time.March, d, 10, 0, 0, 0, time.UTC)
+}
+
+func TestRevenue_ByDoctor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := reports.NewMockReportRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := reports.NewService(logger, mockRepo)
+
+	from, to := day(1), day(31)
+	mockRepo.EXPECT().
+		ListBillings(from, to).
+		Return([]reports.BillingRow{
+			{BillingID: 1, DoctorID: 2, DoctorName: "Dr. B", ConsultationFee: 200000, MedicationFee: 50000, TotalAmount: 272000, PaymentStatus: "paid", CreatedAt: day(2)},
+			{BillingID: 2, DoctorID: 1, DoctorName: "Dr. A", ConsultationFee: 200000, TotalAmount: 222000, PaymentStatus: "unpaid", CreatedAt: day(3)},
+			{BillingID: 3, DoctorID: 2, DoctorName: "Dr. B", ConsultationFee: 200000, MedicationFee: 100000, TotalAmount: 322000, PaymentStatus: "waiting_payment", CreatedAt: day(4)},
+		}, nil).
+		Times(1)
+
+	report, err := service.Revenue(from, to, reports.GroupByDoctor)
+
+	assert.NoError(t, err)
+	assert.Len(t, report.Rows, 2)
+	assert.Equal(t, "Dr. B", report.Rows[1].Label)
+	assert.Equal(t, 2, report.Rows[1].BillingCount)
+	assert.Equal(t, 272000.0, report.Rows[1].Collected)
+	assert.Equal(t, 322000.0, report.Rows[1].Outstanding)
+	assert.Equal(t, 150000.0, report.Rows[1].MedicationRevenue)
+	assert.Equal(t, 1, report.Totals.PaidCount)
+	assert.Equal(t, 2, report.Totals.UnpaidCount)
+	assert.Equal(t, 816000.0, report.Totals.Billed)
+}
+
+func TestRevenue_InvalidGroupBy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := reports.NewMockReportRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := reports.NewService(logger, mockRepo)
+
+	_, err := service.Revenue(day(1), day(31), "year")
+
+	assert.ErrorIs(t, err, reports.ErrInvalidGroupBy)
+}
+
+func TestReceivables_AgingBuckets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := reports.NewMockReportRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	service := reports.NewService(logger, mockRepo)
+
+	asOf := time.Date(2026, time.June, 30, 0, 0, 0, 0, time.UTC)
+	mockRepo.EXPECT().
+		ListUnpaidBillings(asOf).
+		Return([]reports.BillingRow{
+			{BillingID: 1, TotalAmount: 100000, CreatedAt: asOf.AddDate(0, 0, -10)},
+			{BillingID: 2, TotalAmount: 200000, InsuranceAmount: 150000, PatientAmount: 50000, CreatedAt: asOf.AddDate(0, 0, -45)},
+			{BillingID: 3, TotalAmount: 300000, CreatedAt: asOf.AddDate(0, 0, -90)},
+			{BillingID: 4, TotalAmount: 100000, InsuranceAmount: 100000, CreatedAt: asOf.AddDate(0, 0, -5)},
+		}, nil).
+		Times(1)
+
+	report, err := service.Receivables(asOf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100000.0, report.Buckets[0].Amount)
+	assert.Equal(t, 50000.0, report.Buckets[1].Amount)
+	assert.Equal(t, 300000.0, report.Buckets[2].Amount)
+	assert.Equal(t, 450000.0, report.Total)
+	assert.Len(t, report.Receivables, 3)
+}
@@ -0,0 +1,129 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/safety/safety_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/safety/safety_repo.go -destination=service/safety/mock_repo.go -package=safety
+//
+
+// Package safety is a generated GoMock package.
+package safety
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSafetyRepo is a mock of SafetyRepo interface.
+type MockSafetyRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockSafetyRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockSafetyRepoMockRecorder is the mock recorder for MockSafetyRepo.
+type MockSafetyRepoMockRecorder struct {
+	mock *MockSafetyRepo
+}
+
+// NewMockSafetyRepo creates a new mock instance.
+func NewMockSafetyRepo(ctrl *gomock.Controller) *MockSafetyRepo {
+	mock := &MockSafetyRepo{ctrl: ctrl}
+	mock.recorder = &MockSafetyRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSafetyRepo) EXPECT() *MockSafetyRepoMockRecorder {
+	return m.recorder
+}
+
+// CreateAllergy mocks base method.
+func (m *MockSafetyRepo) CreateAllergy(allergy *Allergy) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAllergy", allergy)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAllergy indicates an expected call of CreateAllergy.
+func (mr *MockSafetyRepoMockRecorder) CreateAllergy(allergy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAllergy", reflect.TypeOf((*MockSafetyRepo)(nil).CreateAllergy), allergy)
+}
+
+// DeleteAllergy mocks base method.
+func (m *MockSafetyRepo) DeleteAllergy(id, userID int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAllergy", id, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAllergy indicates an expected call of DeleteAllergy.
+func (mr *MockSafetyRepoMockRecorder) DeleteAllergy(id, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAllergy", reflect.TypeOf((*MockSafetyRepo)(nil).DeleteAllergy), id, userID)
+}
+
+// FindInteractions mocks base method.
+func (m *MockSafetyRepo) FindInteractions(names []string) ([]Interaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindInteractions", names)
+	ret0, _ := ret[0].([]Interaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindInteractions indicates an expected call of FindInteractions.
+func (mr *MockSafetyRepoMockRecorder) FindInteractions(names any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindInteractions", reflect.TypeOf((*MockSafetyRepo)(nil).FindInteractions), names)
+}
+
+// ListAllergies mocks base method.
+func (m *MockSafetyRepo) ListAllergies(userID int64) ([]Allergy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllergies", userID)
+	ret0, _ := ret[0].([]Allergy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllergies indicates an expected call of ListAllergies.
+func (mr *MockSafetyRepoMockRecorder) ListAllergies(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllergies", reflect.TypeOf((*MockSafetyRepo)(nil).ListAllergies), userID)
+}
+
+// ListOverrides mocks base method.
+func (m *MockSafetyRepo) ListOverrides(diagnoseID int64) ([]Override, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOverrides", diagnoseID)
+	ret0, _ := ret[0].([]Override)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOverrides indicates an expected call of ListOverrides.
+func (mr *MockSafetyRepoMockRecorder) ListOverrides(diagnoseID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOverrides", reflect.TypeOf((*MockSafetyRepo)(nil).ListOverrides), diagnoseID)
+}
+
+// UpsertInteractions mocks base method.
+func (m *MockSafetyRepo) UpsertInteractions(rules []Interaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertInteractions", rules)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertInteractions indicates an expected call of UpsertInteractions.
+func (mr *MockSafetyRepoMockRecorder) UpsertInteractions(rules any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertInteractions", reflect.TypeOf((*MockSafetyRepo)(nil).UpsertInteractions), rules)
+}
@@ -0,0 +1,142 @@
+package safety
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	AllergyMild     = "mild"
+	AllergyModerate = "moderate"
+	AllergySevere   = "severe"
+
+	InteractionMinor           = "minor"
+	InteractionModerate        = "moderate"
+	InteractionMajor           = "major"
+	InteractionContraindicated = "contraindicated"
+
+	AlertAllergy     = "allergy"
+	AlertInteraction = "interaction"
+)
+
+// Allergy is a substance the patient reacts to. Substance is stored in lower
+// case and matches every medication that names it.
+type Allergy struct {
+	ID           int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID       int64     `json:"user_id" gorm:"not null;index"`
+	Substance    string    `json:"substance" gorm:"type:varchar(255);not null"`
+	Reaction     string    `json:"reaction" gorm:"type:text"`
+	Severity     string    `json:"severity" gorm:"type:varchar(20);not null"`
+	RecordedBy   string    `json:"recorded_by" gorm:"type:varchar(20);not null"`
+	RecordedByID int64     `json:"recorded_by_id" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// Interaction is a rule that two drugs should not be prescribed together.
+// DrugA sorts before DrugB so each pair is stored once.
+type Interaction struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	DrugA       string    `json:"drug_a" gorm:"type:varchar(255);not null;uniqueIndex:idx_drug_interactions_pair"`
+	DrugB       string    `json:"drug_b" gorm:"type:varchar(255);not null;uniqueIndex:idx_drug_interactions_pair"`
+	Severity    string    `json:"severity" gorm:"type:varchar(20);not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Interaction) TableName() string {
+	return "drug_interactions"
+}
+
+// Alert is a conflict found in a prescription. Blocking alerts stop the
+// prescription unless the doctor justifies overriding them.
+type Alert struct {
+	Kind        string   `json:"kind"`
+	Severity    string   `json:"severity"`
+	Blocking    bool     `json:"blocking"`
+	Medications []string `json:"medications"`
+	Substance   string   `json:"substance,omitempty"`
+	Message     string   `json:"message"`
+}
+
+// Assessment is the outcome of checking a prescription for a patient.
+type Assessment struct {
+	UserID        int64    `json:"user_id"`
+	Medications   []string `json:"medications"`
+	Alerts        []Alert  `json:"alerts"`
+	Blocked       bool     `json:"blocked"`
+	Justification string   `json:"justification,omitempty"`
+}
+
+// Warnings are the alerts that did not block the prescription.
+func (a *Assessment) Warnings() []Alert {
+	var warnings []Alert
+	for _, alert := range a.Alerts {
+		if !alert.Blocking {
+			warnings = append(warnings, alert)
+		}
+	}
+	return warnings
+}
+
+// Overridden tells whether blocking alerts were overridden with a
+// justification.
+func (a *Assessment) Overridden() bool {
+	return a.Blocked && a.Justification != ""
+}
+
+// Override records a doctor prescribing despite blocking alerts.
+type Override struct {
+	ID            int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	DiagnoseID    int64     `json:"diagnose_id" gorm:"not null;index"`
+	DoctorID      int64     `json:"doctor_id" gorm:"not null"`
+	UserID        int64     `json:"user_id" gorm:"not null"`
+	Medications   string    `json:"medications" gorm:"type:text;not null;serializer:encrypted"`
+	Alerts        []Alert   `json:"alerts" gorm:"type:text;serializer:json"`
+	Justification string    `json:"justification" gorm:"type:text;not null;serializer:encrypted"`
+	CreatedAt     time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (Override) TableName() string {
+	return "prescription_overrides"
+}
+
+var nonWord = regexp.MustCompile(`[^a-z0-9']+`)
+
+// Normalize lower cases a drug or substance name and collapses everything but
+// letters, digits and apostrophes to single spaces.
+func Normalize(name string) string {
+	return strings.TrimSpace(nonWord.ReplaceAllString(strings.ToLower(name), " "))
+}
+
+// mentions tells whether the medication names the drug as whole words, so
+// "Aspirin 100mg" mentions "aspirin" but "Paracetamol" does not mention
+// "acetamol".
+func mentions(medication, drug string) bool {
+	return strings.Contains(" "+Normalize(medication)+" ", " "+drug+" ")
+}
+
+// candidates lists the names a medication may be known by in the rule table:
+// its full name, each word and each pair of adjacent words.
+func candidates(medication string) []string {
+	words := strings.Fields(Normalize(medication))
+	names := []string{strings.Join(words, " ")}
+	for i, word := range words {
+		names = append(names, word)
+		if i+1 < len(words) {
+			names = append(names, word+" "+words[i+1])
+		}
+	}
+	return names
+}
+
+// SplitMedications splits a comma separated prescription into medications.
+func SplitMedications(list string) []string {
+	var medications []string
+	for _, med := range strings.Split(list, ",") {
+		if med = strings.TrimSpace(med); med != "" {
+			medications = append(medications, med)
+		}
+	}
+	return medications
+}
@@ -0,0 +1,16 @@
+package safety
+
+type SafetyRepo interface {
+	CreateAllergy(allergy *Allergy) (int64, error)
+	ListAllergies(userID int64) ([]Allergy, error)
+	// DeleteAllergy removes the patient's allergy and reports whether it
+	// existed.
+	DeleteAllergy(id, userID int64) (bool, error)
+	// UpsertInteractions inserts the rules or updates the severity and
+	// description of existing pairs.
+	UpsertInteractions(rules []Interaction) error
+	// FindInteractions returns the rules between two of the given names.
+	FindInteractions(names []string) ([]Interaction, error)
+	// ListOverrides returns the overrides stored with the diagnosis.
+	ListOverrides(diagnoseID int64) ([]Override, error)
+}
@@ -0,0 +1,310 @@
+package safety
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"Dedenruslan19/med-project/service/diagnoses"
+)
+
+const importBatchSize = 500
+
+var (
+	ErrInvalidAllergy      = errors.New("invalid allergy")
+	ErrAllergyNotFound     = errors.New("allergy not found")
+	ErrInvalidRuleTable    = errors.New("invalid drug interaction table")
+	ErrPrescriptionBlocked = errors.New("prescription conflicts with the patient's allergies or other medications, a justification is required to override")
+)
+
+var (
+	allergySeverities     = map[string]bool{AllergyMild: true, AllergyModerate: true, AllergySevere: true}
+	interactionSeverities = map[string]bool{InteractionMinor: true, InteractionModerate: true, InteractionMajor: true, InteractionContraindicated: true}
+)
+
+// blocking tells whether an alert of the given severity stops a prescription:
+// moderate and severe allergies, major and contraindicated interactions.
+func blocking(kind, severity string) bool {
+	if kind == AlertAllergy {
+		return severity != AllergyMild
+	}
+	return severity == InteractionMajor || severity == InteractionContraindicated
+}
+
+type service struct {
+	repo   SafetyRepo
+	logger *slog.Logger
+}
+
+type Service interface {
+	AddAllergy(allergy *Allergy) (int64, error)
+	GetAllergies(userID int64) ([]Allergy, error)
+	RemoveAllergy(id, userID int64) error
+	ImportInteractions(r io.Reader) (int, error)
+	Assess(userID int64, medications []string, justification string) (*Assessment, error)
+	OverrideRecords(doctorID int64, assessment *Assessment) diagnoses.RecordBuilder
+	GetOverrides(diagnoseID int64) ([]Override, error)
+}
+
+func NewService(logger *slog.Logger, repo SafetyRepo) Service {
+	return &service{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+func (s *service) AddAllergy(allergy *Allergy) (int64, error) {
+	allergy.Substance = Normalize(allergy.Substance)
+	allergy.Severity = strings.ToLower(strings.TrimSpace(allergy.Severity))
+	if allergy.Substance == "" {
+		return 0, fmt.Errorf("%w: substance is required", ErrInvalidAllergy)
+	}
+	if !allergySeverities[allergy.Severity] {
+		return 0, fmt.Errorf("%w: severity must be one of mild, moderate, severe", ErrInvalidAllergy)
+	}
+
+	id, err := s.repo.CreateAllergy(allergy)
+	if err != nil {
+		s.logger.Error("failed to create allergy",
+			slog.Any("error", err),
+			slog.Int64("user_id", allergy.UserID),
+		)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *service) GetAllergies(userID int64) ([]Allergy, error) {
+	list, err := s.repo.ListAllergies(userID)
+	if err != nil {
+		s.logger.Error("failed to list allergies",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+		)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *service) RemoveAllergy(id, userID int64) error {
+	deleted, err := s.repo.DeleteAllergy(id, userID)
+	if err != nil {
+		s.logger.Error("failed to delete allergy",
+			slog.Any("error", err),
+			slog.Int64("allergy_id", id),
+		)
+		return err
+	}
+	if !deleted {
+		return ErrAllergyNotFound
+	}
+	return nil
+}
+
+// ImportInteractions reads a rule table CSV with a
+// drug_a,drug_b,severity,description header.
+func (s *service) ImportInteractions(r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidRuleTable, err)
+	}
+	if len(header) < 3 || Normalize(header[0]) != "drug a" || Normalize(header[1]) != "drug b" {
+		return 0, fmt.Errorf("%w: header must be drug_a,drug_b,severity,description", ErrInvalidRuleTable)
+	}
+
+	imported := 0
+	batch := make([]Interaction, 0, importBatchSize)
+	// a pair listed twice in one batch keeps its last rule, the upsert could
+	// not touch the same row twice
+	inBatch := make(map[[2]string]int)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.repo.UpsertInteractions(batch); err != nil {
+			return err
+		}
+		imported += len(batch)
+		batch = batch[:0]
+		clear(inBatch)
+		return nil
+	}
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, fmt.Errorf("%w: %v", ErrInvalidRuleTable, err)
+		}
+		if len(record) < 3 {
+			return imported, fmt.Errorf("%w: row %d has too few columns", ErrInvalidRuleTable, row)
+		}
+
+		drugA, drugB := Normalize(record[0]), Normalize(record[1])
+		severity := strings.ToLower(strings.TrimSpace(record[2]))
+		if drugA == "" || drugB == "" || drugA == drugB || !interactionSeverities[severity] {
+			return imported, fmt.Errorf("%w: row %d has invalid drugs or severity", ErrInvalidRuleTable, row)
+		}
+		if drugB < drugA {
+			drugA, drugB = drugB, drugA
+		}
+		rule := Interaction{DrugA: drugA, DrugB: drugB, Severity: severity}
+		if len(record) > 3 {
+			rule.Description = strings.TrimSpace(record[3])
+		}
+		if i, ok := inBatch[[2]string{drugA, drugB}]; ok {
+			batch[i] = rule
+			continue
+		}
+		inBatch[[2]string{drugA, drugB}] = len(batch)
+		batch = append(batch, rule)
+
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return imported, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return imported, err
+	}
+
+	s.logger.Info("drug interaction table imported", slog.Int("rules", imported))
+	return imported, nil
+}
+
+// Assess checks the medications against the patient's allergies and against
+// each other. When an alert blocks the prescription it returns the
+// assessment with ErrPrescriptionBlocked, unless a justification overrides
+// it.
+func (s *service) Assess(userID int64, medications []string, justification string) (*Assessment, error) {
+	assessment := &Assessment{UserID: userID, Medications: medications, Alerts: []Alert{}}
+	if len(medications) == 0 {
+		return assessment, nil
+	}
+
+	allergies, err := s.repo.ListAllergies(userID)
+	if err != nil {
+		s.logger.Error("failed to list allergies for prescription check",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+		)
+		return nil, err
+	}
+	for _, allergy := range allergies {
+		for _, med := range medications {
+			if !mentions(med, allergy.Substance) {
+				continue
+			}
+			message := fmt.Sprintf("patient has a %s allergy to %s", allergy.Severity, allergy.Substance)
+			if allergy.Reaction != "" {
+				message += " (" + allergy.Reaction + ")"
+			}
+			assessment.Alerts = append(assessment.Alerts, Alert{
+				Kind:        AlertAllergy,
+				Severity:    allergy.Severity,
+				Blocking:    blocking(AlertAllergy, allergy.Severity),
+				Medications: []string{med},
+				Substance:   allergy.Substance,
+				Message:     message,
+			})
+		}
+	}
+
+	var names []string
+	for _, med := range medications {
+		names = append(names, candidates(med)...)
+	}
+	rules, err := s.repo.FindInteractions(names)
+	if err != nil {
+		s.logger.Error("failed to find drug interactions",
+			slog.Any("error", err),
+			slog.Int64("user_id", userID),
+		)
+		return nil, err
+	}
+	for _, rule := range rules {
+		for i, medA := range medications {
+			for j, medB := range medications {
+				if i == j || !mentions(medA, rule.DrugA) || !mentions(medB, rule.DrugB) {
+					continue
+				}
+				message := fmt.Sprintf("%s interaction between %s and %s", rule.Severity, rule.DrugA, rule.DrugB)
+				if rule.Description != "" {
+					message += ": " + rule.Description
+				}
+				assessment.Alerts = append(assessment.Alerts, Alert{
+					Kind:        AlertInteraction,
+					Severity:    rule.Severity,
+					Blocking:    blocking(AlertInteraction, rule.Severity),
+					Medications: []string{medA, medB},
+					Message:     message,
+				})
+			}
+		}
+	}
+
+	for _, alert := range assessment.Alerts {
+		if alert.Blocking {
+			assessment.Blocked = true
+		}
+	}
+	if assessment.Blocked {
+		assessment.Justification = strings.TrimSpace(justification)
+		if assessment.Justification == "" {
+			return assessment, ErrPrescriptionBlocked
+		}
+	}
+	return assessment, nil
+}
+
+// OverrideRecords builds the justification of an overridden assessment as a
+// record of the diagnosis it was prescribed in, so the diagnosis is only saved
+// with it. Assessments that were not overridden add nothing.
+func (s *service) OverrideRecords(doctorID int64, assessment *Assessment) diagnoses.RecordBuilder {
+	return func(diagnose *diagnoses.Diagnose) ([]interface{}, error) {
+		if assessment == nil || !assessment.Overridden() {
+			return nil, nil
+		}
+
+		var blockingAlerts []Alert
+		for _, alert := range assessment.Alerts {
+			if alert.Blocking {
+				blockingAlerts = append(blockingAlerts, alert)
+			}
+		}
+
+		s.logger.Warn("prescription safety alerts overridden",
+			slog.Int64("diagnose_id", diagnose.ID),
+			slog.Int64("doctor_id", doctorID),
+			slog.Int("alerts", len(blockingAlerts)),
+		)
+		return []interface{}{&Override{
+			DiagnoseID:    diagnose.ID,
+			DoctorID:      doctorID,
+			UserID:        assessment.UserID,
+			Medications:   strings.Join(assessment.Medications, ", "),
+			Alerts:        blockingAlerts,
+			Justification: assessment.Justification,
+		}}, nil
+	}
+}
+
+func (s *service) GetOverrides(diagnoseID int64) ([]Override, error) {
+	list, err := s.repo.ListOverrides(diagnoseID)
+	if err != nil {
+		s.logger.Error("failed to list prescription overrides",
+			slog.Any("error", err),
+			slog.Int64("diagnose_id", diagnoseID),
+		)
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,106 @@
+package safety_test
+
+import (
+	"Dedenruslan19/med-project/service/diagnoses"
+	"Dedenruslan19/med-project/service/safety"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setup(t *testing.T) (*safety.MockSafetyRepo, safety.Service) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockRepo := safety.NewMockSafetyRepo(ctrl)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	return mockRepo, safety.NewService(logger, mockRepo)
+}
+
+func TestAssess_BlocksUntilJustified(t *testing.T) {
+	mockRepo, service := setup(t)
+	medications := []string{"Amoxicillin 500mg", "Warfarin", "Aspirin 100mg"}
+
+	mockRepo.EXPECT().ListAllergies(int64(1)).Return([]safety.Allergy{
+		{Substance: "amoxicillin", Severity: safety.AllergySevere, Reaction: "anaphylaxis"},
+		{Substance: "ibuprofen", Severity: safety.AllergySevere},
+	}, nil).Times(2)
+	mockRepo.EXPECT().FindInteractions(gomock.Any()).Return([]safety.Interaction{
+		{DrugA: "aspirin", DrugB: "warfarin", Severity: safety.InteractionModerate, Description: "bleeding risk"},
+	}, nil).Times(2)
+
+	assessment, err := service.Assess(1, medications, "")
+
+	assert.ErrorIs(t, err, safety.ErrPrescriptionBlocked)
+	assert.Len(t, assessment.Alerts, 2)
+	assert.True(t, assessment.Alerts[0].Blocking)
+	assert.Equal(t, []string{"Amoxicillin 500mg"}, assessment.Alerts[0].Medications)
+	assert.Equal(t, []string{"Aspirin 100mg", "Warfarin"}, assessment.Alerts[1].Medications)
+	assert.Len(t, assessment.Warnings(), 1)
+
+	assessment, err = service.Assess(1, medications, "no alternative, allergy reviewed with patient")
+
+	assert.NoError(t, err)
+	assert.True(t, assessment.Overridden())
+
+	records, err := service.OverrideRecords(2, assessment)(&diagnoses.Diagnose{ID: 7})
+
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		override := records[0].(*safety.Override)
+		assert.Equal(t, int64(7), override.DiagnoseID)
+		assert.Equal(t, int64(2), override.DoctorID)
+		assert.Len(t, override.Alerts, 1)
+		assert.Equal(t, "no alternative, allergy reviewed with patient", override.Justification)
+	}
+}
+
+func TestAssess_NoConflicts(t *testing.T) {
+	mockRepo, service := setup(t)
+
+	mockRepo.EXPECT().ListAllergies(int64(1)).Return([]safety.Allergy{
+		{Substance: "acetamol", Severity: safety.AllergySevere},
+	}, nil).Times(1)
+	mockRepo.EXPECT().FindInteractions(gomock.Any()).Return(nil, nil).Times(1)
+
+	assessment, err := service.Assess(1, []string{"Paracetamol"}, "")
+
+	assert.NoError(t, err)
+	assert.Empty(t, assessment.Alerts)
+
+	records, err := service.OverrideRecords(2, assessment)(&diagnoses.Diagnose{ID: 7})
+
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestImportInteractions_OrdersPairs(t *testing.T) {
+	mockRepo, service := setup(t)
+
+	mockRepo.EXPECT().
+		UpsertInteractions(gomock.Any()).
+		DoAndReturn(func(rules []safety.Interaction) error {
+			assert.Len(t, rules, 2)
+			assert.Equal(t, "aspirin", rules[0].DrugA)
+			assert.Equal(t, "warfarin", rules[0].DrugB)
+			assert.Equal(t, safety.InteractionMajor, rules[0].Severity)
+			return nil
+		}).
+		Times(1)
+
+	table := "drug_a,drug_b,severity,description\n" +
+		"Warfarin,Aspirin,moderate,bleeding risk\n" +
+		"Simvastatin,Clarithromycin,contraindicated,myopathy\n" +
+		"aspirin,warfarin,major,bleeding risk\n"
+	imported, err := service.ImportInteractions(strings.NewReader(table))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, imported)
+
+	_, err = service.ImportInteractions(strings.NewReader("drug_a,drug_b,severity\nWarfarin,Aspirin,severe\n"))
+	assert.ErrorIs(t, err, safety.ErrInvalidRuleTable)
+}
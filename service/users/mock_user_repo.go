@@ -83,3 +83,17 @@ func (mr *MockUserRepoMockRecorder) GetByEmail(email any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserRepo)(nil).GetByEmail), email)
 }
+
+// UpdateMeasurements mocks base method.
+func (m *MockUserRepo) UpdateMeasurements(id int64, weight, height float64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMeasurements", id, weight, height)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMeasurements indicates an expected call of UpdateMeasurements.
+func (mr *MockUserRepoMockRecorder) UpdateMeasurements(id, weight, height any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMeasurements", reflect.TypeOf((*MockUserRepo)(nil).UpdateMeasurements), id, weight, height)
+}
@@ -1,5 +1,7 @@
 package users
 
+import "time"
+
 type User struct {
 	ID       int64   `gorm:"primaryKey;autoIncrement" json:"id"`
 	FullName string  `gorm:"type:varchar(255);not null" json:"full_name" validate:"required,min=2,max=255"`
@@ -8,4 +10,6 @@ type User struct {
 	Weight   float64 `gorm:"type:decimal(5,2);not null" json:"weight" validate:"required,gt=0"`
 	Height   float64 `gorm:"not null" json:"height" validate:"required,gt=0"`
 	Token    string  `gorm:"-" json:"token,omitempty"`
+	// ErasedAt is set when the account was anonymised on the user's request.
+	ErasedAt *time.Time `json:"-"`
 }
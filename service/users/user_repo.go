@@ -4,4 +4,7 @@ type UserRepo interface {
 	Create(user User) (int64, error)
 	GetByEmail(email string) (User, error)
 	FindByID(id int64) (User, error)
+	// UpdateMeasurements sets the weight and height, zero values are left
+	// unchanged.
+	UpdateMeasurements(id int64, weight, height float64) error
 }
@@ -20,6 +20,7 @@ type Service interface {
 	Login(email, passwordhash string) (User, error)
 	GetUserByID(userID int64) (User, error)
 	CalculateBMI(weight, height float64) (float64, bool, error)
+	UpdateMeasurements(userID int64, weight, height float64) error
 }
 
 func NewService(logger *slog.Logger, repo UserRepo, bmiRepo bmi.Repository) Service {
@@ -83,6 +84,7 @@ func (s *service) Login(email, password string) (User, error) {
 	return user, nil
 }
 
+// GetUserByID returns ErrUserNotFound for erased accounts too.
 func (s *service) GetUserByID(userID int64) (User, error) {
 	user, err := s.repo.FindByID(userID)
 	if err != nil {
@@ -92,6 +94,9 @@ func (s *service) GetUserByID(userID int64) (User, error) {
 		)
 		return User{}, errs.ErrUserNotFound
 	}
+	if user.ErasedAt != nil {
+		return User{}, errs.ErrUserNotFound
+	}
 
 	return user, nil
 }
@@ -110,3 +115,21 @@ func (s *service) CalculateBMI(weight, height float64) (float64, bool, error) {
 
 	return bmiVal, usedCallback, nil
 }
+
+// UpdateMeasurements records a new weight (kg) and height (cm) on the
+// profile, e.g. from vitals taken at a consultation. Zero values keep the
+// current one.
+func (s *service) UpdateMeasurements(userID int64, weight, height float64) error {
+	if weight == 0 && height == 0 {
+		return nil
+	}
+
+	if err := s.repo.UpdateMeasurements(userID, weight, height); err != nil {
+		s.logger.Error("Failed to update user measurements",
+			slog.Int64("user_id", userID),
+			slog.Any("error", err),
+		)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/vitals/vitals_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service/vitals/vitals_repo.go -destination=service/vitals/mock_repo.go -package=vitals
+//
+
+// Package vitals is a generated GoMock package.
+package vitals
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockVitalRepo is a mock of VitalRepo interface.
+type MockVitalRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockVitalRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockVitalRepoMockRecorder is the mock recorder for MockVitalRepo.
+type MockVitalRepoMockRecorder struct {
+	mock *MockVitalRepo
+}
+
+// NewMockVitalRepo creates a new mock instance.
+func NewMockVitalRepo(ctrl *gomock.Controller) *MockVitalRepo {
+	mock := &MockVitalRepo{ctrl: ctrl}
+	mock.recorder = &MockVitalRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVitalRepo) EXPECT() *MockVitalRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockVitalRepo) Create(vital *Vital) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", vital)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockVitalRepoMockRecorder) Create(vital any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockVitalRepo)(nil).Create), vital)
+}
+
+// ListByAppointmentID mocks base method.
+func (m *MockVitalRepo) ListByAppointmentID(appointmentID int64) ([]Vital, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByAppointmentID", appointmentID)
+	ret0, _ := ret[0].([]Vital)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByAppointmentID indicates an expected call of ListByAppointmentID.
+func (mr *MockVitalRepoMockRecorder) ListByAppointmentID(appointmentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByAppointmentID", reflect.TypeOf((*MockVitalRepo)(nil).ListByAppointmentID), appointmentID)
+}
+
+// ListByUserID mocks base method.
+func (m *MockVitalRepo) ListByUserID(userID int64, from, to time.Time) ([]Vital, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUserID", userID, from, to)
+	ret0, _ := ret[0].([]Vital)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUserID indicates an expected call of ListByUserID.
+func (mr *MockVitalRepoMockRecorder) ListByUserID(userID, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUserID", reflect.TypeOf((*MockVitalRepo)(nil).ListByUserID), userID, from, to)
+}
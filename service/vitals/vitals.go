@@ -0,0 +1,125 @@
+package vitals
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	SystolicBP      = "systolic_bp"
+	DiastolicBP     = "diastolic_bp"
+	HeartRate       = "heart_rate"
+	Temperature     = "temperature"
+	SpO2            = "spo2"
+	RespiratoryRate = "respiratory_rate"
+	Weight          = "weight"
+	Height          = "height"
+	BMI             = "bmi"
+
+	FlagLow  = "low"
+	FlagHigh = "high"
+)
+
+// Vital is the set of measurements a doctor took at an appointment, stored
+// in mmHg, bpm, °C, %, breaths/min, kg and cm. Measurements that were not
+// taken are nil.
+type Vital struct {
+	ID              int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	AppointmentID   int64     `json:"appointment_id" gorm:"not null;index"`
+	UserID          int64     `json:"user_id" gorm:"not null;index"`
+	DoctorID        int64     `json:"doctor_id" gorm:"not null"`
+	SystolicBP      *float64  `json:"systolic_bp,omitempty" gorm:"column:systolic_bp"`
+	DiastolicBP     *float64  `json:"diastolic_bp,omitempty" gorm:"column:diastolic_bp"`
+	HeartRate       *float64  `json:"heart_rate,omitempty"`
+	Temperature     *float64  `json:"temperature,omitempty"`
+	SpO2            *float64  `json:"spo2,omitempty" gorm:"column:spo2"`
+	RespiratoryRate *float64  `json:"respiratory_rate,omitempty"`
+	Weight          *float64  `json:"weight,omitempty"`
+	Height          *float64  `json:"height,omitempty"`
+	BMI             *float64  `json:"bmi,omitempty" gorm:"column:bmi"`
+	Abnormal        bool      `json:"abnormal" gorm:"not null;default:false"`
+	Flags           []Flag    `json:"flags" gorm:"type:text;serializer:json"`
+	RecordedAt      time.Time `json:"recorded_at" gorm:"not null"`
+	CreatedAt       time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// Flag marks a measurement outside its normal adult range.
+type Flag struct {
+	Measurement string  `json:"measurement"`
+	Value       float64 `json:"value"`
+	Level       string  `json:"level"`
+	Normal      string  `json:"normal"`
+}
+
+// Measurements is what a doctor enters. Temperature, weight and height may be
+// given in °F, lb and in; they default to °C, kg and cm.
+type Measurements struct {
+	SystolicBP      *float64
+	DiastolicBP     *float64
+	HeartRate       *float64
+	Temperature     *float64
+	TemperatureUnit string
+	SpO2            *float64
+	RespiratoryRate *float64
+	Weight          *float64
+	WeightUnit      string
+	Height          *float64
+	HeightUnit      string
+	RecordedAt      time.Time
+}
+
+// spec describes one measurement: the range a reading must be in to be
+// plausible at all, and the normal range outside of which it is flagged.
+type spec struct {
+	name      string
+	unit      string
+	min, max  float64
+	low, high float64
+	value     func(v *Vital) *float64
+}
+
+var specs = []spec{
+	{SystolicBP, "mmHg", 50, 300, 90, 139, func(v *Vital) *float64 { return v.SystolicBP }},
+	{DiastolicBP, "mmHg", 20, 200, 60, 89, func(v *Vital) *float64 { return v.DiastolicBP }},
+	{HeartRate, "bpm", 20, 300, 60, 100, func(v *Vital) *float64 { return v.HeartRate }},
+	{Temperature, "°C", 25, 45, 36.1, 37.9, func(v *Vital) *float64 { return v.Temperature }},
+	{SpO2, "%", 50, 100, 95, 100, func(v *Vital) *float64 { return v.SpO2 }},
+	{RespiratoryRate, "breaths/min", 4, 80, 12, 20, func(v *Vital) *float64 { return v.RespiratoryRate }},
+	{Weight, "kg", 0.5, 500, 0, math.MaxFloat64, func(v *Vital) *float64 { return v.Weight }},
+	{Height, "cm", 30, 280, 0, math.MaxFloat64, func(v *Vital) *float64 { return v.Height }},
+	{BMI, "kg/m²", 5, 150, 18.5, 24.9, func(v *Vital) *float64 { return v.BMI }},
+}
+
+func specFor(name string) (spec, bool) {
+	for _, s := range specs {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return spec{}, false
+}
+
+// Point is one reading of a measurement over time.
+type Point struct {
+	RecordedAt    time.Time `json:"recorded_at"`
+	AppointmentID int64     `json:"appointment_id"`
+	Value         float64   `json:"value"`
+	Flag          string    `json:"flag,omitempty"`
+}
+
+// Series is the trend of one measurement.
+type Series struct {
+	Measurement string   `json:"measurement"`
+	Unit        string   `json:"unit"`
+	Points      []Point  `json:"points"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Latest      *float64 `json:"latest,omitempty"`
+}
+
+type Trend struct {
+	PatientID int64     `json:"patient_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Series    []Series  `json:"series"`
+}
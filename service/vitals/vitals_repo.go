@@ -0,0 +1,11 @@
+package vitals
+
+import "time"
+
+type VitalRepo interface {
+	Create(vital *Vital) (int64, error)
+	ListByAppointmentID(appointmentID int64) ([]Vital, error)
+	// ListByUserID returns the patient's vitals recorded in [from, to),
+	// oldest first.
+	ListByUserID(userID int64, from, to time.Time) ([]Vital, error)
+}
@@ -0,0 +1,287 @@
+package vitals
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/users"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAppointmentNotFound = errors.New("appointment not found")
+	ErrNotTreatingDoctor   = errors.New("only the appointment's doctor can record vitals")
+	ErrNoMeasurements      = errors.New("at least one measurement is required")
+	ErrInvalidUnit         = errors.New("invalid unit")
+	ErrOutOfRange          = errors.New("measurement out of range")
+	ErrInvalidMeasurement  = errors.New("invalid measurement")
+	ErrInvalidPeriod       = errors.New("from must be before to")
+)
+
+type service struct {
+	repo               VitalRepo
+	appointmentService appointments.Service
+	userService        users.Service
+	logger             *slog.Logger
+}
+
+type Service interface {
+	Record(doctorID, appointmentID int64, m Measurements, updateProfile bool) (*Vital, error)
+	GetByAppointmentID(appointmentID int64) ([]Vital, error)
+	Trend(patientID int64, measurements []string, from, to time.Time) (*Trend, error)
+}
+
+func NewService(logger *slog.Logger, repo VitalRepo, appointmentService appointments.Service, userService users.Service) Service {
+	return &service{
+		logger:             logger,
+		repo:               repo,
+		appointmentService: appointmentService,
+		userService:        userService,
+	}
+}
+
+func round1(value float64) float64 {
+	return math.Round(value*10) / 10
+}
+
+// convert returns value in the canonical unit, nil stays nil.
+func convert(value *float64, unit string, units map[string]func(float64) float64) (*float64, error) {
+	to, ok := units[strings.ToLower(unit)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidUnit, unit)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	converted := round1(to(*value))
+	return &converted, nil
+}
+
+var (
+	temperatureUnits = map[string]func(float64) float64{
+		"":  func(v float64) float64 { return v },
+		"c": func(v float64) float64 { return v },
+		"f": func(v float64) float64 { return (v - 32) * 5 / 9 },
+	}
+	weightUnits = map[string]func(float64) float64{
+		"":   func(v float64) float64 { return v },
+		"kg": func(v float64) float64 { return v },
+		"lb": func(v float64) float64 { return v * 0.45359237 },
+	}
+	heightUnits = map[string]func(float64) float64{
+		"":   func(v float64) float64 { return v },
+		"cm": func(v float64) float64 { return v },
+		"in": func(v float64) float64 { return v * 2.54 },
+	}
+)
+
+// newVital converts the measurements to canonical units and checks that
+// every reading is plausible.
+func newVital(m Measurements) (*Vital, error) {
+	vital := &Vital{
+		SystolicBP:      m.SystolicBP,
+		DiastolicBP:     m.DiastolicBP,
+		HeartRate:       m.HeartRate,
+		SpO2:            m.SpO2,
+		RespiratoryRate: m.RespiratoryRate,
+		RecordedAt:      m.RecordedAt,
+	}
+
+	var err error
+	if vital.Temperature, err = convert(m.Temperature, m.TemperatureUnit, temperatureUnits); err != nil {
+		return nil, err
+	}
+	if vital.Weight, err = convert(m.Weight, m.WeightUnit, weightUnits); err != nil {
+		return nil, err
+	}
+	if vital.Height, err = convert(m.Height, m.HeightUnit, heightUnits); err != nil {
+		return nil, err
+	}
+
+	taken := 0
+	for _, s := range specs {
+		value := s.value(vital)
+		if value == nil {
+			continue
+		}
+		taken++
+		if *value < s.min || *value > s.max {
+			return nil, fmt.Errorf("%w: %s must be between %g and %g %s", ErrOutOfRange, s.name, s.min, s.max, s.unit)
+		}
+	}
+	if taken == 0 {
+		return nil, ErrNoMeasurements
+	}
+	if (vital.SystolicBP == nil) != (vital.DiastolicBP == nil) {
+		return nil, fmt.Errorf("%w: blood pressure needs both systolic and diastolic values", ErrInvalidMeasurement)
+	}
+	if vital.SystolicBP != nil && *vital.SystolicBP <= *vital.DiastolicBP {
+		return nil, fmt.Errorf("%w: systolic must be above diastolic pressure", ErrOutOfRange)
+	}
+	return vital, nil
+}
+
+// flag lists the readings outside their normal range.
+func (v *Vital) flag() {
+	v.Flags = nil
+	for _, s := range specs {
+		value := s.value(v)
+		if value == nil || s.high == math.MaxFloat64 {
+			continue
+		}
+		level := ""
+		switch {
+		case *value < s.low:
+			level = FlagLow
+		case *value > s.high:
+			level = FlagHigh
+		}
+		if level != "" {
+			v.Flags = append(v.Flags, Flag{
+				Measurement: s.name,
+				Value:       *value,
+				Level:       level,
+				Normal:      fmt.Sprintf("%g-%g %s", s.low, s.high, s.unit),
+			})
+		}
+	}
+	v.Abnormal = len(v.Flags) > 0
+}
+
+// Record stores the vitals the appointment's doctor took. BMI is derived
+// from the weight and the measured or profile height. With updateProfile
+// the measured weight and height are saved on the patient's profile.
+func (s *service) Record(doctorID, appointmentID int64, m Measurements, updateProfile bool) (*Vital, error) {
+	appointment, err := s.appointmentService.GetByID(appointmentID)
+	if err != nil {
+		return nil, ErrAppointmentNotFound
+	}
+	if appointment.DoctorID != doctorID {
+		return nil, ErrNotTreatingDoctor
+	}
+
+	vital, err := newVital(m)
+	if err != nil {
+		return nil, err
+	}
+	vital.AppointmentID = appointment.ID
+	vital.UserID = appointment.UserID
+	vital.DoctorID = doctorID
+	if vital.RecordedAt.IsZero() {
+		vital.RecordedAt = time.Now()
+	}
+
+	if vital.Weight != nil {
+		height := vital.Height
+		if height == nil {
+			if user, err := s.userService.GetUserByID(appointment.UserID); err == nil && user.Height > 0 {
+				height = &user.Height
+			}
+		}
+		if height != nil {
+			meters := *height / 100
+			bmi := round1(*vital.Weight / (meters * meters))
+			vital.BMI = &bmi
+		}
+	}
+	vital.flag()
+
+	if _, err := s.repo.Create(vital); err != nil {
+		s.logger.Error("failed to record vitals",
+			slog.Any("error", err),
+			slog.Int64("appointment_id", appointmentID),
+		)
+		return nil, err
+	}
+
+	if updateProfile && (vital.Weight != nil || vital.Height != nil) {
+		var weight, height float64
+		if vital.Weight != nil {
+			weight = *vital.Weight
+		}
+		if vital.Height != nil {
+			height = *vital.Height
+		}
+		// the vitals are recorded either way, a stale profile is only logged
+		if err := s.userService.UpdateMeasurements(vital.UserID, weight, height); err != nil {
+			s.logger.Error("failed to update profile from vitals",
+				slog.Any("error", err),
+				slog.Int64("vital_id", vital.ID),
+			)
+		}
+	}
+
+	return vital, nil
+}
+
+func (s *service) GetByAppointmentID(appointmentID int64) ([]Vital, error) {
+	list, err := s.repo.ListByAppointmentID(appointmentID)
+	if err != nil {
+		s.logger.Error("failed to get vitals by appointment ID",
+			slog.Any("error", err),
+			slog.Int64("appointment_id", appointmentID),
+		)
+		return nil, err
+	}
+	return list, nil
+}
+
+// Trend returns one series per requested measurement, every measurement
+// when none is given, of the vitals recorded in [from, to).
+func (s *service) Trend(patientID int64, measurements []string, from, to time.Time) (*Trend, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidPeriod
+	}
+
+	selected := make([]spec, 0, len(specs))
+	if len(measurements) == 0 {
+		selected = append(selected, specs...)
+	}
+	for _, name := range measurements {
+		sp, ok := specFor(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMeasurement, name)
+		}
+		selected = append(selected, sp)
+	}
+
+	list, err := s.repo.ListByUserID(patientID, from, to)
+	if err != nil {
+		s.logger.Error("failed to list vitals for trend",
+			slog.Any("error", err),
+			slog.Int64("patient_id", patientID),
+		)
+		return nil, err
+	}
+
+	trend := &Trend{PatientID: patientID, From: from, To: to, Series: make([]Series, 0, len(selected))}
+	for _, sp := range selected {
+		series := Series{Measurement: sp.name, Unit: sp.unit, Points: []Point{}}
+		for i := range list {
+			value := sp.value(&list[i])
+			if value == nil {
+				continue
+			}
+			point := Point{RecordedAt: list[i].RecordedAt, AppointmentID: list[i].AppointmentID, Value: *value}
+			for _, f := range list[i].Flags {
+				if f.Measurement == sp.name {
+					point.Flag = f.Level
+				}
+			}
+			series.Points = append(series.Points, point)
+
+			if series.Min == nil || *value < *series.Min {
+				series.Min = value
+			}
+			if series.Max == nil || *value > *series.Max {
+				series.Max = value
+			}
+			series.Latest = value
+		}
+		trend.Series = append(trend.Series, series)
+	}
+	return trend, nil
+}
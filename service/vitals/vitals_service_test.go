@@ -0,0 +1,121 @@
+package vitals_test
+
+import (
+	"Dedenruslan19/med-project/service/appointments"
+	"Dedenruslan19/med-project/service/users"
+	"Dedenruslan19/med-project/service/vitals"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type fakeUserService struct {
+	users.Service
+	updated []float64
+}
+
+func (f *fakeUserService) GetUserByID(userID int64) (users.User, error) {
+	return users.User{ID: userID, Weight: 80, Height: 180}, nil
+}
+
+func (f *fakeUserService) UpdateMeasurements(userID int64, weight, height float64) error {
+	f.updated = []float64{weight, height}
+	return nil
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+type fixture struct {
+	service         vitals.Service
+	repo            *vitals.MockVitalRepo
+	appointmentRepo *appointments.MockAppointmentRepo
+	users           *fakeUserService
+}
+
+func newFixture(t *testing.T) fixture {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	f := fixture{
+		repo:            vitals.NewMockVitalRepo(ctrl),
+		appointmentRepo: appointments.NewMockAppointmentRepo(ctrl),
+		users:           &fakeUserService{},
+	}
+	f.service = vitals.NewService(logger, f.repo, appointments.NewService(logger, f.appointmentRepo, nil, appointments.Video{}), f.users)
+	return f
+}
+
+func TestRecord_ConvertsFlagsAndUpdatesProfile(t *testing.T) {
+	f := newFixture(t)
+
+	f.appointmentRepo.EXPECT().GetByID(int64(5)).Return(&appointments.Appointment{ID: 5, UserID: 1, DoctorID: 2}, nil).Times(1)
+	f.repo.EXPECT().Create(gomock.Any()).Return(int64(1), nil).Times(1)
+
+	vital, err := f.service.Record(2, 5, vitals.Measurements{
+		SystolicBP:      float(145),
+		DiastolicBP:     float(92),
+		Temperature:     float(101.3),
+		TemperatureUnit: "F",
+		Weight:          float(90),
+	}, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 38.5, *vital.Temperature)
+	// BMI from the profile height of 180 cm
+	assert.Equal(t, 27.8, *vital.BMI)
+	assert.True(t, vital.Abnormal)
+
+	var flagged []string
+	for _, flag := range vital.Flags {
+		flagged = append(flagged, flag.Measurement+":"+flag.Level)
+	}
+	assert.Equal(t, []string{"systolic_bp:high", "diastolic_bp:high", "temperature:high", "bmi:high"}, flagged)
+	assert.Equal(t, []float64{90, 0}, f.users.updated)
+}
+
+func TestRecord_RejectsImplausibleReading(t *testing.T) {
+	f := newFixture(t)
+
+	f.appointmentRepo.EXPECT().GetByID(int64(5)).Return(&appointments.Appointment{ID: 5, UserID: 1, DoctorID: 2}, nil).Times(1)
+	f.repo.EXPECT().Create(gomock.Any()).Times(0)
+
+	_, err := f.service.Record(2, 5, vitals.Measurements{SpO2: float(140)}, false)
+	assert.ErrorIs(t, err, vitals.ErrOutOfRange)
+
+	f.appointmentRepo.EXPECT().GetByID(int64(5)).Return(&appointments.Appointment{ID: 5, UserID: 1, DoctorID: 3}, nil).Times(1)
+	_, err = f.service.Record(2, 5, vitals.Measurements{HeartRate: float(70)}, false)
+	assert.ErrorIs(t, err, vitals.ErrNotTreatingDoctor)
+}
+
+func TestTrend_SeriesPerMeasurement(t *testing.T) {
+	f := newFixture(t)
+	from := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+
+	f.repo.EXPECT().ListByUserID(int64(1), from, to).Return([]vitals.Vital{
+		{AppointmentID: 5, HeartRate: float(72), RecordedAt: from.AddDate(0, 1, 0)},
+		{AppointmentID: 6, Weight: float(80), RecordedAt: from.AddDate(0, 2, 0)},
+		{AppointmentID: 7, HeartRate: float(110), RecordedAt: from.AddDate(0, 3, 0),
+			Flags: []vitals.Flag{{Measurement: vitals.HeartRate, Value: 110, Level: vitals.FlagHigh}}},
+	}, nil).Times(1)
+
+	trend, err := f.service.Trend(1, []string{vitals.HeartRate}, from, to)
+
+	assert.NoError(t, err)
+	assert.Len(t, trend.Series, 1)
+	series := trend.Series[0]
+	assert.Len(t, series.Points, 2)
+	assert.Equal(t, vitals.FlagHigh, series.Points[1].Flag)
+	assert.Equal(t, 72.0, *series.Min)
+	assert.Equal(t, 110.0, *series.Latest)
+
+	_, err = f.service.Trend(1, []string{"pulse"}, from, to)
+	assert.ErrorIs(t, err, vitals.ErrInvalidMeasurement)
+}
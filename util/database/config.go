@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -73,7 +74,14 @@ func (conf *Config) GetDatabaseConnection() *gorm.DB {
 			log.Fatal(err)
 		}
 	case "sqlite":
-		db, err = gorm.Open(sqlite.Open(conf.DBSQLiteName))
+		// wait for locks instead of failing with SQLITE_BUSY, and take the
+		// write lock when a transaction begins so counters stay serialised
+		dsn := conf.DBSQLiteName
+		if !strings.Contains(dsn, "?") {
+			dsn += "?_busy_timeout=5000&_txlock=immediate"
+		}
+
+		db, err = gorm.Open(sqlite.Open(dsn))
 		if err != nil {
 			log.Fatal(err)
 		}
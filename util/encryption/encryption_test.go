@@ -0,0 +1,118 @@
+package encryption_test
+
+import (
+	"Dedenruslan19/med-project/util/encryption"
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type note struct {
+	ID          int64    `gorm:"primaryKey;autoIncrement"`
+	Body        string   `gorm:"type:text;not null;serializer:encrypted"`
+	Medications []string `gorm:"type:text;serializer:encrypted"`
+	Status      string
+}
+
+func keyring(t *testing.T, active string) *encryption.Keyring {
+	k, err := encryption.NewKeyring(active, map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	})
+	require.NoError(t, err)
+	return k
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&note{}))
+	t.Cleanup(func() { encryption.Use(nil) })
+	return db
+}
+
+func rawBody(t *testing.T, db *gorm.DB, id int64) string {
+	var body string
+	require.NoError(t, db.Table("notes").Select("body").Where("id = ?", id).Scan(&body).Error)
+	return body
+}
+
+func TestSerializer_EncryptsOnSqlite(t *testing.T) {
+	db := openDB(t)
+	encryption.Use(keyring(t, "k1"))
+
+	n := note{Body: "Suspected streptococcal pharyngitis", Medications: []string{"Amoxicillin 500mg"}}
+	require.NoError(t, db.Create(&n).Error)
+
+	stored := rawBody(t, db, n.ID)
+	assert.True(t, strings.HasPrefix(stored, "enc:v1:k1:"))
+	assert.NotContains(t, stored, "streptococcal")
+
+	require.NoError(t, db.Model(&note{}).Where("id = ?", n.ID).
+		Select("body").Updates(&note{Body: "Confirmed streptococcal pharyngitis"}).Error)
+
+	var loaded note
+	require.NoError(t, db.First(&loaded, n.ID).Error)
+	assert.Equal(t, "Confirmed streptococcal pharyngitis", loaded.Body)
+	assert.Equal(t, []string{"Amoxicillin 500mg"}, loaded.Medications)
+}
+
+func TestRotate_ReencryptsOldKeysAndPlaintext(t *testing.T) {
+	db := openDB(t)
+	encryption.Use(keyring(t, "k1"))
+	require.NoError(t, db.Create(&note{Body: "written with k1", Medications: []string{"Ibuprofen"}}).Error)
+	// written before encryption was enabled
+	require.NoError(t, db.Exec(`INSERT INTO notes (body, medications) VALUES ('legacy plaintext', '["Paracetamol"]')`).Error)
+
+	rotated := keyring(t, "k2")
+	encryption.Use(rotated)
+
+	result, err := encryption.Rotate(db, rotated, 1, &note{})
+	require.NoError(t, err)
+	assert.Equal(t, []encryption.Rotation{
+		{Table: "notes", Column: "body", Rotated: 2},
+		{Table: "notes", Column: "medications", Rotated: 2},
+	}, result)
+
+	var notes []note
+	require.NoError(t, db.Order("id").Find(&notes).Error)
+	assert.Equal(t, "written with k1", notes[0].Body)
+	assert.Equal(t, "legacy plaintext", notes[1].Body)
+	assert.Equal(t, []string{"Paracetamol"}, notes[1].Medications)
+	for _, n := range notes {
+		assert.Equal(t, "k2", encryption.KeyID(rawBody(t, db, n.ID)))
+	}
+
+	result, err = encryption.Rotate(db, rotated, 1, &note{})
+	require.NoError(t, err)
+	assert.Zero(t, result[0].Rotated+result[1].Rotated)
+}
+
+func TestDecrypt_RejectsUnknownKeyAndTampering(t *testing.T) {
+	value, err := keyring(t, "k2").Encrypt([]byte("HIV positive"))
+	require.NoError(t, err)
+
+	onlyK1, err := encryption.NewKeyring("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)})
+	require.NoError(t, err)
+	_, err = onlyK1.Decrypt(value)
+	assert.ErrorIs(t, err, encryption.ErrUnknownKey)
+
+	tampered := value[:len(value)-2] + "AA"
+	if tampered == value {
+		tampered = value[:len(value)-2] + "BB"
+	}
+	_, err = keyring(t, "k2").Decrypt(tampered)
+	assert.ErrorIs(t, err, encryption.ErrMalformed)
+
+	_, err = encryption.ParseKeyring("k1:c2hvcnQ=", "")
+	assert.ErrorIs(t, err, encryption.ErrInvalidKey)
+}
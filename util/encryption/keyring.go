@@ -0,0 +1,197 @@
+// Package encryption encrypts sensitive columns at rest with AES-256-GCM
+// envelope encryption: every value gets its own data key, which is wrapped
+// with a master key from the keyring. Stored values look like
+//
+//	enc:v1:<key id>:<wrapped data key>:<ciphertext>
+//
+// so the master key needed to read a value is known from the value itself
+// and keys can be rotated row by row.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	prefix  = "enc:v1:"
+	keySize = 32
+)
+
+var (
+	ErrNoKeyring  = errors.New("no encryption keys configured")
+	ErrInvalidKey = errors.New("invalid encryption key")
+	ErrUnknownKey = errors.New("unknown encryption key")
+	ErrMalformed  = errors.New("malformed encrypted value")
+)
+
+var encoding = base64.RawURLEncoding
+
+// Keyring holds the master keys by ID. New values are encrypted with the
+// active key; the others are only kept to read values not rotated yet.
+type Keyring struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// NewKeyring builds a keyring from 32 byte master keys.
+func NewKeyring(activeID string, keys map[string][]byte) (*Keyring, error) {
+	k := &Keyring{activeID: activeID, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("%w: key id %q", ErrInvalidKey, id)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("%w: key %s must be %d bytes", ErrInvalidKey, id, keySize)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+	}
+	if _, ok := k.keys[activeID]; !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, activeID)
+	}
+	return k, nil
+}
+
+// ParseKeyring reads keys given as "id:base64key,id:base64key", e.g. from
+// ENCRYPTION_KEYS. The active key defaults to the first one.
+func ParseKeyring(spec, activeID string) (*Keyring, error) {
+	keys := map[string][]byte{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: expected id:base64key", ErrInvalidKey)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s is not base64", ErrInvalidKey, id)
+		}
+		keys[id] = key
+		if activeID == "" {
+			activeID = id
+		}
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoKeyring
+	}
+	return NewKeyring(activeID, keys)
+}
+
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+}
+
+// Encrypt seals plaintext with a fresh data key and wraps the data key with
+// the active master key. The key ID is authenticated with the data key so it
+// cannot be swapped.
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	wrapped, err := seal(k.keys[k.activeID], dataKey, []byte(k.activeID))
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(aead, plaintext, []byte(k.activeID))
+	if err != nil {
+		return "", err
+	}
+	return prefix + k.activeID + ":" + encoding.EncodeToString(wrapped) + ":" + encoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens a value produced by Encrypt with any key of the keyring.
+func (k *Keyring) Decrypt(value string) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if !IsEncrypted(value) || len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	id := parts[0]
+	master, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	wrapped, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	ciphertext, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	dataKey, err := open(master, wrapped, []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(aead, ciphertext, []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return plaintext, nil
+}
+
+// NeedsRotation reports whether a stored value is plaintext or encrypted
+// with a key other than the active one.
+func (k *Keyring) NeedsRotation(value string) bool {
+	return value != "" && KeyID(value) != k.activeID
+}
+
+// IsEncrypted reports whether a stored value was written by Encrypt.
+// Anything else is plaintext written before encryption was enabled.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID returns the ID of the master key of an encrypted value, or "" for
+// plaintext.
+func KeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id
+}
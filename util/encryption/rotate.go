@@ -0,0 +1,123 @@
+package encryption
+
+import (
+	"database/sql"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Rotation counts the values of a column that were re-encrypted.
+type Rotation struct {
+	Table   string `json:"table"`
+	Column  string `json:"column"`
+	Rotated int    `json:"rotated"`
+}
+
+// Rotate re-encrypts the encrypted columns of models with the active key of
+// keyring, which must still hold the keys the values were written with.
+// Plaintext written before encryption was enabled is encrypted as well. Rows
+// are read in batches by primary key, and a value is only replaced while it
+// is unchanged, so rotation can run next to the live application.
+func Rotate(db *gorm.DB, keyring *Keyring, batchSize int, models ...interface{}) ([]Rotation, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	var result []Rotation
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return result, err
+		}
+		if stmt.Schema.PrioritizedPrimaryField == nil {
+			return result, fmt.Errorf("%s has no primary key", stmt.Schema.Table)
+		}
+
+		var columns []string
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && field.TagSettings["SERIALIZER"] == SerializerName {
+				columns = append(columns, field.DBName)
+			}
+		}
+		for _, column := range columns {
+			rotated, err := rotateColumn(db, keyring, batchSize, stmt.Schema.Table, stmt.Schema.PrioritizedPrimaryField.DBName, column)
+			result = append(result, Rotation{Table: stmt.Schema.Table, Column: column, Rotated: rotated})
+			if err != nil {
+				return result, fmt.Errorf("%s.%s: %w", stmt.Schema.Table, column, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+type storedValue struct {
+	id    int64
+	value string
+}
+
+// readBatch reads up to batchSize rows after lastID and returns the values
+// that need rotation, the last primary key read and the number of rows read.
+func readBatch(db *gorm.DB, keyring *Keyring, batchSize int, table, primaryKey, column string, lastID int64) ([]storedValue, int64, int, error) {
+	rows, err := db.Table(table).
+		Select(primaryKey, column).
+		Where(primaryKey+" > ?", lastID).
+		Order(primaryKey).
+		Limit(batchSize).
+		Rows()
+	if err != nil {
+		return nil, lastID, 0, err
+	}
+	defer rows.Close()
+
+	var batch []storedValue
+	read := 0
+	for rows.Next() {
+		var value sql.NullString
+		if err := rows.Scan(&lastID, &value); err != nil {
+			return nil, lastID, read, err
+		}
+		read++
+		if value.Valid && keyring.NeedsRotation(value.String) {
+			batch = append(batch, storedValue{id: lastID, value: value.String})
+		}
+	}
+	return batch, lastID, read, rows.Err()
+}
+
+func rotateColumn(db *gorm.DB, keyring *Keyring, batchSize int, table, primaryKey, column string) (int, error) {
+	rotated := 0
+	var lastID int64
+	for {
+		batch, last, read, err := readBatch(db, keyring, batchSize, table, primaryKey, column, lastID)
+		if err != nil {
+			return rotated, err
+		}
+		lastID = last
+
+		for _, stored := range batch {
+			plaintext := []byte(stored.value)
+			if IsEncrypted(stored.value) {
+				if plaintext, err = keyring.Decrypt(stored.value); err != nil {
+					return rotated, fmt.Errorf("row %d: %w", stored.id, err)
+				}
+			}
+			encrypted, err := keyring.Encrypt(plaintext)
+			if err != nil {
+				return rotated, err
+			}
+
+			result := db.Table(table).
+				Where(primaryKey+" = ? AND "+column+" = ?", stored.id, stored.value).
+				Update(column, encrypted)
+			if result.Error != nil {
+				return rotated, result.Error
+			}
+			rotated += int(result.RowsAffected)
+		}
+
+		if read < batchSize {
+			return rotated, nil
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package encryption
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is used in model tags: `gorm:"type:text;serializer:encrypted"`.
+const SerializerName = "encrypted"
+
+var current atomic.Pointer[Keyring]
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// Use sets the keyring of the serializer. Until it is called values are
+// written as plaintext and encrypted values cannot be read.
+func Use(keyring *Keyring) {
+	current.Store(keyring)
+}
+
+// Serializer encrypts a column with the keyring passed to Use. Strings are
+// encrypted as they are, other types as JSON. Plaintext values written before
+// encryption was enabled are read as they are until keys are rotated.
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		stored = string(v)
+	case string:
+		stored = v
+	default:
+		return fmt.Errorf("%w: unexpected %T in %s", ErrMalformed, dbValue, field.DBName)
+	}
+
+	plaintext := []byte(stored)
+	if IsEncrypted(stored) {
+		keyring := current.Load()
+		if keyring == nil {
+			return fmt.Errorf("%w: cannot read %s", ErrNoKeyring, field.DBName)
+		}
+		var err error
+		if plaintext, err = keyring.Decrypt(stored); err != nil {
+			return fmt.Errorf("%s: %w", field.DBName, err)
+		}
+	}
+
+	if len(plaintext) > 0 {
+		if field.FieldType.Kind() == reflect.String {
+			fieldValue.Elem().SetString(string(plaintext))
+		} else if err := json.Unmarshal(plaintext, fieldValue.Interface()); err != nil {
+			return err
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plaintext []byte
+	if s, ok := fieldValue.(string); ok {
+		plaintext = []byte(s)
+	} else {
+		result, err := json.Marshal(fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		if string(result) == "null" {
+			if field.TagSettings["NOT NULL"] != "" {
+				return "", nil
+			}
+			return nil, nil
+		}
+		plaintext = result
+	}
+
+	keyring := current.Load()
+	if keyring == nil || len(plaintext) == 0 {
+		return string(plaintext), nil
+	}
+	return keyring.Encrypt(plaintext)
+}
@@ -11,9 +11,9 @@ import (
 )
 
 type Claims struct {
-	UserID int64
-	Email  string
-	Role   string
+	UserID int64  `json:"id"`
+	Email  string `json:"email,omitempty"`
+	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 